@@ -1,3 +1,4 @@
 package v1
 
 //go:generate go run -modfile=../../tools/go.mod github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen --config ./gen.yaml ../../../api/v1.yaml
+//go:generate go run -modfile=../../tools/go.mod github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen --config ./server.gen.yaml ../../../api/v1.yaml
@@ -0,0 +1,2726 @@
+// Package v1 provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.4.1 DO NOT EDIT.
+package v1
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gorilla/mux"
+	"github.com/oapi-codegen/runtime"
+	strictnethttp "github.com/oapi-codegen/runtime/strictmiddleware/nethttp"
+)
+
+// ServerInterface represents all server handlers.
+type ServerInterface interface {
+	// List API keys
+	// (GET /api-keys)
+	ListAPIKeys(w http.ResponseWriter, r *http.Request)
+	// Create an API key
+	// (POST /api-keys)
+	CreateAPIKey(w http.ResponseWriter, r *http.Request)
+	// Revoke an API key
+	// (DELETE /api-keys/{key_id})
+	DeleteAPIKey(w http.ResponseWriter, r *http.Request, keyID string)
+	// Query the audit log
+	// (GET /audit)
+	ListAuditEntries(w http.ResponseWriter, r *http.Request, params ListAuditEntriesParams)
+	// Check the health of the runtime
+	// (GET /health)
+	HealthCheck(w http.ResponseWriter, r *http.Request)
+	// List spaces
+	// (GET /spaces)
+	ListSpaces(w http.ResponseWriter, r *http.Request)
+	// Create a new space
+	// (POST /spaces)
+	CreateSpace(w http.ResponseWriter, r *http.Request)
+	// Delete a space
+	// (DELETE /spaces/{space_id})
+	DeleteSpace(w http.ResponseWriter, r *http.Request, spaceID string, params DeleteSpaceParams)
+	// Get space details
+	// (GET /spaces/{space_id})
+	GetSpace(w http.ResponseWriter, r *http.Request, spaceID string)
+	// Update a space
+	// (PUT /spaces/{space_id})
+	UpdateSpace(w http.ResponseWriter, r *http.Request, spaceID string)
+	// List sandboxes in a space
+	// (GET /spaces/{space_id}/sandboxes)
+	ListSandboxes(w http.ResponseWriter, r *http.Request, spaceID string)
+	// Create a new sandbox
+	// (POST /spaces/{space_id}/sandboxes)
+	CreateSandbox(w http.ResponseWriter, r *http.Request, spaceID string, params CreateSandboxParams)
+	// Delete a sandbox
+	// (DELETE /spaces/{space_id}/sandboxes/{sandbox_id})
+	DeleteSandbox(w http.ResponseWriter, r *http.Request, spaceID string, sandboxID string)
+	// Get sandbox details
+	// (GET /spaces/{space_id}/sandboxes/{sandbox_id})
+	GetSandbox(w http.ResponseWriter, r *http.Request, spaceID string, sandboxID string)
+	// Execute an IPython cell in the sandbox
+	// (POST /spaces/{space_id}/sandboxes/{sandbox_id}/tools:run_ipython_cell)
+	RunIPythonCell(w http.ResponseWriter, r *http.Request, spaceID string, sandboxID string)
+	// Execute a shell command in the sandbox
+	// (POST /spaces/{space_id}/sandboxes/{sandbox_id}/tools:run_shell_command)
+	RunShellCommand(w http.ResponseWriter, r *http.Request, spaceID string, sandboxID string)
+	// List the secrets of a space
+	// (GET /spaces/{space_id}/secrets)
+	ListSecrets(w http.ResponseWriter, r *http.Request, spaceID string)
+	// Delete a secret
+	// (DELETE /spaces/{space_id}/secrets/{name})
+	DeleteSecret(w http.ResponseWriter, r *http.Request, spaceID string, name string)
+	// Set a secret
+	// (PUT /spaces/{space_id}/secrets/{name})
+	SetSecret(w http.ResponseWriter, r *http.Request, spaceID string, name string)
+	// Get space usage
+	// (GET /spaces/{space_id}/usage)
+	GetSpaceUsage(w http.ResponseWriter, r *http.Request, spaceID string, params GetSpaceUsageParams)
+}
+
+// ServerInterfaceWrapper converts contexts to parameters.
+type ServerInterfaceWrapper struct {
+	Handler            ServerInterface
+	HandlerMiddlewares []MiddlewareFunc
+	ErrorHandlerFunc   func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+type MiddlewareFunc func(http.Handler) http.Handler
+
+// ListAPIKeys operation middleware
+func (siw *ServerInterfaceWrapper) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListAPIKeys(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CreateAPIKey operation middleware
+func (siw *ServerInterfaceWrapper) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateAPIKey(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteAPIKey operation middleware
+func (siw *ServerInterfaceWrapper) DeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "key_id" -------------
+	var keyID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "key_id", mux.Vars(r)["key_id"], &keyID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "key_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteAPIKey(w, r, keyID)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListAuditEntries operation middleware
+func (siw *ServerInterfaceWrapper) ListAuditEntries(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListAuditEntriesParams
+
+	// ------------- Optional query parameter "space_id" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "space_id", r.URL.Query(), &params.SpaceID)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "space_id", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListAuditEntries(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// HealthCheck operation middleware
+func (siw *ServerInterfaceWrapper) HealthCheck(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.HealthCheck(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListSpaces operation middleware
+func (siw *ServerInterfaceWrapper) ListSpaces(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListSpaces(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CreateSpace operation middleware
+func (siw *ServerInterfaceWrapper) CreateSpace(w http.ResponseWriter, r *http.Request) {
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateSpace(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteSpace operation middleware
+func (siw *ServerInterfaceWrapper) DeleteSpace(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "space_id" -------------
+	var spaceID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "space_id", mux.Vars(r)["space_id"], &spaceID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "space_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params DeleteSpaceParams
+
+	// ------------- Optional query parameter "retain_volumes" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "retain_volumes", r.URL.Query(), &params.RetainVolumes)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "retain_volumes", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteSpace(w, r, spaceID, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetSpace operation middleware
+func (siw *ServerInterfaceWrapper) GetSpace(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "space_id" -------------
+	var spaceID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "space_id", mux.Vars(r)["space_id"], &spaceID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "space_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetSpace(w, r, spaceID)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// UpdateSpace operation middleware
+func (siw *ServerInterfaceWrapper) UpdateSpace(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "space_id" -------------
+	var spaceID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "space_id", mux.Vars(r)["space_id"], &spaceID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "space_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UpdateSpace(w, r, spaceID)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListSandboxes operation middleware
+func (siw *ServerInterfaceWrapper) ListSandboxes(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "space_id" -------------
+	var spaceID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "space_id", mux.Vars(r)["space_id"], &spaceID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "space_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListSandboxes(w, r, spaceID)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// CreateSandbox operation middleware
+func (siw *ServerInterfaceWrapper) CreateSandbox(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "space_id" -------------
+	var spaceID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "space_id", mux.Vars(r)["space_id"], &spaceID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "space_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params CreateSandboxParams
+
+	// ------------- Optional query parameter "async" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "async", r.URL.Query(), &params.Async)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "async", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CreateSandbox(w, r, spaceID, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteSandbox operation middleware
+func (siw *ServerInterfaceWrapper) DeleteSandbox(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "space_id" -------------
+	var spaceID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "space_id", mux.Vars(r)["space_id"], &spaceID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "space_id", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "sandbox_id" -------------
+	var sandboxID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "sandbox_id", mux.Vars(r)["sandbox_id"], &sandboxID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "sandbox_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteSandbox(w, r, spaceID, sandboxID)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetSandbox operation middleware
+func (siw *ServerInterfaceWrapper) GetSandbox(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "space_id" -------------
+	var spaceID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "space_id", mux.Vars(r)["space_id"], &spaceID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "space_id", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "sandbox_id" -------------
+	var sandboxID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "sandbox_id", mux.Vars(r)["sandbox_id"], &sandboxID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "sandbox_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetSandbox(w, r, spaceID, sandboxID)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// RunIPythonCell operation middleware
+func (siw *ServerInterfaceWrapper) RunIPythonCell(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "space_id" -------------
+	var spaceID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "space_id", mux.Vars(r)["space_id"], &spaceID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "space_id", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "sandbox_id" -------------
+	var sandboxID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "sandbox_id", mux.Vars(r)["sandbox_id"], &sandboxID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "sandbox_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RunIPythonCell(w, r, spaceID, sandboxID)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// RunShellCommand operation middleware
+func (siw *ServerInterfaceWrapper) RunShellCommand(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "space_id" -------------
+	var spaceID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "space_id", mux.Vars(r)["space_id"], &spaceID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "space_id", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "sandbox_id" -------------
+	var sandboxID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "sandbox_id", mux.Vars(r)["sandbox_id"], &sandboxID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "sandbox_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.RunShellCommand(w, r, spaceID, sandboxID)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// ListSecrets operation middleware
+func (siw *ServerInterfaceWrapper) ListSecrets(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "space_id" -------------
+	var spaceID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "space_id", mux.Vars(r)["space_id"], &spaceID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "space_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.ListSecrets(w, r, spaceID)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// DeleteSecret operation middleware
+func (siw *ServerInterfaceWrapper) DeleteSecret(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "space_id" -------------
+	var spaceID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "space_id", mux.Vars(r)["space_id"], &spaceID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "space_id", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "name" -------------
+	var name string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "name", mux.Vars(r)["name"], &name, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "name", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteSecret(w, r, spaceID, name)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// SetSecret operation middleware
+func (siw *ServerInterfaceWrapper) SetSecret(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "space_id" -------------
+	var spaceID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "space_id", mux.Vars(r)["space_id"], &spaceID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "space_id", Err: err})
+		return
+	}
+
+	// ------------- Path parameter "name" -------------
+	var name string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "name", mux.Vars(r)["name"], &name, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "name", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.SetSecret(w, r, spaceID, name)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// GetSpaceUsage operation middleware
+func (siw *ServerInterfaceWrapper) GetSpaceUsage(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// ------------- Path parameter "space_id" -------------
+	var spaceID string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "space_id", mux.Vars(r)["space_id"], &spaceID, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "space_id", Err: err})
+		return
+	}
+
+	ctx := r.Context()
+
+	ctx = context.WithValue(ctx, BearerAuthScopes, []string{})
+
+	r = r.WithContext(ctx)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetSpaceUsageParams
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "bucket" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "bucket", r.URL.Query(), &params.Bucket)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "bucket", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "group_by" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "group_by", r.URL.Query(), &params.GroupBy)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "group_by", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "format" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "format", r.URL.Query(), &params.Format)
+	if err != nil {
+		siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "format", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetSpaceUsage(w, r, spaceID, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+type UnescapedCookieParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnescapedCookieParamError) Error() string {
+	return fmt.Sprintf("error unescaping cookie parameter '%s'", e.ParamName)
+}
+
+func (e *UnescapedCookieParamError) Unwrap() error {
+	return e.Err
+}
+
+type UnmarshalingParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnmarshalingParamError) Error() string {
+	return fmt.Sprintf("Error unmarshaling parameter %s as JSON: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *UnmarshalingParamError) Unwrap() error {
+	return e.Err
+}
+
+type RequiredParamError struct {
+	ParamName string
+}
+
+func (e *RequiredParamError) Error() string {
+	return fmt.Sprintf("Query argument %s is required, but not found", e.ParamName)
+}
+
+type RequiredHeaderError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *RequiredHeaderError) Error() string {
+	return fmt.Sprintf("Header parameter %s is required, but not found", e.ParamName)
+}
+
+func (e *RequiredHeaderError) Unwrap() error {
+	return e.Err
+}
+
+type InvalidParamFormatError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *InvalidParamFormatError) Error() string {
+	return fmt.Sprintf("Invalid format for parameter %s: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *InvalidParamFormatError) Unwrap() error {
+	return e.Err
+}
+
+type TooManyValuesForParamError struct {
+	ParamName string
+	Count     int
+}
+
+func (e *TooManyValuesForParamError) Error() string {
+	return fmt.Sprintf("Expected one value for %s, got %d", e.ParamName, e.Count)
+}
+
+// Handler creates http.Handler with routing matching OpenAPI spec.
+func Handler(si ServerInterface) http.Handler {
+	return HandlerWithOptions(si, GorillaServerOptions{})
+}
+
+type GorillaServerOptions struct {
+	BaseURL          string
+	BaseRouter       *mux.Router
+	Middlewares      []MiddlewareFunc
+	ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// HandlerFromMux creates http.Handler with routing matching OpenAPI spec based on the provided mux.
+func HandlerFromMux(si ServerInterface, r *mux.Router) http.Handler {
+	return HandlerWithOptions(si, GorillaServerOptions{
+		BaseRouter: r,
+	})
+}
+
+func HandlerFromMuxWithBaseURL(si ServerInterface, r *mux.Router, baseURL string) http.Handler {
+	return HandlerWithOptions(si, GorillaServerOptions{
+		BaseURL:    baseURL,
+		BaseRouter: r,
+	})
+}
+
+// HandlerWithOptions creates http.Handler with additional options
+func HandlerWithOptions(si ServerInterface, options GorillaServerOptions) http.Handler {
+	r := options.BaseRouter
+
+	if r == nil {
+		r = mux.NewRouter()
+	}
+	if options.ErrorHandlerFunc == nil {
+		options.ErrorHandlerFunc = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}
+	wrapper := ServerInterfaceWrapper{
+		Handler:            si,
+		HandlerMiddlewares: options.Middlewares,
+		ErrorHandlerFunc:   options.ErrorHandlerFunc,
+	}
+
+	r.HandleFunc(options.BaseURL+"/api-keys", wrapper.ListAPIKeys).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/api-keys", wrapper.CreateAPIKey).Methods("POST")
+
+	r.HandleFunc(options.BaseURL+"/api-keys/{key_id}", wrapper.DeleteAPIKey).Methods("DELETE")
+
+	r.HandleFunc(options.BaseURL+"/audit", wrapper.ListAuditEntries).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/health", wrapper.HealthCheck).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/spaces", wrapper.ListSpaces).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/spaces", wrapper.CreateSpace).Methods("POST")
+
+	r.HandleFunc(options.BaseURL+"/spaces/{space_id}", wrapper.DeleteSpace).Methods("DELETE")
+
+	r.HandleFunc(options.BaseURL+"/spaces/{space_id}", wrapper.GetSpace).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/spaces/{space_id}", wrapper.UpdateSpace).Methods("PUT")
+
+	r.HandleFunc(options.BaseURL+"/spaces/{space_id}/sandboxes", wrapper.ListSandboxes).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/spaces/{space_id}/sandboxes", wrapper.CreateSandbox).Methods("POST")
+
+	r.HandleFunc(options.BaseURL+"/spaces/{space_id}/sandboxes/{sandbox_id}", wrapper.DeleteSandbox).Methods("DELETE")
+
+	r.HandleFunc(options.BaseURL+"/spaces/{space_id}/sandboxes/{sandbox_id}", wrapper.GetSandbox).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/spaces/{space_id}/sandboxes/{sandbox_id}/tools:run_ipython_cell", wrapper.RunIPythonCell).Methods("POST")
+
+	r.HandleFunc(options.BaseURL+"/spaces/{space_id}/sandboxes/{sandbox_id}/tools:run_shell_command", wrapper.RunShellCommand).Methods("POST")
+
+	r.HandleFunc(options.BaseURL+"/spaces/{space_id}/secrets", wrapper.ListSecrets).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/spaces/{space_id}/secrets/{name}", wrapper.DeleteSecret).Methods("DELETE")
+
+	r.HandleFunc(options.BaseURL+"/spaces/{space_id}/secrets/{name}", wrapper.SetSecret).Methods("PUT")
+
+	r.HandleFunc(options.BaseURL+"/spaces/{space_id}/usage", wrapper.GetSpaceUsage).Methods("GET")
+
+	return r
+}
+
+type ListAPIKeysRequestObject struct {
+}
+
+type ListAPIKeysResponseObject interface {
+	VisitListAPIKeysResponse(w http.ResponseWriter) error
+}
+
+type ListAPIKeys200JSONResponse []APIKey
+
+func (response ListAPIKeys200JSONResponse) VisitListAPIKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListAPIKeys403JSONResponse Error
+
+func (response ListAPIKeys403JSONResponse) VisitListAPIKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListAPIKeys404JSONResponse Error
+
+func (response ListAPIKeys404JSONResponse) VisitListAPIKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListAPIKeysdefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response ListAPIKeysdefaultJSONResponse) VisitListAPIKeysResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type CreateAPIKeyRequestObject struct {
+	Body *CreateAPIKeyJSONRequestBody
+}
+
+type CreateAPIKeyResponseObject interface {
+	VisitCreateAPIKeyResponse(w http.ResponseWriter) error
+}
+
+type CreateAPIKey201JSONResponse CreateAPIKeyResponse
+
+func (response CreateAPIKey201JSONResponse) VisitCreateAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateAPIKey400JSONResponse Error
+
+func (response CreateAPIKey400JSONResponse) VisitCreateAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateAPIKey403JSONResponse Error
+
+func (response CreateAPIKey403JSONResponse) VisitCreateAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateAPIKey404JSONResponse Error
+
+func (response CreateAPIKey404JSONResponse) VisitCreateAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateAPIKeydefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response CreateAPIKeydefaultJSONResponse) VisitCreateAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type DeleteAPIKeyRequestObject struct {
+	KeyID string `json:"key_id"`
+}
+
+type DeleteAPIKeyResponseObject interface {
+	VisitDeleteAPIKeyResponse(w http.ResponseWriter) error
+}
+
+type DeleteAPIKey204Response struct {
+}
+
+func (response DeleteAPIKey204Response) VisitDeleteAPIKeyResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type DeleteAPIKey404JSONResponse Error
+
+func (response DeleteAPIKey404JSONResponse) VisitDeleteAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteAPIKey409JSONResponse Error
+
+func (response DeleteAPIKey409JSONResponse) VisitDeleteAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteAPIKeydefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response DeleteAPIKeydefaultJSONResponse) VisitDeleteAPIKeyResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type ListAuditEntriesRequestObject struct {
+	Params ListAuditEntriesParams
+}
+
+type ListAuditEntriesResponseObject interface {
+	VisitListAuditEntriesResponse(w http.ResponseWriter) error
+}
+
+type ListAuditEntries200JSONResponse []AuditEntry
+
+func (response ListAuditEntries200JSONResponse) VisitListAuditEntriesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListAuditEntries400JSONResponse Error
+
+func (response ListAuditEntries400JSONResponse) VisitListAuditEntriesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListAuditEntries403JSONResponse Error
+
+func (response ListAuditEntries403JSONResponse) VisitListAuditEntriesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListAuditEntriesdefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response ListAuditEntriesdefaultJSONResponse) VisitListAuditEntriesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type HealthCheckRequestObject struct {
+}
+
+type HealthCheckResponseObject interface {
+	VisitHealthCheckResponse(w http.ResponseWriter) error
+}
+
+type HealthCheck200JSONResponse HealthStatus
+
+func (response HealthCheck200JSONResponse) VisitHealthCheckResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListSpacesRequestObject struct {
+}
+
+type ListSpacesResponseObject interface {
+	VisitListSpacesResponse(w http.ResponseWriter) error
+}
+
+type ListSpaces200JSONResponse []Space
+
+func (response ListSpaces200JSONResponse) VisitListSpacesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListSpacesdefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response ListSpacesdefaultJSONResponse) VisitListSpacesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type CreateSpaceRequestObject struct {
+	Body *CreateSpaceJSONRequestBody
+}
+
+type CreateSpaceResponseObject interface {
+	VisitCreateSpaceResponse(w http.ResponseWriter) error
+}
+
+type CreateSpace201JSONResponse Space
+
+func (response CreateSpace201JSONResponse) VisitCreateSpaceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateSpace400JSONResponse Error
+
+func (response CreateSpace400JSONResponse) VisitCreateSpaceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateSpace403JSONResponse Error
+
+func (response CreateSpace403JSONResponse) VisitCreateSpaceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateSpace409JSONResponse Error
+
+func (response CreateSpace409JSONResponse) VisitCreateSpaceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(409)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateSpacedefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response CreateSpacedefaultJSONResponse) VisitCreateSpaceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type DeleteSpaceRequestObject struct {
+	SpaceID string `json:"space_id"`
+	Params  DeleteSpaceParams
+}
+
+type DeleteSpaceResponseObject interface {
+	VisitDeleteSpaceResponse(w http.ResponseWriter) error
+}
+
+type DeleteSpace204Response struct {
+}
+
+func (response DeleteSpace204Response) VisitDeleteSpaceResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type DeleteSpace404JSONResponse Error
+
+func (response DeleteSpace404JSONResponse) VisitDeleteSpaceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteSpacedefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response DeleteSpacedefaultJSONResponse) VisitDeleteSpaceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetSpaceRequestObject struct {
+	SpaceID string `json:"space_id"`
+}
+
+type GetSpaceResponseObject interface {
+	VisitGetSpaceResponse(w http.ResponseWriter) error
+}
+
+type GetSpace200JSONResponse Space
+
+func (response GetSpace200JSONResponse) VisitGetSpaceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetSpace404JSONResponse Error
+
+func (response GetSpace404JSONResponse) VisitGetSpaceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetSpacedefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response GetSpacedefaultJSONResponse) VisitGetSpaceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type UpdateSpaceRequestObject struct {
+	SpaceID string `json:"space_id"`
+	Body    *UpdateSpaceJSONRequestBody
+}
+
+type UpdateSpaceResponseObject interface {
+	VisitUpdateSpaceResponse(w http.ResponseWriter) error
+}
+
+type UpdateSpace200JSONResponse Space
+
+func (response UpdateSpace200JSONResponse) VisitUpdateSpaceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateSpace400JSONResponse Error
+
+func (response UpdateSpace400JSONResponse) VisitUpdateSpaceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateSpace403JSONResponse Error
+
+func (response UpdateSpace403JSONResponse) VisitUpdateSpaceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(403)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateSpace404JSONResponse Error
+
+func (response UpdateSpace404JSONResponse) VisitUpdateSpaceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UpdateSpacedefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response UpdateSpacedefaultJSONResponse) VisitUpdateSpaceResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type ListSandboxesRequestObject struct {
+	SpaceID string `json:"space_id"`
+}
+
+type ListSandboxesResponseObject interface {
+	VisitListSandboxesResponse(w http.ResponseWriter) error
+}
+
+type ListSandboxes200JSONResponse []Sandbox
+
+func (response ListSandboxes200JSONResponse) VisitListSandboxesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListSandboxes404JSONResponse Error
+
+func (response ListSandboxes404JSONResponse) VisitListSandboxesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListSandboxesdefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response ListSandboxesdefaultJSONResponse) VisitListSandboxesResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type CreateSandboxRequestObject struct {
+	SpaceID string `json:"space_id"`
+	Params  CreateSandboxParams
+	Body    *CreateSandboxJSONRequestBody
+}
+
+type CreateSandboxResponseObject interface {
+	VisitCreateSandboxResponse(w http.ResponseWriter) error
+}
+
+type CreateSandbox201JSONResponse Sandbox
+
+func (response CreateSandbox201JSONResponse) VisitCreateSandboxResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateSandbox202JSONResponse Sandbox
+
+func (response CreateSandbox202JSONResponse) VisitCreateSandboxResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(202)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateSandbox400JSONResponse Error
+
+func (response CreateSandbox400JSONResponse) VisitCreateSandboxResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateSandbox404JSONResponse Error
+
+func (response CreateSandbox404JSONResponse) VisitCreateSandboxResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateSandbox429JSONResponse Error
+
+func (response CreateSandbox429JSONResponse) VisitCreateSandboxResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(429)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type CreateSandboxdefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response CreateSandboxdefaultJSONResponse) VisitCreateSandboxResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type DeleteSandboxRequestObject struct {
+	SpaceID   string `json:"space_id"`
+	SandboxID string `json:"sandbox_id"`
+}
+
+type DeleteSandboxResponseObject interface {
+	VisitDeleteSandboxResponse(w http.ResponseWriter) error
+}
+
+type DeleteSandbox204Response struct {
+}
+
+func (response DeleteSandbox204Response) VisitDeleteSandboxResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type DeleteSandbox404JSONResponse Error
+
+func (response DeleteSandbox404JSONResponse) VisitDeleteSandboxResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteSandboxdefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response DeleteSandboxdefaultJSONResponse) VisitDeleteSandboxResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetSandboxRequestObject struct {
+	SpaceID   string `json:"space_id"`
+	SandboxID string `json:"sandbox_id"`
+}
+
+type GetSandboxResponseObject interface {
+	VisitGetSandboxResponse(w http.ResponseWriter) error
+}
+
+type GetSandbox200JSONResponse Sandbox
+
+func (response GetSandbox200JSONResponse) VisitGetSandboxResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetSandbox404JSONResponse Error
+
+func (response GetSandbox404JSONResponse) VisitGetSandboxResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetSandboxdefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response GetSandboxdefaultJSONResponse) VisitGetSandboxResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type RunIPythonCellRequestObject struct {
+	SpaceID   string `json:"space_id"`
+	SandboxID string `json:"sandbox_id"`
+	Body      *RunIPythonCellJSONRequestBody
+}
+
+type RunIPythonCellResponseObject interface {
+	VisitRunIPythonCellResponse(w http.ResponseWriter) error
+}
+
+type RunIPythonCell202JSONResponse ActionAccepted
+
+func (response RunIPythonCell202JSONResponse) VisitRunIPythonCellResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(202)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type RunIPythonCell400JSONResponse Error
+
+func (response RunIPythonCell400JSONResponse) VisitRunIPythonCellResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type RunIPythonCell404JSONResponse Error
+
+func (response RunIPythonCell404JSONResponse) VisitRunIPythonCellResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type RunIPythonCelldefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response RunIPythonCelldefaultJSONResponse) VisitRunIPythonCellResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type RunShellCommandRequestObject struct {
+	SpaceID   string `json:"space_id"`
+	SandboxID string `json:"sandbox_id"`
+	Body      *RunShellCommandJSONRequestBody
+}
+
+type RunShellCommandResponseObject interface {
+	VisitRunShellCommandResponse(w http.ResponseWriter) error
+}
+
+type RunShellCommand202JSONResponse ActionAccepted
+
+func (response RunShellCommand202JSONResponse) VisitRunShellCommandResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(202)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type RunShellCommand400JSONResponse Error
+
+func (response RunShellCommand400JSONResponse) VisitRunShellCommandResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type RunShellCommand404JSONResponse Error
+
+func (response RunShellCommand404JSONResponse) VisitRunShellCommandResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type RunShellCommanddefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response RunShellCommanddefaultJSONResponse) VisitRunShellCommandResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type ListSecretsRequestObject struct {
+	SpaceID string `json:"space_id"`
+}
+
+type ListSecretsResponseObject interface {
+	VisitListSecretsResponse(w http.ResponseWriter) error
+}
+
+type ListSecrets200JSONResponse []Secret
+
+func (response ListSecrets200JSONResponse) VisitListSecretsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListSecrets404JSONResponse Error
+
+func (response ListSecrets404JSONResponse) VisitListSecretsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListSecretsdefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response ListSecretsdefaultJSONResponse) VisitListSecretsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type DeleteSecretRequestObject struct {
+	SpaceID string `json:"space_id"`
+	Name    string `json:"name"`
+}
+
+type DeleteSecretResponseObject interface {
+	VisitDeleteSecretResponse(w http.ResponseWriter) error
+}
+
+type DeleteSecret204Response struct {
+}
+
+func (response DeleteSecret204Response) VisitDeleteSecretResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type DeleteSecret404JSONResponse Error
+
+func (response DeleteSecret404JSONResponse) VisitDeleteSecretResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DeleteSecretdefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response DeleteSecretdefaultJSONResponse) VisitDeleteSecretResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type SetSecretRequestObject struct {
+	SpaceID string `json:"space_id"`
+	Name    string `json:"name"`
+	Body    *SetSecretJSONRequestBody
+}
+
+type SetSecretResponseObject interface {
+	VisitSetSecretResponse(w http.ResponseWriter) error
+}
+
+type SetSecret200JSONResponse Secret
+
+func (response SetSecret200JSONResponse) VisitSetSecretResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SetSecret201JSONResponse Secret
+
+func (response SetSecret201JSONResponse) VisitSetSecretResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SetSecret400JSONResponse Error
+
+func (response SetSecret400JSONResponse) VisitSetSecretResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SetSecret404JSONResponse Error
+
+func (response SetSecret404JSONResponse) VisitSetSecretResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type SetSecretdefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response SetSecretdefaultJSONResponse) VisitSetSecretResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type GetSpaceUsageRequestObject struct {
+	SpaceID string `json:"space_id"`
+	Params  GetSpaceUsageParams
+}
+
+type GetSpaceUsageResponseObject interface {
+	VisitGetSpaceUsageResponse(w http.ResponseWriter) error
+}
+
+type GetSpaceUsage200JSONResponse SpaceUsage
+
+func (response GetSpaceUsage200JSONResponse) VisitGetSpaceUsageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetSpaceUsage200TextCsvResponse struct {
+	Body          io.Reader
+	ContentLength int64
+}
+
+func (response GetSpaceUsage200TextCsvResponse) VisitGetSpaceUsageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "text/csv")
+	if response.ContentLength != 0 {
+		w.Header().Set("Content-Length", fmt.Sprint(response.ContentLength))
+	}
+	w.WriteHeader(200)
+
+	if closer, ok := response.Body.(io.ReadCloser); ok {
+		defer closer.Close()
+	}
+	_, err := io.Copy(w, response.Body)
+	return err
+}
+
+type GetSpaceUsage400JSONResponse Error
+
+func (response GetSpaceUsage400JSONResponse) VisitGetSpaceUsageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetSpaceUsage404JSONResponse Error
+
+func (response GetSpaceUsage404JSONResponse) VisitGetSpaceUsageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type GetSpaceUsagedefaultJSONResponse struct {
+	Body       Error
+	StatusCode int
+}
+
+func (response GetSpaceUsagedefaultJSONResponse) VisitGetSpaceUsageResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+// StrictServerInterface represents all server handlers.
+type StrictServerInterface interface {
+	// List API keys
+	// (GET /api-keys)
+	ListAPIKeys(ctx context.Context, request ListAPIKeysRequestObject) (ListAPIKeysResponseObject, error)
+	// Create an API key
+	// (POST /api-keys)
+	CreateAPIKey(ctx context.Context, request CreateAPIKeyRequestObject) (CreateAPIKeyResponseObject, error)
+	// Revoke an API key
+	// (DELETE /api-keys/{key_id})
+	DeleteAPIKey(ctx context.Context, request DeleteAPIKeyRequestObject) (DeleteAPIKeyResponseObject, error)
+	// Query the audit log
+	// (GET /audit)
+	ListAuditEntries(ctx context.Context, request ListAuditEntriesRequestObject) (ListAuditEntriesResponseObject, error)
+	// Check the health of the runtime
+	// (GET /health)
+	HealthCheck(ctx context.Context, request HealthCheckRequestObject) (HealthCheckResponseObject, error)
+	// List spaces
+	// (GET /spaces)
+	ListSpaces(ctx context.Context, request ListSpacesRequestObject) (ListSpacesResponseObject, error)
+	// Create a new space
+	// (POST /spaces)
+	CreateSpace(ctx context.Context, request CreateSpaceRequestObject) (CreateSpaceResponseObject, error)
+	// Delete a space
+	// (DELETE /spaces/{space_id})
+	DeleteSpace(ctx context.Context, request DeleteSpaceRequestObject) (DeleteSpaceResponseObject, error)
+	// Get space details
+	// (GET /spaces/{space_id})
+	GetSpace(ctx context.Context, request GetSpaceRequestObject) (GetSpaceResponseObject, error)
+	// Update a space
+	// (PUT /spaces/{space_id})
+	UpdateSpace(ctx context.Context, request UpdateSpaceRequestObject) (UpdateSpaceResponseObject, error)
+	// List sandboxes in a space
+	// (GET /spaces/{space_id}/sandboxes)
+	ListSandboxes(ctx context.Context, request ListSandboxesRequestObject) (ListSandboxesResponseObject, error)
+	// Create a new sandbox
+	// (POST /spaces/{space_id}/sandboxes)
+	CreateSandbox(ctx context.Context, request CreateSandboxRequestObject) (CreateSandboxResponseObject, error)
+	// Delete a sandbox
+	// (DELETE /spaces/{space_id}/sandboxes/{sandbox_id})
+	DeleteSandbox(ctx context.Context, request DeleteSandboxRequestObject) (DeleteSandboxResponseObject, error)
+	// Get sandbox details
+	// (GET /spaces/{space_id}/sandboxes/{sandbox_id})
+	GetSandbox(ctx context.Context, request GetSandboxRequestObject) (GetSandboxResponseObject, error)
+	// Execute an IPython cell in the sandbox
+	// (POST /spaces/{space_id}/sandboxes/{sandbox_id}/tools:run_ipython_cell)
+	RunIPythonCell(ctx context.Context, request RunIPythonCellRequestObject) (RunIPythonCellResponseObject, error)
+	// Execute a shell command in the sandbox
+	// (POST /spaces/{space_id}/sandboxes/{sandbox_id}/tools:run_shell_command)
+	RunShellCommand(ctx context.Context, request RunShellCommandRequestObject) (RunShellCommandResponseObject, error)
+	// List the secrets of a space
+	// (GET /spaces/{space_id}/secrets)
+	ListSecrets(ctx context.Context, request ListSecretsRequestObject) (ListSecretsResponseObject, error)
+	// Delete a secret
+	// (DELETE /spaces/{space_id}/secrets/{name})
+	DeleteSecret(ctx context.Context, request DeleteSecretRequestObject) (DeleteSecretResponseObject, error)
+	// Set a secret
+	// (PUT /spaces/{space_id}/secrets/{name})
+	SetSecret(ctx context.Context, request SetSecretRequestObject) (SetSecretResponseObject, error)
+	// Get space usage
+	// (GET /spaces/{space_id}/usage)
+	GetSpaceUsage(ctx context.Context, request GetSpaceUsageRequestObject) (GetSpaceUsageResponseObject, error)
+}
+
+type StrictHandlerFunc = strictnethttp.StrictHTTPHandlerFunc
+type StrictMiddlewareFunc = strictnethttp.StrictHTTPMiddlewareFunc
+
+type StrictHTTPServerOptions struct {
+	RequestErrorHandlerFunc  func(w http.ResponseWriter, r *http.Request, err error)
+	ResponseErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+func NewStrictHandler(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc) ServerInterface {
+	return &strictHandler{ssi: ssi, middlewares: middlewares, options: StrictHTTPServerOptions{
+		RequestErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		},
+		ResponseErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		},
+	}}
+}
+
+func NewStrictHandlerWithOptions(ssi StrictServerInterface, middlewares []StrictMiddlewareFunc, options StrictHTTPServerOptions) ServerInterface {
+	return &strictHandler{ssi: ssi, middlewares: middlewares, options: options}
+}
+
+type strictHandler struct {
+	ssi         StrictServerInterface
+	middlewares []StrictMiddlewareFunc
+	options     StrictHTTPServerOptions
+}
+
+// ListAPIKeys operation middleware
+func (sh *strictHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	var request ListAPIKeysRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListAPIKeys(ctx, request.(ListAPIKeysRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListAPIKeys")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListAPIKeysResponseObject); ok {
+		if err := validResponse.VisitListAPIKeysResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// CreateAPIKey operation middleware
+func (sh *strictHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var request CreateAPIKeyRequestObject
+
+	var body CreateAPIKeyJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.CreateAPIKey(ctx, request.(CreateAPIKeyRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "CreateAPIKey")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(CreateAPIKeyResponseObject); ok {
+		if err := validResponse.VisitCreateAPIKeyResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// DeleteAPIKey operation middleware
+func (sh *strictHandler) DeleteAPIKey(w http.ResponseWriter, r *http.Request, keyID string) {
+	var request DeleteAPIKeyRequestObject
+
+	request.KeyID = keyID
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteAPIKey(ctx, request.(DeleteAPIKeyRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteAPIKey")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(DeleteAPIKeyResponseObject); ok {
+		if err := validResponse.VisitDeleteAPIKeyResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ListAuditEntries operation middleware
+func (sh *strictHandler) ListAuditEntries(w http.ResponseWriter, r *http.Request, params ListAuditEntriesParams) {
+	var request ListAuditEntriesRequestObject
+
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListAuditEntries(ctx, request.(ListAuditEntriesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListAuditEntries")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListAuditEntriesResponseObject); ok {
+		if err := validResponse.VisitListAuditEntriesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// HealthCheck operation middleware
+func (sh *strictHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	var request HealthCheckRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.HealthCheck(ctx, request.(HealthCheckRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "HealthCheck")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(HealthCheckResponseObject); ok {
+		if err := validResponse.VisitHealthCheckResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ListSpaces operation middleware
+func (sh *strictHandler) ListSpaces(w http.ResponseWriter, r *http.Request) {
+	var request ListSpacesRequestObject
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListSpaces(ctx, request.(ListSpacesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListSpaces")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListSpacesResponseObject); ok {
+		if err := validResponse.VisitListSpacesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// CreateSpace operation middleware
+func (sh *strictHandler) CreateSpace(w http.ResponseWriter, r *http.Request) {
+	var request CreateSpaceRequestObject
+
+	var body CreateSpaceJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.CreateSpace(ctx, request.(CreateSpaceRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "CreateSpace")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(CreateSpaceResponseObject); ok {
+		if err := validResponse.VisitCreateSpaceResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// DeleteSpace operation middleware
+func (sh *strictHandler) DeleteSpace(w http.ResponseWriter, r *http.Request, spaceID string, params DeleteSpaceParams) {
+	var request DeleteSpaceRequestObject
+
+	request.SpaceID = spaceID
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteSpace(ctx, request.(DeleteSpaceRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteSpace")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(DeleteSpaceResponseObject); ok {
+		if err := validResponse.VisitDeleteSpaceResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetSpace operation middleware
+func (sh *strictHandler) GetSpace(w http.ResponseWriter, r *http.Request, spaceID string) {
+	var request GetSpaceRequestObject
+
+	request.SpaceID = spaceID
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetSpace(ctx, request.(GetSpaceRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetSpace")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetSpaceResponseObject); ok {
+		if err := validResponse.VisitGetSpaceResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// UpdateSpace operation middleware
+func (sh *strictHandler) UpdateSpace(w http.ResponseWriter, r *http.Request, spaceID string) {
+	var request UpdateSpaceRequestObject
+
+	request.SpaceID = spaceID
+
+	var body UpdateSpaceJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.UpdateSpace(ctx, request.(UpdateSpaceRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "UpdateSpace")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(UpdateSpaceResponseObject); ok {
+		if err := validResponse.VisitUpdateSpaceResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ListSandboxes operation middleware
+func (sh *strictHandler) ListSandboxes(w http.ResponseWriter, r *http.Request, spaceID string) {
+	var request ListSandboxesRequestObject
+
+	request.SpaceID = spaceID
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListSandboxes(ctx, request.(ListSandboxesRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListSandboxes")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListSandboxesResponseObject); ok {
+		if err := validResponse.VisitListSandboxesResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// CreateSandbox operation middleware
+func (sh *strictHandler) CreateSandbox(w http.ResponseWriter, r *http.Request, spaceID string, params CreateSandboxParams) {
+	var request CreateSandboxRequestObject
+
+	request.SpaceID = spaceID
+	request.Params = params
+
+	var body CreateSandboxJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.CreateSandbox(ctx, request.(CreateSandboxRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "CreateSandbox")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(CreateSandboxResponseObject); ok {
+		if err := validResponse.VisitCreateSandboxResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// DeleteSandbox operation middleware
+func (sh *strictHandler) DeleteSandbox(w http.ResponseWriter, r *http.Request, spaceID string, sandboxID string) {
+	var request DeleteSandboxRequestObject
+
+	request.SpaceID = spaceID
+	request.SandboxID = sandboxID
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteSandbox(ctx, request.(DeleteSandboxRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteSandbox")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(DeleteSandboxResponseObject); ok {
+		if err := validResponse.VisitDeleteSandboxResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetSandbox operation middleware
+func (sh *strictHandler) GetSandbox(w http.ResponseWriter, r *http.Request, spaceID string, sandboxID string) {
+	var request GetSandboxRequestObject
+
+	request.SpaceID = spaceID
+	request.SandboxID = sandboxID
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetSandbox(ctx, request.(GetSandboxRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetSandbox")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetSandboxResponseObject); ok {
+		if err := validResponse.VisitGetSandboxResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// RunIPythonCell operation middleware
+func (sh *strictHandler) RunIPythonCell(w http.ResponseWriter, r *http.Request, spaceID string, sandboxID string) {
+	var request RunIPythonCellRequestObject
+
+	request.SpaceID = spaceID
+	request.SandboxID = sandboxID
+
+	var body RunIPythonCellJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.RunIPythonCell(ctx, request.(RunIPythonCellRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "RunIPythonCell")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(RunIPythonCellResponseObject); ok {
+		if err := validResponse.VisitRunIPythonCellResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// RunShellCommand operation middleware
+func (sh *strictHandler) RunShellCommand(w http.ResponseWriter, r *http.Request, spaceID string, sandboxID string) {
+	var request RunShellCommandRequestObject
+
+	request.SpaceID = spaceID
+	request.SandboxID = sandboxID
+
+	var body RunShellCommandJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.RunShellCommand(ctx, request.(RunShellCommandRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "RunShellCommand")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(RunShellCommandResponseObject); ok {
+		if err := validResponse.VisitRunShellCommandResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// ListSecrets operation middleware
+func (sh *strictHandler) ListSecrets(w http.ResponseWriter, r *http.Request, spaceID string) {
+	var request ListSecretsRequestObject
+
+	request.SpaceID = spaceID
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.ListSecrets(ctx, request.(ListSecretsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListSecrets")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(ListSecretsResponseObject); ok {
+		if err := validResponse.VisitListSecretsResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// DeleteSecret operation middleware
+func (sh *strictHandler) DeleteSecret(w http.ResponseWriter, r *http.Request, spaceID string, name string) {
+	var request DeleteSecretRequestObject
+
+	request.SpaceID = spaceID
+	request.Name = name
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.DeleteSecret(ctx, request.(DeleteSecretRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DeleteSecret")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(DeleteSecretResponseObject); ok {
+		if err := validResponse.VisitDeleteSecretResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// SetSecret operation middleware
+func (sh *strictHandler) SetSecret(w http.ResponseWriter, r *http.Request, spaceID string, name string) {
+	var request SetSecretRequestObject
+
+	request.SpaceID = spaceID
+	request.Name = name
+
+	var body SetSecretJSONRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sh.options.RequestErrorHandlerFunc(w, r, fmt.Errorf("can't decode JSON body: %w", err))
+		return
+	}
+	request.Body = &body
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.SetSecret(ctx, request.(SetSecretRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "SetSecret")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(SetSecretResponseObject); ok {
+		if err := validResponse.VisitSetSecretResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// GetSpaceUsage operation middleware
+func (sh *strictHandler) GetSpaceUsage(w http.ResponseWriter, r *http.Request, spaceID string, params GetSpaceUsageParams) {
+	var request GetSpaceUsageRequestObject
+
+	request.SpaceID = spaceID
+	request.Params = params
+
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		return sh.ssi.GetSpaceUsage(ctx, request.(GetSpaceUsageRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "GetSpaceUsage")
+	}
+
+	response, err := handler(r.Context(), w, r, request)
+
+	if err != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, err)
+	} else if validResponse, ok := response.(GetSpaceUsageResponseObject); ok {
+		if err := validResponse.VisitGetSpaceUsageResponse(w); err != nil {
+			sh.options.ResponseErrorHandlerFunc(w, r, err)
+		}
+	} else if response != nil {
+		sh.options.ResponseErrorHandlerFunc(w, r, fmt.Errorf("unexpected response type: %T", response))
+	}
+}
+
+// Base64 encoded, gzipped, json marshaled Swagger object
+var swaggerSpec = []string{
+
+	"H4sIAAAAAAAC/+x9/W/cNhLov0LoHZDkQV47SVvgfHh4cJ3eq3FNm4uTK/CavD2uNLvLs0SqJGV7G/h/",
+	"f5ghqY8VtSs7sZte80vi1Qc5HM73DEcfkkyVlZIgrUmOPyQmW0PJ6c+TV2f/gA3+VWlVgbYC6HqmgVvI",
+	"59zir6XSJf6V5NzCgRUlJGliNxUkx4mxWshVcpMmIsdnB5clLyF6w2SqcrMJCyX9AbIuk+NfEp6XQiZp",
+	"YiqewbEGnjc/rrSwOD1cQ5a8j4DhL3Ct+Sa58a/R6DmYTIvKCiWT4+Qcr7OzF4bZNbAL2DBhWCFKYSFn",
+	"VqWMFwVzL7OrNUgGZWU3SdpCu2fqmzTR8GstNOS4JpEnzZrTLoLbRajFfyCzONJJhlCeZBlUFvIh8K/B",
+	"VEoaYFYxY7m2Qq4Yl4zTe0m6tZ3u8lxERjrLQVqxFKCZWhIq3MN/Y8IaphYG9CXHC4ZlXOsNE3Y23P2t",
+	"tbbzRRdX58KeZFbpITg/rxWrQCPFQU4rwochZ7gcHl1cdFUvwmpOXp3h5qbsXcKlkptS1eZd4naU13aN",
+	"q89oYNz/XBi+KCBP0nFC7s/0Iy9ha6696NmFl++k1ZvhNCdTMNHb5gH8PGD8LxqWyXHyPw5bsXDoZcJh",
+	"Z29u0gS0Vjo6GFwLO89UHsHId9fCMryFaOFsKaQwa9xMgi5lB08d9oVluciZVJbpWiIlIzwF+KX5KYW0",
+	"sAK9Q8C0+BiA8lO4xVqCwI0AY1MGs9WMOT485zJfqOuUKe3B/HuAeqk0vQYyp/V0eGwISW0zVUJXkpk6",
+	"y8CYJE2WXBS1hqjM0lAqC3Oe53Fse5hjcoBusIpvCsVzdiXsmhnINFjDNOQ8s5DjCMatcIw2SM6N3rTc",
+	"1mZkt79/8+YVc0+0GHbCKbqJpD0m6pSYBPVPOmLubn6L/hhvndI+O3X3usVmn38+V1X1A2qlRk1ZhX8a",
+	"YCZosLsrJb+w/RjzO4pCpih+WibHv+yRI/RecpNu49gR53CNb9rVGeQ1bhhnC+AaNLPqAuSMnVmWcYny",
+	"YoFEZrWAS5QrKy7kfp3kJx4u9X2zWC8GXu/jtlLlUJBkIPFBupd5DpuxN+uwNcIwyy9AsqVWJe1fxe16",
+	"NtRgJV9FWOt8rbRdc+mEkKkgm9GTKasN5E6I4lWWKzAkRw1YlFBuvDSRdVGgOkuOra4hjbE9ZNP31KPn",
+	"HF+6eb89/M3NKBGRqXVHrOKrA4T1Btj6mbxofwWJFEbZi5ASLM+55YSUPBc4CC9edSe/SaOGZPPm6Cwt",
+	"VvZbEgHgkl//AHJl18nxN8/TpBQy/HyaJhW3FjS++v9+4Qe/HR389f3jXw78X/8zXHryv/8S01W/1sry",
+	"W+w9AvRPege3friDQoOzpEkgMhIsA1wgyUFWa2E380qrpShuIVLO/Zuv/ItxEuyyPOE5Jtu+C3bNluWC",
+	"lxv15QhyQHsjRg+9iveIgiutVpqXJbciY8jChZCrGTstBC6JmbWqi5yV3GZrpiTi7Z2klzW3a5R4a05X",
+	"WQnG8BUwlAIW2YLV8kKqK0lzocNyAe/kv4VESuDFv2fvZJI2yknIS16IfB7sh7S5Qpzf+bm9K2lSSzSP",
+	"lRa/kTm8VHoh8hxweKnsfKlq2ai6ee+KNzW611Du9S8obekCXFfK0Ay4W/NMyWUhMgS182d3SF1LiTTs",
+	"SXgO1xlATiOQ3JtXdVHM0dqia3wF0s5rqYFnayIVpEF9KTLYuhpwmLyfIChysFwUMeFjaWZGpjMT0tk5",
+	"zkCZIH5ot8fIK9zep+rCczHS/x54YdfnZLANLSDTXIdrjsY4vnuxd0L/Wmy+l6qW9tzrmS2fhpV4k+WQ",
+	"FVxDzoRs9SjzFNqHD+2ruZKF95CWvC5scrzkhYFtoUQTM3zhAF+YsW+FzN2MhnENjBdXfGM6T7TLXChV",
+	"AJekIlWts8iO/EsVdQkMiZasbiFbwe38iIVRRW2BrRUZ6HZNkmHRQpFsC/Sh7cb1KmYsnYSxadgwtUPc",
+	"hFHpwvaYl7SgY8ZpTTlzv5lZ09YsNt05wPQUVerdqBxl2VJoY9FCmdFacUDCQC40ZFZhBEEakQNuNehL",
+	"0AfI6GJV4zS8KNRVIdA989tDqIK8v01BvjkYkzTBmZL3+8iU7jZb2qA3RrY/gr1S+uKVKkQWccf9bSaM",
+	"KpyDWdGTzuVtd2LLP8fVRZh7pcEYloOxQvpYC5JKgwzSRCmaxGevGDqJYAyYlJ2evXhtkNYIwbhthj3W",
+	"YFRBdrH1hhQqGFHCk1u4CWlSNmrOM1myrAvUhn3YpZJwzKRi0mOEk7/LFoB77CwCXUucn5GwRbMOL5Jc",
+	"nrEgdI8Z7i1TpP1aKmtIuww2Ndd+IBSksxZHx81QrCpqN3EPo0K6h2cMF3LMUPjj8tFDZkBbMGPn3Zk9",
+	"MC4EiPNKuATdmb1DiYiHrg5JkwayJHWo20uehPIYMb6qC7RBCcaIMFitNKyI/UgBMlSArArPD+yXWmtU",
+	"iYuNjfma3+JllqsriQEFCttoZQxFQwu+AW2StHXehbTffBUP1sR9mjOCcAFIBghnPNTmJxqyXV0uXKDS",
+	"PcEMgGRGsSXXUSjcY/Mcd2f/aA4gEt4FypsNqzQYkDY6tlWWF2NofIM3Gd1Elzbgc4jNrSXsxWzMy3oN",
+	"TqSZaKCYbrnItunJpxl7Kw1YXO9voFV4BCm9lvQD8hnzxn0bCctFCdIQTzUa4JFhZI35MYYublbVEehO",
+	"X701Xb3CSr5B1eGDc0ezr7soyVXtLLVSSFEi3x01yJC0l86GKpXezMvFcLqXdMvB2CgwP7OQ7KX4NrYD",
+	"kdl270Ytz15t7FrJUyiKW7i9GCeqye/177MMiuI2wfyTLAQ7K60uRe4Ud5C/OEcnoM+s5tkFTvf4rSHr",
+	"y8muYsMWYK+QLMOb6H2QwH4yxYqNe0in6N5Y5VcJiPCwzAvQEor9hgvIyx2OecxI78elCb1KMpCXQitZ",
+	"grTskmuBqzFTHHdTFcLOVW2r2u63QH9eg3PmFKMXmbG5qi1h09gctCYt0+6IOdRg6sIa9vjUSeliw8RK",
+	"Km+BOdQpvWMXOpYr7pyqbSxAHxDhH0EwDGRK5tvC/fmzLgM8HZ22IxbRCpjnIpbbUZrIrbUEO1Q/xUHa",
+	"0pfZmL58XcvzNRTFqSpLLvM7saDBAVjmRvgj8qADPMKGdKPDiV/47gvf3ZLvHG3FWM8b0JFgtrvBdDBI",
+	"4tE1H67RkdDK29c/DN2JEHTE14Jj2VXr3E7jFmm5kKDnu5PJftRHhjVvpK4ugF2tRQFMWCaMY3wjlIxb",
+	"t3BdCQ3GF1cMaLfnzeNwORSA1v0CMl4bmsStPaccfTDASn6N5MIKsQSfJYsm2fZiQ5gmyHb8YYy3+iA6",
+	"a9kqyuQ64Wii4ZQJoe9gnLa+GPlevGzCEEKzFUjQ5POcvZhNWVQ/AbpFWVL8WgMTbSlEsHUnx1Q+bVh7",
+	"S24sl5BZcQkszML8LFs4+xsGnZARKDX0QmUXoJmXlYbxqio20ZB8J/kbS21093oBhZIrw6xK7juflHai",
+	"kbcb0L22Pz/QoYkOFnoMsEPGxYOa553wpViG0pK4sPtIvRrTphHK3atdR1z10yDifFhha2T2uKwNytys",
+	"qFHo8pVz2h5VZNQfP5/99dGTAePsD4G70OhIvNWQEu/FE4XzsOk9JqRVXSC7Ia9dlNNGqW9GYWxCYz7U",
+	"NZ0u+8HEKKlXSsdWfe4yFYxux/QbrpxfdEJizK61qldregpfQ1lx3atb84oiOf7m66+ff923M4Z2xT5s",
+	"6G7wYRo+2nhFnO1d/UoMG3SjF3tGDAiJpEyZd6HZJS/qrYCdrbWkcrKcLofCmDYv3yp2YzXwcjaVcBxI",
+	"r2G5H1U3O0RJI+hGhAnd38omxQynhgx2a+5xc0maK9DesFhTqohla8guJlm+9GRTNTpi2DSWE1sKKHLD",
+	"cEJWcGOZhqUGs4b8zqZLa8ghyiJY8EqR7gY6aiF6TBLMy/6UwbWwkE/zt3oFs3tsuituQhna+FrvXHLX",
+	"X5JYeqPU19n53Ol+f8JlUMcX1Bi56Fo06da7bVuoEZxKOUQtxqqq+ohJHXmPkoi7TSTt+S9FZCIgTh/m",
+	"sBQSBU330Y/K8n5fl1weoC1NktzlmBlfoKtICHDOKavW3ExaolLl/EJQlH2nPGgxi6Tp3vBOYgaQ4w6j",
+	"QCg78dNJAsEBOpj6VZd06JktQ7aTWKlA5o5kMTrv/goVz0lKCeFNEqg1WmWH782rTvpkmpLqJV3iOtsB",
+	"tJtoOykZAvsjCJbWOk3CoKvoXOR88zHzmViB1UtK40FLqO5BdjUuFibMRdiZZ2iI7UrVIPBmi2jXHBOO",
+	"IJkfpet2dySav3dLIfNxexZIdeqkV7QUxN0nmDmmAH2Aq68BG5/7XVIoY98lrAQuDTF9C5tUDJ0/QLEg",
+	"jMvyNGza2fYkTVpF05CAU6ZJSjPEk6FD82ikWPTE1zj7dBbaga7ukmw/0nY9yy+Sj7rDAZfR8uC6ym85",
+	"WKxQrWdE9AZ9P4oaNDzHsePsYhdWVjvqErwfut+9dNW51pcgO2zP2IsQYfCX3eyPjC+OQSKnYIUwrJbu",
+	"dTWbZhbEDNm/41g0dC1z0OxQ1/LQzWlSxpktq6VJERgqwG4hpVM9jdCieoN+Wcsjg8lHPQW03XEs3qHP",
+	"/vhNYeeucNLUMsbt0NHQoOA6B5LEii65IJCgA06NrmgYPFIrwCu+EIWI1t0mp527OOAFQOUzt6ff//Tz",
+	"j7eqN8m1qua8KOa753yhVUXZ8+5jZKZUaCMpg5lu42i+90w8GqnmEq7mlRaXooBVbMJXGi7J5tIqo7Ib",
+	"5zRizTlZL+2rsQmaerW5VsouI+O7MjWKaytliVHMxlgo24ond6oDidnxINJ3U8hm2aGru5E5O7RlFQfD",
+	"hcwjB2ROz0I83e+crqXJkniEE62kboBzC1NYixaIHx9to5SyF7hfK2NjM9DC5kb8FquFF78RZ6F/28WA",
+	"h/rrp8/K2JDEzsN4rwHdBKuNH+Lp0dHRMf6TTFRNNojgkexiq486EmHAYvSEj8o0pd5ff/38m9vJCDdM",
+	"VEiQxBkJ7e7Jxuzyat0AvQqzO9sqf556/t1ZhE9ZlP+DL/hBu5zbXqlOW1/n623uv1B/EMjr5zHogEu/",
+	"6k+YUFyqoSp4FgoHfdHolciBtdXqt0hq7Mj2TNulvrkXIx5yHdxjH8MY2+mKNkMxbhK0JHH8YZQi+JAU",
+	"Zuz/glZMaW+jRYvB3skf1BVodzSHLrZnjzhlqZxrgA80I6dsUVsm4aq99E5SKDbzNgmFZJfuUBOqOtyL",
+	"fnG/C265HGuzU++kMEyhnS+sO/cwpebMVeZR5VmXObu4SJkBYG2U+vY1aPwaTzKEUku/0Jgn7VK2snFv",
+	"A068A4X6XckMQt1gFNw9NWoOnJAVnoeCg7Fgsw+dh6QzMSK6fYwvrQsXbegBrxtuWzHnoGlhn4CTYTlw",
+	"y6e75hkvBHQ04B4YpQJfEriDGO5aIUhM+jYeAwz+eaakqctG6/FQ/ixzH4qzFKevcRTHBF6mbPHABCr8",
+	"cUB9WVMFE+hQyDiSooGWvYzXBL8bBywy7pDnBny2Fsaq2HH5lxH0tGe/IWeaSzxIqYocjHVnFmbs2zq7",
+	"AGtICGHM1b2NxK5KYa2LI0xKC9HmuuGixfUTSXM/nnbUrQ735VOaF2+2S3/7xQcNsBSc89XxQyU9LgZ+",
+	"3M/+EVaLZPeJliJs4Mm0uxktQcVU61vS57c9y0pWwJ3Psv4IV6xzZWCp3IsBjJOG96bO2KLp17jxgYO2",
+	"BNUfNkXNnq2ZHjlHOmM/0QF4F2LopUI86SFULCuAa+MblOyGM02uD1bqwF/8j1Fy9ppfvWzO1zV3D8yF",
+	"qA5c9IQXB5VCatNh0OsDFAyuMcwuuzmCir6N3MfHjvX6QT+jFccUXFf87ZHNeU3mpJLAFvSC03Ykn8fK",
+	"bkdNmDcYXkB0bTUdMcwqddEVkuM65RZq0qJjFaYa04Tj0J6+ektKm47zp3jIqSq6RQm9wNwEyMHV+04L",
+	"ZjdSb5dB6AByjzIqPSRtw8q6sMIFEr1ionUIS/mLNRT5NIgr4BfzHcrwe7Faozg1Q0icTSTkqth1hmN8",
+	"ah+MGl/9z7woDrJCZRducf3jj+TmwMRl7qo9PAevMVda1RWywmLTCdTHE0p3TDO4dx2hDDHQkn66zWh9",
+	"Qo4Rz2Avhxq0IxvP0cJwLO1afJzUsUy876fEyKzghr1LTvyRdIo5HbNv6WX2rj46ep5dwIb+gHfJjP2E",
+	"cVOQS6Wz0CqjDR4wYVjn0Cn5nH4uPAOIKsccewX0GBNJG4vnFp+krO3yEv52OYbHokR2MJ0HnpCtjlXW",
+	"7DEGGsmDxUtUo8NMt7LriXNfyfCi0C2tq93PtbVVcoMYFHKp4ohCDVJyyVdIQ6RpTcoq0AYJVdq2nDZt",
+	"AHMnHXw5t+kcYy5n7Ftu3HneTJULISFn/rAOmTaU+7PCorZJXoK0whAGH5+Gp1/SY1g7c4kwEJhPZ09n",
+	"R75lk+SVSI6T57Oj2XPfIIDo4ZBX4gA3An9EDz//IIw1nf0a6z4x67YHOsv9m64xjUnSJPR5oJmeHR15",
+	"dwmRhX9SpsSVbx7+x+fBnWXc6wM0qQnOdiXYwOQKq0kb38OVOfu01gzH+Oro+a1A3AWZa4ARASS04WnC",
+	"Omt+CUPMEjhf3T84AS8uJOGbszWF8MP+bWq5nLmAsj8gct8AvpVwXbkkK/hn0sTUZcn1xlNcQ6qk8VTM",
+	"bXBtcgzjMjzs2wj5PK5x2cqmkDEESAMJ34YJun2dkqa12Lcq33wybMWabd30lZE3lrd48Ok9geAmGaP3",
+	"0LwA6T7EVxzmPaEf3T8dnblGLG7TvvD7H5XfHdl1+JjuNzrt8MMFbOYiv3EioIBoiSpd7wsDVFldk4X7",
+	"yqS66rREcwPmtxEGbq6OMOix41dD4P6BtOLneTCqwElxjdQ8yE/714fjDTGC+s+K8l7DpbroUx5aVZpT",
+	"pNhQsG+4PDHo/orklqSJwCfQKAvJpuPEkW6yLcXTzsK23Y/3RPx1LuyoNfeadJpx3U0ZSKsFmH5g9pgA",
+	"K2vrAmkNBZtOp9juqYeTV2dpp5MtJVPoqGWb4KBDJFcumQEyD0wDxoZ0VKZ0DiMNNW9tc4bGrq4OZeeu",
+	"eMeFnnV7EhKhzbb8WoPetPvSSQuO70Q68DuRhsOm+2g4neUx4nJ0KgxN9KaZ5oQOS8vyrZnhes/MVn2C",
+	"eYeppYBoq7xxNWN47EnIGto4DA4f4KXMrpJUCVfx1Si8oUa6BbkRFFhjkraHcJ4eud87DuHcvB+I5vvw",
+	"Vtr2wxM8lpfcumY+PcZ9eIOJCOhztJc+F8XwTyRMByHtVKFWzihpzz94wdwXXK5R3Kk/1PBR5LdrWb1+",
+	"dCMID4VjwjDSup1IUnL8y/ueAYbw0mrd8ho544ZwK28UweGHNjR3c+iOfiHAOyV0OJR19qJh/r6i7B0r",
+	"vaWybFsAj2lL6nprqP7CuGD5JRcU+HdqwsR1kIsIPUjYg6aaFPVo1hAg/9xcd78d+x13V9/Sq6kbc73P",
+	"fSbt/jzvXo7ygR1vv/k3I2V1wdf2fdGxMdrm4ZWGkFVtm/4sD6s9QlC797WJTtmUj9wq7WpG+3VymF4x",
+	"YLtRwoij/gC+0Ykndl+vJXyxf+ibFg6IfIauecupXhngn6gJvBk91Tfv71jqokZdDyPFwjkwhmmwXMj5",
+	"pT/BLmgL3Qv+2phjHkTFTn30j5AjDuUYzURNDwghjQVORjetzNdSliPGax/guBXrO+9s15pHTNWvxqok",
+	"Q4lZRBY8QFDBwdAPK3wutOp2vykZuUn3WQPuqKhvG9r0nmj9xj5x/R+wrRK6J7tujx7wAH/Z7o3bD2a6",
+	"aJkUu6kHdczdSsYx27QNFtzCMk2Tqo7SHxZmQ9NsNdyi+EpTQaSWQ3E5pMlOddU92UaR+q1JttGD8YSv",
+	"K/+T2kYnQ2tHad9VdJrZ8yeXIo6+Gd9p3Bz2Si4n+5jNW6FIYL+SIaeTtyWYD+B3utlu6Xk2ByC+EFHj",
+	"9XYrbjtGyB3SCcZ5fa6SrTnC0+L87kqqwVjzVxpVXFP8dgdPrz/lNp2jXI4Teu/bQfuMdZfuYM+OnjFR",
+	"lpALbqFwaefmCP12X2L8ueDZxUo7cokb7dxsZHYHW/3eghD9jynF+MAvsTlDGKzYx4hzSu/6KtRw6MuI",
+	"HJ7MknuNXwQhsgfebT3NQiIrLMI1Rgskf/aibeDK3r7+gRbx7OjZQ8LN/ecknZeA5LLWSqra9Jp2uMqT",
+	"ptSk0+TRk2L3YddG4m9Nt3lGRSmV0v4ENjnFrnkVdx/16r3d+7ykCo+7Bly/r93jWkGlzH8gx0U3XNbp",
+	"ye+rKb569kCpb8dzj+gDE5GzJ/78X6f3CrUk/X21WSgnDyJltjMI1LDMbkuplyW4dXQoMJ8vLOLGqExw",
+	"90kTf6ZsNPzT6JQJIRU/z+8cVPFQKM3+GPGV1mS8Y4TFDRCPsYxt39FDSv2Hj7T8MWiAgi59HN2Djfsp",
+	"wzDp7aJAuwH46BzlflF5SM1M9udT/WdM7xVVD5C0nYCQQq2+4KODDzr8cPgB/7s5/IAA3Px3oyeN9epq",
+	"D4L0PoXX9QLp469xSPC9KTB0jslGO/cslb7iVPPWtPEiwFJqErIA3+ce7Rgv6pgpuFmPevH+133RjivR",
+	"803qvzDUFlIOqWnK5gtuWtxY0KWQvPiCkw5OlCrMsa7lXLi27XP65NR/N4bGgoKva2nCKTd3Wq8TI8Ev",
+	"wkjGtz9a5U/0+Rrm8O0LhJmdWcPcJ2vomyD1ovBHks0g7jHWgrzvUvQ/LHbrLFnzRdfwDeOk0kLax4++",
+	"h6JQrtzVj//oSXLTReHOZu7Rz53FAi0ecc2HZ1rXZH8S7tMFytz3mU58LCwGaPudnhAx85vZ3TWuobOn",
+	"o3v44OWwDv+fg7eXMjX4TkynCfnv6wxuB4a+81+l6zA4SsItOyy5uaN0pa+JzTsf5fqzilfe/7Datoh9",
+	"WGna/Ujcx4lTv69JYdhBcSvZGftQXYzNelj7Iji/CM7PTXBuMfZEwdl+6mXHWX8cyH3dertXm3t9xv41",
+	"9s2XkboEP+2DVCXQXFOKEtoT39ufVzcuobbYEBq+VCo0lQq2j7Lb1Co8YPncTto//IBjT8srbbcVJ0i7",
+	"Hy13DXj6fd+ph5SwQ07wSabQl3hCjsnN/uDnfx0ZqtAO47NPKjUc/xkRYTR5ILstin3Hg+iU9N+nKBkN",
+	"hTdK+7a+42Td+54DyExvqHiBW/rMhwtEcun7+LsmWighNSxBg+z0C6axXS1PN5za4ZumzZqbj44F02ep",
+	"QqkHVgMNGajpAX5PFauDHuMPXa/qCTnCkQ6pfgtzX83y9OFm9hvz8HaiYxn/lbkvmnjj2KAj9eLKrg7d",
+	"bnc2AdjRqbxtSVsbSJmE6+YzJL02pK67FV7eapGL1oH7bjCeGEZ54PoNmhn7Wbj8R8nt/8rMpRMpOIR/",
+	"wrcEcBZlii7n6fm/3NEn+vhXDpppdTV+0OKtb7K42+uPnMinij/IWa6uZFjuWtW6/9GVZ1/RRSzEWSoN",
+	"zKrf8QB/DzKprsZA+SQn+l2b9gBL2K4GLjprRZ8AF9KqGXvBN8Z170A1UopcitXasrdvTsegdEPGyyoT",
+	"RHrnG0j+Z8430c8bDYmeUn7UDc5N03QBvVqrcJwXZQ11AK9AD3L6W8BSC8H5YjMCbmjhGuANv/2oI0BH",
+	"ychtVnwaEjrtLP5nZi5jE7y/7+MWjvVQKlm4tocIRm+AbYCGwi+Ij45Z9ns2OfhyVsozhlMpn5ur1+9N",
+	"0O9v+ct7pHdXV+lgpQ/dJ4eXT5Ob9zf/fwDZujR9qJ8AAA==",
+}
+
+// GetSwagger returns the content of the embedded swagger specification file
+// or error if failed to decode
+func decodeSpec() ([]byte, error) {
+	zipped, err := base64.StdEncoding.DecodeString(strings.Join(swaggerSpec, ""))
+	if err != nil {
+		return nil, fmt.Errorf("error base64 decoding spec: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(zipped))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(zr)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+var rawSpec = decodeSpecCached()
+
+// a naive cached of a decoded swagger spec
+func decodeSpecCached() func() ([]byte, error) {
+	data, err := decodeSpec()
+	return func() ([]byte, error) {
+		return data, err
+	}
+}
+
+// Constructs a synthetic filesystem for resolving external references when loading openapi specifications.
+func PathToRawSpec(pathToFile string) map[string]func() ([]byte, error) {
+	res := make(map[string]func() ([]byte, error))
+	if len(pathToFile) > 0 {
+		res[pathToFile] = rawSpec
+	}
+
+	return res
+}
+
+// GetSwagger returns the Swagger specification corresponding to the generated code
+// in this file. The external references of Swagger specification are resolved.
+// The logic of resolving external references is tightly connected to "import-mapping" feature.
+// Externally referenced files must be embedded in the corresponding golang packages.
+// Urls can be supported but this task was out of the scope.
+func GetSwagger() (swagger *openapi3.T, err error) {
+	resolvePath := PathToRawSpec("")
+
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = func(loader *openapi3.Loader, url *url.URL) ([]byte, error) {
+		pathToFile := url.String()
+		pathToFile = path.Clean(pathToFile)
+		getSpec, ok := resolvePath[pathToFile]
+		if !ok {
+			err1 := fmt.Errorf("path not found: %s", pathToFile)
+			return nil, err1
+		}
+		return getSpec()
+	}
+	var specData []byte
+	specData, err = rawSpec()
+	if err != nil {
+		return
+	}
+	swagger, err = loader.LoadFromData(specData)
+	if err != nil {
+		return
+	}
+	return
+}
@@ -3,89 +3,813 @@
 // Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.4.1 DO NOT EDIT.
 package v1
 
-// CreateSandboxRequest defines model for CreateSandboxRequest.
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	BearerAuthScopes = "bearerAuth.Scopes"
+)
+
+// Defines values for APIKeyScopes.
+const (
+	APIKeyScopesAdmin      APIKeyScopes = "admin"
+	APIKeyScopesExec       APIKeyScopes = "exec"
+	APIKeyScopesSpaceRead  APIKeyScopes = "space:read"
+	APIKeyScopesSpaceWrite APIKeyScopes = "space:write"
+)
+
+// Defines values for AuditEntryOutcome.
+const (
+	Failure AuditEntryOutcome = "failure"
+	Success AuditEntryOutcome = "success"
+)
+
+// Defines values for CreateAPIKeyRequestScopes.
+const (
+	CreateAPIKeyRequestScopesAdmin      CreateAPIKeyRequestScopes = "admin"
+	CreateAPIKeyRequestScopesExec       CreateAPIKeyRequestScopes = "exec"
+	CreateAPIKeyRequestScopesSpaceRead  CreateAPIKeyRequestScopes = "space:read"
+	CreateAPIKeyRequestScopesSpaceWrite CreateAPIKeyRequestScopes = "space:write"
+)
+
+// Defines values for CreateAPIKeyResponseScopes.
+const (
+	Admin      CreateAPIKeyResponseScopes = "admin"
+	Exec       CreateAPIKeyResponseScopes = "exec"
+	SpaceRead  CreateAPIKeyResponseScopes = "space:read"
+	SpaceWrite CreateAPIKeyResponseScopes = "space:write"
+)
+
+// Defines values for ErrorCode.
+const (
+	ErrorCodeAgentUnreachable       ErrorCode = "agent_unreachable"
+	ErrorCodeConflict               ErrorCode = "conflict"
+	ErrorCodeForbidden              ErrorCode = "forbidden"
+	ErrorCodeImagePullFailed        ErrorCode = "image_pull_failed"
+	ErrorCodeInternal               ErrorCode = "internal"
+	ErrorCodeInvalidRequest         ErrorCode = "invalid_request"
+	ErrorCodeInvalidSecurityProfile ErrorCode = "invalid_security_profile"
+	ErrorCodeInvalidSpec            ErrorCode = "invalid_spec"
+	ErrorCodeNameConflict           ErrorCode = "name_conflict"
+	ErrorCodeNotFound               ErrorCode = "not_found"
+	ErrorCodePathNotFound           ErrorCode = "path_not_found"
+	ErrorCodePortNotExposed         ErrorCode = "port_not_exposed"
+	ErrorCodeQuotaExceeded          ErrorCode = "quota_exceeded"
+	ErrorCodeSandboxNotFound        ErrorCode = "sandbox_not_found"
+	ErrorCodeSandboxNotRunning      ErrorCode = "sandbox_not_running"
+	ErrorCodeServiceUnreachable     ErrorCode = "service_unreachable"
+	ErrorCodeSpaceNotFound          ErrorCode = "space_not_found"
+	ErrorCodeUnauthorized           ErrorCode = "unauthorized"
+)
+
+// Defines values for MountSpecType.
+const (
+	Bind   MountSpecType = "bind"
+	Volume MountSpecType = "volume"
+)
+
+// Defines values for NetworkPolicyMode.
+const (
+	NetworkPolicyModeAllowlist NetworkPolicyMode = "allowlist"
+	NetworkPolicyModeFull      NetworkPolicyMode = "full"
+	NetworkPolicyModeInternal  NetworkPolicyMode = "internal"
+	NetworkPolicyModeNone      NetworkPolicyMode = "none"
+)
+
+// Defines values for SandboxStatusPhase.
+const (
+	SandboxStatusPhaseFailed   SandboxStatusPhase = "failed"
+	SandboxStatusPhasePending  SandboxStatusPhase = "pending"
+	SandboxStatusPhasePulling  SandboxStatusPhase = "pulling"
+	SandboxStatusPhaseReady    SandboxStatusPhase = "ready"
+	SandboxStatusPhaseStarting SandboxStatusPhase = "starting"
+)
+
+// Defines values for SandboxStatusState.
+const (
+	SandboxStatusStateExited       SandboxStatusState = "exited"
+	SandboxStatusStateFailed       SandboxStatusState = "failed"
+	SandboxStatusStateLost         SandboxStatusState = "lost"
+	SandboxStatusStateProvisioning SandboxStatusState = "provisioning"
+	SandboxStatusStateRunning      SandboxStatusState = "running"
+)
+
+// Defines values for GetSandboxRecordingParamsFormat.
+const (
+	Asciicast GetSandboxRecordingParamsFormat = "asciicast"
+	Jsonl     GetSandboxRecordingParamsFormat = "jsonl"
+)
+
+// Defines values for GetSpaceUsageParamsBucket.
+const (
+	Day  GetSpaceUsageParamsBucket = "day"
+	Hour GetSpaceUsageParamsBucket = "hour"
+)
+
+// Defines values for GetSpaceUsageParamsGroupBy.
+const (
+	GetSpaceUsageParamsGroupBySandbox GetSpaceUsageParamsGroupBy = "sandbox"
+	GetSpaceUsageParamsGroupBySpace   GetSpaceUsageParamsGroupBy = "space"
+)
+
+// Defines values for GetSpaceUsageParamsFormat.
+const (
+	Csv  GetSpaceUsageParamsFormat = "csv"
+	JSON GetSpaceUsageParamsFormat = "json"
+)
+
+// APIKey defines model for APIKey.
+type APIKey struct {
+	CreatedAt time.Time      `json:"created_at"`
+	ID        string         `json:"id"`
+	Name      *string        `json:"name,omitempty"`
+	Scopes    []APIKeyScopes `json:"scopes"`
+
+	// Spaces Space IDs the key is limited to, all spaces when empty
+	Spaces *[]string `json:"spaces,omitempty"`
+}
+
+// APIKeyScopes defines model for APIKey.Scopes.
+type APIKeyScopes string
+
+// ActionAccepted Response to starting an action
+type ActionAccepted struct {
+	// ActionID Identifier of the action; its observations carry it.
+	ActionID string `json:"action_id"`
+}
+
+// ActionResult Result of an action execution, typically sent as an observation
+type ActionResult struct {
+	// ActionID Identifier of the action this result belongs to
+	ActionID string `json:"action_id"`
+
+	// Error Error message if execution failed
+	Error *string `json:"error"`
+
+	// ExitCode Exit code of the executed command or cell
+	ExitCode int32 `json:"exit_code"`
+}
+
+// AuditActor Who performed an audited operation
+type AuditActor struct {
+	// ID ID of the API key, "anonymous" when authentication is disabled
+	ID string `json:"id"`
+
+	// Name Name of the API key
+	Name *string `json:"name,omitempty"`
+}
+
+// AuditEntry An audited operation
+type AuditEntry struct {
+	ActionID *string `json:"action_id,omitempty"`
+
+	// Actor Who performed an audited operation
+	Actor AuditActor `json:"actor"`
+	Error *string    `json:"error,omitempty"`
+
+	// ExitCode Exit code of a finished action, -1 when it did not run to completion
+	ExitCode *int   `json:"exit_code,omitempty"`
+	ID       string `json:"id"`
+
+	// Operation Operation ID of the request, e.g. createSandbox, or actionFinished for the end of an action
+	Operation  string            `json:"operation"`
+	Outcome    AuditEntryOutcome `json:"outcome"`
+	RemoteAddr *string           `json:"remote_addr,omitempty"`
+
+	// Request Request payload with secrets redacted
+	Request   *interface{} `json:"request,omitempty"`
+	SandboxID *string      `json:"sandbox_id,omitempty"`
+	SpaceID   *string      `json:"space_id,omitempty"`
+
+	// StatusCode HTTP status of the response
+	StatusCode *int      `json:"status_code,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// AuditEntryOutcome defines model for AuditEntry.Outcome.
+type AuditEntryOutcome string
+
+// CreateAPIKeyRequest defines model for CreateAPIKeyRequest.
+type CreateAPIKeyRequest struct {
+	Name   *string                     `json:"name,omitempty"`
+	Scopes []CreateAPIKeyRequestScopes `json:"scopes"`
+
+	// Spaces Limit the key to these space IDs
+	Spaces *[]string `json:"spaces,omitempty"`
+}
+
+// CreateAPIKeyRequestScopes defines model for CreateAPIKeyRequest.Scopes.
+type CreateAPIKeyRequestScopes string
+
+// CreateAPIKeyResponse defines model for CreateAPIKeyResponse.
+type CreateAPIKeyResponse struct {
+	CreatedAt time.Time                    `json:"created_at"`
+	ID        string                       `json:"id"`
+	Name      *string                      `json:"name,omitempty"`
+	Scopes    []CreateAPIKeyResponseScopes `json:"scopes"`
+
+	// Secret The key to send as a bearer token. It cannot be retrieved again.
+	Secret string `json:"secret"`
+
+	// Spaces Space IDs the key is limited to, all spaces when empty
+	Spaces *[]string `json:"spaces,omitempty"`
+}
+
+// CreateAPIKeyResponseScopes defines model for CreateAPIKeyResponse.Scopes.
+type CreateAPIKeyResponseScopes string
+
+// CreateSandboxRequest Request model for creating a sandbox. The space is taken from the path.
 type CreateSandboxRequest struct {
-	// Name The name of the sandbox. If not specified, will be generated automatically.
-	Name string `json:"name,omitempty"`
+	// Image Shorthand for spec.image, used when spec does not set an image
+	Image *string      `json:"image"`
+	Spec  *SandboxSpec `json:"spec"`
+}
+
+// CreateSpaceRequest Request model for creating a space
+type CreateSpaceRequest struct {
+	// Description Description of the space
+	Description *string `json:"description"`
 
-	// Spec The specification of a Sandbox.
-	Spec SandboxSpec `json:"spec"`
+	// Metadata Space metadata
+	Metadata *map[string]interface{} `json:"metadata"`
+
+	// Name Name of the space
+	Name string `json:"name"`
+
+	// Quota Requires the admin scope
+	Quota           *SpaceQuota      `json:"quota"`
+	SecurityProfile *SecurityProfile `json:"security_profile"`
+}
+
+// DisplayData A rich output displayed by an IPython cell
+type DisplayData struct {
+	// Data MIME bundle keyed by MIME type, e.g. image/png (base64), text/html, application/json and text/plain.
+	Data map[string]interface{} `json:"data"`
+
+	// Metadata Per-MIME-type display metadata, such as image sizes.
+	Metadata *map[string]interface{} `json:"metadata,omitempty"`
 }
 
-// Error defines model for Error.
+// Error Error response model
 type Error struct {
-	// Message The error message.
+	// Code Error code for programmatic handling. Clients should match on the
+	// code rather than the message and treat unknown codes like
+	// `internal`.
+	Code *ErrorCode `json:"code"`
+
+	// Detail Detailed error information
+	Detail *string `json:"detail"`
+
+	// Message Error message
 	Message string `json:"message"`
 }
 
-// RunIPythonCellRequest The cell to run.
+// ErrorCode Error code for programmatic handling. Clients should match on the
+// code rather than the message and treat unknown codes like
+// `internal`.
+type ErrorCode string
+
+// HealthStatus defines model for HealthStatus.
+type HealthStatus struct {
+	Status string `json:"status"`
+}
+
+// MountSpec A mount declared in a sandbox spec
+type MountSpec struct {
+	// ReadOnly Mount read-only. Bind mounts are always read-only.
+	ReadOnly *bool `json:"read_only,omitempty"`
+
+	// Source Volume name within the space, or absolute host path for bind mounts
+	Source string `json:"source"`
+
+	// Target Absolute path in the sandbox
+	Target string `json:"target"`
+
+	// Type volume: a named volume shared by the sandboxes of the space, created on first use. bind: a host directory inside a server-configured allowlist, always mounted read-only.
+	Type MountSpecType `json:"type"`
+}
+
+// MountSpecType volume: a named volume shared by the sandboxes of the space, created on first use. bind: a host directory inside a server-configured allowlist, always mounted read-only.
+type MountSpecType string
+
+// NetworkPolicy Network isolation policy of a sandbox
+type NetworkPolicy struct {
+	// Allow Egress destinations for allowlist mode, as IP addresses, CIDRs or host names (resolved at creation time)
+	Allow *[]string `json:"allow,omitempty"`
+
+	// Mode none: no network access besides the runtime reaching the agent. internal: only other sandboxes in the same space are reachable. allowlist: internal plus the destinations in allow. full: unrestricted egress. Sandboxes in other spaces are never reachable.
+	Mode NetworkPolicyMode `json:"mode"`
+}
+
+// NetworkPolicyMode none: no network access besides the runtime reaching the agent. internal: only other sandboxes in the same space are reachable. allowlist: internal plus the destinations in allow. full: unrestricted egress. Sandboxes in other spaces are never reachable.
+type NetworkPolicyMode string
+
+// Observation Model for observations pushed from agent to runtime or streamed via WebSocket
+type Observation struct {
+	// ActionID Identifier of the action this observation relates to
+	ActionID string `json:"action_id"`
+
+	// Data Payload of the observation; the MIME bundle if observation_type is 'display_data'
+	Data *map[string]interface{} `json:"data"`
+
+	// Error Error message if observation_type is 'error', 'result' or 'end'
+	Error *string `json:"error"`
+
+	// ExitCode Exit code if observation_type is 'result' or 'end'
+	ExitCode *int32 `json:"exit_code"`
+
+	// Line Content of the stream line if observation_type is 'stream'
+	Line *string `json:"line"`
+
+	// Metadata Display metadata if observation_type is 'display_data'
+	Metadata *map[string]interface{} `json:"metadata"`
+
+	// ObservationType Type of observation (e.g., start, stream, display_data, result, error, end, provisioning, lifecycle). Lifecycle observations report container events such as crashes, OOM kills and restarts. Display data observations carry rich IPython outputs as MIME bundles.
+	ObservationType string `json:"observation_type"`
+
+	// Stream Stream type if observation_type is 'stream'
+	Stream *string `json:"stream"`
+
+	// Timestamp Timestamp when the observation was generated (UTC)
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// PullProgress Aggregated image pull progress
+type PullProgress struct {
+	// CurrentBytes Bytes downloaded across all layers
+	CurrentBytes *int64 `json:"current_bytes,omitempty"`
+
+	// Image Image being pulled
+	Image *string `json:"image,omitempty"`
+
+	// Layers Number of layers seen so far
+	Layers *int `json:"layers,omitempty"`
+
+	// LayersDone Number of layers pulled or already present
+	LayersDone *int `json:"layers_done,omitempty"`
+
+	// TotalBytes Total bytes to download across all layers seen so far
+	TotalBytes *int64 `json:"total_bytes,omitempty"`
+}
+
+// Resources Resource limits of a sandbox. Unset or zero limits are unlimited. Required for the dimensions the space's quota limits.
+type Resources struct {
+	// Cpus CPUs the sandbox may use, e.g. 0.5
+	Cpus *float64 `json:"cpus,omitempty"`
+
+	// MemoryMb Memory limit of the sandbox in MiB
+	MemoryMb *int64 `json:"memory_mb,omitempty"`
+}
+
+// RunIPythonCellRequest Request model for executing IPython cell
 type RunIPythonCellRequest struct {
-	// Code The code to run in the IPython kernel.
+	// ActionID Action ID provided by runtime for observation tracking (Used internally between runtime and agent)
+	ActionID *string `json:"action_id"`
+
+	// Code Code to execute in IPython kernel
 	Code string `json:"code"`
 
-	// SplitOutput Set to true to split the output into stdout and stderr. If set, the output field in the response will be empty and the stdout and stderr fields will be populated.
-	SplitOutput bool `json:"split_output,omitempty"`
+	// Env Execution environment variables
+	Env *map[string]string `json:"env"`
+
+	// SplitOutput Whether to split stdout and stderr in observations/results (Currently ignored by executor)
+	SplitOutput *bool `json:"split_output"`
+
+	// Timeout Execution timeout in seconds
+	Timeout *int32 `json:"timeout"`
+
+	// WorkDir Working directory for execution
+	WorkDir *string `json:"work_dir"`
 }
 
-// RunIPythonCellResult The result from the IPython kernel.
+// RunIPythonCellResult Output of an IPython cell, collected by clients from its observations
 type RunIPythonCellResult struct {
-	// Output The stdout and stderr from the IPython kernel interleaved.
-	Output string `json:"output,omitempty"`
+	// DisplayData Rich outputs of the cell, such as plots and HTML tables, in the order they were displayed.
+	DisplayData *[]DisplayData `json:"display_data,omitempty"`
+
+	// Output The stdout and stderr from the IPython kernel, interleaved.
+	Output *string `json:"output,omitempty"`
 
 	// Stderr The stderr from the IPython kernel.
-	Stderr string `json:"stderr,omitempty"`
+	Stderr *string `json:"stderr,omitempty"`
 
 	// Stdout The stdout from the IPython kernel.
-	Stdout string `json:"stdout,omitempty"`
+	Stdout *string `json:"stdout,omitempty"`
 }
 
-// RunShellCommandRequest The command to run.
+// RunShellCommandRequest Request model for executing shell command
 type RunShellCommandRequest struct {
-	// Command The command to execute.
+	// ActionID Action ID provided by runtime for observation tracking (Used internally between runtime and agent)
+	ActionID *string `json:"action_id"`
+
+	// Command Command to execute
 	Command string `json:"command"`
 
-	// SplitOutput Set to true to split the output into stdout and stderr. If set, the output field in the response will be empty and the stdout and stderr fields will be populated.
-	SplitOutput bool `json:"split_output,omitempty"`
+	// Env Execution environment variables
+	Env *map[string]string `json:"env"`
+
+	// SplitOutput Whether to split stdout and stderr in observations/results (Currently ignored by executor)
+	SplitOutput *bool `json:"split_output"`
+
+	// Timeout Execution timeout in seconds
+	Timeout *int32 `json:"timeout"`
+
+	// WorkDir Working directory for execution
+	WorkDir *string `json:"work_dir"`
 }
 
-// RunShellCommandResult The result from the shell command.
+// RunShellCommandResult Output of a shell command, collected by clients from its observations
 type RunShellCommandResult struct {
-	// Output The stdout and stderr from the shell command.
-	Output string `json:"output,omitempty"`
+	// Output The stdout and stderr from the shell command, interleaved.
+	Output *string `json:"output,omitempty"`
 
 	// Stderr The stderr from the shell command.
-	Stderr string `json:"stderr,omitempty"`
+	Stderr *string `json:"stderr,omitempty"`
 
-	// Stdout The stdout from the shell command
-	Stdout string `json:"stdout,omitempty"`
+	// Stdout The stdout from the shell command.
+	Stdout *string `json:"stdout,omitempty"`
 }
 
-// Sandbox A sandbox environment for running code and commands.
+// Sandbox Sandbox resource model
 type Sandbox struct {
-	// Name The name of the sandbox.
-	Name string `json:"name,omitempty"`
+	// AgentURL URL the runtime reaches the agent inside the sandbox at
+	AgentURL *string `json:"agent_url"`
+
+	// ContainerID ID of the sandbox's container, empty while it is provisioned
+	ContainerID *string `json:"container_id,omitempty"`
+
+	// ExpiresAt When the sandbox is deleted because it reached its space's maximum lifetime
+	ExpiresAt *time.Time `json:"expires_at"`
+
+	// IsRunning Whether the sandbox is ready to run actions
+	IsRunning bool `json:"is_running"`
+
+	// Name Name of the sandbox. Sandboxes are named by their generated ID.
+	Name *string `json:"name"`
 
-	// Spec The specification of a Sandbox.
-	Spec SandboxSpec `json:"spec"`
+	// SandboxID Unique identifier for the sandbox
+	SandboxID string `json:"sandbox_id"`
 
-	// Status The status of the Sandbox.
-	Status *SandboxStatus `json:"status,omitempty"`
+	// SecurityProfile Effective security profile of the sandbox; absent when Docker defaults apply
+	SecurityProfile *SecurityProfile `json:"security_profile"`
 
-	// UID An identifier that is unique to the instance (in time) of the sandbox.
-	UID string `json:"uid,omitempty"`
+	// SpaceID Space the sandbox belongs to
+	SpaceID string         `json:"space_id"`
+	Spec    *SandboxSpec   `json:"spec"`
+	Status  *SandboxStatus `json:"status"`
 }
 
-// SandboxSpec The specification of a Sandbox.
+// SandboxSpec Sandbox specification model
 type SandboxSpec struct {
-	// Env Environment variables for the sandbox.
-	Env map[string]string `json:"env,omitempty"`
+	// Env Environment variables for the sandbox
+	Env *map[string]string `json:"env"`
+
+	// Image Container image for the sandbox (must include tag e.g. 'python:3.9')
+	Image *string `json:"image"`
+
+	// Mounts Volumes and host directories to mount into the sandbox
+	Mounts  *[]MountSpec   `json:"mounts"`
+	Network *NetworkPolicy `json:"network"`
+
+	// Ports Service ports inside the sandbox to make reachable through the port proxy
+	Ports     *[]int     `json:"ports"`
+	Resources *Resources `json:"resources"`
+
+	// Secrets Secrets of the space to inject. Their values are never returned and are redacted from the sandbox's stream.
+	Secrets *[]SecretRef `json:"secrets"`
+}
+
+// SandboxStatus Sandbox status information
+type SandboxStatus struct {
+	// AgentReachable Whether the agent inside the sandbox answered its health check
+	AgentReachable *bool `json:"agent_reachable"`
 
-	// Image The container image the sandbox will run with.
-	Image string `json:"image,omitempty"`
+	// CheckedAt When the container fields were last refreshed
+	CheckedAt *time.Time `json:"checked_at"`
+
+	// ContainerState Docker state of the container (e.g. running, exited)
+	ContainerState *string `json:"container_state"`
+
+	// CreatedAt When the sandbox was requested
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// ExitCode Exit code of the container if it is not running
+	ExitCode *int `json:"exit_code"`
+
+	// FailedAt When provisioning failed
+	FailedAt *time.Time `json:"failed_at"`
+
+	// FinishedAt When the container last stopped
+	FinishedAt *time.Time `json:"finished_at"`
+
+	// Health Docker healthcheck status, if the image defines a healthcheck
+	Health *string `json:"health"`
+
+	// Message Human-readable detail about the current phase
+	Message *string `json:"message"`
+
+	// OomKilled Whether the container was killed for exceeding its memory limit
+	OomKilled *bool `json:"oom_killed"`
+
+	// Phase Provisioning phase of the sandbox
+	Phase        *SandboxStatusPhase `json:"phase,omitempty"`
+	PullProgress *PullProgress       `json:"pull_progress"`
+
+	// PullingAt When the image pull started
+	PullingAt *time.Time `json:"pulling_at"`
+
+	// ReadyAt When the sandbox became ready
+	ReadyAt *time.Time `json:"ready_at"`
+
+	// Reason Machine-readable reason when provisioning failed
+	Reason *string `json:"reason"`
+
+	// RestartCount Number of times the container has been restarted
+	RestartCount *int `json:"restart_count,omitempty"`
+
+	// StartedAt When the container last started
+	StartedAt *time.Time `json:"started_at"`
+
+	// StartingAt When the container was being started
+	StartingAt *time.Time `json:"starting_at"`
+
+	// State Current state of the sandbox. "lost" means its container no longer exists.
+	State *SandboxStatusState `json:"state,omitempty"`
 }
 
-// SandboxStatus The status of the Sandbox.
-type SandboxStatus = map[string]interface{}
+// SandboxStatusPhase Provisioning phase of the sandbox
+type SandboxStatusPhase string
+
+// SandboxStatusState Current state of the sandbox. "lost" means its container no longer exists.
+type SandboxStatusState string
+
+// Secret A secret of a space. The value is never returned.
+type Secret struct {
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SecretRef A secret injected into a sandbox
+type SecretRef struct {
+	// Env Environment variable to set to the value. Defaults to the secret's name when file is unset too.
+	Env *string `json:"env"`
+
+	// File File name under /run/secrets, a tmpfs, to write the value to, readable only by the sandbox's user
+	File *string `json:"file"`
+
+	// Name Name of a secret of the sandbox's space
+	Name string `json:"name"`
+}
+
+// SecurityProfile Hardening options applied to sandbox containers
+type SecurityProfile struct {
+	// Capabilities Capabilities to keep, e.g. CHOWN
+	Capabilities *[]string `json:"capabilities,omitempty"`
+
+	// DropAllCapabilities Drop all capabilities except those listed in capabilities
+	DropAllCapabilities *bool `json:"drop_all_capabilities,omitempty"`
+
+	// NoNewPrivileges Prevent processes from gaining privileges
+	NoNewPrivileges *bool `json:"no_new_privileges,omitempty"`
+
+	// ReadOnlyRootfs Mount the root filesystem read-only with writable tmpfs mounts at /work and /tmp
+	ReadOnlyRootfs *bool `json:"read_only_rootfs,omitempty"`
+
+	// Runtime OCI runtime, e.g. runsc
+	Runtime *string `json:"runtime,omitempty"`
+
+	// SeccompProfile Path of a seccomp profile on the runtime host
+	SeccompProfile *string `json:"seccomp_profile,omitempty"`
+
+	// TmpfsSize Size of each tmpfs mount, e.g. 512m
+	TmpfsSize *string `json:"tmpfs_size,omitempty"`
+
+	// User User to run as, e.g. 1000:1000
+	User *string `json:"user,omitempty"`
+}
+
+// SetSecretRequest The value of a secret
+type SetSecretRequest struct {
+	Value string `json:"value"`
+}
+
+// Space Space resource model
+type Space struct {
+	// CreatedAt Space creation time
+	CreatedAt *time.Time `json:"created_at"`
+
+	// Description Description of the space
+	Description *string `json:"description"`
+
+	// Metadata Space metadata
+	Metadata *map[string]interface{} `json:"metadata"`
+
+	// Name Name of the space
+	Name string `json:"name"`
+
+	// Quota Limits on what the space's sandboxes may use
+	Quota *SpaceQuota `json:"quota"`
+
+	// SecurityProfile Security profile for sandboxes in this space, replacing the server-wide profile
+	SecurityProfile *SecurityProfile `json:"security_profile"`
+
+	// SpaceID Unique identifier for the space
+	SpaceID string `json:"space_id"`
+
+	// UpdatedAt Space last update time
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// SpaceQuota Limits on a space's sandboxes. Zero or unset limits are unlimited.
+// Lowering a limit does not affect existing sandboxes, but new sandboxes
+// and actions are refused with the quota_exceeded code while the space
+// is over it.
+type SpaceQuota struct {
+	// Cpus Total CPUs of the space's sandboxes, see Resources
+	Cpus *float64 `json:"cpus,omitempty"`
+
+	// MaxConcurrentActions Maximum number of actions running at once across the space's sandboxes
+	MaxConcurrentActions *int `json:"max_concurrent_actions,omitempty"`
+
+	// MaxLifetimeSeconds Sandboxes are deleted this long after they are created
+	MaxLifetimeSeconds *int64 `json:"max_lifetime_seconds,omitempty"`
+
+	// MaxSandboxes Maximum number of sandboxes in the space
+	MaxSandboxes *int `json:"max_sandboxes,omitempty"`
+
+	// MemoryMb Total memory of the space's sandboxes in MiB, see Resources
+	MemoryMb *int64 `json:"memory_mb,omitempty"`
+}
+
+// SpaceUsage Current consumption of a space and its metered usage over time
+type SpaceUsage struct {
+	// ConcurrentActions Number of actions currently running in the space's sandboxes
+	ConcurrentActions int `json:"concurrent_actions"`
+
+	// Cpus Total CPUs requested by the space's sandboxes
+	Cpus float64 `json:"cpus"`
+
+	// History Metered usage over the requested range, oldest first. Buckets without usage are omitted.
+	History []UsageBucket `json:"history"`
+
+	// MemoryMb Total memory requested by the space's sandboxes in MiB
+	MemoryMb int64 `json:"memory_mb"`
+
+	// Quota The space's quota; absent when the space has none
+	Quota *SpaceQuota `json:"quota"`
+
+	// Sandboxes Number of sandboxes in the space
+	Sandboxes int `json:"sandboxes"`
+}
+
+// UpdateSpaceRequest Request model for updating a space
+type UpdateSpaceRequest struct {
+	// Description New description for the space
+	Description *string `json:"description"`
+
+	// Metadata New metadata for the space
+	Metadata *map[string]interface{} `json:"metadata"`
+
+	// Quota New SpaceQuota for the space, which requires the admin scope. Omit to keep the current quota; null clears it.
+	Quota json.RawMessage `json:"quota,omitempty"`
+
+	// SecurityProfile New SecurityProfile for the space. Omit to keep the current profile; null clears it.
+	SecurityProfile json.RawMessage `json:"security_profile,omitempty"`
+}
+
+// UsageBucket Metered usage during one bucket of a range
+type UsageBucket struct {
+	// ActionSeconds Time the finished actions took
+	ActionSeconds float64 `json:"action_seconds"`
+
+	// Actions Number of actions that finished
+	Actions int `json:"actions"`
+
+	// CPUSeconds CPU time used, sampled from the containers
+	CPUSeconds float64   `json:"cpu_seconds"`
+	End        time.Time `json:"end"`
+
+	// MemoryMbSeconds Sampled memory use in MiB multiplied by the time it was held
+	MemoryMbSeconds float64 `json:"memory_mb_seconds"`
+
+	// PeakMemoryMb Highest sampled memory use of a single sandbox in MiB
+	PeakMemoryMb float64 `json:"peak_memory_mb"`
+
+	// RuntimeSeconds Wall-clock time the sandboxes existed
+	RuntimeSeconds float64 `json:"runtime_seconds"`
+
+	// SandboxID Set when grouping by sandbox
+	SandboxID *string   `json:"sandbox_id,omitempty"`
+	Start     time.Time `json:"start"`
+}
+
+// ListAuditEntriesParams defines parameters for ListAuditEntries.
+type ListAuditEntriesParams struct {
+	// SpaceID Only entries of this space.
+	SpaceID *string `form:"space_id,omitempty" json:"space_id,omitempty"`
+
+	// From Start of the range, inclusive.
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To End of the range, exclusive.
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Limit Maximum number of entries to return. Continue from the time of the last one to page.
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+}
+
+// DeleteSpaceParams defines parameters for DeleteSpace.
+type DeleteSpaceParams struct {
+	// RetainVolumes Keep the space's volumes in Docker instead of deleting them
+	RetainVolumes *bool `form:"retain_volumes,omitempty" json:"retain_volumes,omitempty"`
+}
+
+// CreateSandboxParams defines parameters for CreateSandbox.
+type CreateSandboxParams struct {
+	// Async Return 202 immediately and provision the sandbox in the background.
+	Async *bool `form:"async,omitempty" json:"async,omitempty"`
+}
+
+// GetSandboxFilesParams defines parameters for GetSandboxFiles.
+type GetSandboxFilesParams struct {
+	// Path Absolute path in the sandbox.
+	Path string `form:"path" json:"path"`
+}
+
+// PutSandboxFilesParams defines parameters for PutSandboxFiles.
+type PutSandboxFilesParams struct {
+	// Path Absolute path of an existing directory in the sandbox.
+	Path string `form:"path" json:"path"`
+}
+
+// GetSandboxLogsParams defines parameters for GetSandboxLogs.
+type GetSandboxLogsParams struct {
+	// Follow Keep the response open and stream new output.
+	Follow *bool `form:"follow,omitempty" json:"follow,omitempty"`
+
+	// Tail Number of lines from the end to return, or "all".
+	Tail *string `form:"tail,omitempty" json:"tail,omitempty"`
+}
+
+// GetSandboxRecordingParams defines parameters for GetSandboxRecording.
+type GetSandboxRecordingParams struct {
+	Format *GetSandboxRecordingParamsFormat `form:"format,omitempty" json:"format,omitempty"`
+}
+
+// GetSandboxRecordingParamsFormat defines parameters for GetSandboxRecording.
+type GetSandboxRecordingParamsFormat string
+
+// ReplaySandboxRecordingParams defines parameters for ReplaySandboxRecording.
+type ReplaySandboxRecordingParams struct {
+	// Speed Playback speed; 1 is the original speed and 2 twice as fast.
+	Speed *float32 `form:"speed,omitempty" json:"speed,omitempty"`
+
+	// MaxIdle Longest pause between observations in seconds, after applying speed. 0 keeps every pause.
+	MaxIdle *float32 `form:"max_idle,omitempty" json:"max_idle,omitempty"`
+}
+
+// OpenSandboxTerminalParams defines parameters for OpenSandboxTerminal.
+type OpenSandboxTerminalParams struct {
+	Rows *int `form:"rows,omitempty" json:"rows,omitempty"`
+	Cols *int `form:"cols,omitempty" json:"cols,omitempty"`
+}
+
+// GetSpaceUsageParams defines parameters for GetSpaceUsage.
+type GetSpaceUsageParams struct {
+	// From Start of the range, rounded down to the hour. Defaults to 24 hours before to.
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To End of the range, exclusive. Defaults to now.
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Bucket Length of the buckets the range is split into. Days start at midnight UTC.
+	Bucket *GetSpaceUsageParamsBucket `form:"bucket,omitempty" json:"bucket,omitempty"`
+
+	// GroupBy Report each bucket for the whole space or once per sandbox.
+	GroupBy *GetSpaceUsageParamsGroupBy `form:"group_by,omitempty" json:"group_by,omitempty"`
+	Format  *GetSpaceUsageParamsFormat  `form:"format,omitempty" json:"format,omitempty"`
+}
+
+// GetSpaceUsageParamsBucket defines parameters for GetSpaceUsage.
+type GetSpaceUsageParamsBucket string
+
+// GetSpaceUsageParamsGroupBy defines parameters for GetSpaceUsage.
+type GetSpaceUsageParamsGroupBy string
+
+// GetSpaceUsageParamsFormat defines parameters for GetSpaceUsage.
+type GetSpaceUsageParamsFormat string
+
+// CreateAPIKeyJSONRequestBody defines body for CreateAPIKey for application/json ContentType.
+type CreateAPIKeyJSONRequestBody = CreateAPIKeyRequest
+
+// CreateSpaceJSONRequestBody defines body for CreateSpace for application/json ContentType.
+type CreateSpaceJSONRequestBody = CreateSpaceRequest
+
+// UpdateSpaceJSONRequestBody defines body for UpdateSpace for application/json ContentType.
+type UpdateSpaceJSONRequestBody = UpdateSpaceRequest
 
 // CreateSandboxJSONRequestBody defines body for CreateSandbox for application/json ContentType.
 type CreateSandboxJSONRequestBody = CreateSandboxRequest
@@ -95,3 +819,6 @@ type RunIPythonCellJSONRequestBody = RunIPythonCellRequest
 
 // RunShellCommandJSONRequestBody defines body for RunShellCommand for application/json ContentType.
 type RunShellCommandJSONRequestBody = RunShellCommandRequest
+
+// SetSecretJSONRequestBody defines body for SetSecret for application/json ContentType.
+type SetSecretJSONRequestBody = SetSecretRequest
@@ -6,15 +6,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 
 	// Import the API types generated from your spec
 	v1 "github.com/foreveryh/sandboxai/go/api/v1"
 )
 
-var ErrSandboxNotFound = fmt.Errorf("sandbox not found")
-
 // Client represents a client for interacting with the SandboxAI API.
 // See the OpenAPI spec for API details.
 type Client struct {
@@ -22,6 +19,7 @@ type Client struct {
 	// IMPORTANT: This BaseURL should NOT include the /v1 path prefix itself.
 	BaseURL string
 	httpc   *http.Client
+	apiKey  string
 }
 
 type ClientOption func(*Client)
@@ -33,6 +31,13 @@ func WithHTTPClient(httpClient *http.Client) ClientOption {
 	}
 }
 
+// WithAPIKey authenticates requests with the given API key.
+func WithAPIKey(apiKey string) ClientOption {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
 // NewClient creates a new API client.
 // baseURL should be the root of the runtime service (e.g., "http://localhost:5266").
 func NewClient(baseURL string, opts ...ClientOption) *Client {
@@ -58,7 +63,7 @@ func (c *Client) CheckHealth(ctx context.Context) error {
 		return err
 	}
 
-	resp, err := c.httpc.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -87,7 +92,7 @@ func (c *Client) CreateSandbox(ctx context.Context, space string, request *v1.Cr
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpc.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -114,15 +119,11 @@ func (c *Client) GetSandbox(ctx context.Context, space, name string) (*v1.Sandbo
 		return nil, err
 	}
 
-	resp, err := c.httpc.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
-	// Handle 404 specifically
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, ErrSandboxNotFound
-	}
 	// Expect 200 OK on success
 	if err := validateResponse(resp, http.StatusOK); err != nil {
 		return nil, err
@@ -145,118 +146,127 @@ func (c *Client) DeleteSandbox(ctx context.Context, space, name string) error {
 		return err
 	}
 
-	resp, err := c.httpc.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 	// Expect 204 No Content on successful deletion
-	if err := validateResponse(resp, http.StatusNoContent); err != nil {
-		// Check if it was a 404 (already deleted or never existed)
-		if resp.StatusCode == http.StatusNotFound {
-			return ErrSandboxNotFound // Return specific error for 404
-		}
-		return err // Return generic validation error for other statuses
-	}
-
-	return nil
+	return validateResponse(resp, http.StatusNoContent)
 }
 
-// RunIPythonCell executes code in an IPython kernel within the sandbox.
-// NOTE: This is the SYNCHRONOUS client method. It expects the server to
-// potentially block and return the full result, which might not match the
-// current ASYNCHRONOUS server behavior (which returns 202 Accepted + action_id).
-// This client might need further changes if used against the async API.
-func (c *Client) RunIPythonCell(ctx context.Context, space, name string, request *v1.RunIPythonCellRequest) (*v1.RunIPythonCellResult, error) {
-	body, err := json.Marshal(request)
-	if err != nil {
-		return nil, err
-	}
-	// --- CORRECTED URL ---
-	url := fmt.Sprintf("%s/v1/spaces/%s/sandboxes/%s/tools:run_ipython_cell", c.BaseURL, space, name) // Added /v1
-	// --- END CORRECTION ---
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+// ListSandboxes lists the sandboxes of a space.
+func (c *Client) ListSandboxes(ctx context.Context, space string) ([]v1.Sandbox, error) {
+	url := fmt.Sprintf("%s/v1/spaces/%s/sandboxes", c.BaseURL, space)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpc.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
-	// !!! IMPORTANT: The server now returns 202 Accepted for async start.
-	// This client expects 200 OK and tries to parse RunIPythonCellResult.
-	// This part WILL LIKELY FAIL against the current server implementation.
-	// For now, we fix the URL, but the expected status code needs review
-	// based on whether this sync client should still be used/supported.
-	// Let's keep expectedStatus=200 for now to match the original client code,
-	// but it will likely fail the test assertion against the current server.
-	if err := validateResponse(resp, http.StatusOK); err != nil { // Kept 200 OK based on original code
-		// Check if it was 202 Accepted (which the *server* sends now)
-		if resp.StatusCode == http.StatusAccepted {
-			// Handle the 202 case - perhaps return nil or a specific indicator?
-			// For now, let's return an error indicating the mismatch for the sync client.
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return nil, fmt.Errorf("received 202 Accepted (expected 200 OK for sync client); server started async execution. Response: %s", string(bodyBytes))
-		}
-		return nil, err // Return other validation errors
+	if err := validateResponse(resp, http.StatusOK); err != nil {
+		return nil, err
 	}
 
-	var response v1.RunIPythonCellResult
+	var response []v1.Sandbox
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
 		return nil, err
 	}
-	return &response, nil
+	return response, nil
 }
 
-// RunShellCommand executes a shell command within the sandbox.
-// NOTE: Similar to RunIPythonCell, this is SYNCHRONOUS and expects 200 OK,
-// which may mismatch the current server's 202 Accepted async behavior.
-func (c *Client) RunShellCommand(ctx context.Context, space, name string, request *v1.RunShellCommandRequest) (*v1.RunShellCommandResult, error) {
+// StartShellCommand starts a shell command in the sandbox and returns the ID of
+// the action. Its output arrives as observations on the sandbox's stream, see
+// StreamObservations.
+func (c *Client) StartShellCommand(ctx context.Context, space, name string, request *v1.RunShellCommandRequest) (string, error) {
+	return c.startAction(ctx, fmt.Sprintf("%s/v1/spaces/%s/sandboxes/%s/tools:run_shell_command", c.BaseURL, space, name), request)
+}
+
+// StartIPythonCell starts running code in the sandbox's IPython kernel and
+// returns the ID of the action. Its output arrives as observations on the
+// sandbox's stream, see StreamObservations.
+func (c *Client) StartIPythonCell(ctx context.Context, space, name string, request *v1.RunIPythonCellRequest) (string, error) {
+	return c.startAction(ctx, fmt.Sprintf("%s/v1/spaces/%s/sandboxes/%s/tools:run_ipython_cell", c.BaseURL, space, name), request)
+}
+
+// startAction posts an action request and returns the action ID.
+func (c *Client) startAction(ctx context.Context, url string, request any) (string, error) {
 	body, err := json.Marshal(request)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	// --- CORRECTED URL ---
-	url := fmt.Sprintf("%s/v1/spaces/%s/sandboxes/%s/tools:run_shell_command", c.BaseURL, space, name) // Added /v1
-	// --- END CORRECTION ---
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpc.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	defer resp.Body.Close()
-	// !!! IMPORTANT: Server returns 202 Accepted. This client expects 200 OK.
-	// Keep 200 OK for now based on original code, but expect test failures.
-	if err := validateResponse(resp, http.StatusOK); err != nil { // Kept 200 OK
-		// Check if it was 202 Accepted
-		if resp.StatusCode == http.StatusAccepted {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			return nil, fmt.Errorf("received 202 Accepted (expected 200 OK for sync client); server started async execution. Response: %s", string(bodyBytes))
-		}
-		return nil, err // Return other validation errors
+	if err := validateResponse(resp, http.StatusAccepted); err != nil {
+		return "", err
 	}
 
-	var response v1.RunShellCommandResult
+	var response v1.ActionAccepted
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", err
+	}
+	return response.ActionID, nil
+}
+
+// RunIPythonCell runs code in the sandbox's IPython kernel and waits for it to
+// finish. With SplitOutput the result has Stdout and Stderr, otherwise Output;
+// rich outputs such as plots are in DisplayData. Use Run for the exit code and
+// errors of the cell.
+func (c *Client) RunIPythonCell(ctx context.Context, space, name string, request *v1.RunIPythonCellRequest) (*v1.RunIPythonCellResult, error) {
+	result, err := c.Run(ctx, space, name, request)
+	if err != nil {
 		return nil, err
 	}
-	return &response, nil
+	cell := &v1.RunIPythonCellResult{Output: &result.Output}
+	if request.SplitOutput != nil && *request.SplitOutput {
+		cell = &v1.RunIPythonCellResult{Stdout: &result.Stdout, Stderr: &result.Stderr}
+	}
+	if len(result.DisplayData) > 0 {
+		cell.DisplayData = &result.DisplayData
+	}
+	return cell, nil
+}
+
+// RunShellCommand runs a shell command in the sandbox and waits for it to
+// finish. With SplitOutput the result has Stdout and Stderr, otherwise Output.
+// Use Run for the exit code of the command.
+func (c *Client) RunShellCommand(ctx context.Context, space, name string, request *v1.RunShellCommandRequest) (*v1.RunShellCommandResult, error) {
+	result, err := c.Run(ctx, space, name, request)
+	if err != nil {
+		return nil, err
+	}
+	if request.SplitOutput != nil && *request.SplitOutput {
+		return &v1.RunShellCommandResult{Stdout: &result.Stdout, Stderr: &result.Stderr}, nil
+	}
+	return &v1.RunShellCommandResult{Output: &result.Output}, nil
 }
 
 // validateResponse checks if the HTTP response has the expected status code.
+// Other responses are returned as an *APIError.
 func validateResponse(resp *http.Response, expectedStatus int) error {
 	if resp.StatusCode != expectedStatus {
-		// Read body for detailed error message if possible
-		plainBody, _ := io.ReadAll(resp.Body) // Read body only on error
-		return fmt.Errorf("expected status %d, got %d: %s", expectedStatus, resp.StatusCode, string(plainBody))
+		return readError(resp)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// do sends a request, adding the API key if the client has one.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return c.httpc.Do(req)
+}
@@ -0,0 +1,101 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	v1 "github.com/foreveryh/sandboxai/go/api/v1"
+)
+
+// Errors reported by the runtime. Match them with errors.Is; errors.As with
+// an *APIError gives the status and message.
+var (
+	ErrInvalidRequest         = errors.New("invalid request")
+	ErrInvalidSpec            = errors.New("invalid sandbox spec")
+	ErrInvalidSecurityProfile = errors.New("invalid security profile")
+	ErrUnauthorized           = errors.New("unauthorized")
+	ErrForbidden              = errors.New("forbidden")
+	ErrNotFound               = errors.New("not found")
+	ErrSpaceNotFound          = errors.New("space not found")
+	ErrSandboxNotFound        = errors.New("sandbox not found")
+	ErrPathNotFound           = errors.New("path not found")
+	ErrPortNotExposed         = errors.New("port not exposed")
+	ErrNameConflict           = errors.New("name conflict")
+	ErrConflict               = errors.New("conflict")
+	ErrSandboxNotRunning      = errors.New("sandbox not running")
+	ErrQuotaExceeded          = errors.New("quota exceeded")
+	ErrImagePullFailed        = errors.New("image pull failed")
+	ErrAgentUnreachable       = errors.New("agent unreachable")
+	ErrServiceUnreachable     = errors.New("service unreachable")
+)
+
+var codeErrors = map[v1.ErrorCode]error{
+	v1.ErrorCodeInvalidRequest:         ErrInvalidRequest,
+	v1.ErrorCodeInvalidSpec:            ErrInvalidSpec,
+	v1.ErrorCodeInvalidSecurityProfile: ErrInvalidSecurityProfile,
+	v1.ErrorCodeUnauthorized:           ErrUnauthorized,
+	v1.ErrorCodeForbidden:              ErrForbidden,
+	v1.ErrorCodeNotFound:               ErrNotFound,
+	v1.ErrorCodeSpaceNotFound:          ErrSpaceNotFound,
+	v1.ErrorCodeSandboxNotFound:        ErrSandboxNotFound,
+	v1.ErrorCodePathNotFound:           ErrPathNotFound,
+	v1.ErrorCodePortNotExposed:         ErrPortNotExposed,
+	v1.ErrorCodeNameConflict:           ErrNameConflict,
+	v1.ErrorCodeConflict:               ErrConflict,
+	v1.ErrorCodeSandboxNotRunning:      ErrSandboxNotRunning,
+	v1.ErrorCodeQuotaExceeded:          ErrQuotaExceeded,
+	v1.ErrorCodeImagePullFailed:        ErrImagePullFailed,
+	v1.ErrorCodeAgentUnreachable:       ErrAgentUnreachable,
+	v1.ErrorCodeServiceUnreachable:     ErrServiceUnreachable,
+}
+
+// APIError is an error response of the runtime.
+type APIError struct {
+	StatusCode int
+	Code       v1.ErrorCode // Empty if the response had none
+	Message    string
+	Detail     string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("status %d", e.StatusCode)
+	if e.Code != "" {
+		msg += " (" + string(e.Code) + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Detail != "" && e.Detail != e.Message {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+// Is reports whether target is the error of e's code.
+func (e *APIError) Is(target error) bool {
+	err, ok := codeErrors[e.Code]
+	return ok && err == target
+}
+
+// readError reads an error response. Bodies that are not the API's error
+// model are kept as the message.
+func readError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	var model v1.Error
+	if err := json.Unmarshal(body, &model); err != nil || model.Message == "" {
+		apiErr.Message = string(body)
+		return apiErr
+	}
+	apiErr.Message = model.Message
+	if model.Code != nil {
+		apiErr.Code = *model.Code
+	}
+	if model.Detail != nil {
+		apiErr.Detail = *model.Detail
+	}
+	return apiErr
+}
@@ -0,0 +1,46 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_APIError(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		body   string
+		is     error
+		code   string
+	}{
+		{name: "sandbox not found", status: http.StatusNotFound, body: `{"message":"Sandbox sb-1 not found in space default","code":"sandbox_not_found"}`, is: ErrSandboxNotFound, code: "sandbox_not_found"},
+		{name: "space not found", status: http.StatusNotFound, body: `{"message":"Space x not found","code":"space_not_found"}`, is: ErrSpaceNotFound, code: "space_not_found"},
+		{name: "quota exceeded", status: http.StatusTooManyRequests, body: `{"message":"Failed to create sandbox","detail":"sandbox limit reached","code":"quota_exceeded"}`, is: ErrQuotaExceeded, code: "quota_exceeded"},
+		{name: "unknown code", status: http.StatusInternalServerError, body: `{"message":"Failed","code":"something_new"}`, code: "something_new"},
+		{name: "plain body", status: http.StatusBadGateway, body: `bad gateway`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(c.status)
+				w.Write([]byte(c.body))
+			}))
+			defer srv.Close()
+
+			_, err := NewClient(srv.URL).GetSandbox(context.Background(), "default", "sb-1")
+			var apiErr *APIError
+			require.ErrorAs(t, err, &apiErr)
+			require.Equal(t, c.status, apiErr.StatusCode)
+			require.Equal(t, c.code, string(apiErr.Code))
+			if c.is != nil {
+				require.ErrorIs(t, err, c.is)
+			}
+			require.False(t, errors.Is(err, ErrNameConflict))
+		})
+	}
+}
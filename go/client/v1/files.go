@@ -0,0 +1,157 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// CopyToSandbox uploads a tar archive and extracts it into the directory dstDir
+// of the sandbox.
+func (c *Client) CopyToSandbox(ctx context.Context, space, name, dstDir string, archive io.Reader) error {
+	u := fmt.Sprintf("%s/v1/spaces/%s/sandboxes/%s/files?path=%s", c.BaseURL, space, name, url.QueryEscape(dstDir))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, archive)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-tar")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return validateResponse(resp, http.StatusNoContent)
+}
+
+// CopyFromSandbox downloads a file or directory from the sandbox as a tar
+// archive. The caller must close it.
+func (c *Client) CopyFromSandbox(ctx context.Context, space, name, srcPath string) (io.ReadCloser, error) {
+	u := fmt.Sprintf("%s/v1/spaces/%s/sandboxes/%s/files?path=%s", c.BaseURL, space, name, url.QueryEscape(srcPath))
+	return c.getStream(ctx, u)
+}
+
+// Logs returns the output of the sandbox's container. tail limits it to the
+// last lines, all when negative, and follow keeps it open for new output until
+// ctx is done. The caller must close it.
+func (c *Client) Logs(ctx context.Context, space, name string, follow bool, tail int) (io.ReadCloser, error) {
+	query := url.Values{}
+	if follow {
+		query.Set("follow", "true")
+	}
+	if tail >= 0 {
+		query.Set("tail", strconv.Itoa(tail))
+	}
+	u := fmt.Sprintf("%s/v1/spaces/%s/sandboxes/%s/logs?%s", c.BaseURL, space, name, query.Encode())
+	return c.getStream(ctx, u)
+}
+
+// getStream sends a GET request and returns the body of a successful response.
+func (c *Client) getStream(ctx context.Context, u string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateResponse(resp, http.StatusOK); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Terminal is an interactive shell in a sandbox. Reads return its output and
+// writes are its input.
+type Terminal struct {
+	conn     *websocket.Conn
+	writeMu  sync.Mutex
+	pending  []byte
+	exitCode *int
+}
+
+// terminalMessage is a control message on the terminal WebSocket.
+type terminalMessage struct {
+	Type     string `json:"type"`
+	Rows     uint   `json:"rows,omitempty"`
+	Cols     uint   `json:"cols,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+}
+
+// OpenTerminal starts a login shell in the sandbox with a terminal of the given
+// size. The caller must close it.
+func (c *Client) OpenTerminal(ctx context.Context, space, name string, rows, cols uint) (*Terminal, error) {
+	conn, err := c.dialWebSocket(ctx, fmt.Sprintf("/v1/spaces/%s/sandboxes/%s/terminal?rows=%d&cols=%d", space, name, rows, cols))
+	if err != nil {
+		return nil, err
+	}
+	return &Terminal{conn: conn}, nil
+}
+
+// Read reads the terminal's output. It returns io.EOF once the shell exits.
+func (t *Terminal) Read(p []byte) (int, error) {
+	for len(t.pending) == 0 {
+		msgType, data, err := t.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return 0, io.EOF
+			}
+			return 0, err
+		}
+		if msgType == websocket.BinaryMessage {
+			t.pending = data
+			continue
+		}
+		var msg terminalMessage
+		if err := json.Unmarshal(data, &msg); err == nil && msg.Type == "exit" {
+			t.exitCode = msg.ExitCode
+		}
+	}
+	n := copy(p, t.pending)
+	t.pending = t.pending[n:]
+	return n, nil
+}
+
+// Write writes to the terminal's input.
+func (t *Terminal) Write(p []byte) (int, error) {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if err := t.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Resize changes the size of the terminal.
+func (t *Terminal) Resize(rows, cols uint) error {
+	data, err := json.Marshal(terminalMessage{Type: "resize", Rows: rows, Cols: cols})
+	if err != nil {
+		return err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	return t.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// ExitCode returns the exit code of the shell once Read has returned io.EOF,
+// and false if it is not known.
+func (t *Terminal) ExitCode() (int, bool) {
+	if t.exitCode == nil {
+		return 0, false
+	}
+	return *t.exitCode, true
+}
+
+// Close closes the terminal, hanging up a shell that is still running.
+func (t *Terminal) Close() error {
+	return t.conn.Close()
+}
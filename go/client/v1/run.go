@@ -0,0 +1,101 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "github.com/foreveryh/sandboxai/go/api/v1"
+)
+
+// RunResult is the collected outcome of an action, see Run.
+type RunResult struct {
+	ActionID string
+	// Output is stdout and stderr interleaved in the order they arrived.
+	Output string
+	Stdout string
+	Stderr string
+	// ExitCode is the exit code of the command, or of the cell (0 on success,
+	// 1 on an exception). It is -1 if the action could not run.
+	ExitCode int
+	// Error describes why the action failed, if it reported a reason.
+	Error string
+	// DisplayData holds the rich outputs of a cell, such as plots and HTML
+	// tables, in the order they were displayed.
+	DisplayData []v1.DisplayData
+	// Observations holds every observation of the action, ending with "end".
+	Observations []v1.Observation
+}
+
+// Run runs an action and collects its output until it ends. request is a
+// *v1.RunShellCommandRequest or a *v1.RunIPythonCellRequest. A non-zero exit
+// code is reported in the result, not as an error.
+func (c *Client) Run(ctx context.Context, space, name string, request any) (*RunResult, error) {
+	var start func(ctx context.Context) (string, error)
+	switch r := request.(type) {
+	case *v1.RunShellCommandRequest:
+		start = func(ctx context.Context) (string, error) { return c.StartShellCommand(ctx, space, name, r) }
+	case *v1.RunIPythonCellRequest:
+		start = func(ctx context.Context) (string, error) { return c.StartIPythonCell(ctx, space, name, r) }
+	default:
+		return nil, fmt.Errorf("unsupported action request %T", request)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// The stream only carries observations published after it connects, so it
+	// has to be open before the action starts.
+	stream, err := c.StreamObservations(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	actionID, err := start(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &RunResult{ActionID: actionID}
+	var output, stdout, stderr strings.Builder
+	for {
+		obs, err := stream.Next()
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			return nil, fmt.Errorf("stream ended before action %s: %w", actionID, err)
+		}
+		if obs.ActionID != actionID {
+			continue
+		}
+		result.Observations = append(result.Observations, *obs)
+		if obs.Error != nil && *obs.Error != "" {
+			result.Error = *obs.Error
+		}
+		if obs.ExitCode != nil {
+			result.ExitCode = int(*obs.ExitCode)
+		}
+		switch obs.ObservationType {
+		case "stream":
+			// Lines keep their newlines, so output that does not end in one
+			// comes back as written.
+			line := ""
+			if obs.Line != nil {
+				line = *obs.Line
+			}
+			output.WriteString(line)
+			if obs.Stream != nil && *obs.Stream == "stderr" {
+				stderr.WriteString(line)
+			} else {
+				stdout.WriteString(line)
+			}
+		case "display_data":
+			if obs.Data != nil {
+				result.DisplayData = append(result.DisplayData, v1.DisplayData{Data: *obs.Data, Metadata: obs.Metadata})
+			}
+		case "end":
+			result.Output, result.Stdout, result.Stderr = output.String(), stdout.String(), stderr.String()
+			return result, nil
+		}
+	}
+}
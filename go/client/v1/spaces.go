@@ -0,0 +1,186 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	v1 "github.com/foreveryh/sandboxai/go/api/v1"
+)
+
+// CreateSpace creates a new space.
+func (c *Client) CreateSpace(ctx context.Context, request *v1.CreateSpaceRequest) (*v1.Space, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/v1/spaces", c.BaseURL), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := validateResponse(resp, http.StatusCreated); err != nil {
+		return nil, err
+	}
+
+	var response v1.Space
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetSpace retrieves a space.
+func (c *Client) GetSpace(ctx context.Context, space string) (*v1.Space, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/spaces/%s", c.BaseURL, url.PathEscape(space)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := validateResponse(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	var response v1.Space
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListSpaces lists the spaces visible to the client.
+func (c *Client) ListSpaces(ctx context.Context) ([]v1.Space, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/spaces", c.BaseURL), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := validateResponse(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	var response []v1.Space
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// UpdateSpace replaces the description and metadata of a space and returns
+// the updated space. The security profile is kept unless the request sets it.
+func (c *Client) UpdateSpace(ctx context.Context, space string, request *v1.UpdateSpaceRequest) (*v1.Space, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/v1/spaces/%s", c.BaseURL, url.PathEscape(space)), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := validateResponse(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	var response v1.Space
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DeleteSpace deletes a space with its sandboxes and volumes.
+func (c *Client) DeleteSpace(ctx context.Context, space string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s/v1/spaces/%s", c.BaseURL, url.PathEscape(space)), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return validateResponse(resp, http.StatusNoContent)
+}
+
+// SpaceUsage retrieves what the sandboxes of a space use, next to its quota,
+// and its metered usage over the range of params, which may be nil.
+// params.Format is ignored.
+func (c *Client) SpaceUsage(ctx context.Context, space string, params *v1.GetSpaceUsageParams) (*v1.SpaceUsage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/spaces/%s/usage?%s", c.BaseURL, url.PathEscape(space), usageQuery(params).Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := validateResponse(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	var response v1.SpaceUsage
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ExportSpaceUsage returns the metered usage of a space as CSV with a header
+// row. The caller must close it.
+func (c *Client) ExportSpaceUsage(ctx context.Context, space string, params *v1.GetSpaceUsageParams) (io.ReadCloser, error) {
+	query := usageQuery(params)
+	query.Set("format", string(v1.Csv))
+	return c.getStream(ctx, fmt.Sprintf("%s/v1/spaces/%s/usage?%s", c.BaseURL, url.PathEscape(space), query.Encode()))
+}
+
+// usageQuery encodes params except the format.
+func usageQuery(params *v1.GetSpaceUsageParams) url.Values {
+	query := url.Values{}
+	if params == nil {
+		return query
+	}
+	if params.From != nil {
+		query.Set("from", params.From.Format(time.RFC3339))
+	}
+	if params.To != nil {
+		query.Set("to", params.To.Format(time.RFC3339))
+	}
+	if params.Bucket != nil {
+		query.Set("bucket", string(*params.Bucket))
+	}
+	if params.GroupBy != nil {
+		query.Set("group_by", string(*params.GroupBy))
+	}
+	return query
+}
@@ -0,0 +1,262 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/foreveryh/sandboxai/go/api/v1"
+	"github.com/gorilla/websocket"
+)
+
+// Defaults for reconnecting an observation stream, see WithReconnect.
+const (
+	defaultMaxReconnects  = 5
+	reconnectInitialDelay = 250 * time.Millisecond
+	reconnectMaxDelay     = 5 * time.Second
+	observationBufferSize = 64
+)
+
+// StreamOption configures StreamObservations.
+type StreamOption func(*ObservationStream)
+
+// WithReconnect sets how many consecutive times a dropped stream is
+// reconnected before it gives up; 0 disables reconnecting. The default is 5.
+func WithReconnect(maxAttempts int) StreamOption {
+	return func(s *ObservationStream) {
+		s.maxReconnects = maxAttempts
+	}
+}
+
+// ObservationStream receives the observations of a sandbox over a WebSocket.
+// It reconnects when the connection drops; observations published while it is
+// disconnected are lost.
+type ObservationStream struct {
+	c             *Client
+	path          string
+	maxReconnects int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	ch     chan *v1.Observation
+	done   chan struct{}
+	err    error // Set before done is closed
+
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+// StreamObservations connects to the observation stream of a sandbox. Only
+// observations published after it connects are received, so connect before
+// starting an action to see all of its output. The stream ends when ctx is
+// done or it is closed.
+func (c *Client) StreamObservations(ctx context.Context, name string, opts ...StreamOption) (*ObservationStream, error) {
+	s := &ObservationStream{
+		c:             c,
+		path:          fmt.Sprintf("/v1/sandboxes/%s/stream", name),
+		maxReconnects: defaultMaxReconnects,
+		ch:            make(chan *v1.Observation, observationBufferSize),
+		done:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	conn, err := c.dialWebSocket(ctx, s.path)
+	if err != nil {
+		return nil, err
+	}
+	s.conn = conn
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	go s.run()
+	return s, nil
+}
+
+// Observations returns a channel delivering the observations in order. It is
+// closed when the stream ends; Err then reports why.
+func (s *ObservationStream) Observations() <-chan *v1.Observation {
+	return s.ch
+}
+
+// Next blocks until the next observation arrives. Once the stream has ended it
+// returns Err, or io.EOF if the stream was closed.
+func (s *ObservationStream) Next() (*v1.Observation, error) {
+	obs, ok := <-s.ch
+	if !ok {
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	return obs, nil
+}
+
+// Err returns the error that ended the stream, or nil while it is running and
+// after it was closed or its context was canceled.
+func (s *ObservationStream) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close ends the stream and waits for its connection to close.
+func (s *ObservationStream) Close() error {
+	s.cancel()
+	s.mu.Lock()
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.mu.Unlock()
+	<-s.done
+	return nil
+}
+
+// run reads observations into the channel, reconnecting when the connection
+// drops, until the stream ends.
+func (s *ObservationStream) run() {
+	defer close(s.ch)
+	defer close(s.done)
+	for {
+		err := s.read()
+		if s.ctx.Err() != nil {
+			return
+		}
+		if s.err = s.reconnect(err); s.err != nil {
+			return
+		}
+	}
+}
+
+// read delivers observations from the current connection until it fails.
+func (s *ObservationStream) read() error {
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+	defer conn.Close()
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		obs, err := parseObservation(data)
+		if err != nil {
+			continue
+		}
+		select {
+		case s.ch <- obs:
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+	}
+}
+
+// reconnect dials the stream again with exponential backoff. It gives up
+// after maxReconnects attempts or when the sandbox is gone.
+func (s *ObservationStream) reconnect(cause error) error {
+	delay := reconnectInitialDelay
+	for attempt := 0; attempt < s.maxReconnects; attempt++ {
+		select {
+		case <-time.After(delay):
+		case <-s.ctx.Done():
+			return nil
+		}
+		conn, err := s.c.dialWebSocket(s.ctx, s.path)
+		if err == nil {
+			s.mu.Lock()
+			if s.ctx.Err() != nil {
+				s.mu.Unlock()
+				conn.Close()
+				return nil
+			}
+			s.conn = conn
+			s.mu.Unlock()
+			return nil
+		}
+		if s.ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, ErrSandboxNotFound) {
+			return err
+		}
+		cause = err
+		delay = min(2*delay, reconnectMaxDelay)
+	}
+	return fmt.Errorf("observation stream disconnected: %w", cause)
+}
+
+// parseObservation decodes an observation. Observations produced by the runtime
+// carry their details under data, those relayed from the agent at the top
+// level; both end up in the top-level fields. The payload itself, such as the
+// MIME bundle of a display_data observation, is kept in Data.
+func parseObservation(data []byte) (*v1.Observation, error) {
+	type details struct {
+		Stream   *string `json:"stream"`
+		Line     *string `json:"line"`
+		ExitCode *int32  `json:"exit_code"`
+		Error    *string `json:"error"`
+	}
+	var wire struct {
+		details
+		ObservationType string          `json:"observation_type"`
+		ActionID        string          `json:"action_id"`
+		Timestamp       string          `json:"timestamp"`
+		Data            json.RawMessage `json:"data"`
+		Metadata        *map[string]any `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return nil, fmt.Errorf("invalid observation: %w", err)
+	}
+	obs := &v1.Observation{
+		ObservationType: wire.ObservationType,
+		ActionID:        wire.ActionID,
+		Stream:          wire.Stream,
+		Line:            wire.Line,
+		ExitCode:        wire.ExitCode,
+		Error:           wire.Error,
+		Metadata:        wire.Metadata,
+	}
+	// A payload that is not an object carries no details and is dropped.
+	if err := json.Unmarshal(wire.Data, &obs.Data); err == nil && obs.Data != nil {
+		var d details
+		json.Unmarshal(wire.Data, &d)
+		obs.Stream = firstNonNil(obs.Stream, d.Stream)
+		obs.Line = firstNonNil(obs.Line, d.Line)
+		obs.ExitCode = firstNonNil(obs.ExitCode, d.ExitCode)
+		obs.Error = firstNonNil(obs.Error, d.Error)
+	}
+	obs.Timestamp, _ = time.Parse(time.RFC3339Nano, wire.Timestamp)
+	return obs, nil
+}
+
+func firstNonNil[T any](a, b *T) *T {
+	if a != nil {
+		return a
+	}
+	return b
+}
+
+// dialWebSocket opens a WebSocket to path on the runtime.
+func (c *Client) dialWebSocket(ctx context.Context, path string) (*websocket.Conn, error) {
+	wsURL := "ws" + strings.TrimPrefix(c.BaseURL, "http") + path
+	header := http.Header{}
+	if c.apiKey != "" {
+		header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, wsURL, header)
+	if err != nil {
+		if resp != nil {
+			defer resp.Body.Close()
+			return nil, validateResponse(resp, http.StatusSwitchingProtocols)
+		}
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,170 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/foreveryh/sandboxai/go/api/v1"
+)
+
+func Test_parseObservation(t *testing.T) {
+	obs, err := parseObservation([]byte(`{"observation_type":"end","action_id":"a1","timestamp":"2025-01-02T03:04:05.5Z","data":{"exit_code":3,"error":"boom"}}`))
+	require.NoError(t, err)
+	require.Equal(t, "end", obs.ObservationType)
+	require.Equal(t, "a1", obs.ActionID)
+	require.Equal(t, int32(3), *obs.ExitCode)
+	require.Equal(t, "boom", *obs.Error)
+	require.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 5e8, time.UTC), obs.Timestamp)
+
+	obs, err = parseObservation([]byte(`{"observation_type":"stream","action_id":"a1","stream":"stderr","line":"oops"}`))
+	require.NoError(t, err)
+	require.Equal(t, "stderr", *obs.Stream)
+	require.Equal(t, "oops", *obs.Line)
+}
+
+// fakeRuntime serves the stream and the shell command endpoint of sandbox
+// "sb". Stream connections are handed to the test on streams.
+type fakeRuntime struct {
+	streams     chan *websocket.Conn
+	connections atomic.Int32
+}
+
+// newFakeRuntime starts a fake runtime. onCommand runs after a shell command
+// was accepted as action "a1".
+func newFakeRuntime(t *testing.T, onCommand func(command string)) (*fakeRuntime, *Client) {
+	f := &fakeRuntime{streams: make(chan *websocket.Conn, 4)}
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/sandboxes/sb/stream", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		f.connections.Add(1)
+		f.streams <- conn
+	})
+	mux.HandleFunc("/v1/spaces/default/sandboxes/sb/tools:run_shell_command", func(w http.ResponseWriter, r *http.Request) {
+		var req v1.RunShellCommandRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"action_id":"a1"}`))
+		go onCommand(req.Command)
+	})
+	mux.HandleFunc("/v1/spaces/default/sandboxes/sb/tools:run_ipython_cell", func(w http.ResponseWriter, r *http.Request) {
+		var req v1.RunIPythonCellRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"action_id":"a1"}`))
+		go onCommand(req.Code)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return f, NewClient(srv.URL)
+}
+
+func send(t *testing.T, conn *websocket.Conn, observations ...string) {
+	for _, obs := range observations {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(obs)))
+	}
+}
+
+func Test_StreamObservationsReconnects(t *testing.T) {
+	f, c := newFakeRuntime(t, nil)
+
+	stream, err := c.StreamObservations(context.Background(), "sb")
+	require.NoError(t, err)
+	defer stream.Close()
+
+	conn := <-f.streams
+	send(t, conn, `{"observation_type":"start","action_id":"a1"}`)
+	conn.Close()
+	conn = <-f.streams
+	send(t, conn, `{"observation_type":"end","action_id":"a1","data":{"exit_code":0}}`)
+
+	first := <-stream.Observations()
+	require.Equal(t, "start", first.ObservationType)
+	second, err := stream.Next()
+	require.NoError(t, err)
+	require.Equal(t, "end", second.ObservationType)
+	require.Equal(t, int32(2), f.connections.Load())
+
+	require.NoError(t, stream.Close())
+	_, err = stream.Next()
+	require.ErrorIs(t, err, io.EOF)
+	require.NoError(t, stream.Err())
+}
+
+func Test_StreamObservationsGivesUp(t *testing.T) {
+	f, c := newFakeRuntime(t, nil)
+	stream, err := c.StreamObservations(context.Background(), "sb", WithReconnect(0))
+	require.NoError(t, err)
+	defer stream.Close()
+
+	(<-f.streams).Close()
+	_, err = stream.Next()
+	require.ErrorContains(t, err, "observation stream disconnected")
+	require.Error(t, stream.Err())
+}
+
+func Test_RunIPythonCellDisplayData(t *testing.T) {
+	var f *fakeRuntime
+	f, c := newFakeRuntime(t, func(string) {
+		conn := <-f.streams
+		send(t, conn,
+			`{"observation_type":"start","action_id":"a1","data":{"action_type":"ipython"}}`,
+			`{"observation_type":"stream","action_id":"a1","stream":"stdout","line":"plotting\n"}`,
+			`{"observation_type":"display_data","action_id":"a1","data":{"image/png":"iVBORw0KGgo=","text/plain":"<Figure>"},"metadata":{"image/png":{"width":640}}}`,
+			`{"observation_type":"display_data","action_id":"a1","data":{"text/html":"<table></table>"}}`,
+			`{"observation_type":"result","action_id":"a1","exit_code":0}`,
+			`{"observation_type":"end","action_id":"a1","data":{"exit_code":0}}`,
+		)
+	})
+
+	result, err := c.RunIPythonCell(context.Background(), "default", "sb", &v1.RunIPythonCellRequest{Code: "plt.show()"})
+	require.NoError(t, err)
+	require.Equal(t, "plotting\n", *result.Output)
+	require.NotNil(t, result.DisplayData)
+	require.Equal(t, []v1.DisplayData{
+		{Data: map[string]any{"image/png": "iVBORw0KGgo=", "text/plain": "<Figure>"}, Metadata: &map[string]any{"image/png": map[string]any{"width": float64(640)}}},
+		{Data: map[string]any{"text/html": "<table></table>"}},
+	}, *result.DisplayData)
+}
+
+func Test_Run(t *testing.T) {
+	var f *fakeRuntime
+	f, c := newFakeRuntime(t, func(command string) {
+		conn := <-f.streams
+		send(t, conn,
+			`{"observation_type":"start","action_id":"a1"}`,
+			`{"observation_type":"stream","action_id":"other","stream":"stdout","line":"not mine"}`,
+			`{"observation_type":"stream","action_id":"a1","stream":"stdout","line":"out\n"}`,
+			`{"observation_type":"stream","action_id":"a1","stream":"stderr","line":"err\n"}`,
+			`{"observation_type":"stream","action_id":"a1","stream":"stdout","line":"no newline"}`,
+			`{"observation_type":"result","action_id":"a1","exit_code":2,"error":"err"}`,
+			`{"observation_type":"end","action_id":"a1","data":{"exit_code":2}}`,
+		)
+	})
+
+	result, err := c.Run(context.Background(), "default", "sb", &v1.RunShellCommandRequest{Command: "false"})
+	require.NoError(t, err)
+	require.Equal(t, "a1", result.ActionID)
+	require.Equal(t, "out\nerr\nno newline", result.Output)
+	require.Equal(t, "out\nno newline", result.Stdout)
+	require.Equal(t, "err\n", result.Stderr)
+	require.Equal(t, 2, result.ExitCode)
+	require.Equal(t, "err", result.Error)
+	require.Len(t, result.Observations, 6)
+	require.Empty(t, result.DisplayData)
+
+	_, err = c.Run(context.Background(), "default", "sb", "echo")
+	require.ErrorContains(t, err, "unsupported action request")
+}
@@ -0,0 +1,257 @@
+// Package audit keeps an append-only record of the operations that change
+// state: who did what to which space and sandbox, with what request and what
+// outcome. Handlers mark the requests to record with Describe; Middleware
+// completes and appends their entries once they are served. A nil *Log
+// records nothing.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/auth"
+)
+
+// ErrInvalidQuery is returned for queries with an empty time range.
+var ErrInvalidQuery = errors.New("invalid audit query")
+
+// Outcomes of an entry.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// AnonymousActor identifies requests made while authentication is disabled.
+const AnonymousActor = "anonymous"
+
+// Actor identifies who performed an operation by their API key.
+type Actor struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// ActorFrom returns the actor of the request in ctx.
+func ActorFrom(ctx context.Context) Actor {
+	key := auth.KeyFromContext(ctx)
+	if key == nil {
+		return Actor{ID: AnonymousActor}
+	}
+	return Actor{ID: key.ID, Name: key.Name}
+}
+
+// Entry is one audited operation.
+type Entry struct {
+	ID         string          `json:"id"`
+	Time       time.Time       `json:"time"` // When the entry was appended, once the operation finished
+	Actor      Actor           `json:"actor"`
+	RemoteAddr string          `json:"remote_addr,omitempty"`
+	Operation  string          `json:"operation"`
+	SpaceID    string          `json:"space_id,omitempty"`
+	SandboxID  string          `json:"sandbox_id,omitempty"`
+	ActionID   string          `json:"action_id,omitempty"`
+	Request    json.RawMessage `json:"request,omitempty"` // Redacted request payload
+	Outcome    string          `json:"outcome"`
+	StatusCode int             `json:"status_code,omitempty"` // HTTP status of the response
+	ExitCode   *int            `json:"exit_code,omitempty"`   // Set for finished actions
+	Error      string          `json:"error,omitempty"`
+}
+
+// Query selects entries. Zero fields do not filter.
+type Query struct {
+	SpaceID string
+	From    time.Time // Inclusive
+	To      time.Time // Exclusive
+	Limit   int       // Maximum number of entries, oldest first
+}
+
+// Validate checks that the time range is not empty.
+func (q Query) Validate() error {
+	if !q.From.IsZero() && !q.To.IsZero() && !q.To.After(q.From) {
+		return errors.Join(ErrInvalidQuery, errors.New("to must be after from"))
+	}
+	if q.Limit < 0 {
+		return errors.Join(ErrInvalidQuery, errors.New("limit must not be negative"))
+	}
+	return nil
+}
+
+// Matches reports whether q selects e.
+func (q Query) Matches(e Entry) bool {
+	if q.SpaceID != "" && e.SpaceID != q.SpaceID {
+		return false
+	}
+	if !q.From.IsZero() && e.Time.Before(q.From) {
+		return false
+	}
+	return q.To.IsZero() || e.Time.Before(q.To)
+}
+
+// Log appends entries to a sink after redacting their requests.
+type Log struct {
+	mu       sync.Mutex // Keeps appends in time order
+	sink     Sink
+	redactor *Redactor
+	logger   *slog.Logger
+}
+
+// New creates a log writing to sink. A nil redactor uses the default rules.
+func New(sink Sink, redactor *Redactor, logger *slog.Logger) *Log {
+	if redactor == nil {
+		redactor, _ = NewRedactor(nil, nil)
+	}
+	return &Log{sink: sink, redactor: redactor, logger: logger.With("component", "audit")}
+}
+
+// Append records an entry, giving it an ID unless set and the current time.
+// Failures are logged rather than returned so auditing never fails the
+// operation.
+func (l *Log) Append(e Entry) {
+	if l == nil {
+		return
+	}
+	if e.ID == "" {
+		e.ID = uuid.NewString()
+	}
+	// Stamped while appending, so sinks receive entries in time order even
+	// when a slow request finishes after a faster one that started later.
+	l.mu.Lock()
+	e.Time = time.Now().UTC()
+	err := l.sink.Append(e)
+	l.mu.Unlock()
+	if err != nil {
+		l.logger.Error("Failed to append audit entry", "operation", e.Operation, "spaceID", e.SpaceID, "sandboxID", e.SandboxID, "error", err)
+	}
+}
+
+// Query returns the entries q selects, oldest first.
+func (l *Log) Query(q Query) ([]Entry, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	if l == nil {
+		return nil, nil
+	}
+	return l.sink.Query(q)
+}
+
+// Redact returns the redacted JSON form of a request payload.
+func (l *Log) Redact(request any) json.RawMessage {
+	if l == nil || request == nil {
+		return nil
+	}
+	redacted, err := l.redactor.Redact(request)
+	if err != nil {
+		l.logger.Warn("Failed to redact audited request", "error", err)
+		return json.RawMessage(`"unrecordable request"`)
+	}
+	return redacted
+}
+
+type pendingKey struct{}
+
+// pending is the entry of a request being served.
+type pending struct {
+	mu      sync.Mutex
+	entry   Entry
+	request any
+	audited bool
+}
+
+func pendingFrom(ctx context.Context) *pending {
+	p, _ := ctx.Value(pendingKey{}).(*pending)
+	return p
+}
+
+// Describe marks the request in ctx to be audited as operation with the given
+// payload. It has no effect outside Middleware.
+func Describe(ctx context.Context, operation string, request any) {
+	if p := pendingFrom(ctx); p != nil {
+		p.mu.Lock()
+		p.entry.Operation = operation
+		p.entry.Actor = ActorFrom(ctx)
+		p.request = request
+		p.audited = true
+		p.mu.Unlock()
+	}
+}
+
+// SetSpaceID records the space a request created.
+func SetSpaceID(ctx context.Context, spaceID string) {
+	update(ctx, func(e *Entry) { e.SpaceID = spaceID })
+}
+
+// SetSandboxID records the sandbox a request created.
+func SetSandboxID(ctx context.Context, sandboxID string) {
+	update(ctx, func(e *Entry) { e.SandboxID = sandboxID })
+}
+
+// SetActionID records the action a request started.
+func SetActionID(ctx context.Context, actionID string) {
+	update(ctx, func(e *Entry) { e.ActionID = actionID })
+}
+
+func update(ctx context.Context, f func(*Entry)) {
+	if p := pendingFrom(ctx); p != nil {
+		p.mu.Lock()
+		f(&p.entry)
+		p.mu.Unlock()
+	}
+}
+
+// maxErrorBody bounds how much of a failed response is kept to find its
+// error message.
+const maxErrorBody = 4096
+
+// Middleware appends an entry for each request a handler marked with
+// Describe, once it has been served. The space and sandbox default to the
+// route's space_id and sandbox_id variables.
+func (l *Log) Middleware(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		p := &pending{entry: Entry{
+			RemoteAddr: r.RemoteAddr,
+			SpaceID:    vars["space_id"],
+			SandboxID:  vars["sandbox_id"],
+		}}
+		rw := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), pendingKey{}, p)))
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if !p.audited {
+			return
+		}
+		e := p.entry
+		e.Request = l.Redact(p.request)
+		e.StatusCode = rw.status()
+		e.Outcome = OutcomeSuccess
+		if e.StatusCode >= 400 {
+			e.Outcome = OutcomeFailure
+			e.Error = errorMessage(rw.body.Bytes())
+		}
+		l.Append(e)
+	})
+}
+
+// errorMessage extracts the message of an API error body.
+func errorMessage(body []byte) string {
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+		return apiErr.Message
+	}
+	return string(bytes.TrimSpace(body))
+}
@@ -0,0 +1,200 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/auth"
+)
+
+func Test_Redactor(t *testing.T) {
+	r, err := NewRedactor([]string{"pin"}, []string{`(?i)mysql -p(\S+)`})
+	require.NoError(t, err)
+
+	out, err := r.Redact(map[string]any{
+		"command": "curl -H 'Authorization: Bearer abc.def' https://x && TOKEN=s3cr3t make && mysql -phunter2",
+		"env":     map[string]string{"GITHUB_TOKEN": "ghp_x", "HOME": "/root", "PIN": "1234"},
+		"args":    []any{"sbx_" + "0123456789abcdefABCDEF", 3},
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"command": "curl -H 'Authorization: Bearer REDACTED' https://x && TOKEN=REDACTED make && mysql -pREDACTED",
+		"env": {"GITHUB_TOKEN": "REDACTED", "HOME": "/root", "PIN": "REDACTED"},
+		"args": ["REDACTED", 3]
+	}`, string(out))
+
+	_, err = NewRedactor(nil, []string{"("})
+	require.ErrorContains(t, err, "invalid redaction pattern")
+}
+
+func Test_FileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, space := range []string{"s1", "s2", "s1"} {
+		require.NoError(t, sink.Append(Entry{ID: space, Time: start.Add(time.Duration(i) * time.Hour), SpaceID: space, Operation: "createSandbox", Outcome: OutcomeSuccess}))
+	}
+	require.NoError(t, sink.Close())
+
+	// Entries survive reopening, and a line cut short by a crash is skipped.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"id":"torn",`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	sink, err = NewFileSink(path)
+	require.NoError(t, err)
+	defer sink.Close()
+
+	entries, err := sink.Query(Query{SpaceID: "s1"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, start, entries[0].Time)
+
+	entries, err = sink.Query(Query{From: start.Add(time.Hour), Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "s2", entries[0].SpaceID)
+}
+
+func Test_MemorySink(t *testing.T) {
+	sink := NewMemorySink(2)
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, sink.Append(Entry{ID: id}))
+	}
+	entries, err := sink.Query(Query{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "c"}, []string{entries[0].ID, entries[1].ID})
+	require.NoError(t, sink.Append(Entry{ID: "d"}))
+	entries, err = sink.Query(Query{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"c", "d"}, []string{entries[0].ID, entries[1].ID}, "oldest first after wrapping")
+	entries, err = sink.Query(Query{Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, "c", entries[0].ID)
+
+	unbounded := NewMemorySink(0)
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, unbounded.Append(Entry{ID: id}))
+	}
+	entries, err = unbounded.Query(Query{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+}
+
+func Test_QueryValidate(t *testing.T) {
+	now := time.Now()
+	require.NoError(t, Query{From: now, To: now.Add(time.Second)}.Validate())
+	require.ErrorIs(t, Query{From: now, To: now}.Validate(), ErrInvalidQuery)
+	require.ErrorIs(t, Query{Limit: -1}.Validate(), ErrInvalidQuery)
+}
+
+func Test_Middleware(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	log := New(NewMemorySink(10), nil, logger)
+	keys, err := auth.NewStore("")
+	require.NoError(t, err)
+	key, secret, err := keys.Create("ci", []auth.Scope{auth.ScopeSpaceWrite}, nil)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.Use(auth.Middleware(keys, logger), log.Middleware)
+	router.HandleFunc("/spaces/{space_id}/sandboxes", func(w http.ResponseWriter, r *http.Request) {
+		Describe(r.Context(), "createSandbox", map[string]string{"image": "box", "api_key": "x"})
+		SetSandboxID(r.Context(), "sb1")
+		w.WriteHeader(http.StatusCreated)
+	}).Methods("POST")
+	router.HandleFunc("/spaces/{space_id}/sandboxes/{sandbox_id}", func(w http.ResponseWriter, r *http.Request) {
+		Describe(r.Context(), "deleteSandbox", nil)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"Sandbox sb2 not found"}`))
+	}).Methods("DELETE")
+	router.HandleFunc("/spaces/{space_id}", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("POST", "/spaces/s1/sandboxes", nil),
+		httptest.NewRequest("DELETE", "/spaces/s1/sandboxes/sb2", nil),
+		httptest.NewRequest("GET", "/spaces/s1", nil),
+	} {
+		req.Header.Set("Authorization", "Bearer "+secret)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	entries, err := log.Query(Query{SpaceID: "s1"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "requests without Describe are not audited")
+
+	created, deleted := entries[0], entries[1]
+	require.NotEmpty(t, created.ID)
+	require.Equal(t, Actor{ID: key.ID, Name: "ci"}, created.Actor)
+	require.Equal(t, "createSandbox", created.Operation)
+	require.Equal(t, "sb1", created.SandboxID)
+	require.Equal(t, OutcomeSuccess, created.Outcome)
+	require.Equal(t, http.StatusCreated, created.StatusCode)
+	var request map[string]string
+	require.NoError(t, json.Unmarshal(created.Request, &request))
+	require.Equal(t, map[string]string{"image": "box", "api_key": Redacted}, request)
+
+	require.Equal(t, "sb2", deleted.SandboxID)
+	require.Equal(t, OutcomeFailure, deleted.Outcome)
+	require.Equal(t, "Sandbox sb2 not found", deleted.Error)
+}
+
+func Test_nilLog(t *testing.T) {
+	var log *Log
+	log.Append(Entry{})
+	entries, err := log.Query(Query{})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	Describe(context.Background(), "createSpace", nil)
+}
+
+func Test_MiddlewareTimeOrder(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	log := New(NewMemorySink(10), nil, logger)
+	release := make(chan struct{})
+	router := mux.NewRouter()
+	router.Use(log.Middleware)
+	router.HandleFunc("/spaces/{space_id}/{operation}", func(w http.ResponseWriter, r *http.Request) {
+		Describe(r.Context(), mux.Vars(r)["operation"], nil)
+		if mux.Vars(r)["operation"] == "slow" {
+			<-release
+		}
+	}).Methods("POST")
+
+	// The slow request starts first but finishes last.
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/spaces/s1/slow", nil))
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/spaces/s1/fast", nil))
+	close(release)
+	<-done
+
+	entries, err := log.Query(Query{SpaceID: "s1"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "fast", entries[0].Operation)
+	require.Equal(t, "slow", entries[1].Operation)
+	require.False(t, entries[1].Time.Before(entries[0].Time))
+
+	// Limits apply in time order.
+	first, err := log.Query(Query{SpaceID: "s1", Limit: 1, From: entries[0].Time})
+	require.NoError(t, err)
+	require.Equal(t, entries[:1], first)
+}
@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Redacted replaces secrets in recorded requests.
+const Redacted = "REDACTED"
+
+// DefaultRedactKeys are redacted wherever they appear in a request, e.g. as
+// environment variable names. Keys match case-insensitively on substrings.
+var DefaultRedactKeys = []string{"password", "passwd", "secret", "token", "api_key", "apikey", "authorization", "credential", "private_key"}
+
+// DefaultRedactPatterns are redacted wherever they appear in request strings,
+// e.g. inside a shell command.
+var DefaultRedactPatterns = []string{
+	`sbx_[A-Za-z0-9_-]{16,}`,                                // API keys of this runtime
+	`(?i)bearer\s+([A-Za-z0-9._~+/-]+=*)`,                   // Authorization headers
+	`(?i)(?:password|passwd|secret|token|api_key)\w*=(\S+)`, // Assignments on command lines
+}
+
+// Redactor masks secrets in request payloads by object key and by pattern.
+type Redactor struct {
+	keys     []string
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor creates a redactor with the default rules plus keys and
+// patterns, which are regular expressions. A pattern with a group only has
+// its first group redacted, so the context of the secret stays readable.
+func NewRedactor(keys, patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, k := range slices.Concat(DefaultRedactKeys, keys) {
+		r.keys = append(r.keys, strings.ToLower(k))
+	}
+	for _, p := range slices.Concat(DefaultRedactPatterns, patterns) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns the JSON form of v with the values of matching keys and the
+// matching parts of strings replaced.
+func (r *Redactor) Redact(v any) (json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(r.redact(generic))
+}
+
+func (r *Redactor) redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, elem := range v {
+			if r.secretKey(k) {
+				v[k] = Redacted
+			} else {
+				v[k] = r.redact(elem)
+			}
+		}
+	case []any:
+		for i, elem := range v {
+			v[i] = r.redact(elem)
+		}
+	case string:
+		for _, re := range r.patterns {
+			v = re.ReplaceAllStringFunc(v, func(match string) string {
+				if re.NumSubexp() == 0 {
+					return Redacted
+				}
+				loc := re.FindStringSubmatchIndex(match)
+				if loc[2] < 0 {
+					return match
+				}
+				return match[:loc[2]] + Redacted + match[loc[3]:]
+			})
+		}
+		return v
+	}
+	return v
+}
+
+func (r *Redactor) secretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// Sink stores audit entries. Entries are only ever appended, in time order.
+type Sink interface {
+	Append(e Entry) error
+	// Query returns the entries q selects, oldest first.
+	Query(q Query) ([]Entry, error)
+}
+
+// FileSink appends entries to a file as JSON lines.
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewFileSink opens the file at path for appending, creating it if needed.
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &FileSink{path: path, file: f}, nil
+}
+
+// Append writes e as one line.
+func (s *FileSink) Append(e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+// Query scans the file. Lines that do not parse, such as one cut short by a
+// crash, are skipped.
+func (s *FileSink) Query(q Query) ([]Entry, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if json.Unmarshal(scanner.Bytes(), &e) != nil || !q.Matches(e) {
+			continue
+		}
+		entries = append(entries, e)
+		if q.Limit > 0 && len(entries) == q.Limit {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// MemorySink keeps the most recent entries in memory.
+type MemorySink struct {
+	mu      sync.Mutex
+	entries []Entry // A ring once it holds max entries
+	head    int     // Index of the oldest entry in the ring
+	max     int
+}
+
+// NewMemorySink creates a sink holding up to max entries, dropping the oldest
+// beyond that. A max of zero or less keeps every entry.
+func NewMemorySink(max int) *MemorySink {
+	return &MemorySink{max: max}
+}
+
+func (s *MemorySink) Append(e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.max <= 0 || len(s.entries) < s.max {
+		s.entries = append(s.entries, e)
+		return nil
+	}
+	s.entries[s.head] = e
+	s.head = (s.head + 1) % len(s.entries)
+	return nil
+}
+
+func (s *MemorySink) Query(q Query) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []Entry
+	for i := range s.entries {
+		e := s.entries[(s.head+i)%len(s.entries)]
+		if !q.Matches(e) {
+			continue
+		}
+		entries = append(entries, e)
+		if q.Limit > 0 && len(entries) == q.Limit {
+			break
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_APIKeyAllows(t *testing.T) {
+	cases := []struct {
+		name    string
+		key     APIKey
+		scope   Scope
+		spaceID string
+		exp     bool
+	}{
+		{name: "admin", key: APIKey{Scopes: []Scope{ScopeAdmin}, Spaces: []string{"a"}}, scope: ScopeExec, spaceID: "b", exp: true},
+		{name: "scope granted", key: APIKey{Scopes: []Scope{ScopeExec}}, scope: ScopeExec, spaceID: "a", exp: true},
+		{name: "scope missing", key: APIKey{Scopes: []Scope{ScopeSpaceRead}}, scope: ScopeExec, spaceID: "a"},
+		{name: "write implies read", key: APIKey{Scopes: []Scope{ScopeSpaceWrite}}, scope: ScopeSpaceRead, spaceID: "a", exp: true},
+		{name: "read does not imply write", key: APIKey{Scopes: []Scope{ScopeSpaceRead}}, scope: ScopeSpaceWrite, spaceID: "a"},
+		{name: "allowed space", key: APIKey{Scopes: []Scope{ScopeExec}, Spaces: []string{"a"}}, scope: ScopeExec, spaceID: "a", exp: true},
+		{name: "other space", key: APIKey{Scopes: []Scope{ScopeExec}, Spaces: []string{"a"}}, scope: ScopeExec, spaceID: "b"},
+		{name: "no space", key: APIKey{Scopes: []Scope{ScopeSpaceRead}, Spaces: []string{"a"}}, scope: ScopeSpaceRead, exp: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.exp, c.key.Allows(c.scope, c.spaceID))
+		})
+	}
+}
+
+func Test_Store(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	store, err := NewStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.AddStatic("admin", "bootstrap", ScopeAdmin))
+
+	_, _, err = store.Create("bad", []Scope{"root"}, nil)
+	require.ErrorIs(t, err, ErrInvalidScope)
+
+	key, secret, err := store.Create("ci", []Scope{ScopeExec}, []string{"space1"})
+	require.NoError(t, err)
+	got, err := store.Authenticate(secret)
+	require.NoError(t, err)
+	require.Equal(t, key.ID, got.ID)
+	_, err = store.Authenticate("wrong")
+	require.ErrorIs(t, err, ErrInvalidKey)
+
+	// Created keys survive a restart, static keys are configured again.
+	reloaded, err := NewStore(path)
+	require.NoError(t, err)
+	got, err = reloaded.Authenticate(secret)
+	require.NoError(t, err)
+	require.Equal(t, []string{"space1"}, got.Spaces)
+	_, err = reloaded.Authenticate("bootstrap")
+	require.ErrorIs(t, err, ErrInvalidKey)
+
+	require.ErrorIs(t, store.Delete("admin"), ErrStaticKey)
+	require.NoError(t, store.Delete(key.ID))
+	require.ErrorIs(t, store.Delete(key.ID), ErrKeyNotFound)
+	_, err = store.Authenticate(secret)
+	require.ErrorIs(t, err, ErrInvalidKey)
+	require.Len(t, store.List(), 1)
+}
+
+func Test_Middleware(t *testing.T) {
+	store, err := NewStore("")
+	require.NoError(t, err)
+	_, secret, err := store.Create("reader", []Scope{ScopeSpaceRead}, []string{"space1"})
+	require.NoError(t, err)
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router := mux.NewRouter()
+	router.Use(Middleware(store, slog.New(slog.NewTextHandler(io.Discard, nil)), "/v1/health"))
+	router.HandleFunc("/v1/health", ok)
+	router.Handle("/v1/spaces/{space_id}", Require(ScopeSpaceRead, ok)).Methods("GET")
+	router.Handle("/v1/spaces/{space_id}", Require(ScopeSpaceWrite, ok)).Methods("DELETE")
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		exp    int
+	}{
+		{name: "public", method: "GET", path: "/v1/health", exp: http.StatusOK},
+		{name: "missing key", method: "GET", path: "/v1/spaces/space1", exp: http.StatusUnauthorized},
+		{name: "invalid key", method: "GET", path: "/v1/spaces/space1", header: "Bearer nope", exp: http.StatusUnauthorized},
+		{name: "allowed", method: "GET", path: "/v1/spaces/space1", header: "Bearer " + secret, exp: http.StatusOK},
+		{name: "other space", method: "GET", path: "/v1/spaces/space2", header: "Bearer " + secret, exp: http.StatusForbidden},
+		{name: "missing scope", method: "DELETE", path: "/v1/spaces/space1", header: "Bearer " + secret, exp: http.StatusForbidden},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(c.method, c.path, nil)
+			if c.header != "" {
+				req.Header.Set("Authorization", c.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			require.Equal(t, c.exp, rec.Code)
+		})
+	}
+}
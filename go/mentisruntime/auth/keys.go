@@ -0,0 +1,281 @@
+// Package auth implements API key authentication and per-space authorization
+// for the runtime's HTTP API.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidKey   = errors.New("invalid API key")
+	ErrKeyNotFound  = errors.New("API key not found")
+	ErrInvalidScope = errors.New("invalid scope")
+	ErrStaticKey    = errors.New("API key is configured at startup and cannot be deleted")
+)
+
+// Scope grants access to a group of endpoints.
+type Scope string
+
+const (
+	// ScopeAdmin grants every permission, including key management.
+	ScopeAdmin Scope = "admin"
+	// ScopeSpaceRead allows reading spaces and their sandboxes.
+	ScopeSpaceRead Scope = "space:read"
+	// ScopeSpaceWrite allows creating, updating and deleting spaces and
+	// sandboxes. It implies ScopeSpaceRead.
+	ScopeSpaceWrite Scope = "space:write"
+	// ScopeExec allows running code in sandboxes and reaching their service ports.
+	ScopeExec Scope = "exec"
+)
+
+// ParseScope validates a scope name.
+func ParseScope(s string) (Scope, error) {
+	switch scope := Scope(s); scope {
+	case ScopeAdmin, ScopeSpaceRead, ScopeSpaceWrite, ScopeExec:
+		return scope, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidScope, s)
+}
+
+// APIKey describes a key. The secret itself is never stored, only its hash.
+type APIKey struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name,omitempty"`
+	Scopes    []Scope   `json:"scopes"`
+	Spaces    []string  `json:"spaces,omitempty"` // Space IDs the key is limited to, all spaces when empty
+	CreatedAt time.Time `json:"created_at"`
+
+	hash   string // Hex SHA-256 of the secret
+	static bool   // Configured at startup rather than stored
+}
+
+// Allows reports whether the key grants scope on a space. An empty spaceID
+// checks the scope alone; handlers of endpoints that are not bound to a space
+// must use CanAccessSpace to limit what they return.
+func (k *APIKey) Allows(scope Scope, spaceID string) bool {
+	if slices.Contains(k.Scopes, ScopeAdmin) {
+		return true
+	}
+	granted := slices.Contains(k.Scopes, scope) ||
+		(scope == ScopeSpaceRead && slices.Contains(k.Scopes, ScopeSpaceWrite))
+	if !granted {
+		return false
+	}
+	return spaceID == "" || k.CanAccessSpace(spaceID)
+}
+
+// CanAccessSpace reports whether the key is not restricted away from a space.
+func (k *APIKey) CanAccessSpace(spaceID string) bool {
+	return len(k.Spaces) == 0 || slices.Contains(k.Scopes, ScopeAdmin) || slices.Contains(k.Spaces, spaceID)
+}
+
+// Restricted reports whether the key is limited to some spaces.
+func (k *APIKey) Restricted() bool {
+	return len(k.Spaces) > 0 && !slices.Contains(k.Scopes, ScopeAdmin)
+}
+
+// storedKey is the on-disk form of a key.
+type storedKey struct {
+	APIKey
+	Hash string `json:"hash"`
+}
+
+// Store holds API keys. Keys created through the store are persisted to a JSON
+// file when the store has a path; keys added with AddStatic live in memory.
+type Store struct {
+	mu     sync.RWMutex
+	path   string
+	keys   map[string]*APIKey // Map key ID to key
+	byHash map[string]*APIKey // Map secret hash to key
+}
+
+// NewStore creates a store, loading keys from path if it exists. An empty path
+// keeps created keys in memory only.
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path:   path,
+		keys:   make(map[string]*APIKey),
+		byHash: make(map[string]*APIKey),
+	}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys: %w", err)
+	}
+	var stored []storedKey
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse API keys %s: %w", path, err)
+	}
+	for i := range stored {
+		key := stored[i].APIKey
+		key.hash = stored[i].Hash
+		s.add(&key)
+	}
+	return s, nil
+}
+
+// AddStatic adds a key with a fixed secret, e.g. a bootstrap admin key from the
+// environment. Static keys are not persisted and cannot be deleted.
+func (s *Store) AddStatic(id, secret string, scopes ...Scope) error {
+	if secret == "" {
+		return fmt.Errorf("%w: empty secret", ErrInvalidKey)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(&APIKey{
+		ID:        id,
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+		hash:      hashSecret(secret),
+		static:    true,
+	})
+	return nil
+}
+
+func (s *Store) add(key *APIKey) {
+	s.keys[key.ID] = key
+	s.byHash[key.hash] = key
+}
+
+// Create generates a key and returns it with its secret, which cannot be
+// retrieved again.
+func (s *Store) Create(name string, scopes []Scope, spaces []string) (*APIKey, string, error) {
+	if len(scopes) == 0 {
+		return nil, "", fmt.Errorf("%w: a key needs at least one scope", ErrInvalidScope)
+	}
+	for _, scope := range scopes {
+		if _, err := ParseScope(string(scope)); err != nil {
+			return nil, "", err
+		}
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	secret := "sbx_" + base64.RawURLEncoding.EncodeToString(buf)
+	key := &APIKey{
+		ID:        uuid.NewString(),
+		Name:      name,
+		Scopes:    scopes,
+		Spaces:    spaces,
+		CreatedAt: time.Now().UTC(),
+		hash:      hashSecret(secret),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(key)
+	if err := s.saveLocked(); err != nil {
+		delete(s.keys, key.ID)
+		delete(s.byHash, key.hash)
+		return nil, "", err
+	}
+	keyCopy := *key
+	return &keyCopy, secret, nil
+}
+
+// List returns all keys, oldest first.
+func (s *Store) List() []APIKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, *key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys
+}
+
+// Delete revokes a key.
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, exists := s.keys[id]
+	if !exists {
+		return ErrKeyNotFound
+	}
+	if key.static {
+		return ErrStaticKey
+	}
+	delete(s.keys, id)
+	delete(s.byHash, key.hash)
+	if err := s.saveLocked(); err != nil {
+		s.add(key)
+		return err
+	}
+	return nil
+}
+
+// Authenticate returns the key with the given secret.
+func (s *Store) Authenticate(secret string) (*APIKey, error) {
+	if secret == "" {
+		return nil, ErrInvalidKey
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, exists := s.byHash[hashSecret(secret)]
+	if !exists {
+		return nil, ErrInvalidKey
+	}
+	keyCopy := *key
+	return &keyCopy, nil
+}
+
+// saveLocked writes the persisted keys. The file is replaced atomically and is
+// only readable by the owner.
+func (s *Store) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	stored := make([]storedKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		if !key.static {
+			stored = append(stored, storedKey{APIKey: *key, Hash: key.hash})
+		}
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].CreatedAt.Before(stored[j].CreatedAt) })
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode API keys: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".apikeys-*")
+	if err != nil {
+		return fmt.Errorf("failed to save API keys: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save API keys: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save API keys: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save API keys: %w", err)
+	}
+	return nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	strictnethttp "github.com/oapi-codegen/runtime/strictmiddleware/nethttp"
+)
+
+type contextKey struct{}
+
+// KeyFromContext returns the key that authenticated the request, or nil when
+// authentication is disabled.
+func KeyFromContext(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(contextKey{}).(*APIKey)
+	return key
+}
+
+// Middleware authenticates requests with an "Authorization: Bearer <key>"
+// header. Requests for paths starting with one of the public prefixes pass
+// through unauthenticated.
+func Middleware(store *Store, logger *slog.Logger, public ...string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, prefix := range public {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			secret, ok := bearerToken(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="sandboxai"`)
+				writeError(w, "unauthorized", "Missing API key", http.StatusUnauthorized)
+				return
+			}
+			key, err := store.Authenticate(secret)
+			if err != nil {
+				logger.Warn("Rejected API key", "path", r.URL.Path, "remoteAddr", r.RemoteAddr)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="sandboxai", error="invalid_token"`)
+				writeError(w, "unauthorized", "Invalid API key", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, key)))
+		})
+	}
+}
+
+// Require allows a request only if its key grants scope on the space in the
+// route's space_id variable. Requests without a key only reach it when
+// authentication is disabled and are allowed.
+func Require(scope Scope, h http.HandlerFunc) http.Handler {
+	return RequireSpace(scope, func(r *http.Request) string { return mux.Vars(r)["space_id"] }, h)
+}
+
+// RequireSpace is Require for routes that identify the space some other way,
+// e.g. through a sandbox ID.
+func RequireSpace(scope Scope, spaceOf func(*http.Request) string, h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := KeyFromContext(r.Context())
+		if key != nil && !key.Allows(scope, spaceOf(r)) {
+			writeError(w, "forbidden", "API key does not grant "+string(scope)+" on this resource", http.StatusForbidden)
+			return
+		}
+		h(w, r)
+	})
+}
+
+// RequireOperation is Require for the operations of the generated strict
+// server. scopes maps operation IDs to the scope they require, the empty scope
+// for none; operations missing from it are refused.
+func RequireOperation(scopes map[string]Scope) strictnethttp.StrictHTTPMiddlewareFunc {
+	return func(f strictnethttp.StrictHTTPHandlerFunc, operationID string) strictnethttp.StrictHTTPHandlerFunc {
+		scope, known := scopes[operationID]
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+			key := KeyFromContext(ctx)
+			if key == nil || (known && scope == "") {
+				return f(ctx, w, r, request)
+			}
+			if !known {
+				writeError(w, "forbidden", "Operation "+operationID+" is not available to API keys", http.StatusForbidden)
+				return nil, nil
+			}
+			if !key.Allows(scope, mux.Vars(r)["space_id"]) {
+				writeError(w, "forbidden", "API key does not grant "+string(scope)+" on this resource", http.StatusForbidden)
+				return nil, nil
+			}
+			return f(ctx, w, r, request)
+		}
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// writeError writes the API's error body; code is one of manager's error
+// codes, see handler.WriteError.
+func writeError(w http.ResponseWriter, code, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{"message": message, "code": code})
+}
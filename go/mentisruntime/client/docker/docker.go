@@ -17,8 +17,8 @@ import (
 	"github.com/docker/docker/api/types/network"
 	dclient "github.com/docker/docker/client"
 	"github.com/docker/go-connections/nat"
-	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	v1 "github.com/foreveryh/sandboxai/go/api/v1"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 var _ sclient.Client = &DockerClient{}
@@ -62,14 +62,22 @@ const labelKeyScope = "sandboxai.scope"
 const labelKeySpace = "sandboxai.space"
 const labelKeyName = "sandboxai.name"
 
-func (c *DockerClient) CreateSandbox(ctx context.Context, space string, req *v1.CreateSandboxRequest) (*sclient.Sandbox, error) {
+// labelKeySecretEnv lists the comma-separated environment variables the
+// runtime injected secrets into. They are never reported back.
+const labelKeySecretEnv = "sandboxai.secret-env"
+
+// runtimeEnv lists the variables the runtime sets for the agent. They hold the
+// agent's credentials for the runtime and are never reported back either.
+var runtimeEnv = []string{"SANDBOX_ID", "RUNTIME_OBSERVATION_URL", "RUNTIME_OBSERVATION_TOKEN"}
+
+func (c *DockerClient) CreateSandbox(ctx context.Context, space, name string, req *v1.CreateSandboxRequest) (*sclient.Sandbox, error) {
 	if space == "" {
 		return nil, fmt.Errorf("space cannot be empty")
 	}
-	if req.Name == "" {
-		req.Name = generateRandomName()
+	if name == "" {
+		name = generateRandomName()
 	}
-	cname := containerName(space, req.Name)
+	cname := containerName(space, name)
 
 	const boxPortNumber = "8000"
 	boxPort, err := nat.NewPort("tcp", boxPortNumber)
@@ -77,20 +85,34 @@ func (c *DockerClient) CreateSandbox(ctx context.Context, space string, req *v1.
 		return nil, fmt.Errorf("create port: %w", err)
 	}
 
+	var spec v1.SandboxSpec
+	if req.Spec != nil {
+		spec = *req.Spec
+	}
+	image := req.Image
+	if spec.Image != nil && *spec.Image != "" {
+		image = spec.Image
+	}
+	if image == nil || *image == "" {
+		return nil, fmt.Errorf("image cannot be empty")
+	}
+
 	var env []string
-	for k, v := range req.Spec.Env {
-		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	if spec.Env != nil {
+		for k, v := range *spec.Env {
+			env = append(env, fmt.Sprintf("%s=%s", k, v))
+		}
 	}
 
 	config := &container.Config{
-		Image: req.Spec.Image,
+		Image: *image,
 		ExposedPorts: nat.PortSet{
 			boxPort: struct{}{},
 		},
 		Labels: map[string]string{
 			labelKeyScope: c.scope,
 			labelKeySpace: space,
-			labelKeyName:  req.Name,
+			labelKeyName:  name,
 		},
 		Env: env,
 	}
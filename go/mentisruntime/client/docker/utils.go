@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -159,12 +160,13 @@ func containerName(space, name string) string {
 func containerJSONToSandbox(c types.ContainerJSON) (*sclient.Sandbox, error) {
 	var env map[string]string
 	if len(c.Config.Env) > 0 {
+		secretEnv := strings.Split(c.Config.Labels[labelKeySecretEnv], ",")
 		for _, kv := range c.Config.Env {
 			if env == nil {
 				env = make(map[string]string)
 			}
 			key, val := parseEnvKeyVal(kv)
-			if key != "" {
+			if key != "" && !slices.Contains(secretEnv, key) && !slices.Contains(runtimeEnv, key) {
 				env[key] = val
 			}
 		}
@@ -179,17 +181,58 @@ func containerJSONToSandbox(c types.ContainerJSON) (*sclient.Sandbox, error) {
 
 	return &sclient.Sandbox{
 		Sandbox: &v1.Sandbox{
-			Name: name,
-			UID:  c.ID,
-			Spec: v1.SandboxSpec{
-				Image: c.Config.Image,
-				Env:   env,
+			SandboxID:   name,
+			Name:        &name,
+			SpaceID:     c.Config.Labels[labelKeySpace],
+			ContainerID: &c.ID,
+			IsRunning:   c.State != nil && c.State.Running,
+			Spec: &v1.SandboxSpec{
+				Image: &c.Config.Image,
+				Env:   &env,
 			},
+			Status: containerStatus(c),
 		},
 		BoxHostPort: boxHostPort,
 	}, nil
 }
 
+// containerStatus maps the container's Docker state to a sandbox status.
+func containerStatus(c types.ContainerJSON) *v1.SandboxStatus {
+	if c.ContainerJSONBase == nil || c.State == nil {
+		return nil
+	}
+	state := v1.SandboxStatusStateExited
+	if c.State.Running {
+		state = v1.SandboxStatusStateRunning
+	}
+	status := &v1.SandboxStatus{
+		State:          &state,
+		ContainerState: &c.State.Status,
+		OomKilled:      &c.State.OOMKilled,
+		RestartCount:   &c.RestartCount,
+		StartedAt:      parseDockerTime(c.State.StartedAt),
+		FinishedAt:     parseDockerTime(c.State.FinishedAt),
+	}
+	if !c.State.Running {
+		exitCode := c.State.ExitCode
+		status.ExitCode = &exitCode
+	}
+	if c.State.Health != nil {
+		status.Health = &c.State.Health.Status
+	}
+	return status
+}
+
+// parseDockerTime parses a timestamp from container inspect output, returning
+// nil for Docker's zero time.
+func parseDockerTime(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil || t.Year() <= 1 {
+		return nil
+	}
+	return &t
+}
+
 func getBoxHostPort(dockerContainer types.ContainerJSON) (int, error) {
 	boxHostPortStr := dockerContainer.NetworkSettings.Ports["8000/tcp"][0].HostPort
 	boxHostPort, err := strconv.Atoi(boxHostPortStr)
@@ -3,6 +3,9 @@ package docker
 import (
 	"testing"
 
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
 	"github.com/stretchr/testify/require"
 )
 
@@ -37,3 +40,21 @@ func Test_parseEnvKeyVal(t *testing.T) {
 		})
 	}
 }
+
+func Test_containerJSONToSandboxOmitsSecrets(t *testing.T) {
+	c := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: "c1"},
+		Config: &container.Config{
+			Image: "box",
+			Env: []string{"HOME=/root", "OPENAI_API_KEY=sk-123", "DB_PASSWORD=hunter2",
+				"SANDBOX_ID=sb1", "RUNTIME_OBSERVATION_URL=http://runtime/v1/internal/observations/sb1", "RUNTIME_OBSERVATION_TOKEN=tok-123"},
+			Labels: map[string]string{labelKeyName: "sb1", labelKeySecretEnv: "OPENAI_API_KEY,DB_PASSWORD"},
+		},
+		NetworkSettings: &types.NetworkSettings{NetworkSettingsBase: types.NetworkSettingsBase{
+			Ports: nat.PortMap{"8000/tcp": {{HostPort: "32768"}}},
+		}},
+	}
+	sb, err := containerJSONToSandbox(c)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"HOME": "/root"}, *sb.Spec.Env)
+}
@@ -15,7 +15,7 @@ type Sandbox struct {
 }
 
 type Client interface {
-	CreateSandbox(ctx context.Context, space string, req *v1.CreateSandboxRequest) (*Sandbox, error)
+	CreateSandbox(ctx context.Context, space, name string, req *v1.CreateSandboxRequest) (*Sandbox, error)
 	GetSandbox(ctx context.Context, space, name string) (*Sandbox, error)
 	DeleteSandbox(ctx context.Context, space, name string) error
 }
@@ -0,0 +1,395 @@
+// Package config defines the configuration of sandboxaid. Settings come from
+// defaults, then an optional YAML file, then environment variables, which take
+// precedence.
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/sethvargo/go-envconfig"
+	"gopkg.in/yaml.v3"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/audit"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/manager"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/secrets"
+)
+
+// Config is the complete runtime configuration.
+type Config struct {
+	Listen           ListenConfig    `yaml:"listen"`
+	Scope            string          `yaml:"scope" env:"SANDBOXAID_SCOPE"` // Separates the containers of runtimes sharing a Docker host
+	DefaultImage     string          `yaml:"default_image" env:"BOX_IMAGE"`
+	DeleteOnShutdown bool            `yaml:"delete_on_shutdown" env:"SANDBOXAID_DELETE_ON_SHUTDOWN"`
+	Timeouts         TimeoutsConfig  `yaml:"timeouts"`
+	Limits           LimitsConfig    `yaml:"limits"`
+	Sandbox          SandboxConfig   `yaml:"sandbox"`
+	Callback         CallbackConfig  `yaml:"callback"`
+	Pools            PoolList        `yaml:"pools" env:"SANDBOXAID_WARM_POOLS"`
+	Auth             AuthConfig      `yaml:"auth"`
+	Usage            UsageConfig     `yaml:"usage"`
+	Tracing          TracingConfig   `yaml:"tracing"`
+	Audit            AuditConfig     `yaml:"audit"`
+	Secrets          SecretsConfig   `yaml:"secrets"`
+	Recording        RecordingConfig `yaml:"recording"`
+	Logging          LoggingConfig   `yaml:"logging"`
+
+	warnings []string // Deprecated settings in use, see Warnings
+}
+
+// Warnings describes deprecated settings the configuration was loaded with.
+func (c *Config) Warnings() []string {
+	return c.warnings
+}
+
+// ListenConfig configures the HTTP listeners.
+type ListenConfig struct {
+	Host string `yaml:"host" env:"SANDBOXAID_HOST"`
+	Port int    `yaml:"port" env:"SANDBOXAID_PORT"` // 0 picks a free port and prints it to stdout
+	// InternalAddr serves agent callbacks on a separate listener, e.g.
+	// "172.17.0.1:5267". It must be reachable from containers.
+	InternalAddr string `yaml:"internal_addr,omitempty" env:"SANDBOXAID_INTERNAL_ADDR"`
+}
+
+// TimeoutsConfig holds durations such as "30s" or "2m".
+type TimeoutsConfig struct {
+	ShutdownGrace time.Duration `yaml:"shutdown_grace" env:"SANDBOXAID_SHUTDOWN_GRACE"`
+	AgentReady    time.Duration `yaml:"agent_ready" env:"SANDBOXAID_AGENT_READY_TIMEOUT"`
+	// StatusRefreshInterval refreshes sandbox status from Docker in the
+	// background; 0 disables it.
+	StatusRefreshInterval time.Duration `yaml:"status_refresh_interval" env:"SANDBOXAID_STATUS_REFRESH_INTERVAL"`
+	ReadHeader            time.Duration `yaml:"read_header" env:"SANDBOXAID_READ_HEADER_TIMEOUT"`
+	Idle                  time.Duration `yaml:"idle" env:"SANDBOXAID_IDLE_TIMEOUT"`
+}
+
+// LimitsConfig caps the number of sandboxes; 0 means unlimited.
+type LimitsConfig struct {
+	MaxSandboxes         int `yaml:"max_sandboxes" env:"SANDBOXAID_MAX_SANDBOXES"`
+	MaxSandboxesPerSpace int `yaml:"max_sandboxes_per_space" env:"SANDBOXAID_MAX_SANDBOXES_PER_SPACE"`
+}
+
+// SandboxConfig configures sandbox containers.
+type SandboxConfig struct {
+	// SecurityProfile is "hardened" or the path of a JSON profile; empty uses
+	// Docker's defaults.
+	SecurityProfile    string   `yaml:"security_profile,omitempty" env:"SANDBOXAID_SECURITY_PROFILE"`
+	BindMountAllowlist []string `yaml:"bind_mount_allowlist,omitempty" env:"SANDBOXAID_BIND_MOUNT_ALLOWLIST"`
+	RestartPolicy      string   `yaml:"restart_policy,omitempty" env:"SANDBOXAID_RESTART_POLICY"` // e.g. "on-failure:3"
+	AgentBindAddress   string   `yaml:"agent_bind_address" env:"SANDBOXAID_AGENT_BIND_ADDRESS"`
+}
+
+// CallbackConfig configures how agents reach the runtime.
+type CallbackConfig struct {
+	// Host is "host-gateway", "gateway" or a host name or IP.
+	Host string `yaml:"host" env:"SANDBOXAID_CALLBACK_HOST"`
+	// URL overrides the host and port, e.g. "http://10.0.0.5:5266".
+	URL string `yaml:"url,omitempty" env:"SANDBOXAID_CALLBACK_URL"`
+	// Check runs a startup self-test: "warn", "require" or "off".
+	Check string `yaml:"check" env:"SANDBOXAID_CALLBACK_CHECK"`
+}
+
+// PoolConfig configures the warm pool of one image.
+type PoolConfig struct {
+	Image   string `yaml:"image"`
+	MinIdle int    `yaml:"min_idle"`
+	MaxSize int    `yaml:"max_size,omitempty"` // Defaults to MinIdle
+}
+
+// PoolList is the warm pool configuration. In the environment it uses the
+// "<image>=<minIdle>[:<maxSize>],..." format of manager.ParsePoolConfigs.
+type PoolList []PoolConfig
+
+// EnvDecode implements envconfig.Decoder.
+func (p *PoolList) EnvDecode(val string) error {
+	configs, err := manager.ParsePoolConfigs(val)
+	if err != nil {
+		return err
+	}
+	*p = nil
+	for _, c := range configs {
+		*p = append(*p, PoolConfig{Image: c.Image, MinIdle: c.MinIdle, MaxSize: c.MaxSize})
+	}
+	return nil
+}
+
+// Manager returns the pools as manager.PoolConfig.
+func (p PoolList) Manager() []manager.PoolConfig {
+	var configs []manager.PoolConfig
+	for _, c := range p {
+		maxSize := c.MaxSize
+		if maxSize == 0 {
+			maxSize = c.MinIdle
+		}
+		configs = append(configs, manager.PoolConfig{Image: c.Image, MinIdle: c.MinIdle, MaxSize: maxSize})
+	}
+	return configs
+}
+
+// AuthConfig configures API key authentication, which is enabled when either
+// field is set.
+type AuthConfig struct {
+	APIKeysFile string `yaml:"api_keys_file,omitempty" env:"SANDBOXAID_API_KEYS_FILE"`
+	AdminAPIKey string `yaml:"admin_api_key,omitempty" env:"SANDBOXAID_ADMIN_API_KEY"`
+}
+
+// Enabled reports whether API keys are required.
+func (a AuthConfig) Enabled() bool {
+	return a.APIKeysFile != "" || a.AdminAPIKey != ""
+}
+
+// UsageConfig configures usage metering.
+type UsageConfig struct {
+	// File persists the hourly usage of sandboxes; empty keeps it in memory.
+	File string `yaml:"file,omitempty" env:"SANDBOXAID_USAGE_FILE"`
+	// SampleInterval samples the CPU and memory of sandbox containers; 0
+	// meters runtime and actions only.
+	SampleInterval time.Duration `yaml:"sample_interval" env:"SANDBOXAID_USAGE_SAMPLE_INTERVAL"`
+	// FlushInterval is how often usage is saved to File, independent of
+	// sampling.
+	FlushInterval time.Duration `yaml:"flush_interval" env:"SANDBOXAID_USAGE_FLUSH_INTERVAL"`
+	// Retention is how long hourly usage is kept; 0 keeps it forever.
+	Retention time.Duration `yaml:"retention" env:"SANDBOXAID_USAGE_RETENTION"`
+}
+
+// TracingConfig configures OpenTelemetry tracing.
+type TracingConfig struct {
+	Exporter string `yaml:"exporter" env:"SANDBOXAID_TRACING_EXPORTER"` // none, otlp or stdout
+	// Endpoint is the OTLP/HTTP collector URL, e.g. "http://localhost:4318";
+	// empty defers to the OTEL_EXPORTER_OTLP_* environment variables.
+	Endpoint string `yaml:"endpoint,omitempty" env:"SANDBOXAID_TRACING_ENDPOINT"`
+}
+
+// AuditConfig configures the audit log of mutating operations and actions.
+type AuditConfig struct {
+	// File appends the audit log as JSON lines; empty keeps the latest
+	// entries in memory.
+	File string `yaml:"file,omitempty" env:"SANDBOXAID_AUDIT_FILE"`
+	// MemoryEntries is how many entries are kept in memory without File; 0
+	// keeps all of them.
+	MemoryEntries int `yaml:"memory_entries" env:"SANDBOXAID_AUDIT_MEMORY_ENTRIES"`
+	// RedactKeys and RedactPatterns extend the default redaction rules of
+	// recorded requests. Patterns are regular expressions, separated by ";"
+	// in the environment.
+	RedactKeys     []string `yaml:"redact_keys,omitempty" env:"SANDBOXAID_AUDIT_REDACT_KEYS"`
+	RedactPatterns []string `yaml:"redact_patterns,omitempty" env:"SANDBOXAID_AUDIT_REDACT_PATTERNS, delimiter=;"`
+}
+
+// SecretsConfig configures the per-space secret store.
+type SecretsConfig struct {
+	// File persists secrets encrypted with Key; empty keeps them in memory
+	// under a key generated at startup.
+	File string `yaml:"file,omitempty" env:"SANDBOXAID_SECRETS_FILE"`
+	// Key is the base64-encoded 32-byte server key, e.g. from
+	// "openssl rand -base64 32".
+	Key string `yaml:"key,omitempty" env:"SANDBOXAID_SECRETS_KEY"`
+}
+
+// RecordingConfig configures session recording.
+type RecordingConfig struct {
+	// Dir keeps a JSON lines file of the observations of each sandbox, under a
+	// directory per space; empty disables recording.
+	Dir string `yaml:"dir,omitempty" env:"SANDBOXAID_RECORDING_DIR"`
+}
+
+// LoggingConfig configures the runtime's log output.
+type LoggingConfig struct {
+	Level  string `yaml:"level" env:"SANDBOXAID_LOG_LEVEL"`   // debug, info, warn or error
+	Format string `yaml:"format" env:"SANDBOXAID_LOG_FORMAT"` // json or text
+}
+
+// Default returns the configuration used when nothing is set.
+func Default() *Config {
+	return &Config{
+		Listen:       ListenConfig{Host: "127.0.0.1", Port: 5266},
+		Scope:        "default",
+		DefaultImage: "mentisai/sandboxai-box:latest",
+		Timeouts: TimeoutsConfig{
+			ShutdownGrace: 30 * time.Second,
+			AgentReady:    30 * time.Second,
+			ReadHeader:    10 * time.Second,
+			Idle:          2 * time.Minute,
+		},
+		Sandbox:  SandboxConfig{AgentBindAddress: "127.0.0.1"},
+		Callback: CallbackConfig{Host: manager.CallbackHostGateway, Check: "warn"},
+		Usage:    UsageConfig{SampleInterval: 30 * time.Second, FlushInterval: time.Minute, Retention: 90 * 24 * time.Hour},
+		Tracing:  TracingConfig{Exporter: "none"},
+		Audit:    AuditConfig{MemoryEntries: 10000},
+		Logging:  LoggingConfig{Level: "debug", Format: "json"},
+	}
+}
+
+// Load reads the YAML file at path, if any, over the defaults, applies
+// environment overrides from lookuper and validates the result. Unknown keys in
+// the file are errors.
+func Load(ctx context.Context, path string, lookuper envconfig.Lookuper) (*Config, error) {
+	if lookuper == nil {
+		lookuper = envconfig.OsLookuper()
+	}
+	cfg := Default()
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open config file: %w", err)
+		}
+		defer f.Close()
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+	err := envconfig.ProcessWith(ctx, &envconfig.Config{
+		Target:           cfg,
+		Lookuper:         lookuper,
+		DefaultOverwrite: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid environment override: %w", err)
+	}
+	// SANDBOX_SCOPE predates SANDBOXAID_SCOPE. Dropping it would move an
+	// existing deployment to another scope, away from its containers.
+	if legacy, ok := lookuper.Lookup("SANDBOX_SCOPE"); ok && legacy != "" {
+		if scope, ok := lookuper.Lookup("SANDBOXAID_SCOPE"); ok && scope != legacy {
+			return nil, fmt.Errorf("SANDBOX_SCOPE %q conflicts with SANDBOXAID_SCOPE %q; remove SANDBOX_SCOPE", legacy, scope)
+		}
+		cfg.Scope = legacy
+		cfg.warnings = append(cfg.warnings, "SANDBOX_SCOPE is deprecated, set SANDBOXAID_SCOPE or scope in the config file instead")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+var scopePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(field, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	if c.Listen.Port < 0 || c.Listen.Port > 65535 {
+		fail("listen.port", "%d is not a valid port", c.Listen.Port)
+	}
+	if !scopePattern.MatchString(c.Scope) {
+		fail("scope", "%q must be letters, digits, '_', '.' or '-'", c.Scope)
+	}
+	if c.DefaultImage == "" {
+		fail("default_image", "must not be empty")
+	}
+	for name, d := range map[string]time.Duration{
+		"timeouts.shutdown_grace":          c.Timeouts.ShutdownGrace,
+		"timeouts.agent_ready":             c.Timeouts.AgentReady,
+		"timeouts.status_refresh_interval": c.Timeouts.StatusRefreshInterval,
+		"timeouts.read_header":             c.Timeouts.ReadHeader,
+		"timeouts.idle":                    c.Timeouts.Idle,
+		"usage.sample_interval":            c.Usage.SampleInterval,
+		"usage.retention":                  c.Usage.Retention,
+	} {
+		if d < 0 {
+			fail(name, "must not be negative")
+		}
+	}
+	if c.Timeouts.AgentReady == 0 {
+		fail("timeouts.agent_ready", "must be positive")
+	}
+	if c.Usage.FlushInterval <= 0 {
+		fail("usage.flush_interval", "must be positive")
+	}
+	if c.Limits.MaxSandboxes < 0 {
+		fail("limits.max_sandboxes", "must not be negative")
+	}
+	if c.Limits.MaxSandboxesPerSpace < 0 {
+		fail("limits.max_sandboxes_per_space", "must not be negative")
+	}
+	if c.Sandbox.SecurityProfile != "" {
+		if _, err := manager.LoadSecurityProfile(c.Sandbox.SecurityProfile); err != nil {
+			fail("sandbox.security_profile", "%v", err)
+		}
+	}
+	if c.Sandbox.RestartPolicy != "" {
+		if _, err := manager.ParseRestartPolicy(c.Sandbox.RestartPolicy); err != nil {
+			fail("sandbox.restart_policy", "%v", err)
+		}
+	}
+	if c.Callback.URL != "" {
+		if u, err := url.Parse(c.Callback.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail("callback.url", "%q is not an http(s) URL", c.Callback.URL)
+		}
+	}
+	switch c.Callback.Check {
+	case "warn", "require", "off":
+	default:
+		fail("callback.check", "%q must be warn, require or off", c.Callback.Check)
+	}
+	for i, p := range c.Pools {
+		field := "pools[" + strconv.Itoa(i) + "]"
+		if p.Image == "" {
+			fail(field+".image", "must not be empty")
+		}
+		if p.MinIdle < 0 {
+			fail(field+".min_idle", "must not be negative")
+		}
+		if p.MaxSize != 0 && p.MaxSize < p.MinIdle {
+			fail(field+".max_size", "must be at least min_idle")
+		}
+	}
+	switch c.Tracing.Exporter {
+	case "none", "otlp", "stdout":
+	default:
+		fail("tracing.exporter", "%q must be none, otlp or stdout", c.Tracing.Exporter)
+	}
+	if c.Tracing.Endpoint != "" {
+		if u, err := url.Parse(c.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail("tracing.endpoint", "%q is not an http(s) URL", c.Tracing.Endpoint)
+		}
+	}
+	if c.Audit.MemoryEntries < 0 {
+		fail("audit.memory_entries", "must not be negative")
+	}
+	if _, err := audit.NewRedactor(c.Audit.RedactKeys, c.Audit.RedactPatterns); err != nil {
+		fail("audit.redact_patterns", "%v", err)
+	}
+	if c.Secrets.Key != "" {
+		if _, err := secrets.ParseKey(c.Secrets.Key); err != nil {
+			fail("secrets.key", "%v", err)
+		}
+	} else if c.Secrets.File != "" {
+		fail("secrets.key", "must be set with secrets.file")
+	}
+	switch c.Logging.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		fail("logging.level", "%q must be debug, info, warn or error", c.Logging.Level)
+	}
+	switch c.Logging.Format {
+	case "json", "text":
+	default:
+		fail("logging.format", "%q must be json or text", c.Logging.Format)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Redacted returns a copy safe to print, with secrets masked.
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	if redacted.Auth.AdminAPIKey != "" {
+		redacted.Auth.AdminAPIKey = "REDACTED"
+	}
+	if redacted.Secrets.Key != "" {
+		redacted.Secrets.Key = "REDACTED"
+	}
+	return &redacted
+}
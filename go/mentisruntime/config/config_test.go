@@ -0,0 +1,176 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sethvargo/go-envconfig"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sandboxaid.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func Test_Load(t *testing.T) {
+	path := writeConfig(t, `
+listen:
+  host: 0.0.0.0
+  port: 8080
+scope: staging
+default_image: example/box:1.2
+timeouts:
+  agent_ready: 1m
+limits:
+  max_sandboxes: 20
+pools:
+  - image: example/box:1.2
+    min_idle: 2
+    max_size: 4
+logging:
+  format: text
+`)
+	cfg, err := Load(context.Background(), path, envconfig.MapLookuper(map[string]string{
+		"SANDBOXAID_PORT":      "9090",
+		"SANDBOXAID_LOG_LEVEL": "info",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, "0.0.0.0", cfg.Listen.Host)
+	require.Equal(t, 9090, cfg.Listen.Port, "environment overrides the file")
+	require.Equal(t, "staging", cfg.Scope)
+	require.Equal(t, "example/box:1.2", cfg.DefaultImage)
+	require.Equal(t, time.Minute, cfg.Timeouts.AgentReady)
+	require.Equal(t, 30*time.Second, cfg.Timeouts.ShutdownGrace, "defaults are kept")
+	require.Equal(t, 20, cfg.Limits.MaxSandboxes)
+	require.Equal(t, PoolList{{Image: "example/box:1.2", MinIdle: 2, MaxSize: 4}}, cfg.Pools)
+	require.Equal(t, LoggingConfig{Level: "info", Format: "text"}, cfg.Logging)
+}
+
+func Test_LoadEnvironment(t *testing.T) {
+	cfg, err := Load(context.Background(), "", envconfig.MapLookuper(map[string]string{
+		"SANDBOXAID_SCOPE":                 "ci",
+		"BOX_IMAGE":                        "example/box:dev",
+		"SANDBOXAID_WARM_POOLS":            "example/box:dev=1",
+		"SANDBOXAID_BIND_MOUNT_ALLOWLIST":  "/srv/a,/srv/b",
+		"SANDBOXAID_DELETE_ON_SHUTDOWN":    "true",
+		"SANDBOXAID_AUDIT_REDACT_PATTERNS": `key-[a-z0-9]{8,};(?i)pin=(\d{4,6})`,
+		"SANDBOXAID_AUDIT_MEMORY_ENTRIES":  "500",
+		"SANDBOXAID_USAGE_RETENTION":       "720h",
+		"SANDBOXAID_USAGE_FLUSH_INTERVAL":  "10s",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, "ci", cfg.Scope)
+	require.Equal(t, "example/box:dev", cfg.DefaultImage)
+	require.Equal(t, PoolList{{Image: "example/box:dev", MinIdle: 1, MaxSize: 1}}, cfg.Pools)
+	require.Equal(t, []string{"/srv/a", "/srv/b"}, cfg.Sandbox.BindMountAllowlist)
+	require.True(t, cfg.DeleteOnShutdown)
+	require.Equal(t, []string{"key-[a-z0-9]{8,}", `(?i)pin=(\d{4,6})`}, cfg.Audit.RedactPatterns)
+	require.Equal(t, 500, cfg.Audit.MemoryEntries)
+	require.Equal(t, 30*24*time.Hour, cfg.Usage.Retention)
+	require.Equal(t, 10*time.Second, cfg.Usage.FlushInterval)
+	require.Equal(t, "127.0.0.1", cfg.Listen.Host)
+}
+
+func Test_LoadLegacyScope(t *testing.T) {
+	cfg, err := Load(context.Background(), "", envconfig.MapLookuper(map[string]string{"SANDBOX_SCOPE": "prod"}))
+	require.NoError(t, err)
+	require.Equal(t, "prod", cfg.Scope)
+	require.Len(t, cfg.Warnings(), 1)
+
+	cfg, err = Load(context.Background(), "", envconfig.MapLookuper(map[string]string{"SANDBOX_SCOPE": "prod", "SANDBOXAID_SCOPE": "prod"}))
+	require.NoError(t, err)
+	require.Equal(t, "prod", cfg.Scope)
+
+	_, err = Load(context.Background(), "", envconfig.MapLookuper(map[string]string{"SANDBOX_SCOPE": "prod", "SANDBOXAID_SCOPE": "staging"}))
+	require.ErrorContains(t, err, "SANDBOX_SCOPE")
+
+	cfg, err = Load(context.Background(), "", envconfig.MapLookuper(nil))
+	require.NoError(t, err)
+	require.Equal(t, "default", cfg.Scope)
+	require.Empty(t, cfg.Warnings())
+}
+
+func Test_LoadErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		config string
+		env    map[string]string
+		exp    []string
+	}{
+		{
+			name:   "unknown key",
+			config: "listen:\n  hots: 0.0.0.0\n",
+			exp:    []string{"field hots not found"},
+		},
+		{
+			name:   "bad duration",
+			config: "timeouts:\n  idle: soon\n",
+			exp:    []string{"sandboxaid.yaml"},
+		},
+		{
+			name: "invalid values",
+			config: `
+listen:
+  port: 70000
+scope: "no spaces"
+callback:
+  check: maybe
+pools:
+  - image: ""
+    min_idle: 3
+    max_size: 1
+audit:
+  memory_entries: -1
+`,
+			exp: []string{"listen.port", "scope", "callback.check", "pools[0].image", "pools[0].max_size", "audit.memory_entries"},
+		},
+		{
+			name: "invalid environment",
+			env:  map[string]string{"SANDBOXAID_MAX_SANDBOXES": "many"},
+			exp:  []string{"MaxSandboxes"},
+		},
+		{
+			name: "invalid environment value",
+			env:  map[string]string{"SANDBOXAID_RESTART_POLICY": "sometimes", "SANDBOXAID_LOG_FORMAT": "xml", "SANDBOXAID_TRACING_EXPORTER": "jaeger", "SANDBOXAID_AUDIT_REDACT_PATTERNS": "[unclosed"},
+			exp:  []string{"sandbox.restart_policy", "logging.format", "tracing.exporter", "audit.redact_patterns"},
+		},
+		{
+			name: "invalid secrets key",
+			env:  map[string]string{"SANDBOXAID_SECRETS_KEY": "c2hvcnQ="},
+			exp:  []string{"secrets.key", "must be 32 bytes"},
+		},
+		{
+			name:   "secrets file without key",
+			config: "secrets:\n  file: /var/lib/sandboxaid/secrets.json\n",
+			exp:    []string{"secrets.key", "must be set with secrets.file"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var path string
+			if c.config != "" {
+				path = writeConfig(t, c.config)
+			}
+			_, err := Load(context.Background(), path, envconfig.MapLookuper(c.env))
+			require.Error(t, err)
+			for _, exp := range c.exp {
+				require.ErrorContains(t, err, exp)
+			}
+		})
+	}
+}
+
+func Test_Redacted(t *testing.T) {
+	cfg := Default()
+	cfg.Auth.AdminAPIKey = "bootstrap"
+	cfg.Secrets.Key = "c2VjcmV0"
+	require.Equal(t, "REDACTED", cfg.Redacted().Auth.AdminAPIKey)
+	require.Equal(t, "REDACTED", cfg.Redacted().Secrets.Key)
+	require.Equal(t, "bootstrap", cfg.Auth.AdminAPIKey)
+}
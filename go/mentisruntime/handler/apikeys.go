@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	v1 "github.com/foreveryh/sandboxai/go/api/v1"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/auth"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/manager"
+)
+
+// errKeysDisabled is returned by the API key endpoints when authentication is
+// disabled.
+const errKeysDisabled = "API keys are disabled because authentication is off"
+
+// CreateAPIKey handles requests to create an API key. The secret is only
+// returned in this response.
+func (h *APIHandler) CreateAPIKey(ctx context.Context, request v1.CreateAPIKeyRequestObject) (v1.CreateAPIKeyResponseObject, error) {
+	if h.keys == nil {
+		return v1.CreateAPIKey404JSONResponse(apiError(manager.CodeNotFound, errKeysDisabled)), nil
+	}
+	scopes := make([]auth.Scope, len(request.Body.Scopes))
+	for i, scope := range request.Body.Scopes {
+		scopes[i] = auth.Scope(scope)
+	}
+
+	key, secret, err := h.keys.Create(deref(request.Body.Name), scopes, deref(request.Body.Spaces))
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidScope) {
+			return v1.CreateAPIKey400JSONResponse(apiError(manager.CodeInvalidRequest, err.Error())), nil
+		}
+		h.logger.Error("Failed to create API key", "error", err)
+		status, body := errorResponse("Failed to create API key", err)
+		return v1.CreateAPIKeydefaultJSONResponse{StatusCode: status, Body: body}, nil
+	}
+	h.logger.Info("API key created", "keyID", key.ID, "name", key.Name, "scopes", key.Scopes, "spaces", key.Spaces)
+
+	created := apiKeyToV1(*key)
+	out := v1.CreateAPIKey201JSONResponse{
+		ID:        created.ID,
+		Name:      created.Name,
+		Spaces:    created.Spaces,
+		CreatedAt: created.CreatedAt,
+		Secret:    secret,
+		Scopes:    make([]v1.CreateAPIKeyResponseScopes, len(created.Scopes)),
+	}
+	for i, scope := range created.Scopes {
+		out.Scopes[i] = v1.CreateAPIKeyResponseScopes(scope)
+	}
+	return out, nil
+}
+
+// ListAPIKeys handles requests to list API keys.
+func (h *APIHandler) ListAPIKeys(ctx context.Context, request v1.ListAPIKeysRequestObject) (v1.ListAPIKeysResponseObject, error) {
+	if h.keys == nil {
+		return v1.ListAPIKeys404JSONResponse(apiError(manager.CodeNotFound, errKeysDisabled)), nil
+	}
+	keys := h.keys.List()
+	out := make(v1.ListAPIKeys200JSONResponse, len(keys))
+	for i, key := range keys {
+		out[i] = apiKeyToV1(key)
+	}
+	return out, nil
+}
+
+// DeleteAPIKey handles requests to revoke an API key.
+func (h *APIHandler) DeleteAPIKey(ctx context.Context, request v1.DeleteAPIKeyRequestObject) (v1.DeleteAPIKeyResponseObject, error) {
+	if h.keys == nil {
+		return v1.DeleteAPIKey404JSONResponse(apiError(manager.CodeNotFound, errKeysDisabled)), nil
+	}
+	keyID := request.KeyID
+	if err := h.keys.Delete(keyID); err != nil {
+		switch {
+		case errors.Is(err, auth.ErrKeyNotFound):
+			return v1.DeleteAPIKey404JSONResponse(apiError(manager.CodeNotFound, "API key "+keyID+" not found")), nil
+		case errors.Is(err, auth.ErrStaticKey):
+			return v1.DeleteAPIKey409JSONResponse(apiError(manager.CodeConflict, err.Error())), nil
+		default:
+			h.logger.Error("Failed to delete API key", "keyID", keyID, "error", err)
+			status, body := errorResponse("Failed to delete API key", err)
+			return v1.DeleteAPIKeydefaultJSONResponse{StatusCode: status, Body: body}, nil
+		}
+	}
+	h.logger.Info("API key deleted", "keyID", keyID)
+	return v1.DeleteAPIKey204Response{}, nil
+}
@@ -0,0 +1,400 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/client"
+	v1 "github.com/foreveryh/sandboxai/go/api/v1"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/audit"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/auth"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/manager"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/recording"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/secrets"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/ws"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// specServer serves the API and checks every response against api/v1.yaml.
+type specServer struct {
+	t        *testing.T
+	handler  http.Handler
+	routes   routers.Router
+	recorder *recording.Recorder
+}
+
+const testBaseURL = "http://sandboxaid.test/v1"
+
+func newSpecServer(t *testing.T, keys *auth.Store) *specServer {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Docker is only reached when a space is deleted; it has no networks and
+	// no volumes.
+	docker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/volumes") {
+			w.Write([]byte(`{"Volumes":[],"Warnings":[]}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"not found"}`))
+	}))
+	t.Cleanup(docker.Close)
+	dockerClient, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(docker.URL, "http://")), client.WithVersion("1.47"))
+	require.NoError(t, err)
+
+	hub := ws.NewHub(logger)
+	spaceManager := manager.NewSpaceManager(logger)
+	secretStore, err := secrets.NewStore("", nil)
+	require.NoError(t, err)
+	recorder, err := recording.NewRecorder(t.TempDir())
+	require.NoError(t, err)
+	sandboxManager, err := manager.NewSandboxManager(context.Background(), dockerClient, hub, spaceManager, logger, "conformance",
+		manager.WithSecrets(secretStore), manager.WithRecorder(recorder))
+	require.NoError(t, err)
+	t.Cleanup(func() { sandboxManager.Shutdown(context.Background()) })
+
+	auditLog := audit.New(audit.NewMemorySink(100), nil, logger)
+	router := mux.NewRouter()
+	api := router.PathPrefix("/v1").Subrouter()
+	api.Use(auditLog.Middleware)
+	apiHandler := NewAPIHandler(logger, sandboxManager, spaceManager, hub, keys, auditLog)
+	apiHandler.RegisterRoutes(api)
+	api.HandleFunc("/spaces/{space_id}/sandboxes/{sandbox_id}/recording", apiHandler.GetRecordingHandler).Methods("GET")
+	api.HandleFunc("/spaces/{space_id}/sandboxes/{sandbox_id}/recording/replay", apiHandler.ReplayRecordingHandler).Methods("GET")
+
+	doc, err := v1.GetSwagger()
+	require.NoError(t, err)
+	doc.Servers = openapi3.Servers{{URL: testBaseURL}}
+	routes, err := gorillamux.NewRouter(doc)
+	require.NoError(t, err)
+	return &specServer{t: t, handler: router, routes: routes, recorder: recorder}
+}
+
+// do sends a request and validates the response against the operation's
+// declared responses, including its status code.
+func (s *specServer) do(method, path, body string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	req := httptest.NewRequest(method, testBaseURL+path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+
+	route, pathParams, err := s.routes.FindRoute(req)
+	require.NoError(s.t, err, "%s %s is not in the spec", method, path)
+	err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{Request: req, PathParams: pathParams, Route: route},
+		Status:                 rec.Code,
+		Header:                 rec.Header(),
+		Body:                   io.NopCloser(strings.NewReader(rec.Body.String())),
+		Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+	})
+	require.NoError(s.t, err, "%s %s: response %d %s does not match the spec", method, path, rec.Code, rec.Body.String())
+	return rec
+}
+
+func Test_Conformance(t *testing.T) {
+	s := newSpecServer(t, nil)
+
+	rec := s.do("POST", "/spaces", `{"name":"conformance","description":"Spec conformance","metadata":{"team":"qa"},"security_profile":{"no_new_privileges":true,"capabilities":["chown"]}}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var space v1.Space
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &space))
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   manager.ErrorCode
+	}{
+		{name: "health", method: "GET", path: "/health", status: http.StatusOK},
+		{name: "list spaces", method: "GET", path: "/spaces", status: http.StatusOK},
+		{name: "create space without name", method: "POST", path: "/spaces", body: `{"description":"x"}`, status: http.StatusBadRequest, code: manager.CodeInvalidRequest},
+		{name: "create space with invalid body", method: "POST", path: "/spaces", body: `{`, status: http.StatusBadRequest, code: manager.CodeInvalidRequest},
+		{name: "create space with taken name", method: "POST", path: "/spaces", body: `{"name":"conformance"}`, status: http.StatusConflict, code: manager.CodeNameConflict},
+		{name: "get space", method: "GET", path: "/spaces/{space}", status: http.StatusOK},
+		{name: "get default space", method: "GET", path: "/spaces/default", status: http.StatusOK},
+		{name: "get missing space", method: "GET", path: "/spaces/missing", status: http.StatusNotFound, code: manager.CodeSpaceNotFound},
+		{name: "update space", method: "PUT", path: "/spaces/{space}", body: `{"description":"Updated"}`, status: http.StatusOK},
+		{name: "clear security profile", method: "PUT", path: "/spaces/{space}", body: `{"security_profile":null}`, status: http.StatusOK},
+		{name: "update space with invalid profile", method: "PUT", path: "/spaces/{space}", body: `{"security_profile":{"capabilities":[""]}}`, status: http.StatusBadRequest, code: manager.CodeInvalidSecurityProfile},
+		{name: "update missing space", method: "PUT", path: "/spaces/missing", body: `{}`, status: http.StatusNotFound, code: manager.CodeSpaceNotFound},
+		{name: "list sandboxes", method: "GET", path: "/spaces/{space}/sandboxes", status: http.StatusOK},
+		{name: "list sandboxes of missing space", method: "GET", path: "/spaces/missing/sandboxes", status: http.StatusNotFound, code: manager.CodeSpaceNotFound},
+		{name: "create sandbox in missing space", method: "POST", path: "/spaces/missing/sandboxes", body: `{}`, status: http.StatusNotFound, code: manager.CodeSpaceNotFound},
+		{name: "create sandbox with invalid spec", method: "POST", path: "/spaces/{space}/sandboxes", body: `{"spec":{"env":{"SANDBOX_ID":"x"}}}`, status: http.StatusBadRequest, code: manager.CodeInvalidSpec},
+		{name: "create sandbox asynchronously with invalid spec", method: "POST", path: "/spaces/{space}/sandboxes?async=true", body: `{"image":"box:latest","spec":{"network":{"mode":"bogus"}}}`, status: http.StatusBadRequest, code: manager.CodeInvalidSpec},
+		{name: "get missing sandbox", method: "GET", path: "/spaces/{space}/sandboxes/missing", status: http.StatusNotFound, code: manager.CodeSandboxNotFound},
+		{name: "get sandbox of missing space", method: "GET", path: "/spaces/missing/sandboxes/missing", status: http.StatusNotFound, code: manager.CodeSpaceNotFound},
+		{name: "delete missing sandbox", method: "DELETE", path: "/spaces/{space}/sandboxes/missing", status: http.StatusNotFound, code: manager.CodeSandboxNotFound},
+		{name: "run shell command in missing sandbox", method: "POST", path: "/spaces/{space}/sandboxes/missing/tools:run_shell_command", body: `{"command":"ls"}`, status: http.StatusNotFound, code: manager.CodeSandboxNotFound},
+		{name: "run ipython cell in missing sandbox", method: "POST", path: "/spaces/{space}/sandboxes/missing/tools:run_ipython_cell", body: `{"code":"1"}`, status: http.StatusNotFound, code: manager.CodeSandboxNotFound},
+		{name: "list api keys without auth", method: "GET", path: "/api-keys", status: http.StatusNotFound, code: manager.CodeNotFound},
+		{name: "create api key without auth", method: "POST", path: "/api-keys", body: `{"scopes":["exec"]}`, status: http.StatusNotFound, code: manager.CodeNotFound},
+		{name: "delete api key without auth", method: "DELETE", path: "/api-keys/missing", status: http.StatusNotFound, code: manager.CodeNotFound},
+		{name: "get space usage", method: "GET", path: "/spaces/{space}/usage", status: http.StatusOK},
+		{name: "get usage of missing space", method: "GET", path: "/spaces/missing/usage", status: http.StatusNotFound, code: manager.CodeSpaceNotFound},
+		{name: "set quota", method: "PUT", path: "/spaces/{space}", body: `{"quota":{"max_sandboxes":2,"cpus":1,"max_concurrent_actions":4,"max_lifetime_seconds":3600}}`, status: http.StatusOK},
+		{name: "set invalid quota", method: "PUT", path: "/spaces/{space}", body: `{"quota":{"max_sandboxes":-1}}`, status: http.StatusBadRequest, code: manager.CodeInvalidRequest},
+		{name: "create sandbox without resources under a CPU quota", method: "POST", path: "/spaces/{space}/sandboxes", body: `{"image":"box:latest"}`, status: http.StatusBadRequest, code: manager.CodeInvalidSpec},
+		{name: "create sandbox over the CPU quota", method: "POST", path: "/spaces/{space}/sandboxes?async=true", body: `{"spec":{"image":"box:latest","resources":{"cpus":2}}}`, status: http.StatusTooManyRequests, code: manager.CodeQuotaExceeded},
+		{name: "get space usage with quota", method: "GET", path: "/spaces/{space}/usage", status: http.StatusOK},
+		{name: "clear quota", method: "PUT", path: "/spaces/{space}", body: `{"quota":null}`, status: http.StatusOK},
+		{name: "get daily usage by sandbox", method: "GET", path: "/spaces/{space}/usage?bucket=day&group_by=sandbox&from=2026-01-01T00:00:00Z", status: http.StatusOK},
+		{name: "export usage as CSV", method: "GET", path: "/spaces/{space}/usage?format=csv", status: http.StatusOK},
+		{name: "get usage of an empty range", method: "GET", path: "/spaces/{space}/usage?from=2026-01-02T00:00:00Z&to=2026-01-01T00:00:00Z", status: http.StatusBadRequest, code: manager.CodeInvalidRequest},
+		{name: "list audit entries", method: "GET", path: "/audit", status: http.StatusOK},
+		{name: "list audit entries of a space", method: "GET", path: "/audit?space_id={space}&from=2026-01-01T00:00:00Z&limit=10", status: http.StatusOK},
+		{name: "list audit entries of an empty range", method: "GET", path: "/audit?from=2026-01-02T00:00:00Z&to=2026-01-01T00:00:00Z", status: http.StatusBadRequest, code: manager.CodeInvalidRequest},
+		{name: "delete space", method: "DELETE", path: "/spaces/{space}?retain_volumes=false", status: http.StatusNoContent},
+		{name: "delete missing space", method: "DELETE", path: "/spaces/missing", status: http.StatusNotFound, code: manager.CodeSpaceNotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec := s.do(c.method, strings.ReplaceAll(c.path, "{space}", space.SpaceID), c.body)
+			require.Equal(t, c.status, rec.Code, rec.Body.String())
+			if c.code != "" {
+				var body v1.Error
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				require.NotNil(t, body.Code, rec.Body.String())
+				require.Equal(t, c.code, manager.ErrorCode(*body.Code))
+			}
+		})
+	}
+}
+
+func Test_ConformanceAPIKeys(t *testing.T) {
+	keys, err := auth.NewStore("")
+	require.NoError(t, err)
+	require.NoError(t, keys.AddStatic("admin", "static-secret", auth.ScopeAdmin))
+	s := newSpecServer(t, keys)
+
+	rec := s.do("POST", "/api-keys", `{"name":"ci","scopes":["space:read","exec"],"spaces":["default"]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created v1.CreateAPIKeyResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.NotEmpty(t, created.Secret)
+
+	require.Equal(t, http.StatusBadRequest, s.do("POST", "/api-keys", `{"scopes":["root"]}`).Code)
+	require.Equal(t, http.StatusOK, s.do("GET", "/api-keys", "").Code)
+	require.Equal(t, http.StatusConflict, s.do("DELETE", "/api-keys/admin", "").Code)
+	require.Equal(t, http.StatusNoContent, s.do("DELETE", "/api-keys/"+created.ID, "").Code)
+	require.Equal(t, http.StatusNotFound, s.do("DELETE", "/api-keys/"+created.ID, "").Code)
+}
+
+func Test_ConformanceAudit(t *testing.T) {
+	s := newSpecServer(t, nil)
+
+	rec := s.do("POST", "/spaces", `{"name":"audited"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var space v1.Space
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &space))
+	rec = s.do("POST", "/spaces/"+space.SpaceID+"/sandboxes/missing/tools:run_shell_command", `{"command":"mysql --password=hunter2"}`)
+	require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+	require.Equal(t, http.StatusOK, s.do("GET", "/spaces/"+space.SpaceID, "").Code)
+
+	rec = s.do("GET", "/audit?space_id="+space.SpaceID, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var entries []v1.AuditEntry
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+	require.Len(t, entries, 2, "reads are not audited")
+
+	created, ran := entries[0], entries[1]
+	require.Equal(t, "createSpace", created.Operation)
+	require.Equal(t, v1.Success, created.Outcome)
+	require.Equal(t, audit.AnonymousActor, created.Actor.ID)
+	require.Equal(t, "runShellCommand", ran.Operation)
+	require.Equal(t, v1.Failure, ran.Outcome)
+	require.Equal(t, "missing", *ran.SandboxID)
+	require.Equal(t, http.StatusNotFound, *ran.StatusCode)
+	require.NotNil(t, ran.Error)
+	request, err := json.Marshal(ran.Request)
+	require.NoError(t, err)
+	require.Contains(t, string(request), "--password=REDACTED")
+	require.NotContains(t, string(request), "hunter2")
+}
+
+func Test_ConformanceSecrets(t *testing.T) {
+	s := newSpecServer(t, nil)
+
+	rec := s.do("POST", "/spaces", `{"name":"secretive"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var space v1.Space
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &space))
+	path := "/spaces/" + space.SpaceID + "/secrets"
+
+	require.Equal(t, http.StatusCreated, s.do("PUT", path+"/OPENAI_API_KEY", `{"value":"sk-0123456789"}`).Code)
+	rec = s.do("PUT", path+"/OPENAI_API_KEY", `{"value":"sk-9876543210"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NotContains(t, rec.Body.String(), "sk-9876543210")
+	require.Equal(t, http.StatusBadRequest, s.do("PUT", path+"/.hidden", `{"value":"x"}`).Code)
+	require.Equal(t, http.StatusNotFound, s.do("PUT", "/spaces/missing/secrets/KEY", `{"value":"x"}`).Code)
+
+	rec = s.do("GET", path, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var list []v1.Secret
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+	require.Equal(t, "OPENAI_API_KEY", list[0].Name)
+	require.NotContains(t, rec.Body.String(), "sk-")
+
+	rec = s.do("POST", "/spaces/"+space.SpaceID+"/sandboxes", `{"spec":{"image":"box:latest","secrets":[{"name":"MISSING"}]}}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), string(manager.CodeInvalidSpec))
+
+	// Secret values never reach the audit log.
+	rec = s.do("GET", "/audit?space_id="+space.SpaceID, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), "setSecret")
+	require.NotContains(t, rec.Body.String(), "sk-")
+
+	require.Equal(t, http.StatusNoContent, s.do("DELETE", path+"/OPENAI_API_KEY", "").Code)
+	require.Equal(t, http.StatusNotFound, s.do("DELETE", path+"/OPENAI_API_KEY", "").Code)
+	require.Equal(t, http.StatusNotFound, s.do("GET", "/spaces/missing/secrets", "").Code)
+}
+
+// The recording endpoints are served by hand-written handlers, which the
+// embedded spec leaves out, so their responses are checked directly.
+func Test_RecordingHandlers(t *testing.T) {
+	s := newSpecServer(t, nil)
+
+	rec := s.do("POST", "/spaces", `{"name":"recorded"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var space v1.Space
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &space))
+	for _, obs := range []string{
+		`{"observation_type":"start","action_id":"a1","timestamp":"2026-01-01T00:00:00Z","data":{"action_type":"shell"}}`,
+		`{"observation_type":"stream","action_id":"a1","timestamp":"2026-01-01T00:00:00Z","stream":"stdout","line":"hello"}`,
+		`{"observation_type":"end","action_id":"a1","timestamp":"2026-01-01T00:00:00Z","data":{"exit_code":0}}`,
+	} {
+		require.NoError(t, s.recorder.Record(space.SpaceID, "sb1", []byte(obs)))
+	}
+	path := "/spaces/" + space.SpaceID + "/sandboxes/sb1/recording"
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.handler.ServeHTTP(rec, httptest.NewRequest("GET", testBaseURL+path, nil))
+		return rec
+	}
+
+	rec = get(path)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	require.Len(t, strings.Split(strings.TrimSpace(rec.Body.String()), "\n"), 3)
+
+	rec = get(path + "?format=asciicast")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Contains(t, rec.Body.String(), `"o","hello\r\n"`)
+
+	require.Equal(t, http.StatusBadRequest, get(path+"?format=mp4").Code)
+	require.Equal(t, http.StatusNotFound, get("/spaces/"+space.SpaceID+"/sandboxes/missing/recording").Code)
+	require.Equal(t, http.StatusNotFound, get("/spaces/missing/sandboxes/sb1/recording").Code)
+	require.Equal(t, http.StatusBadRequest, get(path+"/replay?speed=0").Code)
+	require.Equal(t, http.StatusNotFound, get("/spaces/"+space.SpaceID+"/sandboxes/missing/recording/replay").Code)
+
+	// The replay is a WebSocket sending the recorded observations.
+	server := httptest.NewServer(s.handler)
+	defer server.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/v1"+path+"/replay?speed=100", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	var types []string
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			require.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), err)
+			break
+		}
+		var obs v1.Observation
+		require.NoError(t, json.Unmarshal(data, &obs))
+		types = append(types, obs.ObservationType)
+	}
+	require.Equal(t, []string{"start", "stream", "end"}, types)
+}
+
+// Sandboxes cannot be created without Docker, so the conversion of a fully
+// populated sandbox is checked against the schema directly.
+func Test_sandboxToV1MatchesSpec(t *testing.T) {
+	doc, err := v1.GetSwagger()
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	exitCode := 137
+	reachable := false
+	state := &manager.SandboxState{
+		ID:          "sb-1",
+		ContainerID: "c0ffee",
+		AgentURL:    "http://127.0.0.1:32768",
+		SpaceID:     "default",
+		Image:       "box:latest",
+		Network:     &manager.NetworkPolicy{Mode: manager.NetworkAllowlist, Allow: []string{"10.0.0.0/8"}},
+		Security:    &manager.SecurityProfile{DropAllCapabilities: true, Capabilities: []string{"CHOWN"}, User: "1000:1000"},
+		Mounts:      []manager.MountSpec{{Type: manager.MountVolume, Source: "data", Target: "/data"}},
+		Ports:       []int{8080},
+		Resources:   &manager.Resources{CPUs: 0.5, MemoryMB: 512},
+		ExpiresAt:   &now,
+		Secrets:     []manager.SecretRef{{Name: "OPENAI_API_KEY", Env: "OPENAI_API_KEY"}, {Name: "db.password", File: "db"}},
+		Status: manager.SandboxStatus{
+			State:          manager.StateExited,
+			Phase:          manager.PhaseReady,
+			Message:        "Container exited",
+			CreatedAt:      now,
+			PullingAt:      &now,
+			ReadyAt:        &now,
+			PullProgress:   &manager.PullProgress{Image: "box:latest", Layers: 3, LayersDone: 1, CurrentBytes: 10, TotalBytes: 30},
+			ContainerState: "exited",
+			StartedAt:      &now,
+			FinishedAt:     &now,
+			ExitCode:       &exitCode,
+			OOMKilled:      true,
+			RestartCount:   2,
+			Health:         "unhealthy",
+			AgentReachable: &reachable,
+			CheckedAt:      &now,
+		},
+	}
+
+	data, err := json.Marshal(sandboxToV1(state))
+	require.NoError(t, err)
+	var value any
+	require.NoError(t, json.Unmarshal(data, &value))
+	require.NoError(t, doc.Components.Schemas["Sandbox"].Value.VisitJSON(value))
+
+	var sandbox v1.Sandbox
+	require.NoError(t, json.Unmarshal(data, &sandbox))
+	require.Equal(t, "box:latest", *sandbox.Spec.Image)
+	require.Equal(t, v1.SandboxStatusStateExited, *sandbox.Status.State)
+	require.Equal(t, []string{"10.0.0.0/8"}, *sandbox.Spec.Network.Allow)
+}
+
+// The spec lists exactly the codes that have a status.
+func Test_errorCodesMatchSpec(t *testing.T) {
+	doc, err := v1.GetSwagger()
+	require.NoError(t, err)
+	var specCodes []manager.ErrorCode
+	for _, code := range doc.Components.Schemas["Error"].Value.Properties["code"].Value.Enum {
+		specCodes = append(specCodes, manager.ErrorCode(code.(string)))
+	}
+
+	var codes []manager.ErrorCode
+	for code := range errorStatus {
+		codes = append(codes, code)
+	}
+	require.ElementsMatch(t, specCodes, codes)
+}
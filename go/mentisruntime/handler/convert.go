@@ -0,0 +1,321 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"time"
+
+	v1 "github.com/foreveryh/sandboxai/go/api/v1"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/audit"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/auth"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/manager"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/secrets"
+)
+
+// Conversions between the manager's state and the API models of api/v1.yaml.
+
+func spaceToV1(space *manager.SpaceState) v1.Space {
+	out := v1.Space{
+		SpaceID:         space.ID,
+		Name:            space.Name,
+		Description:     &space.Description,
+		CreatedAt:       &space.CreatedAt,
+		UpdatedAt:       &space.UpdatedAt,
+		SecurityProfile: securityProfileToV1(space.SecurityProfile),
+		Quota:           spaceQuotaToV1(space.Quota),
+	}
+	if space.Metadata != nil {
+		out.Metadata = &space.Metadata
+	}
+	return out
+}
+
+func sandboxToV1(state *manager.SandboxState) v1.Sandbox {
+	spec := v1.SandboxSpec{
+		Image: optional(state.Image),
+	}
+	if state.Network != nil {
+		spec.Network = &v1.NetworkPolicy{Mode: v1.NetworkPolicyMode(state.Network.Mode)}
+		if len(state.Network.Allow) > 0 {
+			spec.Network.Allow = &state.Network.Allow
+		}
+	}
+	if len(state.Mounts) > 0 {
+		mounts := make([]v1.MountSpec, len(state.Mounts))
+		for i, m := range state.Mounts {
+			mounts[i] = v1.MountSpec{Type: v1.MountSpecType(m.Type), Source: m.Source, Target: m.Target, ReadOnly: ptr(m.ReadOnly)}
+		}
+		spec.Mounts = &mounts
+	}
+	if len(state.Ports) > 0 {
+		spec.Ports = &state.Ports
+	}
+	if r := state.Resources; r != nil {
+		spec.Resources = &v1.Resources{Cpus: ptr(r.CPUs), MemoryMb: ptr(r.MemoryMB)}
+	}
+	if len(state.Secrets) > 0 {
+		refs := make([]v1.SecretRef, len(state.Secrets))
+		for i, ref := range state.Secrets {
+			refs[i] = v1.SecretRef{Name: ref.Name, Env: optional(ref.Env), File: optional(ref.File)}
+		}
+		spec.Secrets = &refs
+	}
+	return v1.Sandbox{
+		SandboxID:       state.ID,
+		Name:            &state.ID,
+		SpaceID:         state.SpaceID,
+		IsRunning:       state.IsRunning,
+		ContainerID:     optional(state.ContainerID),
+		AgentURL:        optional(state.AgentURL),
+		Spec:            &spec,
+		Status:          sandboxStatusToV1(state.Status),
+		SecurityProfile: securityProfileToV1(state.Security),
+		ExpiresAt:       state.ExpiresAt,
+	}
+}
+
+func sandboxStatusToV1(status manager.SandboxStatus) *v1.SandboxStatus {
+	out := &v1.SandboxStatus{
+		Reason:         optional(status.Reason),
+		Message:        optional(status.Message),
+		PullingAt:      status.PullingAt,
+		StartingAt:     status.StartingAt,
+		ReadyAt:        status.ReadyAt,
+		FailedAt:       status.FailedAt,
+		ContainerState: optional(status.ContainerState),
+		StartedAt:      status.StartedAt,
+		FinishedAt:     status.FinishedAt,
+		ExitCode:       status.ExitCode,
+		OomKilled:      ptr(status.OOMKilled),
+		RestartCount:   ptr(status.RestartCount),
+		Health:         optional(status.Health),
+		AgentReachable: status.AgentReachable,
+		CheckedAt:      status.CheckedAt,
+	}
+	if status.State != "" {
+		out.State = ptr(v1.SandboxStatusState(status.State))
+	}
+	if status.Phase != "" {
+		out.Phase = ptr(v1.SandboxStatusPhase(status.Phase))
+	}
+	if !status.CreatedAt.IsZero() {
+		out.CreatedAt = &status.CreatedAt
+	}
+	if p := status.PullProgress; p != nil {
+		out.PullProgress = &v1.PullProgress{
+			Image:        &p.Image,
+			Layers:       &p.Layers,
+			LayersDone:   &p.LayersDone,
+			CurrentBytes: &p.CurrentBytes,
+			TotalBytes:   &p.TotalBytes,
+		}
+	}
+	return out
+}
+
+// sandboxSpecFromV1 converts a create request, applying its image shorthand.
+func sandboxSpecFromV1(req *v1.CreateSandboxRequest) manager.SandboxSpec {
+	var spec manager.SandboxSpec
+	if s := req.Spec; s != nil {
+		spec.Image = deref(s.Image)
+		spec.Env = deref(s.Env)
+		spec.Ports = deref(s.Ports)
+		if s.Network != nil {
+			spec.Network = &manager.NetworkPolicy{Mode: manager.NetworkMode(s.Network.Mode), Allow: deref(s.Network.Allow)}
+		}
+		for _, m := range deref(s.Mounts) {
+			spec.Mounts = append(spec.Mounts, manager.MountSpec{Type: manager.MountType(m.Type), Source: m.Source, Target: m.Target, ReadOnly: deref(m.ReadOnly)})
+		}
+		if r := s.Resources; r != nil {
+			spec.Resources = &manager.Resources{CPUs: deref(r.Cpus), MemoryMB: deref(r.MemoryMb)}
+		}
+		for _, ref := range deref(s.Secrets) {
+			spec.Secrets = append(spec.Secrets, manager.SecretRef{Name: ref.Name, Env: deref(ref.Env), File: deref(ref.File)})
+		}
+	}
+	if spec.Image == "" {
+		spec.Image = deref(req.Image)
+	}
+	return spec
+}
+
+func securityProfileToV1(p *manager.SecurityProfile) *v1.SecurityProfile {
+	if p == nil {
+		return nil
+	}
+	out := &v1.SecurityProfile{
+		DropAllCapabilities: ptr(p.DropAllCapabilities),
+		NoNewPrivileges:     ptr(p.NoNewPrivileges),
+		ReadOnlyRootfs:      ptr(p.ReadOnlyRootfs),
+		TmpfsSize:           optional(p.TmpfsSize),
+		User:                optional(p.User),
+		SeccompProfile:      optional(p.SeccompProfile),
+		Runtime:             optional(p.Runtime),
+	}
+	if len(p.Capabilities) > 0 {
+		out.Capabilities = &p.Capabilities
+	}
+	return out
+}
+
+func securityProfileFromV1(p *v1.SecurityProfile) *manager.SecurityProfile {
+	if p == nil {
+		return nil
+	}
+	return &manager.SecurityProfile{
+		DropAllCapabilities: deref(p.DropAllCapabilities),
+		Capabilities:        deref(p.Capabilities),
+		NoNewPrivileges:     deref(p.NoNewPrivileges),
+		ReadOnlyRootfs:      deref(p.ReadOnlyRootfs),
+		TmpfsSize:           deref(p.TmpfsSize),
+		User:                deref(p.User),
+		SeccompProfile:      deref(p.SeccompProfile),
+		Runtime:             deref(p.Runtime),
+	}
+}
+
+func spaceQuotaToV1(q *manager.SpaceQuota) *v1.SpaceQuota {
+	if q == nil {
+		return nil
+	}
+	return &v1.SpaceQuota{
+		MaxSandboxes:         ptr(q.MaxSandboxes),
+		MaxConcurrentActions: ptr(q.MaxConcurrentActions),
+		Cpus:                 ptr(q.CPUs),
+		MemoryMb:             ptr(q.MemoryMB),
+		MaxLifetimeSeconds:   ptr(int64(q.MaxLifetime / time.Second)),
+	}
+}
+
+func spaceQuotaFromV1(q *v1.SpaceQuota) *manager.SpaceQuota {
+	if q == nil {
+		return nil
+	}
+	return &manager.SpaceQuota{
+		MaxSandboxes:         deref(q.MaxSandboxes),
+		MaxConcurrentActions: deref(q.MaxConcurrentActions),
+		CPUs:                 deref(q.Cpus),
+		MemoryMB:             deref(q.MemoryMb),
+		MaxLifetime:          time.Duration(deref(q.MaxLifetimeSeconds)) * time.Second,
+	}
+}
+
+// usageQueryFromV1 applies the defaults of getSpaceUsage: the hourly usage of
+// the 24 hours before now.
+func usageQueryFromV1(params v1.GetSpaceUsageParams, now time.Time) manager.UsageQuery {
+	q := manager.UsageQuery{To: now, Bucket: time.Hour}
+	if params.To != nil {
+		q.To = *params.To
+	}
+	q.From = q.To.Add(-24 * time.Hour)
+	if params.From != nil {
+		q.From = *params.From
+	}
+	if params.Bucket != nil && *params.Bucket == v1.Day {
+		q.Bucket = 24 * time.Hour
+	}
+	q.BySandbox = params.GroupBy != nil && *params.GroupBy == v1.GetSpaceUsageParamsGroupBySandbox
+	return q
+}
+
+func usageBucketToV1(b manager.UsageBucket) v1.UsageBucket {
+	return v1.UsageBucket{
+		Start:           b.Start,
+		End:             b.End,
+		SandboxID:       optional(b.SandboxID),
+		RuntimeSeconds:  b.RuntimeSeconds,
+		Actions:         b.Actions,
+		ActionSeconds:   b.ActionSeconds,
+		CPUSeconds:      b.CPUSeconds,
+		MemoryMbSeconds: b.MemoryMBSeconds,
+		PeakMemoryMb:    b.PeakMemoryMB,
+	}
+}
+
+// usageCSV renders usage buckets with a header row, one column per field of
+// the UsageBucket schema.
+func usageCSV(buckets []manager.UsageBucket) *bytes.Buffer {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"start", "end", "space_id", "sandbox_id", "runtime_seconds", "actions", "action_seconds", "cpu_seconds", "memory_mb_seconds", "peak_memory_mb"})
+	number := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	for _, b := range buckets {
+		w.Write([]string{
+			b.Start.Format(time.RFC3339), b.End.Format(time.RFC3339), b.SpaceID, b.SandboxID,
+			number(b.RuntimeSeconds), strconv.Itoa(b.Actions), number(b.ActionSeconds),
+			number(b.CPUSeconds), number(b.MemoryMBSeconds), number(b.PeakMemoryMB),
+		})
+	}
+	w.Flush()
+	return &buf
+}
+
+func apiKeyToV1(key auth.APIKey) v1.APIKey {
+	out := v1.APIKey{ID: key.ID, Name: optional(key.Name), CreatedAt: key.CreatedAt, Scopes: make([]v1.APIKeyScopes, len(key.Scopes))}
+	for i, scope := range key.Scopes {
+		out.Scopes[i] = v1.APIKeyScopes(scope)
+	}
+	if len(key.Spaces) > 0 {
+		out.Spaces = &key.Spaces
+	}
+	return out
+}
+
+// auditQueryFromV1 applies the defaults of listAuditEntries: the first 1000
+// matching entries.
+func auditQueryFromV1(params v1.ListAuditEntriesParams) audit.Query {
+	q := audit.Query{SpaceID: deref(params.SpaceID), From: deref(params.From), To: deref(params.To), Limit: 1000}
+	if params.Limit != nil {
+		q.Limit = *params.Limit
+	}
+	return q
+}
+
+func auditEntryToV1(e audit.Entry) v1.AuditEntry {
+	out := v1.AuditEntry{
+		ID:         e.ID,
+		Time:       e.Time,
+		Actor:      v1.AuditActor{ID: e.Actor.ID, Name: optional(e.Actor.Name)},
+		RemoteAddr: optional(e.RemoteAddr),
+		Operation:  e.Operation,
+		SpaceID:    optional(e.SpaceID),
+		SandboxID:  optional(e.SandboxID),
+		ActionID:   optional(e.ActionID),
+		Outcome:    v1.AuditEntryOutcome(e.Outcome),
+		ExitCode:   e.ExitCode,
+		Error:      optional(e.Error),
+	}
+	if e.StatusCode != 0 {
+		out.StatusCode = &e.StatusCode
+	}
+	if len(e.Request) > 0 {
+		var request interface{} = e.Request
+		out.Request = &request
+	}
+	return out
+}
+
+func secretToV1(s secrets.Secret) v1.Secret {
+	return v1.Secret{Name: s.Name, CreatedAt: s.CreatedAt, UpdatedAt: s.UpdatedAt}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// optional returns nil for the empty string, which the API models as absent.
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	v1 "github.com/foreveryh/sandboxai/go/api/v1"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/manager"
+)
+
+// errorStatus maps error codes to the status of their responses. Codes
+// missing from it are reported as internal errors.
+var errorStatus = map[manager.ErrorCode]int{
+	manager.CodeInvalidRequest:         http.StatusBadRequest,
+	manager.CodeInvalidSpec:            http.StatusBadRequest,
+	manager.CodeInvalidSecurityProfile: http.StatusBadRequest,
+	manager.CodeUnauthorized:           http.StatusUnauthorized,
+	manager.CodeForbidden:              http.StatusForbidden,
+	manager.CodeNotFound:               http.StatusNotFound,
+	manager.CodeSpaceNotFound:          http.StatusNotFound,
+	manager.CodeSandboxNotFound:        http.StatusNotFound,
+	manager.CodePathNotFound:           http.StatusNotFound,
+	manager.CodePortNotExposed:         http.StatusNotFound,
+	manager.CodeNameConflict:           http.StatusConflict,
+	manager.CodeConflict:               http.StatusConflict,
+	manager.CodeSandboxNotRunning:      http.StatusServiceUnavailable,
+	manager.CodeQuotaExceeded:          http.StatusTooManyRequests,
+	manager.CodeImagePullFailed:        http.StatusBadGateway,
+	manager.CodeAgentUnreachable:       http.StatusBadGateway,
+	manager.CodeServiceUnreachable:     http.StatusBadGateway,
+	manager.CodeInternal:               http.StatusInternalServerError,
+}
+
+// statusOf returns the response status for code.
+func statusOf(code manager.ErrorCode) int {
+	if status, ok := errorStatus[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+func apiError(code manager.ErrorCode, message string) v1.Error {
+	c := v1.ErrorCode(code)
+	return v1.Error{Message: message, Code: &c}
+}
+
+// errorResponse describes a failed operation: message says what failed and
+// the code and detail come from err.
+func errorResponse(message string, err error) (int, v1.Error) {
+	code := manager.CodeOf(err)
+	body := apiError(code, message)
+	body.Detail = ptr(err.Error())
+	return statusOf(code), body
+}
+
+func sandboxNotFound(spaceID, sandboxID string) v1.Error {
+	return apiError(manager.CodeSandboxNotFound, fmt.Sprintf("Sandbox %s not found in space %s", sandboxID, spaceID))
+}
+
+func spaceNotFound(spaceID string) v1.Error {
+	return apiError(manager.CodeSpaceNotFound, fmt.Sprintf("Space %s not found", spaceID))
+}
+
+// WriteError writes an error response in JSON format. The status follows from
+// the code.
+func WriteError(w http.ResponseWriter, code manager.ErrorCode, message string) {
+	writeBody(w, statusOf(code), apiError(code, message))
+}
+
+// writeFailure is WriteError for a failed operation, see errorResponse.
+func writeFailure(w http.ResponseWriter, message string, err error) {
+	status, body := errorResponse(message, err)
+	writeBody(w, status, body)
+}
+
+func writeBody(w http.ResponseWriter, status int, body v1.Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/audit"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/manager"
+	"github.com/gorilla/mux"
+)
+
+// GetFilesHandler handles requests to download a file or directory from a
+// sandbox as a tar archive.
+func (h *APIHandler) GetFilesHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID, sandboxID := vars["space_id"], vars["sandbox_id"]
+	srcPath := r.URL.Query().Get("path")
+	if !path.IsAbs(srcPath) {
+		WriteError(w, manager.CodeInvalidRequest, "Query parameter path must be an absolute path")
+		return
+	}
+
+	archive, _, err := h.sandboxManager.CopyFromSandbox(r.Context(), spaceID, sandboxID, srcPath)
+	if err != nil {
+		h.writeSandboxAccessError(w, err, spaceID, sandboxID, "copy from sandbox")
+		return
+	}
+	defer archive.Close()
+	w.Header().Set("Content-Type", "application/x-tar")
+	if _, err := io.Copy(w, archive); err != nil {
+		h.logger.Warn("Failed to send archive", "sandboxID", sandboxID, "path", srcPath, "error", err)
+	}
+}
+
+// PutFilesHandler handles requests to upload a tar archive, which is extracted
+// into a directory of a sandbox.
+func (h *APIHandler) PutFilesHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID, sandboxID := vars["space_id"], vars["sandbox_id"]
+	dstDir := r.URL.Query().Get("path")
+	audit.Describe(r.Context(), "putSandboxFiles", map[string]string{"path": dstDir})
+	if !path.IsAbs(dstDir) {
+		WriteError(w, manager.CodeInvalidRequest, "Query parameter path must be an absolute path")
+		return
+	}
+
+	if err := h.sandboxManager.CopyToSandbox(r.Context(), spaceID, sandboxID, dstDir, r.Body); err != nil {
+		h.writeSandboxAccessError(w, err, spaceID, sandboxID, "copy to sandbox")
+		return
+	}
+	h.logger.Info("Files copied to sandbox", "sandboxID", sandboxID, "path", dstDir)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetLogsHandler handles requests for the output of a sandbox's container.
+// With ?follow=true the response streams new output until the client goes away.
+func (h *APIHandler) GetLogsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID, sandboxID := vars["space_id"], vars["sandbox_id"]
+	follow := r.URL.Query().Get("follow") == "true"
+	tail := r.URL.Query().Get("tail")
+	if tail == "" {
+		tail = "all"
+	} else if _, err := strconv.Atoi(tail); err != nil && tail != "all" {
+		WriteError(w, manager.CodeInvalidRequest, "Query parameter tail must be a number or all")
+		return
+	}
+
+	logs, err := h.sandboxManager.SandboxLogs(r.Context(), spaceID, sandboxID, follow, tail)
+	if err != nil {
+		h.writeSandboxAccessError(w, err, spaceID, sandboxID, "read logs of sandbox")
+		return
+	}
+	defer logs.Close()
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rc := http.NewResponseController(w)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := logs.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return
+			}
+			rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// writeSandboxAccessError maps errors of operations on a sandbox's container to
+// responses.
+func (h *APIHandler) writeSandboxAccessError(w http.ResponseWriter, err error, spaceID, sandboxID, operation string) {
+	switch code := manager.CodeOf(err); code {
+	case manager.CodeSandboxNotFound:
+		writeBody(w, http.StatusNotFound, sandboxNotFound(spaceID, sandboxID))
+	case manager.CodePathNotFound:
+		WriteError(w, code, err.Error())
+	case manager.CodeSandboxNotRunning:
+		WriteError(w, code, fmt.Sprintf("Sandbox %s is not running", sandboxID))
+	default:
+		h.logger.Error("Failed to "+operation, "spaceID", spaceID, "sandboxID", sandboxID, "error", err)
+		writeFailure(w, "Failed to "+operation, err)
+	}
+}
@@ -1,163 +1,266 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"reflect"
 	"strings"
+	"time"
 
+	v1 "github.com/foreveryh/sandboxai/go/api/v1"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/audit"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/auth"
 	"github.com/foreveryh/sandboxai/go/mentisruntime/manager"
 	"github.com/foreveryh/sandboxai/go/mentisruntime/ws"
 	"github.com/gorilla/mux"
 )
 
+// APIHandler implements the operations of api/v1.yaml through the strict
+// server generated in go/api/v1, plus the streaming and WebSocket endpoints the
+// generator cannot express.
 type APIHandler struct {
 	logger         *slog.Logger
 	sandboxManager *manager.SandboxManager
 	spaceManager   *manager.SpaceManager
-	hub           *ws.Hub
+	hub            *ws.Hub
+	keys           *auth.Store // nil when authentication is disabled
+	audit          *audit.Log  // nil when auditing is disabled
 }
 
-func NewAPIHandler(logger *slog.Logger, sandboxManager *manager.SandboxManager, spaceManager *manager.SpaceManager, hub *ws.Hub) *APIHandler {
+var _ v1.StrictServerInterface = (*APIHandler)(nil)
+
+func NewAPIHandler(logger *slog.Logger, sandboxManager *manager.SandboxManager, spaceManager *manager.SpaceManager, hub *ws.Hub, keys *auth.Store, auditLog *audit.Log) *APIHandler {
 	return &APIHandler{
 		logger:         logger,
 		sandboxManager: sandboxManager,
 		spaceManager:   spaceManager,
-		hub:           hub,
+		hub:            hub,
+		keys:           keys,
+		audit:          auditLog,
 	}
 }
 
-// PostShellCommandHandler handles requests to execute a shell command asynchronously.
-func (h *APIHandler) PostShellCommandHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	spaceID := vars["spaceID"]     // Extract spaceID from path
-	sandboxID := vars["sandboxID"] // Corrected key based on route definition
+// operationScopes lists the scope each generated operation requires. The
+// health check needs none.
+var operationScopes = map[string]auth.Scope{
+	"HealthCheck":      "",
+	"ListAPIKeys":      auth.ScopeAdmin,
+	"CreateAPIKey":     auth.ScopeAdmin,
+	"DeleteAPIKey":     auth.ScopeAdmin,
+	"ListAuditEntries": auth.ScopeAdmin,
+	"ListSpaces":       auth.ScopeSpaceRead,
+	"CreateSpace":      auth.ScopeSpaceWrite,
+	"GetSpace":         auth.ScopeSpaceRead,
+	"UpdateSpace":      auth.ScopeSpaceWrite,
+	"DeleteSpace":      auth.ScopeSpaceWrite,
+	"GetSpaceUsage":    auth.ScopeSpaceRead,
+	"ListSecrets":      auth.ScopeSpaceRead,
+	"SetSecret":        auth.ScopeSpaceWrite,
+	"DeleteSecret":     auth.ScopeSpaceWrite,
+	"ListSandboxes":    auth.ScopeSpaceRead,
+	"CreateSandbox":    auth.ScopeSpaceWrite,
+	"GetSandbox":       auth.ScopeSpaceRead,
+	"DeleteSandbox":    auth.ScopeSpaceWrite,
+	"RunShellCommand":  auth.ScopeExec,
+	"RunIPythonCell":   auth.ScopeExec,
+}
 
-	if spaceID == "" || sandboxID == "" {
-		WriteError(w, "Missing spaceID or sandboxID in path", http.StatusBadRequest)
-		return
-	}
+// auditedOperations lists the generated operations that change state. Their
+// requests are recorded in the audit log, including those the API key does not
+// permit.
+var auditedOperations = map[string]bool{
+	"CreateAPIKey":    true,
+	"DeleteAPIKey":    true,
+	"CreateSpace":     true,
+	"UpdateSpace":     true,
+	"DeleteSpace":     true,
+	"SetSecret":       true,
+	"DeleteSecret":    true,
+	"CreateSandbox":   true,
+	"DeleteSandbox":   true,
+	"RunShellCommand": true,
+	"RunIPythonCell":  true,
+}
 
-	// --- Validation: Check if sandbox belongs to the space --- 
-	sandboxState, getErr := h.sandboxManager.GetSandbox(r.Context(), sandboxID)
-	if getErr != nil {
-		// If sandbox doesn't exist at all, return 404
-		if errors.Is(getErr, manager.ErrSandboxNotFound) || strings.Contains(getErr.Error(), "not found") {
-			WriteError(w, fmt.Sprintf("Sandbox %s not found", sandboxID), http.StatusNotFound)
-		} else {
-			h.logger.Error("Failed to get sandbox before initiating action", "spaceID", spaceID, "sandboxID", sandboxID, "error", getErr)
-			WriteError(w, "Failed to check sandbox before initiating action: "+getErr.Error(), http.StatusInternalServerError)
-		}
-		return
-	}
-	if sandboxState.SpaceID != spaceID {
-		h.logger.Warn("Attempt to run shell command on sandbox via incorrect space path", "requestedSpaceID", spaceID, "actualSpaceID", sandboxState.SpaceID, "sandboxID", sandboxID)
-		WriteError(w, fmt.Sprintf("Sandbox %s not found in space %s", sandboxID, spaceID), http.StatusNotFound)
-		return
-	}
-	// --- End Validation --- 
+// unrecordedBodies lists the audited operations whose request body is a
+// secret value and is left out of the audit log.
+var unrecordedBodies = map[string]bool{
+	"SetSecret": true,
+}
 
-	var payload map[string]interface{} // Use map for flexibility
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		WriteError(w, "Invalid request body: "+err.Error(), http.StatusBadRequest) // Use WriteError
-		return
+// auditOperation marks the requests of auditedOperations for the audit log
+// under the spec's operation ID, with their body and query parameters as the
+// payload.
+func auditOperation(f v1.StrictHandlerFunc, operationID string) v1.StrictHandlerFunc {
+	if !auditedOperations[operationID] {
+		return f
+	}
+	operation := strings.ToLower(operationID[:1]) + operationID[1:]
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		payload := map[string]any{}
+		if v := reflect.ValueOf(request); v.Kind() == reflect.Struct {
+			for _, name := range []string{"Body", "Params"} {
+				if name == "Body" && unrecordedBodies[operationID] {
+					continue
+				}
+				if field := v.FieldByName(name); field.IsValid() && !field.IsZero() {
+					payload[strings.ToLower(name)] = field.Interface()
+				}
+			}
+		}
+		audit.Describe(ctx, operation, payload)
+		return f(ctx, w, r, request)
 	}
+}
 
-	// Basic validation (ensure command exists, etc.) - can be expanded
-	if _, ok := payload["command"]; !ok {
-		WriteError(w, "Missing 'command' in request body", http.StatusBadRequest) // Use WriteError
-		return
-	}
+// RegisterRoutes registers the generated operations on router, which must
+// serve the spec's /v1 base path.
+func (h *APIHandler) RegisterRoutes(router *mux.Router) {
+	// The last middleware runs first, so denied requests are audited too.
+	middlewares := []v1.StrictMiddlewareFunc{auth.RequireOperation(operationScopes), auditOperation}
+	strict := v1.NewStrictHandlerWithOptions(h, middlewares, v1.StrictHTTPServerOptions{
+		RequestErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			WriteError(w, manager.CodeInvalidRequest, "Invalid request body: "+err.Error())
+		},
+		ResponseErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			h.logger.Error("Failed to write response", "path", r.URL.Path, "error", err)
+			WriteError(w, manager.CodeInternal, "Failed to write response: "+err.Error())
+		},
+	})
+	v1.HandlerWithOptions(strict, v1.GorillaServerOptions{
+		BaseRouter: router,
+		ErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			WriteError(w, manager.CodeInvalidRequest, err.Error())
+		},
+	})
+}
 
-	actionID, err := h.sandboxManager.InitiateAction(r.Context(), sandboxID, "shell", payload)
-	if err != nil {
-		h.logger.Error("Failed to initiate shell action", "sandboxID", sandboxID, "error", err)
-		// Map manager errors to appropriate HTTP status codes
-		// Example: Check for specific errors like sandbox not found or not running
-		if strings.Contains(err.Error(), "not found or not running") { // Basic check, refine with specific errors
-			WriteError(w, fmt.Sprintf("Failed to initiate shell command: sandbox %s not found or not running", sandboxID), http.StatusNotFound)
-		} else {
-			WriteError(w, "Failed to initiate shell command: "+err.Error(), http.StatusInternalServerError)
-		}
-		return
-	}
+// HealthCheck responds with a simple OK status.
+func (h *APIHandler) HealthCheck(ctx context.Context, request v1.HealthCheckRequestObject) (v1.HealthCheckResponseObject, error) {
+	return v1.HealthCheck200JSONResponse{Status: "ok"}, nil
+}
 
+// HealthCheckHandler responds with a simple OK status. It answers the agents'
+// ping on the internal listener.
+func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusAccepted) // 202 Accepted
-	json.NewEncoder(w).Encode(map[string]string{"action_id": actionID})
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(v1.HealthStatus{Status: "ok"})
 }
 
-// PostIPythonCellHandler handles requests to execute an IPython cell asynchronously.
-func (h *APIHandler) PostIPythonCellHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	spaceID := vars["spaceID"]     // Extract spaceID from path
-	sandboxID := vars["sandboxID"] // Corrected key based on route definition
+// RunShellCommand handles requests to execute a shell command asynchronously.
+// Its output is published on the sandbox's stream under the returned action ID.
+func (h *APIHandler) RunShellCommand(ctx context.Context, request v1.RunShellCommandRequestObject) (v1.RunShellCommandResponseObject, error) {
+	if _, err := h.sandboxInSpace(ctx, request.SpaceID, request.SandboxID); err != nil {
+		if errors.Is(err, manager.ErrSandboxNotFound) {
+			return v1.RunShellCommand404JSONResponse(sandboxNotFound(request.SpaceID, request.SandboxID)), nil
+		}
+		status, body := errorResponse("Failed to check sandbox before initiating action", err)
+		return v1.RunShellCommanddefaultJSONResponse{StatusCode: status, Body: body}, nil
+	}
+	if request.Body.Command == "" {
+		return v1.RunShellCommand400JSONResponse(apiError(manager.CodeInvalidRequest, "Missing 'command' in request body")), nil
+	}
 
-	if spaceID == "" || sandboxID == "" {
-		WriteError(w, "Missing spaceID or sandboxID in path", http.StatusBadRequest)
-		return
+	actionID, err := h.initiateAction(ctx, request.SandboxID, "shell", request.Body)
+	if err != nil {
+		status, body := errorResponse("Failed to initiate shell command", err)
+		return v1.RunShellCommanddefaultJSONResponse{StatusCode: status, Body: body}, nil
 	}
+	return v1.RunShellCommand202JSONResponse{ActionID: actionID}, nil
+}
 
-	// --- Validation: Check if sandbox belongs to the space --- 
-	sandboxState, getErr := h.sandboxManager.GetSandbox(r.Context(), sandboxID)
-	if getErr != nil {
-		// If sandbox doesn't exist at all, return 404
-		if errors.Is(getErr, manager.ErrSandboxNotFound) || strings.Contains(getErr.Error(), "not found") {
-			WriteError(w, fmt.Sprintf("Sandbox %s not found", sandboxID), http.StatusNotFound)
-		} else {
-			h.logger.Error("Failed to get sandbox before initiating action", "spaceID", spaceID, "sandboxID", sandboxID, "error", getErr)
-			WriteError(w, "Failed to check sandbox before initiating action: "+getErr.Error(), http.StatusInternalServerError)
+// RunIPythonCell handles requests to execute an IPython cell asynchronously.
+// Its output is published on the sandbox's stream under the returned action ID.
+func (h *APIHandler) RunIPythonCell(ctx context.Context, request v1.RunIPythonCellRequestObject) (v1.RunIPythonCellResponseObject, error) {
+	if _, err := h.sandboxInSpace(ctx, request.SpaceID, request.SandboxID); err != nil {
+		if errors.Is(err, manager.ErrSandboxNotFound) {
+			return v1.RunIPythonCell404JSONResponse(sandboxNotFound(request.SpaceID, request.SandboxID)), nil
 		}
-		return
+		status, body := errorResponse("Failed to check sandbox before initiating action", err)
+		return v1.RunIPythonCelldefaultJSONResponse{StatusCode: status, Body: body}, nil
 	}
-	if sandboxState.SpaceID != spaceID {
-		h.logger.Warn("Attempt to run ipython cell on sandbox via incorrect space path", "requestedSpaceID", spaceID, "actualSpaceID", sandboxState.SpaceID, "sandboxID", sandboxID)
-		WriteError(w, fmt.Sprintf("Sandbox %s not found in space %s", sandboxID, spaceID), http.StatusNotFound)
-		return
+	if request.Body.Code == "" {
+		return v1.RunIPythonCell400JSONResponse(apiError(manager.CodeInvalidRequest, "Missing 'code' in request body")), nil
 	}
-	// --- End Validation --- 
 
-	var payload map[string]interface{} // Use map for flexibility
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		WriteError(w, "Invalid request body: "+err.Error(), http.StatusBadRequest) // Use WriteError
-		return
+	actionID, err := h.initiateAction(ctx, request.SandboxID, "ipython", request.Body)
+	if err != nil {
+		status, body := errorResponse("Failed to initiate IPython cell execution", err)
+		return v1.RunIPythonCelldefaultJSONResponse{StatusCode: status, Body: body}, nil
 	}
+	return v1.RunIPythonCell202JSONResponse{ActionID: actionID}, nil
+}
 
-	// Basic validation (ensure code exists, etc.) - can be expanded
-	if _, ok := payload["code"]; !ok {
-		WriteError(w, "Missing 'code' in request body", http.StatusBadRequest) // Use WriteError
-		return
+// initiateAction passes an action request on to the manager.
+func (h *APIHandler) initiateAction(ctx context.Context, sandboxID, actionType string, request any) (string, error) {
+	// The agent takes the request as sent by the client, minus unset fields.
+	// The runtime assigns the action ID.
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return "", err
+	}
+	for k, v := range payload {
+		if v == nil || k == "action_id" {
+			delete(payload, k)
+		}
 	}
 
-	actionID, err := h.sandboxManager.InitiateAction(r.Context(), sandboxID, "ipython", payload)
+	actionID, err := h.sandboxManager.InitiateAction(ctx, sandboxID, actionType, payload)
 	if err != nil {
-		h.logger.Error("Failed to initiate ipython action", "sandboxID", sandboxID, "error", err)
-		// Map manager errors to appropriate HTTP status codes
-		// Example: Check for specific errors like sandbox not found or not running
-		if strings.Contains(err.Error(), "not found or not running") { // Basic check, refine with specific errors
-			WriteError(w, fmt.Sprintf("Failed to initiate IPython cell execution: sandbox %s not found or not running", sandboxID), http.StatusNotFound)
-		} else {
-			WriteError(w, "Failed to initiate IPython cell execution: "+err.Error(), http.StatusInternalServerError)
-		}
-		return
+		h.logger.Error("Failed to initiate action", "sandboxID", sandboxID, "actionType", actionType, "error", err)
+		return "", err
 	}
+	audit.SetActionID(ctx, actionID)
+	return actionID, nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusAccepted) // 202 Accepted
-	json.NewEncoder(w).Encode(map[string]string{"action_id": actionID})
+// sandboxInSpace returns a sandbox, reporting sandboxes of other spaces as not
+// found.
+func (h *APIHandler) sandboxInSpace(ctx context.Context, spaceID, sandboxID string) (*manager.SandboxState, error) {
+	state, err := h.sandboxManager.GetSandbox(ctx, sandboxID)
+	if err != nil {
+		if errors.Is(err, manager.ErrSandboxNotFound) {
+			return nil, err
+		}
+		h.logger.Error("Failed to get sandbox", "spaceID", spaceID, "sandboxID", sandboxID, "error", err)
+		return nil, err
+	}
+	if state.SpaceID != spaceID {
+		h.logger.Warn("Sandbox requested via incorrect space path", "requestedSpaceID", spaceID, "actualSpaceID", state.SpaceID, "sandboxID", sandboxID)
+		return nil, manager.ErrSandboxNotFound
+	}
+	return state, nil
 }
 
+// ObservationTokenHeader carries the token from RUNTIME_OBSERVATION_TOKEN on
+// observation callbacks.
+const ObservationTokenHeader = "X-Observation-Token"
+
+// InternalObservationHandler receives observations pushed by a sandbox's agent.
 func (h *APIHandler) InternalObservationHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r) // Uses gorilla/mux as per your provided code
-	// sandboxID := vars["sandbox_id"] // Correct key for mux
-	sandboxID := vars["sandboxID"] // Changed to sandboxID
+	sandboxID := vars["sandbox_id"]
 
 	if sandboxID == "" {
 		// http.Error(w, "Missing sandbox_id in path", http.StatusBadRequest)
-		WriteError(w, "Missing sandboxID in path", http.StatusBadRequest) // Use WriteError and updated message
+		WriteError(w, manager.CodeInvalidRequest, "Missing sandbox_id in path") // Use WriteError and updated message
+		return
+	}
+
+	// Only the sandbox's own agent knows its token; anything else could inject
+	// output into the sandbox's stream.
+	if err := h.sandboxManager.AuthenticateObservation(sandboxID, r.Header.Get(ObservationTokenHeader)); err != nil {
+		h.logger.Warn("Rejected internal observation", "sandboxID", sandboxID, "remoteAddr", r.RemoteAddr, "error", err)
+		WriteError(w, manager.CodeUnauthorized, "Invalid observation token")
 		return
 	}
 
@@ -166,7 +269,7 @@ func (h *APIHandler) InternalObservationHandler(w http.ResponseWriter, r *http.R
 	if err != nil {
 		h.logger.Error("Failed to read internal observation body", "sandboxID", sandboxID, "error", err)
 		// http.Error(w, "Failed to read request body: "+err.Error(), http.StatusInternalServerError)
-		WriteError(w, "Failed to read request body: "+err.Error(), http.StatusInternalServerError) // Use WriteError
+		writeFailure(w, "Failed to read request body", err) // Use WriteError
 		return
 	}
 	defer r.Body.Close() // Ensure body is closed
@@ -177,12 +280,12 @@ func (h *APIHandler) InternalObservationHandler(w http.ResponseWriter, r *http.R
 	// ***************************
 
 	// Pass the raw bytes to the manager for processing and broadcasting
-	err = h.sandboxManager.ReceiveInternalObservation(sandboxID, bodyBytes)
+	err = h.sandboxManager.ReceiveInternalObservation(r.Context(), sandboxID, bodyBytes)
 	if err != nil {
 		h.logger.Error("Failed to process internal observation", "sandboxID", sandboxID, "error", err)
 		// Determine appropriate error code based on manager error
 		// http.Error(w, "Failed to process observation: "+err.Error(), http.StatusInternalServerError)
-		WriteError(w, "Failed to process observation: "+err.Error(), http.StatusInternalServerError) // Use WriteError
+		writeFailure(w, "Failed to process observation", err) // Use WriteError
 		return
 	}
 
@@ -191,352 +294,372 @@ func (h *APIHandler) InternalObservationHandler(w http.ResponseWriter, r *http.R
 	w.WriteHeader(http.StatusOK)
 }
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Message string `json:"message"`
-	Detail  string `json:"detail,omitempty"`
-}
-
-// WriteError writes an error response in JSON format
-func WriteError(w http.ResponseWriter, message string, statusCode int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(ErrorResponse{Message: message})
-}
-
-// CreateSandboxRequest represents the request body for creating a sandbox
-type CreateSandboxRequest struct {
-	SpaceID     string   `json:"space_id"` // Ensure this matches the expected JSON key
-	Image       string   `json:"image,omitempty"`
-	Command     string   `json:"command,omitempty"` // Keep as string in request
-	Metadata    map[string]interface{} `json:"metadata,omitempty"`
-}
-
-// CreateSandboxHandler handles requests to create a new sandbox.
-func (h *APIHandler) CreateSandboxHandler(w http.ResponseWriter, r *http.Request) {
-	// --- Get spaceID from path --- 
-	vars := mux.Vars(r)
-	spaceID := vars["spaceID"]
-	if spaceID == "" {
-		// If spaceID is missing in the path, maybe default or error?
-		// For now, let's assume it's required in the path based on the route definition.
-		WriteError(w, "Missing spaceID in path", http.StatusBadRequest)
-		return
+// CreateSandbox handles requests to create a new sandbox. With ?async=true it
+// responds as soon as the sandbox is registered and provisions it in the
+// background.
+func (h *APIHandler) CreateSandbox(ctx context.Context, request v1.CreateSandboxRequestObject) (v1.CreateSandboxResponseObject, error) {
+	spaceID := request.SpaceID
+	if _, err := h.spaceManager.GetSpace(ctx, spaceID); err != nil {
+		if errors.Is(err, manager.ErrSpaceNotFound) {
+			return v1.CreateSandbox404JSONResponse(spaceNotFound(spaceID)), nil
+		}
+		h.logger.Error("Failed to validate space during sandbox creation", "spaceID", spaceID, "error", err)
+		status, body := errorResponse("Failed to validate space", err)
+		return v1.CreateSandboxdefaultJSONResponse{StatusCode: status, Body: body}, nil
 	}
 
-	// --- Decode request body --- 
-	var req CreateSandboxRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteError(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
-		return
-	}
-	defer r.Body.Close()
+	spec := sandboxSpecFromV1(request.Body)
+	h.logger.Info("Received request to create sandbox", "spaceID", spaceID, "image", spec.Image)
 
-	// --- Validate space exists --- 
-	_, spaceErr := h.spaceManager.GetSpace(r.Context(), spaceID)
-	if spaceErr != nil {
-		if errors.Is(spaceErr, manager.ErrSpaceNotFound) {
-			WriteError(w, fmt.Sprintf("Space %s not found", spaceID), http.StatusNotFound)
-		} else {
-			h.logger.Error("Failed to validate space during sandbox creation", "spaceID", spaceID, "error", spaceErr)
-			WriteError(w, "Failed to validate space: "+spaceErr.Error(), http.StatusInternalServerError)
+	createError := func(err error) v1.CreateSandboxResponseObject {
+		h.logger.Error("Failed to create sandbox", "spaceID", spaceID, "image", spec.Image, "error", err)
+		if errors.Is(err, manager.ErrSpaceNotFound) {
+			return v1.CreateSandbox404JSONResponse(spaceNotFound(spaceID))
 		}
-		return
+		status, body := errorResponse("Failed to create sandbox", err)
+		return v1.CreateSandboxdefaultJSONResponse{StatusCode: status, Body: body}
 	}
 
-	h.logger.Info("Received request to create sandbox", "spaceID", spaceID, "image", req.Image, "command", req.Command)
-
-	// --- Prepare command slice (currently unused due to previous fix) --- 
-	var commandSlice []string
-	// if req.Command != "" { 
-	// 	commandSlice = []string{req.Command}
-	// }
-
-	// --- Call manager to create sandbox --- 
-	sandboxID, err := h.sandboxManager.CreateSandbox(r.Context(), spaceID, req.Image, commandSlice) // Pass empty slice
-	if err != nil {
-		h.logger.Error("Failed to create sandbox", "spaceID", spaceID, "image", req.Image, "command", req.Command, "error", err)
-		if errors.Is(err, manager.ErrSpaceNotFound) { // Should be caught by space validation above, but keep for safety
-			WriteError(w, fmt.Sprintf("Space %s not found", spaceID), http.StatusNotFound)
-		} else {
-			WriteError(w, fmt.Sprintf("Failed to create sandbox: %v", err), http.StatusInternalServerError)
+	// Poll GetSandbox or watch the stream for the progress of an asynchronous creation.
+	if deref(request.Params.Async) {
+		state, err := h.sandboxManager.CreateSandboxAsync(ctx, spaceID, spec, nil)
+		if err != nil {
+			return createError(err), nil
 		}
-		return
+		audit.SetSandboxID(ctx, state.ID)
+		return v1.CreateSandbox202JSONResponse(sandboxToV1(state)), nil
 	}
 
-	// --- Retrieve the created sandbox state to include in the response --- 
-	sandboxState, getErr := h.sandboxManager.GetSandbox(r.Context(), sandboxID)
-	if getErr != nil {
-		// This shouldn't happen right after creation, but handle defensively
-		h.logger.Error("Failed to retrieve sandbox state immediately after creation", "sandboxID", sandboxID, "error", getErr)
-		// Return 201 Created but with a warning or minimal body
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(map[string]string{
-			"sandbox_id": sandboxID, // Use snake_case for consistency?
-			"warning":    "Sandbox created, but failed to retrieve its full state.",
-		})
-		return
+	sandboxID, err := h.sandboxManager.CreateSandbox(ctx, spaceID, spec, nil)
+	if err != nil {
+		return createError(err), nil
 	}
-
-	// --- Return successful response with sandbox details --- 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated) // 201 Created
-	// Return the full sandbox state in the response
-	json.NewEncoder(w).Encode(sandboxState) // Encode the retrieved state
-}
-
-// GetSandboxHandler handles requests to retrieve a specific sandbox.
-func (h *APIHandler) GetSandboxHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	spaceID := vars["spaceID"]     // Use mux.Vars
-	sandboxID := vars["sandboxID"] // Use mux.Vars
-
-	if spaceID == "" || sandboxID == "" {
-		WriteError(w, "Missing spaceID or sandboxID in path", http.StatusBadRequest)
-		return
+	audit.SetSandboxID(ctx, sandboxID)
+	state, err := h.sandboxManager.GetSandbox(ctx, sandboxID)
+	if err != nil {
+		// Deleted right after creation; report what is known.
+		h.logger.Error("Failed to retrieve sandbox state immediately after creation", "sandboxID", sandboxID, "error", err)
+		return v1.CreateSandbox201JSONResponse{SandboxID: sandboxID, Name: &sandboxID, SpaceID: spaceID}, nil
 	}
+	return v1.CreateSandbox201JSONResponse(sandboxToV1(state)), nil
+}
 
-	// First, check if the space exists (optional but good practice)
-	_, err := h.spaceManager.GetSpace(r.Context(), spaceID)
+// ListSandboxes handles requests to list the sandboxes of a space.
+func (h *APIHandler) ListSandboxes(ctx context.Context, request v1.ListSandboxesRequestObject) (v1.ListSandboxesResponseObject, error) {
+	sandboxes, err := h.sandboxManager.ListSandboxes(ctx, request.SpaceID)
 	if err != nil {
 		if errors.Is(err, manager.ErrSpaceNotFound) {
-			WriteError(w, fmt.Sprintf("Space %s not found", spaceID), http.StatusNotFound)
-		} else {
-			h.logger.Error("Failed to get space during sandbox retrieval", "spaceID", spaceID, "error", err)
-			WriteError(w, "Failed to check space existence: "+err.Error(), http.StatusInternalServerError)
+			return v1.ListSandboxes404JSONResponse(spaceNotFound(request.SpaceID)), nil
 		}
-		return
+		h.logger.Error("Failed to list sandboxes", "spaceID", request.SpaceID, "error", err)
+		status, body := errorResponse("Failed to list sandboxes", err)
+		return v1.ListSandboxesdefaultJSONResponse{StatusCode: status, Body: body}, nil
 	}
-
-	// Get the sandbox state from the manager
-	sandboxState, err := h.sandboxManager.GetSandbox(r.Context(), sandboxID)
-	if err != nil {
-		// Check if the error indicates the sandbox wasn't found
-		// Assuming GetSandbox returns an error containing "not found" for that case.
-		// TODO: Refine manager.GetSandbox to return a specific error like ErrSandboxNotFound
-		if errors.Is(err, manager.ErrSandboxNotFound) { // Use ErrSandboxNotFound if defined in manager
-			WriteError(w, fmt.Sprintf("Sandbox %s not found in space %s", sandboxID, spaceID), http.StatusNotFound)
-		} else if strings.Contains(err.Error(), "not found") { // Fallback check
-			WriteError(w, fmt.Sprintf("Sandbox %s not found in space %s", sandboxID, spaceID), http.StatusNotFound)
-		} else {
-			h.logger.Error("Failed to get sandbox", "spaceID", spaceID, "sandboxID", sandboxID, "error", err)
-			WriteError(w, "Failed to retrieve sandbox: "+err.Error(), http.StatusInternalServerError)
-		}
-		return
+	out := make(v1.ListSandboxes200JSONResponse, len(sandboxes))
+	for i, state := range sandboxes {
+		out[i] = sandboxToV1(state)
 	}
-
-	// Check if the retrieved sandbox actually belongs to the requested space
-	if sandboxState.SpaceID != spaceID {
-		h.logger.Warn("Sandbox found but belongs to different space", "requestedSpaceID", spaceID, "actualSpaceID", sandboxState.SpaceID, "sandboxID", sandboxID)
-		WriteError(w, fmt.Sprintf("Sandbox %s not found in space %s", sandboxID, spaceID), http.StatusNotFound)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	// Encode the SandboxState (or a subset/transformed version if needed)
-	json.NewEncoder(w).Encode(sandboxState)
+	return out, nil
 }
 
-// DeleteSandboxHandler handles requests to delete an existing sandbox.
-func (h *APIHandler) DeleteSandboxHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	spaceID := vars["spaceID"]     // Use mux.Vars
-	sandboxID := vars["sandboxID"] // Use mux.Vars
-
-	if spaceID == "" || sandboxID == "" {
-		WriteError(w, "Missing spaceID or sandboxID in path", http.StatusBadRequest)
-		return
+// GetSandbox handles requests to retrieve a specific sandbox.
+func (h *APIHandler) GetSandbox(ctx context.Context, request v1.GetSandboxRequestObject) (v1.GetSandboxResponseObject, error) {
+	if _, err := h.spaceManager.GetSpace(ctx, request.SpaceID); err != nil {
+		if errors.Is(err, manager.ErrSpaceNotFound) {
+			return v1.GetSandbox404JSONResponse(spaceNotFound(request.SpaceID)), nil
+		}
+		h.logger.Error("Failed to get space during sandbox retrieval", "spaceID", request.SpaceID, "error", err)
+		status, body := errorResponse("Failed to check space existence", err)
+		return v1.GetSandboxdefaultJSONResponse{StatusCode: status, Body: body}, nil
 	}
-
-	// Optional: Check if space exists first (consistency)
-	_, spaceErr := h.spaceManager.GetSpace(r.Context(), spaceID)
-	if spaceErr != nil {
-		if errors.Is(spaceErr, manager.ErrSpaceNotFound) {
-			WriteError(w, fmt.Sprintf("Space %s not found", spaceID), http.StatusNotFound)
-		} else {
-			h.logger.Error("Failed to get space during sandbox deletion", "spaceID", spaceID, "error", spaceErr)
-			WriteError(w, "Failed to check space existence: "+spaceErr.Error(), http.StatusInternalServerError)
+	state, err := h.sandboxInSpace(ctx, request.SpaceID, request.SandboxID)
+	if err != nil {
+		if errors.Is(err, manager.ErrSandboxNotFound) {
+			return v1.GetSandbox404JSONResponse(sandboxNotFound(request.SpaceID, request.SandboxID)), nil
 		}
-		return
+		status, body := errorResponse("Failed to retrieve sandbox", err)
+		return v1.GetSandboxdefaultJSONResponse{StatusCode: status, Body: body}, nil
 	}
+	return v1.GetSandbox200JSONResponse(sandboxToV1(state)), nil
+}
 
-	// Optional: Get sandbox first to verify it belongs to the space before deleting
-	// This adds an extra check but prevents deleting a sandbox via the wrong space path.
-	sandboxState, getErr := h.sandboxManager.GetSandbox(r.Context(), sandboxID)
-	if getErr != nil {
-		// If sandbox doesn't exist at all, return 404
-		if errors.Is(getErr, manager.ErrSandboxNotFound) || strings.Contains(getErr.Error(), "not found") {
-			WriteError(w, fmt.Sprintf("Sandbox %s not found", sandboxID), http.StatusNotFound)
-		} else {
-			h.logger.Error("Failed to get sandbox before deletion", "spaceID", spaceID, "sandboxID", sandboxID, "error", getErr)
-			WriteError(w, "Failed to check sandbox before deletion: "+getErr.Error(), http.StatusInternalServerError)
+// DeleteSandbox handles requests to delete an existing sandbox.
+func (h *APIHandler) DeleteSandbox(ctx context.Context, request v1.DeleteSandboxRequestObject) (v1.DeleteSandboxResponseObject, error) {
+	spaceID, sandboxID := request.SpaceID, request.SandboxID
+	if _, err := h.spaceManager.GetSpace(ctx, spaceID); err != nil {
+		if errors.Is(err, manager.ErrSpaceNotFound) {
+			return v1.DeleteSandbox404JSONResponse(spaceNotFound(spaceID)), nil
 		}
-		return
-	}
-	if sandboxState.SpaceID != spaceID {
-		h.logger.Warn("Attempt to delete sandbox via incorrect space path", "requestedSpaceID", spaceID, "actualSpaceID", sandboxState.SpaceID, "sandboxID", sandboxID)
-		WriteError(w, fmt.Sprintf("Sandbox %s not found in space %s", sandboxID, spaceID), http.StatusNotFound)
-		return
+		h.logger.Error("Failed to get space during sandbox deletion", "spaceID", spaceID, "error", err)
+		status, body := errorResponse("Failed to check space existence", err)
+		return v1.DeleteSandboxdefaultJSONResponse{StatusCode: status, Body: body}, nil
+	}
+	// Checked first so a sandbox cannot be deleted through another space's path.
+	if _, err := h.sandboxInSpace(ctx, spaceID, sandboxID); err != nil {
+		if errors.Is(err, manager.ErrSandboxNotFound) {
+			return v1.DeleteSandbox404JSONResponse(sandboxNotFound(spaceID, sandboxID)), nil
+		}
+		status, body := errorResponse("Failed to check sandbox before deletion", err)
+		return v1.DeleteSandboxdefaultJSONResponse{StatusCode: status, Body: body}, nil
 	}
 
-	// Proceed with deletion
-	err := h.sandboxManager.DeleteSandbox(r.Context(), sandboxID)
-	if err != nil {
+	if err := h.sandboxManager.DeleteSandbox(ctx, sandboxID); err != nil {
 		h.logger.Error("Failed to delete sandbox", "spaceID", spaceID, "sandboxID", sandboxID, "error", err)
-		// Check if the error indicates the sandbox wasn't found (might be redundant due to check above, but safe)
-		// TODO: Refine manager.DeleteSandbox to return a specific ErrSandboxNotFound
-		if errors.Is(err, manager.ErrSandboxNotFound) { // Use ErrSandboxNotFound if defined in manager
-			WriteError(w, fmt.Sprintf("Sandbox %s not found", sandboxID), http.StatusNotFound)
-		} else if strings.Contains(err.Error(), "not found") { // Fallback check
-			WriteError(w, fmt.Sprintf("Sandbox %s not found", sandboxID), http.StatusNotFound)
-		} else {
-			WriteError(w, "Failed to delete sandbox: "+err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, manager.ErrSandboxNotFound) {
+			return v1.DeleteSandbox404JSONResponse(sandboxNotFound(spaceID, sandboxID)), nil
 		}
-		return
+		status, body := errorResponse("Failed to delete sandbox", err)
+		return v1.DeleteSandboxdefaultJSONResponse{StatusCode: status, Body: body}, nil
 	}
-
-	w.WriteHeader(http.StatusNoContent) // 204 No Content for successful deletion
-}
-
-// HealthCheckHandler responds with a simple OK status.
-func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	return v1.DeleteSandbox204Response{}, nil
 }
 
-// CreateSpaceHandler handles requests to create a new space.
-func (h *APIHandler) CreateSpaceHandler(w http.ResponseWriter, r *http.Request) {
-	var payload struct {
-		Name        string                 `json:"name"`
-		Description string                 `json:"description,omitempty"`
-		Metadata    map[string]interface{} `json:"metadata,omitempty"`
+// CreateSpace handles requests to create a new space.
+func (h *APIHandler) CreateSpace(ctx context.Context, request v1.CreateSpaceRequestObject) (v1.CreateSpaceResponseObject, error) {
+	body := request.Body
+	if body.Name == "" {
+		return v1.CreateSpace400JSONResponse(apiError(manager.CodeInvalidRequest, "Name is required")), nil
 	}
-
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		WriteError(w, "Invalid request body", http.StatusBadRequest)
-		return
+	// A key limited to some spaces cannot add spaces outside its restriction.
+	if key := auth.KeyFromContext(ctx); key != nil && key.Restricted() {
+		return v1.CreateSpace403JSONResponse(apiError(manager.CodeForbidden, "API key is limited to existing spaces")), nil
 	}
-
-	if payload.Name == "" {
-		WriteError(w, "Name is required", http.StatusBadRequest)
-		return
+	profile := securityProfileFromV1(body.SecurityProfile)
+	if profile != nil {
+		if !allowAdminSettings(ctx) {
+			return v1.CreateSpace403JSONResponse(apiError(manager.CodeForbidden, errSecurityProfileScope)), nil
+		}
+		if err := profile.Validate(); err != nil {
+			return v1.CreateSpace400JSONResponse(apiError(manager.CodeInvalidSecurityProfile, err.Error())), nil
+		}
+	}
+	quota := spaceQuotaFromV1(body.Quota)
+	if quota != nil {
+		if !allowAdminSettings(ctx) {
+			return v1.CreateSpace403JSONResponse(apiError(manager.CodeForbidden, errQuotaScope)), nil
+		}
+		if err := quota.Validate(); err != nil {
+			return v1.CreateSpace400JSONResponse(apiError(manager.CodeInvalidRequest, err.Error())), nil
+		}
 	}
 
-	spaceID, err := h.spaceManager.CreateSpace(r.Context(), payload.Name, payload.Description, payload.Metadata)
+	spaceID, err := h.spaceManager.CreateSpace(ctx, body.Name, deref(body.Description), deref(body.Metadata))
 	if err != nil {
 		h.logger.Error("Failed to create space", "error", err)
-		// Check if the error indicates a duplicate name
-		// Use a simple string check for now, ideally SpaceManager returns a specific error type
-		if errors.Is(err, manager.ErrSpaceNameConflict) { // Assuming ErrSpaceNameConflict exists
-			WriteError(w, "Failed to create space: "+err.Error(), http.StatusConflict) // Return 409 Conflict
-		} else {
-			WriteError(w, "Failed to create space: "+err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, manager.ErrSpaceNameConflict) {
+			return v1.CreateSpace409JSONResponse(apiError(manager.CodeNameConflict, "Failed to create space: "+err.Error())), nil
+		}
+		status, body := errorResponse("Failed to create space", err)
+		return v1.CreateSpacedefaultJSONResponse{StatusCode: status, Body: body}, nil
+	}
+	audit.SetSpaceID(ctx, spaceID)
+	if profile != nil {
+		if err := h.spaceManager.SetSecurityProfile(ctx, spaceID, profile); err != nil {
+			h.logger.Error("Failed to set security profile of new space", "spaceID", spaceID, "error", err)
+			status, body := errorResponse("Failed to set security profile", err)
+			return v1.CreateSpacedefaultJSONResponse{StatusCode: status, Body: body}, nil
+		}
+	}
+	if quota != nil {
+		if err := h.spaceManager.SetQuota(ctx, spaceID, quota); err != nil {
+			h.logger.Error("Failed to set quota of new space", "spaceID", spaceID, "error", err)
+			status, body := errorResponse("Failed to set quota", err)
+			return v1.CreateSpacedefaultJSONResponse{StatusCode: status, Body: body}, nil
 		}
-		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	// Return the created space details
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"space_id":    spaceID,
-		"name":        payload.Name,
-		"description": payload.Description,
-		"metadata":    payload.Metadata,
-	})
-}
-
-// GetSpaceHandler handles requests to get a space by ID.
-func (h *APIHandler) GetSpaceHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	spaceID := vars["spaceID"] // Use mux.Vars
-	if spaceID == "" {
-		WriteError(w, "Missing spaceID in path", http.StatusBadRequest) // Use WriteError for consistency
-		return
+	space, err := h.spaceManager.GetSpace(ctx, spaceID)
+	if err != nil {
+		status, body := errorResponse("Failed to get space", err)
+		return v1.CreateSpacedefaultJSONResponse{StatusCode: status, Body: body}, nil
 	}
+	return v1.CreateSpace201JSONResponse(spaceToV1(space)), nil
+}
 
-	space, err := h.spaceManager.GetSpace(r.Context(), spaceID)
+// GetSpace handles requests to get a space by ID.
+func (h *APIHandler) GetSpace(ctx context.Context, request v1.GetSpaceRequestObject) (v1.GetSpaceResponseObject, error) {
+	space, err := h.spaceManager.GetSpace(ctx, request.SpaceID)
 	if err != nil {
 		if errors.Is(err, manager.ErrSpaceNotFound) {
-			WriteError(w, fmt.Sprintf("Space %s not found", spaceID), http.StatusNotFound) // Use WriteError
-			return
+			return v1.GetSpace404JSONResponse(spaceNotFound(request.SpaceID)), nil
 		}
-		h.logger.Error("Failed to get space", "spaceID", spaceID, "error", err)
-		WriteError(w, fmt.Sprintf("Failed to get space: %v", err), http.StatusInternalServerError) // Use WriteError
-		return
+		h.logger.Error("Failed to get space", "spaceID", request.SpaceID, "error", err)
+		status, body := errorResponse("Failed to get space", err)
+		return v1.GetSpacedefaultJSONResponse{StatusCode: status, Body: body}, nil
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(space)
+	return v1.GetSpace200JSONResponse(spaceToV1(space)), nil
 }
 
-// ListSpacesHandler handles requests to list all spaces.
-func (h *APIHandler) ListSpacesHandler(w http.ResponseWriter, r *http.Request) {
-	spaces, err := h.spaceManager.ListSpaces(r.Context())
+// ListSpaces handles requests to list all spaces. Keys limited to some spaces
+// only see those.
+func (h *APIHandler) ListSpaces(ctx context.Context, request v1.ListSpacesRequestObject) (v1.ListSpacesResponseObject, error) {
+	spaces, err := h.spaceManager.ListSpaces(ctx)
 	if err != nil {
 		h.logger.Error("Failed to list spaces", "error", err)
-		WriteError(w, "Failed to list spaces: "+err.Error(), http.StatusInternalServerError)
-		return
+		status, body := errorResponse("Failed to list spaces", err)
+		return v1.ListSpacesdefaultJSONResponse{StatusCode: status, Body: body}, nil
+	}
+	key := auth.KeyFromContext(ctx)
+	out := make(v1.ListSpaces200JSONResponse, 0, len(spaces))
+	for _, space := range spaces {
+		if key == nil || !key.Restricted() || key.CanAccessSpace(space.ID) {
+			out = append(out, spaceToV1(space))
+		}
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(spaces)
+	return out, nil
 }
 
-// UpdateSpaceHandler handles requests to update a space.
-func (h *APIHandler) UpdateSpaceHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	spaceID := vars["spaceID"] // Use mux.Vars
-	if spaceID == "" {
-		WriteError(w, "Missing spaceID in path", http.StatusBadRequest)
-		return
+// UpdateSpace handles requests to update a space. It responds with the
+// updated space.
+func (h *APIHandler) UpdateSpace(ctx context.Context, request v1.UpdateSpaceRequestObject) (v1.UpdateSpaceResponseObject, error) {
+	spaceID, body := request.SpaceID, request.Body
+	// Left unchanged when absent; null clears the space's profile.
+	rawProfile, rawQuota := body.SecurityProfile, body.Quota
+	if len(rawProfile) > 0 && !allowAdminSettings(ctx) {
+		return v1.UpdateSpace403JSONResponse(apiError(manager.CodeForbidden, errSecurityProfileScope)), nil
+	}
+	if len(rawQuota) > 0 && !allowAdminSettings(ctx) {
+		return v1.UpdateSpace403JSONResponse(apiError(manager.CodeForbidden, errQuotaScope)), nil
+	}
+	var profile *manager.SecurityProfile
+	if len(rawProfile) > 0 && string(rawProfile) != "null" {
+		profile = &manager.SecurityProfile{}
+		if err := json.Unmarshal(rawProfile, profile); err != nil {
+			return v1.UpdateSpace400JSONResponse(apiError(manager.CodeInvalidSecurityProfile, "Invalid security_profile: "+err.Error())), nil
+		}
+		if err := profile.Validate(); err != nil {
+			return v1.UpdateSpace400JSONResponse(apiError(manager.CodeInvalidSecurityProfile, err.Error())), nil
+		}
 	}
-
-	var payload struct {
-		Description string                 `json:"description,omitempty"`
-		Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	var quota *manager.SpaceQuota
+	if len(rawQuota) > 0 && string(rawQuota) != "null" {
+		var q v1.SpaceQuota
+		if err := json.Unmarshal(rawQuota, &q); err != nil {
+			return v1.UpdateSpace400JSONResponse(apiError(manager.CodeInvalidRequest, "Invalid quota: "+err.Error())), nil
+		}
+		quota = spaceQuotaFromV1(&q)
+		if err := quota.Validate(); err != nil {
+			return v1.UpdateSpace400JSONResponse(apiError(manager.CodeInvalidRequest, err.Error())), nil
+		}
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		WriteError(w, "Invalid request body", http.StatusBadRequest)
-		return
+	if err := h.spaceManager.UpdateSpace(ctx, spaceID, deref(body.Description), deref(body.Metadata)); err != nil {
+		h.logger.Error("Failed to update space", "spaceID", spaceID, "error", err)
+		if errors.Is(err, manager.ErrSpaceNotFound) {
+			return v1.UpdateSpace404JSONResponse(spaceNotFound(spaceID)), nil
+		}
+		status, body := errorResponse("Failed to update space", err)
+		return v1.UpdateSpacedefaultJSONResponse{StatusCode: status, Body: body}, nil
+	}
+	if len(rawProfile) > 0 {
+		if err := h.spaceManager.SetSecurityProfile(ctx, spaceID, profile); err != nil {
+			h.logger.Error("Failed to update space security profile", "spaceID", spaceID, "error", err)
+			status, body := errorResponse("Failed to update security profile", err)
+			return v1.UpdateSpacedefaultJSONResponse{StatusCode: status, Body: body}, nil
+		}
+	}
+	if len(rawQuota) > 0 {
+		if err := h.spaceManager.SetQuota(ctx, spaceID, quota); err != nil {
+			h.logger.Error("Failed to update space quota", "spaceID", spaceID, "error", err)
+			status, body := errorResponse("Failed to update quota", err)
+			return v1.UpdateSpacedefaultJSONResponse{StatusCode: status, Body: body}, nil
+		}
 	}
 
-	if err := h.spaceManager.UpdateSpace(r.Context(), spaceID, payload.Description, payload.Metadata); err != nil {
-		h.logger.Error("Failed to update space", "spaceID", spaceID, "error", err)
+	space, err := h.spaceManager.GetSpace(ctx, spaceID)
+	if err != nil {
 		if errors.Is(err, manager.ErrSpaceNotFound) {
-			WriteError(w, fmt.Sprintf("Space %s not found", spaceID), http.StatusNotFound)
-		} else {
-			WriteError(w, "Failed to update space: "+err.Error(), http.StatusInternalServerError)
+			return v1.UpdateSpace404JSONResponse(spaceNotFound(spaceID)), nil
 		}
-		return
+		status, body := errorResponse("Failed to get space", err)
+		return v1.UpdateSpacedefaultJSONResponse{StatusCode: status, Body: body}, nil
 	}
+	return v1.UpdateSpace200JSONResponse(spaceToV1(space)), nil
+}
 
-	w.WriteHeader(http.StatusNoContent)
+const (
+	errSecurityProfileScope = "Setting a security profile requires the admin scope"
+	errQuotaScope           = "Setting a quota requires the admin scope"
+)
+
+// allowAdminSettings reports whether the request may set a security profile or
+// quota. Only admin keys may, as a profile can lift the server's container
+// hardening and a space's own keys must not raise its quota.
+func allowAdminSettings(ctx context.Context) bool {
+	key := auth.KeyFromContext(ctx)
+	return key == nil || key.Allows(auth.ScopeAdmin, "")
 }
 
-// DeleteSpaceHandler handles requests to delete a space and its sandboxes.
-func (h *APIHandler) DeleteSpaceHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	spaceID := vars["spaceID"] // Use mux.Vars
-	if spaceID == "" {
-		WriteError(w, "Missing spaceID in path", http.StatusBadRequest)
-		return
+// DeleteSpace handles requests to delete a space and its sandboxes. Space
+// volumes are deleted too unless ?retain_volumes=true.
+func (h *APIHandler) DeleteSpace(ctx context.Context, request v1.DeleteSpaceRequestObject) (v1.DeleteSpaceResponseObject, error) {
+	if err := h.sandboxManager.DeleteSpace(ctx, request.SpaceID, deref(request.Params.RetainVolumes)); err != nil {
+		h.logger.Error("Failed to delete space", "spaceID", request.SpaceID, "error", err)
+		if errors.Is(err, manager.ErrSpaceNotFound) {
+			return v1.DeleteSpace404JSONResponse(spaceNotFound(request.SpaceID)), nil
+		}
+		status, body := errorResponse("Failed to delete space", err)
+		return v1.DeleteSpacedefaultJSONResponse{StatusCode: status, Body: body}, nil
 	}
+	return v1.DeleteSpace204Response{}, nil
+}
 
-	err := h.spaceManager.DeleteSpace(r.Context(), spaceID)
+// GetSpaceUsage handles requests for what a space's sandboxes currently use.
+func (h *APIHandler) GetSpaceUsage(ctx context.Context, request v1.GetSpaceUsageRequestObject) (v1.GetSpaceUsageResponseObject, error) {
+	query := usageQueryFromV1(request.Params, time.Now())
+	history, err := h.sandboxManager.SpaceUsageHistory(ctx, request.SpaceID, query)
 	if err != nil {
-		h.logger.Error("Failed to delete space", "spaceID", spaceID, "error", err)
-		if errors.Is(err, manager.ErrSpaceNotFound) {
-			WriteError(w, fmt.Sprintf("Space %s not found", spaceID), http.StatusNotFound)
-		} else {
-			WriteError(w, "Failed to delete space: "+err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, manager.ErrSpaceNotFound):
+			return v1.GetSpaceUsage404JSONResponse(spaceNotFound(request.SpaceID)), nil
+		case errors.Is(err, manager.ErrInvalidUsageQuery):
+			return v1.GetSpaceUsage400JSONResponse(apiError(manager.CodeInvalidRequest, err.Error())), nil
 		}
-		return
+		h.logger.Error("Failed to get space usage history", "spaceID", request.SpaceID, "error", err)
+		status, body := errorResponse("Failed to get space usage history", err)
+		return v1.GetSpaceUsagedefaultJSONResponse{StatusCode: status, Body: body}, nil
 	}
+	if request.Params.Format != nil && *request.Params.Format == v1.Csv {
+		return v1.GetSpaceUsage200TextCsvResponse{Body: usageCSV(history)}, nil
+	}
+
+	usage, err := h.sandboxManager.SpaceUsage(ctx, request.SpaceID)
+	if err != nil {
+		if errors.Is(err, manager.ErrSpaceNotFound) {
+			return v1.GetSpaceUsage404JSONResponse(spaceNotFound(request.SpaceID)), nil
+		}
+		h.logger.Error("Failed to get space usage", "spaceID", request.SpaceID, "error", err)
+		status, body := errorResponse("Failed to get space usage", err)
+		return v1.GetSpaceUsagedefaultJSONResponse{StatusCode: status, Body: body}, nil
+	}
+	buckets := make([]v1.UsageBucket, len(history))
+	for i, b := range history {
+		buckets[i] = usageBucketToV1(b)
+	}
+	return v1.GetSpaceUsage200JSONResponse{
+		Sandboxes:         usage.Sandboxes,
+		ConcurrentActions: usage.ConcurrentActions,
+		Cpus:              usage.CPUs,
+		MemoryMb:          usage.MemoryMB,
+		Quota:             spaceQuotaToV1(usage.Quota),
+		History:           buckets,
+	}, nil
+}
 
-	w.WriteHeader(http.StatusNoContent) // 204 No Content for successful deletion
-}
\ No newline at end of file
+// ListAuditEntries handles requests to read the audit log.
+func (h *APIHandler) ListAuditEntries(ctx context.Context, request v1.ListAuditEntriesRequestObject) (v1.ListAuditEntriesResponseObject, error) {
+	entries, err := h.audit.Query(auditQueryFromV1(request.Params))
+	if err != nil {
+		if errors.Is(err, audit.ErrInvalidQuery) {
+			return v1.ListAuditEntries400JSONResponse(apiError(manager.CodeInvalidRequest, err.Error())), nil
+		}
+		h.logger.Error("Failed to query audit log", "error", err)
+		status, body := errorResponse("Failed to query audit log", err)
+		return v1.ListAuditEntriesdefaultJSONResponse{StatusCode: status, Body: body}, nil
+	}
+	out := make(v1.ListAuditEntries200JSONResponse, len(entries))
+	for i, e := range entries {
+		out[i] = auditEntryToV1(e)
+	}
+	return out, nil
+}
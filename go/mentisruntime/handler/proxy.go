@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/manager"
+	"github.com/gorilla/mux"
+)
+
+// ProxyPortHandler forwards requests under
+// /spaces/{spaceID}/sandboxes/{sandboxID}/ports/{port}/ to a service listening on
+// that port inside the sandbox. WebSocket upgrades are passed through. The
+// service sees the path below the prefix and the prefix in X-Forwarded-Prefix.
+func (h *APIHandler) ProxyPortHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID := vars["space_id"]
+	sandboxID := vars["sandbox_id"]
+	port, err := strconv.Atoi(vars["port"])
+	if spaceID == "" || sandboxID == "" || err != nil {
+		WriteError(w, manager.CodeInvalidRequest, "Missing spaceID, sandboxID or port in path")
+		return
+	}
+
+	prefix := proxyPrefix(r, vars)
+	if r.URL.Path == prefix {
+		// Relative links in the service's pages resolve against the directory.
+		target := prefix + "/"
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return
+	}
+
+	target, err := h.sandboxManager.ServiceTarget(r.Context(), spaceID, sandboxID, port)
+	if err != nil {
+		switch code := manager.CodeOf(err); code {
+		case manager.CodeSandboxNotFound:
+			writeBody(w, http.StatusNotFound, sandboxNotFound(spaceID, sandboxID))
+		case manager.CodePortNotExposed:
+			WriteError(w, code, fmt.Sprintf("Port %d is not exposed by sandbox %s", port, sandboxID))
+		case manager.CodeSandboxNotRunning:
+			WriteError(w, code, fmt.Sprintf("Sandbox %s is not running", sandboxID))
+		default:
+			h.logger.Error("Failed to resolve sandbox service port", "spaceID", spaceID, "sandboxID", sandboxID, "port", port, "error", err)
+			writeFailure(w, "Failed to resolve sandbox port", err)
+		}
+		return
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.Out.URL.Path = strings.TrimPrefix(pr.In.URL.Path, prefix)
+			pr.Out.URL.RawPath = ""
+			pr.SetXForwarded()
+			pr.Out.Header.Set("X-Forwarded-Prefix", prefix)
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			h.logger.Warn("Sandbox port proxy failed", "sandboxID", sandboxID, "port", port, "error", err)
+			WriteError(w, manager.CodeServiceUnreachable, fmt.Sprintf("Service on port %d is unavailable: %v", port, err))
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// proxyPrefix returns the path the proxy route matched, without a trailing slash.
+func proxyPrefix(r *http.Request, vars map[string]string) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		u, err := route.URLPath("space_id", vars["space_id"], "sandbox_id", vars["sandbox_id"], "port", vars["port"])
+		if err == nil {
+			return strings.TrimSuffix(u.Path, "/")
+		}
+	}
+	// Without route information, cut the path after the port segment.
+	marker := "/ports/" + vars["port"]
+	if i := strings.Index(r.URL.Path, marker); i >= 0 {
+		return r.URL.Path[:i+len(marker)]
+	}
+	return r.URL.Path
+}
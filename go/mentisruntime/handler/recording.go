@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/manager"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/recording"
+)
+
+// errRecordingFound stops reading a recording once it is known to exist.
+var errRecordingFound = errors.New("recording found")
+
+// GetRecordingHandler serves the recorded session of a sandbox as JSON lines,
+// or with format=asciicast as an asciicast v2 file of its shell output.
+func (h *APIHandler) GetRecordingHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID, sandboxID := vars["space_id"], vars["sandbox_id"]
+	format := r.URL.Query().Get("format")
+	var write func(recording.Entry) error
+	var finish func() error
+	var contentType string
+	switch format {
+	case "", "jsonl":
+		contentType = "application/x-ndjson"
+		enc := json.NewEncoder(w)
+		write = func(e recording.Entry) error { return enc.Encode(e) }
+		finish = func() error { return nil }
+	case "asciicast":
+		contentType = "application/x-asciicast"
+		cast := recording.NewAsciicastWriter(w, "Sandbox "+sandboxID)
+		write, finish = cast.Write, cast.Close
+	default:
+		WriteError(w, manager.CodeInvalidRequest, "Query parameter format must be jsonl or asciicast")
+		return
+	}
+
+	if err := h.checkRecording(r.Context(), w, spaceID, sandboxID); err != nil {
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", recordingFilename(sandboxID, format)))
+	err := h.sandboxManager.ReadRecording(r.Context(), spaceID, sandboxID, write)
+	if err == nil {
+		err = finish()
+	}
+	if err != nil {
+		// The response has started; the client sees a truncated recording.
+		h.logger.Error("Failed to send recording", "spaceID", spaceID, "sandboxID", sandboxID, "error", err)
+	}
+}
+
+// ReplayRecordingHandler sends the recorded observations of a sandbox over a
+// WebSocket with their recorded pauses, scaled by the speed query parameter
+// and capped by max_idle, then closes the connection.
+func (h *APIHandler) ReplayRecordingHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID, sandboxID := vars["space_id"], vars["sandbox_id"]
+	replayer := recording.Replayer{Speed: 1}
+	if s := r.URL.Query().Get("speed"); s != "" {
+		speed, err := strconv.ParseFloat(s, 64)
+		if err != nil || speed <= 0 {
+			WriteError(w, manager.CodeInvalidRequest, "Query parameter speed must be a positive number")
+			return
+		}
+		replayer.Speed = speed
+	}
+	if s := r.URL.Query().Get("max_idle"); s != "" {
+		seconds, err := strconv.ParseFloat(s, 64)
+		if err != nil || seconds < 0 {
+			WriteError(w, manager.CodeInvalidRequest, "Query parameter max_idle must be a non-negative number")
+			return
+		}
+		replayer.MaxIdle = time.Duration(seconds * float64(time.Second))
+	}
+	if err := h.checkRecording(r.Context(), w, spaceID, sandboxID); err != nil {
+		return
+	}
+
+	// Like terminals, replays are for API clients, authorized by their key.
+	conn, err := terminalUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.logger.Error("Failed to upgrade replay connection", "sandboxID", sandboxID, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// The replay stops when the client goes away.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	err = h.sandboxManager.ReadRecording(ctx, spaceID, sandboxID, func(e recording.Entry) error {
+		if err := replayer.Next(ctx, e); err != nil {
+			return err
+		}
+		return conn.WriteMessage(websocket.TextMessage, e.Observation)
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			h.logger.Error("Failed to replay recording", "spaceID", spaceID, "sandboxID", sandboxID, "error", err)
+		}
+		return
+	}
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	h.logger.Info("Recording replayed", "sandboxID", sandboxID, "speed", replayer.Speed)
+}
+
+// checkRecording writes the error response and returns an error unless the
+// sandbox has a recording in the space.
+func (h *APIHandler) checkRecording(ctx context.Context, w http.ResponseWriter, spaceID, sandboxID string) error {
+	err := h.sandboxManager.ReadRecording(ctx, spaceID, sandboxID, func(recording.Entry) error { return errRecordingFound })
+	switch {
+	case err == nil, errors.Is(err, errRecordingFound):
+		return nil
+	case errors.Is(err, manager.ErrSpaceNotFound):
+		writeBody(w, http.StatusNotFound, spaceNotFound(spaceID))
+	case errors.Is(err, recording.ErrNotFound):
+		WriteError(w, manager.CodeNotFound, fmt.Sprintf("No recording of sandbox %s in space %s", sandboxID, spaceID))
+	default:
+		h.logger.Error("Failed to read recording", "spaceID", spaceID, "sandboxID", sandboxID, "error", err)
+		writeFailure(w, "Failed to read recording", err)
+	}
+	return err
+}
+
+func recordingFilename(sandboxID, format string) string {
+	if format == "asciicast" {
+		return sandboxID + ".cast"
+	}
+	return sandboxID + ".jsonl"
+}
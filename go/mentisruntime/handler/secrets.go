@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	v1 "github.com/foreveryh/sandboxai/go/api/v1"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/manager"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/secrets"
+)
+
+// ListSecrets handles requests to list the secrets of a space. Values are
+// never returned.
+func (h *APIHandler) ListSecrets(ctx context.Context, request v1.ListSecretsRequestObject) (v1.ListSecretsResponseObject, error) {
+	list, err := h.sandboxManager.ListSecrets(ctx, request.SpaceID)
+	if err != nil {
+		if errors.Is(err, manager.ErrSpaceNotFound) {
+			return v1.ListSecrets404JSONResponse(spaceNotFound(request.SpaceID)), nil
+		}
+		h.logger.Error("Failed to list secrets", "spaceID", request.SpaceID, "error", err)
+		status, body := errorResponse("Failed to list secrets", err)
+		return v1.ListSecretsdefaultJSONResponse{StatusCode: status, Body: body}, nil
+	}
+	out := make(v1.ListSecrets200JSONResponse, len(list))
+	for i, s := range list {
+		out[i] = secretToV1(s)
+	}
+	return out, nil
+}
+
+// SetSecret handles requests to create or replace a secret of a space.
+func (h *APIHandler) SetSecret(ctx context.Context, request v1.SetSecretRequestObject) (v1.SetSecretResponseObject, error) {
+	secret, created, err := h.sandboxManager.SetSecret(ctx, request.SpaceID, request.Name, request.Body.Value)
+	if err != nil {
+		switch {
+		case errors.Is(err, manager.ErrSpaceNotFound):
+			return v1.SetSecret404JSONResponse(spaceNotFound(request.SpaceID)), nil
+		case errors.Is(err, secrets.ErrInvalidName), errors.Is(err, secrets.ErrInvalidValue):
+			return v1.SetSecret400JSONResponse(apiError(manager.CodeInvalidRequest, err.Error())), nil
+		}
+		h.logger.Error("Failed to set secret", "spaceID", request.SpaceID, "name", request.Name, "error", err)
+		status, body := errorResponse("Failed to set secret", err)
+		return v1.SetSecretdefaultJSONResponse{StatusCode: status, Body: body}, nil
+	}
+	if created {
+		return v1.SetSecret201JSONResponse(secretToV1(secret)), nil
+	}
+	return v1.SetSecret200JSONResponse(secretToV1(secret)), nil
+}
+
+// DeleteSecret handles requests to delete a secret of a space.
+func (h *APIHandler) DeleteSecret(ctx context.Context, request v1.DeleteSecretRequestObject) (v1.DeleteSecretResponseObject, error) {
+	if err := h.sandboxManager.DeleteSecret(ctx, request.SpaceID, request.Name); err != nil {
+		switch {
+		case errors.Is(err, manager.ErrSpaceNotFound):
+			return v1.DeleteSecret404JSONResponse(spaceNotFound(request.SpaceID)), nil
+		case errors.Is(err, secrets.ErrNotFound):
+			return v1.DeleteSecret404JSONResponse(apiError(manager.CodeNotFound, "Secret "+request.Name+" not found")), nil
+		}
+		h.logger.Error("Failed to delete secret", "spaceID", request.SpaceID, "name", request.Name, "error", err)
+		status, body := errorResponse("Failed to delete secret", err)
+		return v1.DeleteSecretdefaultJSONResponse{StatusCode: status, Body: body}, nil
+	}
+	return v1.DeleteSecret204Response{}, nil
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/audit"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+// TerminalMessage is a control message on a terminal WebSocket, sent as a text
+// frame. Terminal input and output travel in binary frames.
+type TerminalMessage struct {
+	Type     string `json:"type"`                // "resize" from the client, "exit" from the server
+	Rows     uint   `json:"rows,omitempty"`      // resize
+	Cols     uint   `json:"cols,omitempty"`      // resize
+	ExitCode *int   `json:"exit_code,omitempty"` // exit
+}
+
+var terminalUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	// API clients are not browsers; access is controlled by API keys.
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// TerminalHandler serves an interactive shell in a sandbox over a WebSocket.
+// The initial size comes from the rows and cols query parameters. When the
+// shell exits the server sends an exit message and closes the connection.
+func (h *APIHandler) TerminalHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID, sandboxID := vars["space_id"], vars["sandbox_id"]
+	rows, _ := strconv.ParseUint(r.URL.Query().Get("rows"), 10, 16)
+	cols, _ := strconv.ParseUint(r.URL.Query().Get("cols"), 10, 16)
+	audit.Describe(r.Context(), "openSandboxTerminal", map[string]uint64{"rows": rows, "cols": cols})
+
+	// The terminal outlives the request context once the connection is hijacked.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	term, err := h.sandboxManager.OpenTerminal(ctx, spaceID, sandboxID, uint(rows), uint(cols))
+	if err != nil {
+		h.writeSandboxAccessError(w, err, spaceID, sandboxID, "open terminal")
+		return
+	}
+	defer term.Close()
+
+	conn, err := terminalUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.logger.Error("Failed to upgrade terminal connection", "sandboxID", sandboxID, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// Input and control messages from the client.
+	go func() {
+		defer term.Close()
+		for {
+			msgType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if msgType == websocket.BinaryMessage {
+				if _, err := term.Write(data); err != nil {
+					return
+				}
+				continue
+			}
+			var msg TerminalMessage
+			if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "resize" {
+				h.logger.Warn("Ignoring invalid terminal message", "sandboxID", sandboxID, "message", string(data))
+				continue
+			}
+			if err := term.Resize(ctx, msg.Rows, msg.Cols); err != nil {
+				h.logger.Warn("Failed to resize terminal", "sandboxID", sandboxID, "error", err)
+			}
+		}
+	}()
+
+	// Output until the shell exits or the client goes away.
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := term.Read(buf)
+		if n > 0 {
+			if werr := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	exit := TerminalMessage{Type: "exit"}
+	exitCtx, cancelExit := context.WithTimeout(ctx, 2*time.Second)
+	defer cancelExit()
+	if code, err := term.ExitCode(exitCtx); err == nil {
+		exit.ExitCode = &code
+	}
+	if data, err := json.Marshal(exit); err == nil {
+		conn.WriteMessage(websocket.TextMessage, data)
+	}
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	h.logger.Info("Terminal closed", "sandboxID", sandboxID)
+}
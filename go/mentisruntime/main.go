@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"log" // Import standard log package
 	"log/slog"
@@ -11,16 +12,25 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"strings"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/docker/docker/client" // Docker client
 	"github.com/gorilla/mux"          // HTTP router
+	"github.com/sethvargo/go-envconfig"
+	"gopkg.in/yaml.v3"
 
 	// Local packages (adjust paths if necessary)
+	"github.com/foreveryh/sandboxai/go/mentisruntime/audit"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/auth"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/config"
 	"github.com/foreveryh/sandboxai/go/mentisruntime/handler"
 	"github.com/foreveryh/sandboxai/go/mentisruntime/manager"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/metrics"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/recording"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/secrets"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/tracing"
 	"github.com/foreveryh/sandboxai/go/mentisruntime/ws"
 
 	// Specific client for cleanup, separate from the manager's client
@@ -28,46 +38,193 @@ import (
 )
 
 func main() {
-	// --- Configuration --- 
-	host, ok := os.LookupEnv("SANDBOXAID_HOST")
-	if !ok {
-		host = "127.0.0.1"
+	configPath := flag.String("config", os.Getenv("SANDBOXAID_CONFIG"), "path of a YAML configuration file")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration and exit")
+	flag.Parse()
+
+	// --- Configuration ---
+	// Defaults, then the config file, then SANDBOXAID_* environment overrides.
+	cfg, err := config.Load(context.Background(), *configPath, envconfig.OsLookuper())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-	port, ok := os.LookupEnv("SANDBOXAID_PORT")
-	if !ok {
-		port = "5266"
+	if *printConfig {
+		out, err := yaml.Marshal(cfg.Redacted())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(out)
+		return
 	}
-	scope, ok := os.LookupEnv("SANDBOXAID_SCOPE")
-	if !ok {
-		scope = "default"
+
+	// --- Logger ---
+	var level slog.Level
+	level.UnmarshalText([]byte(cfg.Logging.Level)) // Validated by config.Load
+	logOpts := &slog.HandlerOptions{Level: level}
+	var logHandler slog.Handler = slog.NewJSONHandler(os.Stderr, logOpts)
+	if cfg.Logging.Format == "text" {
+		logHandler = slog.NewTextHandler(os.Stderr, logOpts)
+	}
+	logger := slog.New(logHandler)
+	slog.SetDefault(logger)
+	if *configPath != "" {
+		logger.Info("Configuration loaded", "path", *configPath)
 	}
-	var deleteOnShutdown bool
-	if val, ok := os.LookupEnv("SANDBOXAID_DELETE_ON_SHUTDOWN"); ok {
-		deleteOnShutdown = strings.ToLower(strings.TrimSpace(val)) == "true"
+	for _, warning := range cfg.Warnings() {
+		logger.Warn("Deprecated configuration", "warning", warning)
 	}
 
-	// --- Logger --- 
-	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	slog.SetDefault(logger)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter, cfg.Tracing.Endpoint)
+	if err != nil {
+		logger.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	if cfg.Tracing.Exporter != tracing.ExporterNone {
+		logger.Info("Tracing enabled", "exporter", cfg.Tracing.Exporter)
+	}
+
+	managerOpts := []manager.Option{
+		manager.WithDefaultImage(cfg.DefaultImage),
+		manager.WithAgentReadyTimeout(cfg.Timeouts.AgentReady),
+		manager.WithSandboxLimits(cfg.Limits.MaxSandboxes, cfg.Limits.MaxSandboxesPerSpace),
+		manager.WithAgentBindAddress(cfg.Sandbox.AgentBindAddress),
+		manager.WithCallbackHost(cfg.Callback.Host),
+	}
+	if len(cfg.Pools) > 0 {
+		managerOpts = append(managerOpts, manager.WithWarmPool(cfg.Pools.Manager()...))
+	}
+	if cfg.Timeouts.StatusRefreshInterval > 0 {
+		managerOpts = append(managerOpts, manager.WithStatusRefresh(cfg.Timeouts.StatusRefreshInterval))
+	}
+	if cfg.Sandbox.SecurityProfile != "" {
+		profile, err := manager.LoadSecurityProfile(cfg.Sandbox.SecurityProfile)
+		if err != nil {
+			logger.Error("Invalid security profile", "error", err)
+			os.Exit(1)
+		}
+		managerOpts = append(managerOpts, manager.WithSecurityProfile(profile))
+	}
+	if len(cfg.Sandbox.BindMountAllowlist) > 0 {
+		managerOpts = append(managerOpts, manager.WithBindMountAllowlist(cfg.Sandbox.BindMountAllowlist...))
+	}
+	if cfg.Sandbox.RestartPolicy != "" {
+		policy, _ := manager.ParseRestartPolicy(cfg.Sandbox.RestartPolicy) // Validated by config.Load
+		managerOpts = append(managerOpts, manager.WithRestartPolicy(policy))
+	}
+	if cfg.Callback.URL != "" {
+		managerOpts = append(managerOpts, manager.WithCallbackURL(cfg.Callback.URL))
+	}
+	usageMeter, err := manager.NewUsageMeter(cfg.Usage.File, cfg.Usage.Retention)
+	if err != nil {
+		logger.Error("Invalid usage file", "error", err)
+		os.Exit(1)
+	}
+	managerOpts = append(managerOpts, manager.WithUsageMeter(usageMeter, cfg.Usage.SampleInterval), manager.WithUsageFlushInterval(cfg.Usage.FlushInterval))
+	mx := metrics.New()
+	managerOpts = append(managerOpts, manager.WithMetrics(mx))
+
+	// Audit log of mutating operations and actions, in memory unless a file is set
+	var auditSink audit.Sink = audit.NewMemorySink(cfg.Audit.MemoryEntries)
+	if cfg.Audit.File != "" {
+		fileSink, err := audit.NewFileSink(cfg.Audit.File)
+		if err != nil {
+			logger.Error("Invalid audit file", "error", err)
+			os.Exit(1)
+		}
+		defer fileSink.Close()
+		auditSink = fileSink
+	}
+	redactor, _ := audit.NewRedactor(cfg.Audit.RedactKeys, cfg.Audit.RedactPatterns) // Validated by config.Load
+	auditLog := audit.New(auditSink, redactor, logger)
+	managerOpts = append(managerOpts, manager.WithAuditLog(auditLog))
+
+	// Secrets of spaces, encrypted with the configured key
+	var secretsKey []byte
+	if cfg.Secrets.Key != "" {
+		secretsKey, _ = secrets.ParseKey(cfg.Secrets.Key) // Validated by config.Load
+	}
+	secretStore, err := secrets.NewStore(cfg.Secrets.File, secretsKey)
+	if err != nil {
+		logger.Error("Invalid secrets file", "error", err)
+		os.Exit(1)
+	}
+	managerOpts = append(managerOpts, manager.WithSecrets(secretStore))
+
+	// Session recordings, when a directory is set
+	if cfg.Recording.Dir != "" {
+		recorder, err := recording.NewRecorder(cfg.Recording.Dir)
+		if err != nil {
+			logger.Error("Invalid recording directory", "error", err)
+			os.Exit(1)
+		}
+		defer recorder.Close()
+		managerOpts = append(managerOpts, manager.WithRecorder(recorder))
+	}
+
+	// Listen before starting the manager so agents are told the port actually bound,
+	// which differs from the configured one when that is 0.
+	listenAddr := net.JoinHostPort(cfg.Listen.Host, strconv.Itoa(cfg.Listen.Port))
+	ln, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		logger.Error("Failed to listen", "address", listenAddr, "error", err)
+		os.Exit(1)
+	}
+	callbackLn := ln
+
+	// Serve agent callbacks on a separate listener so they need not share an
+	// address with the public API.
+	var internalLn net.Listener
+	if cfg.Listen.InternalAddr != "" {
+		internalLn, err = net.Listen("tcp", cfg.Listen.InternalAddr)
+		if err != nil {
+			logger.Error("Failed to listen on internal address", "error", err, "address", cfg.Listen.InternalAddr)
+			os.Exit(1)
+		}
+		callbackLn = internalLn
+	}
+	managerOpts = append(managerOpts, manager.WithCallbackPort(strconv.Itoa(callbackLn.Addr().(*net.TCPAddr).Port)))
+
+	// API keys: the keys file persists keys created through the API and the admin
+	// key is a bootstrap key. Authentication is enabled when either is set.
+	var keyStore *auth.Store
+	if cfg.Auth.Enabled() {
+		keyStore, err = auth.NewStore(cfg.Auth.APIKeysFile)
+		if err != nil {
+			logger.Error("Invalid API keys file", "error", err)
+			os.Exit(1)
+		}
+		if cfg.Auth.AdminAPIKey != "" {
+			if err := keyStore.AddStatic("admin", cfg.Auth.AdminAPIKey, auth.ScopeAdmin); err != nil {
+				logger.Error("Invalid admin API key", "error", err)
+				os.Exit(1)
+			}
+		}
+		logger.Info("API key authentication enabled", "keys", len(keyStore.List()))
+	} else if ip := net.ParseIP(cfg.Listen.Host); ip == nil || !ip.IsLoopback() {
+		logger.Warn("API key authentication is disabled while listening on a non-loopback address", "host", cfg.Listen.Host)
+	}
 
 	// --- Initialize Managers ---
 	// Create Docker client
-	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation(), tracing.DockerClientOption())
 	if err != nil {
 		logger.Error("Failed to create Docker client", "error", err)
 		os.Exit(1)
 	}
 	logger.Info("Docker client initialized")
-	
+
 	// Create WebSocket hub
 	hub := ws.NewHub(logger)
+	hub.SetMetrics(mx)
 	go hub.Run()
 	logger.Info("WebSocket hub started")
 
 	// Create Space Manager first
 	spaceManager := manager.NewSpaceManager(logger)
 	logger.Info("Space manager initialized")
-	
+
 	// Create Sandbox Manager (depends on Space Manager)
 	sandboxManager, err := manager.NewSandboxManager(
 		context.Background(),
@@ -75,7 +232,8 @@ func main() {
 		hub,
 		spaceManager, // Add SpaceManager parameter
 		logger,
-		os.Getenv("SANDBOX_SCOPE"),
+		cfg.Scope,
+		managerOpts...,
 	)
 	if err != nil {
 		logger.Error("Failed to create sandbox manager", "error", err)
@@ -84,48 +242,67 @@ func main() {
 	logger.Info("Sandbox manager initialized")
 
 	// --- Initialize API Handler ---
-	apiHandler := handler.NewAPIHandler(logger, sandboxManager, spaceManager, hub)
+	apiHandler := handler.NewAPIHandler(logger, sandboxManager, spaceManager, hub, keyStore, auditLog)
 	logger.Info("API handler initialized")
 
-	// --- Router --- 
+	// --- Router ---
 	router := mux.NewRouter()
+	router.Use(tracing.Middleware, mx.Middleware)
 
-	// Register handlers
-	api := router.PathPrefix("/v1").Subrouter()
-	api.HandleFunc("/health", handler.HealthCheckHandler).Methods("GET")
+	// Health checks and agent callbacks are not authenticated with API keys
+	if keyStore != nil {
+		router.Use(auth.Middleware(keyStore, logger, "/v1/health", "/v1/internal/"))
+	}
 
-	// Space routes (using chi style params)
-	api.HandleFunc("/spaces", apiHandler.CreateSpaceHandler).Methods("POST")
-	api.HandleFunc("/spaces", apiHandler.ListSpacesHandler).Methods("GET")
-	api.HandleFunc("/spaces/{spaceID}", apiHandler.GetSpaceHandler).Methods("GET")
-	api.HandleFunc("/spaces/{spaceID}", apiHandler.UpdateSpaceHandler).Methods("PUT")
-	api.HandleFunc("/spaces/{spaceID}", apiHandler.DeleteSpaceHandler).Methods("DELETE")
+	// Metrics are labelled with space IDs, so they need the admin scope when API keys are required
+	router.Handle("/metrics", auth.RequireSpace(auth.ScopeAdmin, func(*http.Request) string { return "" }, mx.Handler().ServeHTTP)).Methods("GET")
 
-	// Sandbox routes (associated with a space, using chi style params)
-	api.HandleFunc("/spaces/{spaceID}/sandboxes", apiHandler.CreateSandboxHandler).Methods("POST")
-	api.HandleFunc("/spaces/{spaceID}/sandboxes/{sandboxID}", apiHandler.GetSandboxHandler).Methods("GET")    // Added GET sandbox
-	api.HandleFunc("/spaces/{spaceID}/sandboxes/{sandboxID}", apiHandler.DeleteSandboxHandler).Methods("DELETE") // Corrected DELETE sandbox path
+	// Operations of api/v1.yaml, served by the generated strict server
+	api := router.PathPrefix("/v1").Subrouter()
+	api.Use(auditLog.Middleware)
+	apiHandler.RegisterRoutes(api)
 
-	// Action routes (associated with a specific sandbox)
-	api.HandleFunc("/spaces/{spaceID}/sandboxes/{sandboxID}/tools:run_shell_command", apiHandler.PostShellCommandHandler).Methods("POST") // Corrected shell path
-	api.HandleFunc("/spaces/{spaceID}/sandboxes/{sandboxID}/tools:run_ipython_cell", apiHandler.PostIPythonCellHandler).Methods("POST") // Corrected ipython path
+	// Container access: files, logs and an interactive terminal
+	api.Handle("/spaces/{space_id}/sandboxes/{sandbox_id}/files", auth.Require(auth.ScopeExec, apiHandler.GetFilesHandler)).Methods("GET")
+	api.Handle("/spaces/{space_id}/sandboxes/{sandbox_id}/files", auth.Require(auth.ScopeExec, apiHandler.PutFilesHandler)).Methods("PUT")
+	api.Handle("/spaces/{space_id}/sandboxes/{sandbox_id}/logs", auth.Require(auth.ScopeSpaceRead, apiHandler.GetLogsHandler)).Methods("GET")
+	api.Handle("/spaces/{space_id}/sandboxes/{sandbox_id}/terminal", auth.Require(auth.ScopeExec, apiHandler.TerminalHandler)).Methods("GET")
+	api.Handle("/spaces/{space_id}/sandboxes/{sandbox_id}/recording", auth.Require(auth.ScopeSpaceRead, apiHandler.GetRecordingHandler)).Methods("GET")
+	api.Handle("/spaces/{space_id}/sandboxes/{sandbox_id}/recording/replay", auth.Require(auth.ScopeSpaceRead, apiHandler.ReplayRecordingHandler)).Methods("GET")
 
-	// Internal Observation Route
-	api.HandleFunc("/internal/observations/{sandboxID}", apiHandler.InternalObservationHandler).Methods("POST") // Changed to sandboxID
+	// Service port proxy, matched on any method for HTTP and WebSocket pass-through
+	api.PathPrefix("/spaces/{space_id}/sandboxes/{sandbox_id}/ports/{port:[0-9]+}").Handler(auth.Require(auth.ScopeExec, apiHandler.ProxyPortHandler))
+
+	// Internal Observation Route, authenticated with per-sandbox observation tokens
+	var internalServer *http.Server
+	if internalLn != nil {
+		internalRouter := mux.NewRouter()
+		internalRouter.Use(tracing.Middleware, mx.Middleware)
+		internalRouter.HandleFunc("/v1/internal/observations/{sandbox_id}", apiHandler.InternalObservationHandler).Methods("POST")
+		internalRouter.HandleFunc("/v1/internal/ping", handler.HealthCheckHandler).Methods("GET")
+		internalServer = &http.Server{Addr: internalLn.Addr().String(), Handler: internalRouter, ReadHeaderTimeout: cfg.Timeouts.ReadHeader, IdleTimeout: cfg.Timeouts.Idle}
+	} else {
+		api.HandleFunc("/internal/observations/{sandbox_id}", apiHandler.InternalObservationHandler).Methods("POST")
+		api.HandleFunc("/internal/ping", handler.HealthCheckHandler).Methods("GET")
+	}
 
 	// WebSocket Route (associated with a specific sandbox)
-	router.HandleFunc("/v1/sandboxes/{sandboxID}/stream", func(w http.ResponseWriter, r *http.Request) { // Changed to sandboxID
+	sandboxSpace := func(r *http.Request) string {
+		spaceID, _ := sandboxManager.SandboxSpace(mux.Vars(r)["sandbox_id"])
+		return spaceID
+	}
+	router.Handle("/v1/sandboxes/{sandbox_id}/stream", auth.RequireSpace(auth.ScopeSpaceRead, sandboxSpace, func(w http.ResponseWriter, r *http.Request) {
 		// Assuming ServeWs signature: hub, checker, w, r, logger
 		// Pass sandboxManager as it implements the SandboxChecker interface
 		ws.ServeWs(hub, sandboxManager, w, r, logger)
-	})
+	}))
 
-	// --- Cleanup Logic (using separate, original client) --- 
-	if deleteOnShutdown {
+	// --- Cleanup Logic (using separate, original client) ---
+	if cfg.DeleteOnShutdown {
 		defer func() {
 			logger.Info("Cleanup: Ensuring all sandboxes are deleted")
 			// Use the original docker client specifically for cleanup as manager might not expose ListAll
-			cleanupClient, cleanupErr := cleanupdocker.NewSandboxClient(nil, &http.Client{}, scope)
+			cleanupClient, cleanupErr := cleanupdocker.NewSandboxClient(nil, &http.Client{}, cfg.Scope)
 			if cleanupErr != nil {
 				logger.Error("Cleanup: Failed to create sandbox client for cleanup", "error", cleanupErr)
 				return
@@ -156,21 +333,18 @@ func main() {
 		}()
 	}
 
-	// --- HTTP Server --- 
+	// --- HTTP Server ---
 	server := &http.Server{
-		Addr:    fmt.Sprintf("%s:%s", host, port),
-		Handler: router, // Use the mux router
+		Addr:              ln.Addr().String(),
+		Handler:           router, // Use the mux router
+		ReadHeaderTimeout: cfg.Timeouts.ReadHeader,
+		IdleTimeout:       cfg.Timeouts.Idle,
 	}
 
-	// --- Start Server Goroutine --- 
+	// --- Start Server Goroutine ---
 	go func() {
-		ln, err := net.Listen("tcp", server.Addr)
-		if err != nil {
-			logger.Error("Failed to listen", "address", server.Addr, "error", err)
-			os.Exit(1)
-		}
 		addr := ln.Addr().(*net.TCPAddr)
-		if port == "0" {
+		if cfg.Listen.Port == 0 {
 			// If "any free port" was specified, output the selected port.
 			if err := json.NewEncoder(os.Stdout).Encode(serverInfo{Host: addr.IP.String(), Port: addr.Port}); err != nil {
 				logger.Error("Failed to output server info", "error", err)
@@ -185,12 +359,43 @@ func main() {
 		logger.Info("Stopped serving new connections")
 	}()
 
-	// --- Graceful Shutdown --- 
+	if internalServer != nil {
+		go func() {
+			logger.Info("Starting internal HTTP server", "address", internalLn.Addr().String())
+			if err := internalServer.Serve(internalLn); !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("Internal HTTP server error", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	// --- Callback Check ---
+	if cfg.Callback.Check != "off" {
+		checkCallback := func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute) // May pull the box image
+			defer cancel()
+			return sandboxManager.CheckCallback(ctx)
+		}
+		if cfg.Callback.Check == "require" {
+			if err := checkCallback(); err != nil {
+				logger.Error("Callback check failed", "error", err)
+				os.Exit(1)
+			}
+		} else {
+			go func() {
+				if err := checkCallback(); err != nil {
+					logger.Warn("Callback check failed; sandboxes fall back to the observation channel only", "error", err)
+				}
+			}()
+		}
+	}
+
+	// --- Graceful Shutdown ---
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	sig := <-sigChan
 
-	gracePeriod := 30 * time.Second
+	gracePeriod := cfg.Timeouts.ShutdownGrace
 	shutdownCtx, shutdownRelease := context.WithTimeout(context.Background(), gracePeriod)
 	defer shutdownRelease()
 
@@ -200,6 +405,15 @@ func main() {
 		logger.Error("Error shutting down HTTP server", "error", err)
 		os.Exit(1) // Exit with error on shutdown failure
 	}
+	if internalServer != nil {
+		if err := internalServer.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Error shutting down internal HTTP server", "error", err)
+		}
+	}
+	sandboxManager.Shutdown(shutdownCtx)
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("Error flushing traces", "error", err)
+	}
 	logger.Info("Graceful shutdown complete")
 }
 
@@ -0,0 +1,135 @@
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/audit"
+)
+
+// actionRecord tracks an action that has been sent to a sandbox's agent and has
+// not yet produced an end observation.
+type actionRecord struct {
+	ID        string
+	SandboxID string
+	SpaceID   string
+	Type      string
+	StartedAt time.Time
+
+	actor        audit.Actor // Who initiated the action
+	span         trace.Span  // Follows the action until it ends, nil when untraced
+	displayTypes []string    // MIME types of the rich outputs the action displayed
+}
+
+// trackAction records an in-flight action unless its space has reached its
+// quota of concurrent actions.
+func (m *SandboxManager) trackAction(rec *actionRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if state, ok := m.sandboxes[rec.SandboxID]; ok {
+		if err := m.checkActionQuotaLocked(state.SpaceID); err != nil {
+			return err
+		}
+	}
+	m.actions[rec.ID] = rec
+	return nil
+}
+
+// noteDisplayData records the MIME types of a rich output displayed by an
+// in-flight action.
+func (m *SandboxManager) noteDisplayData(actionID string, bundle map[string]json.RawMessage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, ok := m.actions[actionID]
+	if !ok {
+		return
+	}
+	for mimeType := range bundle {
+		if !slices.Contains(rec.displayTypes, mimeType) {
+			rec.displayTypes = append(rec.displayTypes, mimeType)
+		}
+	}
+	slices.Sort(rec.displayTypes)
+}
+
+// finishAction forgets an in-flight action that ended with exitCode, negative
+// when it did not run to completion. It reports whether the action was still
+// being tracked.
+func (m *SandboxManager) finishAction(actionID string, exitCode int) bool {
+	m.mu.Lock()
+	rec, ok := m.actions[actionID]
+	delete(m.actions, actionID)
+	m.mu.Unlock()
+	if !ok {
+		return false
+	}
+	m.recordActionEnd(rec, exitCode)
+	return true
+}
+
+// recordActionEnd accounts a finished action in the usage meter, the metrics
+// and the audit log. It writes to the audit sink, so it must be called without
+// holding m.mu.
+func (m *SandboxManager) recordActionEnd(rec *actionRecord, exitCode int) {
+	now := time.Now()
+	m.meter.recordAction(rec.SandboxID, rec.StartedAt, now)
+	m.metrics.ObserveAction(rec.Type, exitCode, now.Sub(rec.StartedAt))
+	var err error
+	if exitCode < 0 {
+		err = errors.New("action did not complete")
+	}
+	if rec.span != nil {
+		rec.span.SetAttributes(attrExitCode.Int(exitCode))
+		endSpan(rec.span, err)
+	}
+
+	request := map[string]any{"type": rec.Type}
+	if len(rec.displayTypes) > 0 {
+		request["display_types"] = rec.displayTypes
+	}
+	entry := audit.Entry{
+		Actor:     rec.actor,
+		Operation: "actionFinished",
+		SpaceID:   rec.SpaceID,
+		SandboxID: rec.SandboxID,
+		ActionID:  rec.ID,
+		Request:   m.audit.Redact(request),
+		Outcome:   audit.OutcomeSuccess,
+		ExitCode:  &exitCode,
+	}
+	if exitCode != 0 {
+		entry.Outcome = audit.OutcomeFailure
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	m.audit.Append(entry)
+}
+
+// takeActionsLocked removes and returns every in-flight action of a sandbox.
+// The caller must hold m.mu, and record the ends of the actions after releasing
+// it.
+func (m *SandboxManager) takeActionsLocked(sandboxID string) []*actionRecord {
+	var taken []*actionRecord
+	for id, rec := range m.actions {
+		if rec.SandboxID == sandboxID {
+			taken = append(taken, rec)
+			delete(m.actions, id)
+		}
+	}
+	return taken
+}
+
+// failActions ends actions that can no longer complete, e.g. because their
+// sandbox's container died.
+func (m *SandboxManager) failActions(actions []*actionRecord, reason string) {
+	for _, rec := range actions {
+		m.recordActionEnd(rec, -1)
+		m.pushErrorObservation(rec.SandboxID, rec.ID, reason)
+		m.pushObservation(rec.SandboxID, rec.ID, "end", EndObservationData{ExitCode: -1, Error: reason})
+	}
+}
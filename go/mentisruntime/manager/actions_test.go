@@ -0,0 +1,101 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/audit"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/recording"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/ws"
+)
+
+// lockCheckingSink fails appends made while the manager's lock is held.
+type lockCheckingSink struct {
+	*audit.MemorySink
+	m *SandboxManager
+}
+
+func (s lockCheckingSink) Append(e audit.Entry) error {
+	if !s.m.mu.TryLock() {
+		return errors.New("audit entry appended while holding the manager lock")
+	}
+	s.m.mu.Unlock()
+	return s.MemorySink.Append(e)
+}
+
+func Test_actionEndsAuditedWithoutLock(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	m := &SandboxManager{
+		sandboxes:    make(map[string]*SandboxState),
+		actions:      make(map[string]*actionRecord),
+		logger:       logger,
+		hub:          ws.NewHub(logger),
+		spaceManager: NewSpaceManager(logger),
+	}
+	sink := lockCheckingSink{MemorySink: audit.NewMemorySink(10), m: m}
+	m.audit = audit.New(sink, nil, logger)
+	m.sandboxes["sb1"] = &SandboxState{ID: "sb1", SpaceID: "s1", IsRunning: true}
+	for _, id := range []string{"a1", "a2"} {
+		require.NoError(t, m.trackAction(&actionRecord{ID: id, SandboxID: "sb1", SpaceID: "s1", Type: "shell", StartedAt: time.Now()}))
+	}
+
+	require.True(t, m.finishAction("a1", 0))
+	require.False(t, m.finishAction("a1", 0))
+	m.unregisterSandbox("sb1", "s1")
+
+	// Failed appends are logged and dropped, so both ends reaching the sink
+	// shows neither was written under the lock.
+	entries, err := sink.Query(audit.Query{SpaceID: "s1"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, 0, *entries[0].ExitCode)
+	require.Equal(t, -1, *entries[1].ExitCode)
+}
+
+func Test_displayData(t *testing.T) {
+	recorder, err := recording.NewRecorder(t.TempDir())
+	require.NoError(t, err)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	auditLog := audit.New(audit.NewMemorySink(10), nil, logger)
+	m := &SandboxManager{
+		sandboxes: make(map[string]*SandboxState),
+		actions:   make(map[string]*actionRecord),
+		logger:    logger,
+		hub:       ws.NewHub(logger),
+		audit:     auditLog,
+		recorder:  recorder,
+	}
+	m.sandboxes["sb1"] = &SandboxState{ID: "sb1", SpaceID: "s1", IsRunning: true}
+	require.NoError(t, m.trackAction(&actionRecord{ID: "a1", SandboxID: "sb1", SpaceID: "s1", Type: "ipython", StartedAt: time.Now()}))
+
+	plot := `{"observation_type":"display_data","action_id":"a1","data":{"image/png":"iVBORw0KGgo=","text/plain":"<Figure>"},"metadata":{"image/png":{"width":640}}}`
+	table := `{"observation_type":"display_data","action_id":"a1","data":{"text/html":"<table></table>","text/plain":"df"}}`
+	for _, obs := range []string{plot, table} {
+		require.NoError(t, m.ReceiveInternalObservation(context.Background(), "sb1", []byte(obs)))
+	}
+	require.NoError(t, m.ReceiveInternalObservation(context.Background(), "sb1", []byte(`{"observation_type":"display_data","action_id":"a1"}`)),
+		"a display without a bundle is passed through but not recorded on the action")
+	require.NoError(t, m.ReceiveInternalObservation(context.Background(), "sb1", []byte(`{"observation_type":"result","action_id":"a1","exit_code":0}`)))
+
+	// Bundles reach clients unchanged.
+	var published []json.RawMessage
+	require.NoError(t, recorder.Each("s1", "sb1", func(e recording.Entry) error {
+		published = append(published, e.Observation)
+		return nil
+	}))
+	require.Len(t, published, 5)
+	require.JSONEq(t, plot, string(published[0]))
+	require.JSONEq(t, table, string(published[1]))
+
+	entries, err := auditLog.Query(audit.Query{SpaceID: "s1"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.JSONEq(t, `{"type":"ipython","display_types":["image/png","text/html","text/plain"]}`, string(entries[0].Request))
+}
@@ -0,0 +1,167 @@
+package manager
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// ErrInvalidObservationToken is returned when an observation callback does not
+// carry its sandbox's token.
+var ErrInvalidObservationToken = errors.New("invalid observation token")
+
+// Ways agents can reach the runtime, see WithCallbackHost.
+const (
+	// CallbackHostGateway maps host.docker.internal to the host with Docker's
+	// host-gateway, which works on Docker Desktop and Linux alike.
+	CallbackHostGateway = "host-gateway"
+	// CallbackNetworkGateway uses the gateway address of the sandbox's network.
+	CallbackNetworkGateway = "gateway"
+)
+
+// WithCallbackPort sets the port of the listener serving agent callbacks. It
+// should be the port actually bound, which differs from the configured one when
+// that is 0.
+func WithCallbackPort(port string) Option {
+	return func(m *SandboxManager) {
+		m.callbackPortNumber = port
+	}
+}
+
+// WithCallbackHost sets how agents address the runtime: CallbackHostGateway
+// (the default), CallbackNetworkGateway, or a fixed host name or IP. Sandboxes
+// on internal networks always use their network's gateway, as nothing else is
+// reachable from them.
+func WithCallbackHost(host string) Option {
+	return func(m *SandboxManager) {
+		m.callbackHost = host
+	}
+}
+
+// WithCallbackURL sets the base URL agents reach the runtime at, e.g.
+// "http://10.0.0.5:5266", for setups where it cannot be derived, such as a
+// runtime behind a proxy. It replaces the host and port entirely.
+func WithCallbackURL(baseURL string) Option {
+	return func(m *SandboxManager) {
+		m.callbackURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// callbackPort returns the port of the listener serving agent callbacks.
+func (m *SandboxManager) callbackPort() string {
+	if m.callbackPortNumber != "" {
+		return m.callbackPortNumber
+	}
+	return "5266" // Default port used in main.go
+}
+
+// callbackEndpoint returns the base URL agents on network nw reach the runtime
+// at, and the extra hosts entries their containers need to resolve it.
+func (m *SandboxManager) callbackEndpoint(ctx context.Context, nw sandboxNetwork) (string, []string, error) {
+	if m.callbackURL != "" {
+		return m.callbackURL, nil, nil
+	}
+	host := m.callbackHost
+	var extraHosts []string
+	switch {
+	case nw.Internal || host == CallbackNetworkGateway:
+		// Internal networks have no route off the bridge; the host is reachable
+		// at the network's gateway address.
+		gateway, _, err := m.networkGateway(ctx, nw.Name)
+		if err != nil {
+			return "", nil, err
+		}
+		host = gateway
+	case host == "" || host == CallbackHostGateway:
+		host = "host.docker.internal"
+		extraHosts = []string{"host.docker.internal:host-gateway"}
+	}
+	return "http://" + net.JoinHostPort(host, m.callbackPort()), extraHosts, nil
+}
+
+// CheckCallback verifies that agents can reach the runtime by running a
+// throwaway container from the default image that requests the internal ping
+// endpoint, on a network set up like a sandbox's.
+func (m *SandboxManager) CheckCallback(ctx context.Context) error {
+	nw := sandboxNetwork{Name: fmt.Sprintf("sandboxai-%s-callback-check", m.scope)}
+	if err := m.ensureNetwork(ctx, nw, map[string]string{labelScope: m.scope}); err != nil {
+		return err
+	}
+	defer m.removeNetwork(nw.Name)
+
+	imageName := m.defaultImage
+	if err := m.ensureImage(ctx, imageName, nil); err != nil {
+		return err
+	}
+	base, extraHosts, err := m.callbackEndpoint(ctx, nw)
+	if err != nil {
+		return err
+	}
+	pingURL := base + "/v1/internal/ping"
+
+	resp, err := m.dockerClient.ContainerCreate(ctx,
+		&container.Config{
+			Image:  imageName,
+			Labels: map[string]string{labelScope: m.scope},
+			Cmd: []string{"python3", "-c",
+				"import sys, urllib.request; urllib.request.urlopen(sys.argv[1], timeout=5)", pingURL},
+		},
+		&container.HostConfig{NetworkMode: container.NetworkMode(nw.Name), ExtraHosts: extraHosts},
+		nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to create callback check container: %w", err)
+	}
+	defer m.removeContainer(resp.ID)
+	if err := m.dockerClient.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return fmt.Errorf("failed to start callback check container: %w", err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	statusCh, errCh := m.dockerClient.ContainerWait(waitCtx, resp.ID, container.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("failed to wait for callback check container: %w", err)
+	case status := <-statusCh:
+		if status.StatusCode != 0 {
+			return fmt.Errorf("a container could not reach the runtime at %s (exit code %d); configure the callback host or URL and make sure the runtime listens on an address containers can reach", pingURL, status.StatusCode)
+		}
+	}
+	m.logger.Info("Callback check passed", "url", pingURL)
+	return nil
+}
+
+// newObservationToken generates the secret a sandbox's agent authenticates its
+// observation callbacks with. It is passed to the agent in
+// RUNTIME_OBSERVATION_TOKEN.
+func newObservationToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate observation token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// AuthenticateObservation checks the token of an observation callback. Unknown
+// sandboxes are reported as ErrInvalidObservationToken too, so callers cannot
+// probe for sandbox IDs.
+func (m *SandboxManager) AuthenticateObservation(sandboxID, token string) error {
+	m.mu.RLock()
+	var expected string
+	if state, exists := m.sandboxes[sandboxID]; exists {
+		expected = state.observationToken
+	}
+	m.mu.RUnlock()
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(token)) != 1 {
+		return ErrInvalidObservationToken
+	}
+	return nil
+}
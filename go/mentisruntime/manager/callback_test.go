@@ -0,0 +1,45 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AuthenticateObservation(t *testing.T) {
+	token, err := newObservationToken()
+	require.NoError(t, err)
+	m := &SandboxManager{sandboxes: map[string]*SandboxState{
+		"sbx":     {ID: "sbx", observationToken: token},
+		"pending": {ID: "pending"},
+	}}
+
+	require.NoError(t, m.AuthenticateObservation("sbx", token))
+	require.ErrorIs(t, m.AuthenticateObservation("sbx", "forged"), ErrInvalidObservationToken)
+	require.ErrorIs(t, m.AuthenticateObservation("sbx", ""), ErrInvalidObservationToken)
+	require.ErrorIs(t, m.AuthenticateObservation("pending", ""), ErrInvalidObservationToken, "sandboxes without a token accept nothing")
+	require.ErrorIs(t, m.AuthenticateObservation("missing", token), ErrInvalidObservationToken)
+}
+
+func Test_callbackEndpoint(t *testing.T) {
+	nw := sandboxNetwork{Name: "sandboxai-test-space1"}
+
+	m := &SandboxManager{}
+	WithCallbackPort("41234")(m)
+	base, extraHosts, err := m.callbackEndpoint(context.Background(), nw)
+	require.NoError(t, err)
+	require.Equal(t, "http://host.docker.internal:41234", base)
+	require.Equal(t, []string{"host.docker.internal:host-gateway"}, extraHosts)
+
+	WithCallbackHost("10.0.0.5")(m)
+	base, extraHosts, err = m.callbackEndpoint(context.Background(), nw)
+	require.NoError(t, err)
+	require.Equal(t, "http://10.0.0.5:41234", base)
+	require.Empty(t, extraHosts)
+
+	WithCallbackURL("https://runtime.example.com/")(m)
+	base, _, err = m.callbackEndpoint(context.Background(), sandboxNetwork{Name: "n", Internal: true})
+	require.NoError(t, err)
+	require.Equal(t, "https://runtime.example.com", base)
+}
@@ -0,0 +1,212 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// channelWindow is how many observations an agent may send before the runtime
+// acknowledges them. Acknowledging only after observations are processed makes
+// a slow runtime throttle its agents instead of buffering without bound.
+const channelWindow = 256
+
+// errChannelUnsupported is returned for agents without a channel endpoint; they
+// keep pushing observations to the HTTP callback.
+var errChannelUnsupported = errors.New("agent does not support the observation channel")
+
+// channelEntry is one observation in a channel batch.
+type channelEntry struct {
+	Seq         uint64          `json:"seq"`
+	Observation json.RawMessage `json:"observation"`
+}
+
+// channelBatch is a frame sent by the agent.
+type channelBatch struct {
+	Batch []channelEntry `json:"batch"`
+}
+
+// channelHello is the first frame the agent sends. Instance identifies the agent
+// process, whose sequence numbers start at 1; NextSeq is the number it assigns
+// next.
+type channelHello struct {
+	Instance string `json:"instance"`
+	NextSeq  uint64 `json:"next_seq"`
+}
+
+// channelCursor is the last observation processed from a channel, and the
+// agent process that numbered it.
+type channelCursor struct {
+	instance string
+	lastSeq  uint64
+}
+
+// resume moves the cursor to the agent process that said hello. A different
+// process, or one that has not yet numbered the last processed observation,
+// numbers afresh, so the cursor starts over.
+func (c *channelCursor) resume(hello channelHello) bool {
+	restarted := hello.Instance != c.instance || hello.NextSeq <= c.lastSeq
+	if restarted {
+		c.instance, c.lastSeq = hello.Instance, 0
+	}
+	return restarted
+}
+
+// channelAck is a frame sent to the agent. Ack is cumulative.
+type channelAck struct {
+	Ack    uint64 `json:"ack"`
+	Window int    `json:"window"`
+}
+
+// openObservationChannel starts receiving a sandbox's observations over a
+// persistent WebSocket the runtime opens to its agent. The agent buffers
+// observations until they are acknowledged and sends them in order, so none
+// are lost or reordered across reconnects. Any channel already open for the
+// sandbox is replaced, as a restarted agent numbers its observations afresh.
+func (m *SandboxManager) openObservationChannel(sandboxID string) {
+	parent := m.backgroundCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+
+	m.mu.Lock()
+	state, exists := m.sandboxes[sandboxID]
+	if !exists || state.deleting {
+		m.mu.Unlock()
+		cancel()
+		return
+	}
+	if state.stopChannel != nil {
+		state.stopChannel()
+	}
+	state.stopChannel = cancel
+	m.mu.Unlock()
+
+	go m.runObservationChannel(ctx, sandboxID)
+}
+
+// runObservationChannel keeps the channel connected until ctx is cancelled,
+// reconnecting with backoff and resuming after the last processed observation.
+func (m *SandboxManager) runObservationChannel(ctx context.Context, sandboxID string) {
+	var cursor channelCursor
+	backoff := time.Second
+	for {
+		connected, err := m.receiveObservations(ctx, sandboxID, &cursor)
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, errChannelUnsupported) {
+			m.logger.Info("Agent has no observation channel, using HTTP callbacks", "sandboxID", sandboxID)
+			return
+		}
+		if errors.Is(err, ErrSandboxNotFound) {
+			return
+		}
+		if connected {
+			backoff = time.Second
+		}
+		m.logger.Warn("Observation channel disconnected, reconnecting", "sandboxID", sandboxID, "lastSeq", cursor.lastSeq, "retryIn", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+// receiveObservations connects to the agent and processes batches until the
+// connection fails. It reports whether the connection was established.
+func (m *SandboxManager) receiveObservations(ctx context.Context, sandboxID string, cursor *channelCursor) (bool, error) {
+	m.mu.RLock()
+	var agentURL, token string
+	state, exists := m.sandboxes[sandboxID]
+	if exists {
+		agentURL, token = state.AgentURL, state.observationToken
+	}
+	m.mu.RUnlock()
+	if !exists {
+		return false, ErrSandboxNotFound
+	}
+	if agentURL == "" {
+		return false, errors.New("agent URL not known")
+	}
+
+	channelURL := "ws" + strings.TrimPrefix(agentURL, "http") +
+		fmt.Sprintf("/observations/channel?resume=%d&instance=%s", cursor.lastSeq, url.QueryEscape(cursor.instance))
+	header := http.Header{}
+	header.Set("X-Observation-Token", token)
+	dialer := websocket.Dialer{HandshakeTimeout: 10 * time.Second}
+	conn, resp, err := dialer.DialContext(ctx, channelURL, header)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, errChannelUnsupported
+		}
+		return false, fmt.Errorf("failed to connect to agent: %w", err)
+	}
+	defer conn.Close()
+	m.logger.Info("Observation channel connected", "sandboxID", sandboxID, "resume", cursor.lastSeq)
+
+	// Unblock the read below when the channel is closed.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	// Agents that predate the hello start with a batch instead.
+	var first struct {
+		channelHello
+		channelBatch
+	}
+	if err := conn.ReadJSON(&first); err != nil {
+		return true, fmt.Errorf("failed to read hello: %w", err)
+	}
+	pending := first.Batch
+	if first.Instance != "" {
+		previous := cursor.lastSeq
+		if cursor.resume(first.channelHello) && previous > 0 {
+			m.logger.Warn("Agent restarted, observation channel starts over", "sandboxID", sandboxID, "instance", first.Instance, "previousSeq", previous)
+		}
+		if err := conn.WriteJSON(channelAck{Ack: cursor.lastSeq, Window: channelWindow}); err != nil {
+			return true, fmt.Errorf("failed to send ack: %w", err)
+		}
+	}
+	for {
+		if pending == nil {
+			var batch channelBatch
+			if err := conn.ReadJSON(&batch); err != nil {
+				return true, fmt.Errorf("failed to read batch: %w", err)
+			}
+			pending = batch.Batch
+		}
+		for _, entry := range pending {
+			if entry.Seq <= cursor.lastSeq {
+				continue // Already processed before a reconnect
+			}
+			if entry.Seq != cursor.lastSeq+1 {
+				m.logger.Warn("Observation channel skipped sequence numbers", "sandboxID", sandboxID, "expected", cursor.lastSeq+1, "got", entry.Seq)
+			}
+			if err := m.ReceiveInternalObservation(ctx, sandboxID, entry.Observation); err != nil {
+				m.logger.Error("Failed to process observation from channel", "sandboxID", sandboxID, "seq", entry.Seq, "error", err)
+			}
+			cursor.lastSeq = entry.Seq
+		}
+		pending = nil
+		if err := conn.WriteJSON(channelAck{Ack: cursor.lastSeq, Window: channelWindow}); err != nil {
+			return true, fmt.Errorf("failed to send ack: %w", err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package manager
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/ws"
+)
+
+func Test_channelCursorResume(t *testing.T) {
+	c := channelCursor{}
+	require.True(t, c.resume(channelHello{Instance: "a", NextSeq: 1}))
+	c.lastSeq = 5
+	require.False(t, c.resume(channelHello{Instance: "a", NextSeq: 6}))
+	require.Equal(t, uint64(5), c.lastSeq)
+	require.True(t, c.resume(channelHello{Instance: "a", NextSeq: 3}), "numbering went back")
+	require.Zero(t, c.lastSeq)
+	c.lastSeq = 5
+	require.True(t, c.resume(channelHello{Instance: "b", NextSeq: 9}), "another agent process")
+	require.Equal(t, channelCursor{instance: "b"}, c)
+}
+
+func Test_ObservationChannel(t *testing.T) {
+	type connection struct {
+		resume, instance string
+		acks             chan channelAck
+		conn             *websocket.Conn
+	}
+	conns := make(chan *connection, 3)
+	upgrader := websocket.Upgrader{}
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/observations/channel" || r.Header.Get("X-Observation-Token") != "secret" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		query := r.URL.Query()
+		c := &connection{resume: query.Get("resume"), instance: query.Get("instance"), acks: make(chan channelAck, 10), conn: conn}
+		conns <- c
+		for {
+			var ack channelAck
+			if err := conn.ReadJSON(&ack); err != nil {
+				close(c.acks)
+				return
+			}
+			c.acks <- ack
+		}
+	}))
+	defer agent.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := &SandboxManager{
+		sandboxes:     map[string]*SandboxState{"sb1": {ID: "sb1", AgentURL: agent.URL, observationToken: "secret"}},
+		actions:       make(map[string]*actionRecord),
+		logger:        logger,
+		hub:           ws.NewHub(logger),
+		spaceManager:  NewSpaceManager(logger),
+		backgroundCtx: ctx,
+	}
+	m.openObservationChannel("sb1")
+
+	observation := func(seq uint64) channelEntry {
+		return channelEntry{Seq: seq, Observation: []byte(`{"observation_type":"stream","action_id":"a1","stream":"stdout","line":"x"}`)}
+	}
+	nextAck := func(c *connection) channelAck {
+		select {
+		case ack := <-c.acks:
+			return ack
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for ack")
+			return channelAck{}
+		}
+	}
+
+	nextConn := func() *connection {
+		select {
+		case c := <-conns:
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for connection")
+			return nil
+		}
+	}
+
+	first := nextConn()
+	require.Equal(t, "0", first.resume)
+	require.Empty(t, first.instance)
+	require.NoError(t, first.conn.WriteJSON(channelHello{Instance: "agent-1", NextSeq: 1}))
+	require.Equal(t, channelAck{Ack: 0, Window: channelWindow}, nextAck(first))
+	require.NoError(t, first.conn.WriteJSON(channelBatch{Batch: []channelEntry{observation(1), observation(2)}}))
+	require.Equal(t, channelAck{Ack: 2, Window: channelWindow}, nextAck(first))
+	first.conn.Close()
+
+	// The runtime reconnects, resumes after what it processed and ignores resent observations.
+	second := nextConn()
+	require.Equal(t, "2", second.resume)
+	require.Equal(t, "agent-1", second.instance)
+	require.NoError(t, second.conn.WriteJSON(channelHello{Instance: "agent-1", NextSeq: 4}))
+	require.Equal(t, uint64(2), nextAck(second).Ack)
+	require.NoError(t, second.conn.WriteJSON(channelBatch{Batch: []channelEntry{observation(2), observation(3)}}))
+	require.Equal(t, uint64(3), nextAck(second).Ack)
+	second.conn.Close()
+
+	// A restarted agent numbers from 1 again; the runtime starts over instead
+	// of skipping its first observations.
+	third := nextConn()
+	require.Equal(t, "3", third.resume)
+	require.NoError(t, third.conn.WriteJSON(channelHello{Instance: "agent-2", NextSeq: 2}))
+	require.Equal(t, uint64(0), nextAck(third).Ack)
+	require.NoError(t, third.conn.WriteJSON(channelBatch{Batch: []channelEntry{observation(1)}}))
+	require.Equal(t, uint64(1), nextAck(third).Ack)
+
+	// Unregistering the sandbox closes the channel.
+	m.unregisterSandbox("sb1", "")
+	select {
+	case _, ok := <-third.acks:
+		require.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel was not closed")
+	}
+}
@@ -0,0 +1,73 @@
+package manager
+
+import (
+	"errors"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/recording"
+)
+
+// ErrorCode classifies an error for API clients, which match on it instead of
+// the message. The codes are listed in the Error schema of api/v1.yaml.
+type ErrorCode string
+
+const (
+	CodeInvalidRequest         ErrorCode = "invalid_request"
+	CodeInvalidSpec            ErrorCode = "invalid_spec"
+	CodeInvalidSecurityProfile ErrorCode = "invalid_security_profile"
+	CodeUnauthorized           ErrorCode = "unauthorized"
+	CodeForbidden              ErrorCode = "forbidden"
+	CodeNotFound               ErrorCode = "not_found"
+	CodeSpaceNotFound          ErrorCode = "space_not_found"
+	CodeSandboxNotFound        ErrorCode = "sandbox_not_found"
+	CodePathNotFound           ErrorCode = "path_not_found"
+	CodePortNotExposed         ErrorCode = "port_not_exposed"
+	CodeNameConflict           ErrorCode = "name_conflict"
+	CodeConflict               ErrorCode = "conflict"
+	CodeSandboxNotRunning      ErrorCode = "sandbox_not_running"
+	CodeQuotaExceeded          ErrorCode = "quota_exceeded"
+	CodeImagePullFailed        ErrorCode = "image_pull_failed"
+	CodeAgentUnreachable       ErrorCode = "agent_unreachable"
+	CodeServiceUnreachable     ErrorCode = "service_unreachable"
+	CodeInternal               ErrorCode = "internal"
+)
+
+var (
+	// ErrImagePullFailed is returned when a sandbox's image cannot be pulled.
+	ErrImagePullFailed = errors.New("image pull failed")
+	// ErrAgentUnreachable is returned when a sandbox's agent does not answer.
+	ErrAgentUnreachable = errors.New("agent unreachable")
+)
+
+// errorCodes lists the code of each error of the package, checked in order.
+var errorCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{ErrSpaceNotFound, CodeSpaceNotFound},
+	{ErrSandboxNotFound, CodeSandboxNotFound},
+	{ErrPathNotFound, CodePathNotFound},
+	{ErrPortNotExposed, CodePortNotExposed},
+	{ErrSpaceNameConflict, CodeNameConflict},
+	{ErrSandboxNotRunning, CodeSandboxNotRunning},
+	{ErrSandboxLimitReached, CodeQuotaExceeded},
+	{ErrQuotaExceeded, CodeQuotaExceeded},
+	{ErrInvalidSpec, CodeInvalidSpec},
+	{ErrInvalidSecurityProfile, CodeInvalidSecurityProfile},
+	{ErrInvalidQuota, CodeInvalidRequest},
+	{ErrInvalidUsageQuery, CodeInvalidRequest},
+	{ErrInvalidObservationToken, CodeUnauthorized},
+	{ErrImagePullFailed, CodeImagePullFailed},
+	{ErrAgentUnreachable, CodeAgentUnreachable},
+	{recording.ErrNotFound, CodeNotFound},
+}
+
+// CodeOf returns the code of the first error of the package that err wraps,
+// or CodeInternal.
+func CodeOf(err error) ErrorCode {
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	return CodeInternal
+}
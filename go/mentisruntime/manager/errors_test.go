@@ -0,0 +1,29 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CodeOf(t *testing.T) {
+	cases := []struct {
+		err  error
+		code ErrorCode
+	}{
+		{fmt.Errorf("%w: sb-1", ErrSandboxNotFound), CodeSandboxNotFound},
+		{fmt.Errorf("%w: sb-1", ErrSandboxNotRunning), CodeSandboxNotRunning},
+		{fmt.Errorf("%w: space a allows 2 sandboxes", ErrSandboxLimitReached), CodeQuotaExceeded},
+		{fmt.Errorf("failed to verify space a: %w", ErrSpaceNotFound), CodeSpaceNotFound},
+		{&provisionError{Reason: ReasonImagePullFailed, Err: errors.New("manifest unknown")}, CodeImagePullFailed},
+		{&provisionError{Reason: ReasonAgentUnreachable, Err: errors.New("timeout")}, CodeAgentUnreachable},
+		{&provisionError{Reason: ReasonContainerFailed, Err: fmt.Errorf("%w: port 8000 is used by the agent", ErrInvalidSpec)}, CodeInvalidSpec},
+		{&provisionError{Reason: ReasonContainerFailed, Err: errors.New("no space left")}, CodeInternal},
+		{errors.New("boom"), CodeInternal},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.code, CodeOf(c.err), c.err.Error())
+	}
+}
@@ -0,0 +1,254 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// LifecycleObservationData is published on the sandbox stream when its container
+// changes state outside of the runtime's control, e.g. it crashes or is OOM killed.
+type LifecycleObservationData struct {
+	Event     string `json:"event"` // Docker event: die, oom, stop, destroy or start
+	State     string `json:"state"` // Sandbox state after the event
+	Reason    string `json:"reason,omitempty"`
+	ExitCode  *int   `json:"exit_code,omitempty"`
+	OOMKilled bool   `json:"oom_killed,omitempty"`
+}
+
+// ParseRestartPolicy parses a Docker restart policy such as "no", "always",
+// "unless-stopped" or "on-failure[:<maxRetries>]".
+func ParseRestartPolicy(s string) (container.RestartPolicy, error) {
+	name, retries, hasRetries := strings.Cut(strings.TrimSpace(s), ":")
+	policy := container.RestartPolicy{Name: container.RestartPolicyMode(name)}
+	if hasRetries {
+		n, err := strconv.Atoi(retries)
+		if err != nil {
+			return container.RestartPolicy{}, fmt.Errorf("invalid restart policy %q: bad retry count %q", s, retries)
+		}
+		policy.MaximumRetryCount = n
+	}
+	if err := container.ValidateRestartPolicy(policy); err != nil {
+		return container.RestartPolicy{}, fmt.Errorf("invalid restart policy %q: %w", s, err)
+	}
+	return policy, nil
+}
+
+// WithRestartPolicy sets the Docker restart policy of sandbox containers. When
+// Docker restarts a crashed container, the events watcher re-resolves its agent
+// URL and marks the sandbox running again once the agent is healthy.
+func WithRestartPolicy(policy container.RestartPolicy) Option {
+	return func(m *SandboxManager) {
+		m.restartPolicy = policy
+	}
+}
+
+// watchEvents follows Docker container events for this scope until ctx is
+// cancelled, reconnecting with backoff if the stream breaks.
+func (m *SandboxManager) watchEvents(ctx context.Context) {
+	opts := events.ListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("type", string(events.ContainerEventType)),
+			filters.Arg("label", labelScope+"="+m.scope),
+			filters.Arg("event", string(events.ActionDie)),
+			filters.Arg("event", string(events.ActionOOM)),
+			filters.Arg("event", string(events.ActionStop)),
+			filters.Arg("event", string(events.ActionDestroy)),
+			filters.Arg("event", string(events.ActionStart)),
+		),
+	}
+
+	backoff := time.Second
+	for {
+		msgs, errs := m.dockerClient.Events(ctx, opts)
+		err := func() error {
+			for {
+				select {
+				case msg := <-msgs:
+					backoff = time.Second
+					m.handleContainerEvent(ctx, msg)
+				case err := <-errs:
+					return err
+				}
+			}
+		}()
+		if ctx.Err() != nil {
+			return
+		}
+		m.logger.Warn("Docker events stream closed, reconnecting", "error", err, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+
+		// Events may have been missed while disconnected.
+		m.refreshAllStatuses(ctx)
+	}
+}
+
+// refreshAllStatuses refreshes the status of every known sandbox from Docker.
+func (m *SandboxManager) refreshAllStatuses(ctx context.Context) {
+	m.mu.RLock()
+	ids := make([]string, 0, len(m.sandboxes))
+	for id := range m.sandboxes {
+		ids = append(ids, id)
+	}
+	m.mu.RUnlock()
+
+	for _, id := range ids {
+		m.refreshSandboxStatus(ctx, id, true)
+	}
+}
+
+// handleContainerEvent applies a Docker container event to the sandbox it
+// belongs to. Events for sandboxes that are provisioning or being deleted, and
+// for warm pool containers, are ignored.
+func (m *SandboxManager) handleContainerEvent(ctx context.Context, msg events.Message) {
+	sandboxID := msg.Actor.Attributes[labelID]
+	at := time.Unix(0, msg.TimeNano).UTC()
+	if msg.TimeNano == 0 {
+		at = time.Now().UTC()
+	}
+
+	m.mu.Lock()
+	state, exists := m.sandboxes[sandboxID]
+	if !exists || state.deleting || state.ContainerID != msg.Actor.ID || state.Status.State == StateProvisioning {
+		m.mu.Unlock()
+		return
+	}
+	wasRunning := state.Status.State == StateRunning
+	lifecycle := LifecycleObservationData{Event: string(msg.Action)}
+	var inFlight []*actionRecord
+	var failReason string
+
+	switch msg.Action {
+	case events.ActionOOM:
+		state.Status.OOMKilled = true
+		state.Status.Reason = ReasonOOMKilled
+
+	case events.ActionDie, events.ActionStop:
+		if msg.Action == events.ActionStop && !wasRunning {
+			// Already handled by the preceding die event.
+			m.mu.Unlock()
+			return
+		}
+		state.IsRunning = false
+		state.Status.State = StateExited
+		state.Status.ContainerState = "exited"
+		state.Status.AgentReachable = nil
+		state.Status.FinishedAt = &at
+		if code, err := strconv.Atoi(msg.Actor.Attributes["exitCode"]); err == nil {
+			state.Status.ExitCode = &code
+		}
+		if state.Status.OOMKilled {
+			state.Status.Reason = ReasonOOMKilled
+		}
+		inFlight = m.takeActionsLocked(sandboxID)
+		failReason = "sandbox container exited"
+		if state.Status.ExitCode != nil {
+			failReason = fmt.Sprintf("sandbox container exited with code %d", *state.Status.ExitCode)
+		}
+		if state.Status.OOMKilled {
+			failReason += " (OOM killed)"
+		}
+		m.meter.stop(sandboxID, at)
+
+	case events.ActionDestroy:
+		state.IsRunning = false
+		state.Status.State = StateLost
+		state.Status.Reason = ReasonContainerNotFound
+		state.Status.ContainerState = ""
+		state.Status.AgentReachable = nil
+		inFlight = m.takeActionsLocked(sandboxID)
+		failReason = "sandbox container was removed"
+		m.meter.stop(sandboxID, at)
+
+	case events.ActionStart:
+		if wasRunning {
+			m.mu.Unlock()
+			return
+		}
+		// Restarted by Docker's restart policy or by hand; the agent needs a
+		// moment to come up and its host port may have changed.
+		containerID := state.ContainerID
+		m.mu.Unlock()
+		go m.recoverSandbox(ctx, sandboxID, containerID)
+		return
+
+	default:
+		m.mu.Unlock()
+		return
+	}
+
+	lifecycle.State = state.Status.State
+	lifecycle.Reason = state.Status.Reason
+	lifecycle.ExitCode = state.Status.ExitCode
+	lifecycle.OOMKilled = state.Status.OOMKilled
+	m.mu.Unlock()
+
+	m.logger.Warn("Sandbox container event", "sandboxID", sandboxID, "containerID", msg.Actor.ID, "event", msg.Action,
+		"state", lifecycle.State, "exitCode", msg.Actor.Attributes["exitCode"], "failedActions", len(inFlight))
+	m.failActions(inFlight, failReason)
+	m.pushObservation(sandboxID, "", "lifecycle", lifecycle)
+}
+
+// recoverSandbox waits for the agent of a restarted container and marks the
+// sandbox running again.
+func (m *SandboxManager) recoverSandbox(ctx context.Context, sandboxID, containerID string) {
+	m.mu.RLock()
+	var nw sandboxNetwork
+	var policy *NetworkPolicy
+	var secretFiles map[string]string
+	if state, exists := m.sandboxes[sandboxID]; exists {
+		nw, policy, secretFiles = state.network, state.Network, state.secretFiles
+	}
+	m.mu.RUnlock()
+
+	// The container may have a new address, which the egress rules match on.
+	err := m.applyEgressPolicy(ctx, sandboxID, containerID, nw, policy)
+	if err == nil {
+		err = m.writeSecretFiles(ctx, containerID, secretFiles)
+	}
+	var agentURL string
+	if err == nil {
+		agentURL, err = m.resolveAgentURL(ctx, sandboxID, containerID)
+	}
+	if err == nil {
+		err = m.waitForAgentReady(ctx, agentURL+"/health", m.agentReadyTimeout)
+	}
+	if err != nil {
+		m.logger.Error("Restarted sandbox did not become ready", "sandboxID", sandboxID, "containerID", containerID, "error", err)
+		return
+	}
+
+	m.mu.Lock()
+	state, exists := m.sandboxes[sandboxID]
+	if !exists || state.deleting || state.ContainerID != containerID {
+		m.mu.Unlock()
+		return
+	}
+	state.AgentURL = agentURL
+	m.mu.Unlock()
+
+	// The restarted agent numbers its observations from the start again.
+	m.openObservationChannel(sandboxID)
+	m.refreshSandboxStatus(ctx, sandboxID, true)
+
+	m.mu.RLock()
+	lifecycle := LifecycleObservationData{Event: string(events.ActionStart)}
+	if state, exists := m.sandboxes[sandboxID]; exists {
+		lifecycle.State = state.Status.State
+	}
+	m.mu.RUnlock()
+
+	m.logger.Info("Restarted sandbox is ready", "sandboxID", sandboxID, "containerID", containerID, "agentURL", agentURL)
+	m.pushObservation(sandboxID, "", "lifecycle", lifecycle)
+}
@@ -0,0 +1,93 @@
+package manager
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/stretchr/testify/require"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/ws"
+)
+
+func Test_ParseRestartPolicy(t *testing.T) {
+	cases := []struct {
+		input  string
+		exp    container.RestartPolicy
+		expErr bool
+	}{
+		{input: "no", exp: container.RestartPolicy{Name: container.RestartPolicyDisabled}},
+		{input: "always", exp: container.RestartPolicy{Name: container.RestartPolicyAlways}},
+		{input: "on-failure:3", exp: container.RestartPolicy{Name: container.RestartPolicyOnFailure, MaximumRetryCount: 3}},
+		{input: "on-failure:x", expErr: true},
+		{input: "always:3", expErr: true},
+		{input: "sometimes", expErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			policy, err := ParseRestartPolicy(c.input)
+			if c.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.exp, policy)
+		})
+	}
+}
+
+func Test_handleContainerEvent(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	meter, err := NewUsageMeter("", 0)
+	require.NoError(t, err)
+	m := &SandboxManager{
+		sandboxes: make(map[string]*SandboxState),
+		actions:   make(map[string]*actionRecord),
+		logger:    logger,
+		hub:       ws.NewHub(logger),
+		meter:     meter,
+	}
+	m.sandboxes["sb1"] = &SandboxState{ID: "sb1", ContainerID: "c1", IsRunning: true, Status: SandboxStatus{State: StateRunning}}
+	m.sandboxes["sb2"] = &SandboxState{ID: "sb2", ContainerID: "c2", IsRunning: true, Status: SandboxStatus{State: StateRunning}, deleting: true}
+	m.trackAction(&actionRecord{ID: "a1", SandboxID: "sb1"})
+	m.trackAction(&actionRecord{ID: "a2", SandboxID: "sb2"})
+	meter.start("s1", "sb1", time.Now())
+
+	event := func(containerID, sandboxID string, action events.Action, attrs map[string]string) events.Message {
+		attributes := map[string]string{labelID: sandboxID}
+		for k, v := range attrs {
+			attributes[k] = v
+		}
+		return events.Message{Type: events.ContainerEventType, Action: action, Actor: events.Actor{ID: containerID, Attributes: attributes}}
+	}
+
+	m.handleContainerEvent(context.Background(), event("c1", "sb1", events.ActionOOM, nil))
+	m.handleContainerEvent(context.Background(), event("c1", "sb1", events.ActionDie, map[string]string{"exitCode": "137"}))
+	sb1 := m.sandboxes["sb1"]
+	require.False(t, sb1.IsRunning)
+	require.Equal(t, StateExited, sb1.Status.State)
+	require.Equal(t, ReasonOOMKilled, sb1.Status.Reason)
+	require.NotNil(t, sb1.Status.ExitCode)
+	require.Equal(t, 137, *sb1.Status.ExitCode)
+	require.NotContains(t, m.actions, "a1")
+	require.NotContains(t, meter.running, "sb1", "exited sandboxes are not metered")
+
+	// Sandboxes being deleted are left to DeleteSandbox.
+	m.handleContainerEvent(context.Background(), event("c2", "sb2", events.ActionDie, map[string]string{"exitCode": "0"}))
+	require.True(t, m.sandboxes["sb2"].IsRunning)
+	require.Contains(t, m.actions, "a2")
+
+	// Events from a container that no longer backs the sandbox are ignored.
+	m.sandboxes["sb1"].Status.State = StateRunning
+	m.handleContainerEvent(context.Background(), event("old", "sb1", events.ActionDestroy, nil))
+	require.Equal(t, StateRunning, m.sandboxes["sb1"].Status.State)
+
+	m.handleContainerEvent(context.Background(), event("c1", "sb1", events.ActionDestroy, nil))
+	require.Equal(t, StateLost, m.sandboxes["sb1"].Status.State)
+	require.Equal(t, ReasonContainerNotFound, m.sandboxes["sb1"].Status.Reason)
+}
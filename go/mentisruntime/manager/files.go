@@ -0,0 +1,107 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// ErrPathNotFound is returned when a path does not exist inside a sandbox.
+var ErrPathNotFound = errors.New("path not found")
+
+// ListSandboxes returns the sandboxes of a space, oldest first.
+func (m *SandboxManager) ListSandboxes(ctx context.Context, spaceID string) ([]*SandboxState, error) {
+	if _, err := m.spaceManager.GetSpace(ctx, spaceID); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	sandboxes := make([]*SandboxState, 0)
+	for _, state := range m.sandboxes {
+		if state.SpaceID == spaceID {
+			stateCopy := *state
+			sandboxes = append(sandboxes, &stateCopy)
+		}
+	}
+	m.mu.RUnlock()
+	sort.Slice(sandboxes, func(i, j int) bool {
+		return sandboxes[i].Status.CreatedAt.Before(sandboxes[j].Status.CreatedAt)
+	})
+	return sandboxes, nil
+}
+
+// sandboxContainer returns the container of a sandbox in spaceID. Sandboxes of
+// other spaces are reported as not found.
+func (m *SandboxManager) sandboxContainer(spaceID, sandboxID string) (string, error) {
+	m.mu.RLock()
+	state, exists := m.sandboxes[sandboxID]
+	var containerID string
+	exists = exists && state.SpaceID == spaceID
+	if exists {
+		containerID = state.ContainerID
+	}
+	m.mu.RUnlock()
+	if !exists {
+		return "", ErrSandboxNotFound
+	}
+	if containerID == "" {
+		return "", ErrSandboxNotRunning
+	}
+	return containerID, nil
+}
+
+// CopyToSandbox extracts a tar archive into the directory dstDir of a sandbox.
+func (m *SandboxManager) CopyToSandbox(ctx context.Context, spaceID, sandboxID, dstDir string, archive io.Reader) error {
+	containerID, err := m.sandboxContainer(spaceID, sandboxID)
+	if err != nil {
+		return err
+	}
+	err = m.dockerClient.CopyToContainer(ctx, containerID, dstDir, archive, container.CopyToContainerOptions{})
+	if client.IsErrNotFound(err) {
+		return fmt.Errorf("%w: %s", ErrPathNotFound, dstDir)
+	}
+	return err
+}
+
+// CopyFromSandbox returns a tar archive of srcPath in a sandbox, a file or a
+// directory, and its stat.
+func (m *SandboxManager) CopyFromSandbox(ctx context.Context, spaceID, sandboxID, srcPath string) (io.ReadCloser, container.PathStat, error) {
+	containerID, err := m.sandboxContainer(spaceID, sandboxID)
+	if err != nil {
+		return nil, container.PathStat{}, err
+	}
+	archive, stat, err := m.dockerClient.CopyFromContainer(ctx, containerID, srcPath)
+	if client.IsErrNotFound(err) {
+		return nil, container.PathStat{}, fmt.Errorf("%w: %s", ErrPathNotFound, srcPath)
+	}
+	return archive, stat, err
+}
+
+// SandboxLogs returns the output of a sandbox's container, which runs the
+// agent. tail limits it to the last lines ("all" or a number) and follow keeps
+// it open for new output until ctx is done. Secret values are masked as in
+// observations.
+func (m *SandboxManager) SandboxLogs(ctx context.Context, spaceID, sandboxID string, follow bool, tail string) (io.ReadCloser, error) {
+	containerID, err := m.sandboxContainer(spaceID, sandboxID)
+	if err != nil {
+		return nil, err
+	}
+	// Containers run with a TTY, so the log stream is not multiplexed.
+	logs, err := m.dockerClient.ContainerLogs(ctx, containerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     follow,
+		Tail:       tail,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{newRedactingReader(logs, m.sandboxRedactions(sandboxID), false), logs}, nil
+}
@@ -0,0 +1,179 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// egressFirewall restricts the outbound traffic of sandbox containers.
+type egressFirewall interface {
+	// Apply replaces the rules for a sandbox so traffic from sourceIP may only
+	// reach the allowed CIDRs (plus replies to inbound connections and DNS).
+	Apply(ctx context.Context, sandboxID, sourceIP string, allow []string) error
+	// Remove deletes the rules of a sandbox. It is a no-op for unknown sandboxes.
+	Remove(ctx context.Context, sandboxID string) error
+	// Reset deletes the rules a previous run of the runtime left behind.
+	Reset(ctx context.Context) error
+}
+
+// iptablesFirewall enforces egress policies with a per-sandbox chain hooked into
+// Docker's DOCKER-USER chain, which Docker evaluates before its own forwarding
+// rules. The runtime must run in the host network namespace with CAP_NET_ADMIN.
+//
+// Jump rules are tagged with the runtime's scope, so a restarted runtime can
+// find the rules it no longer tracks: a container later given the same address
+// would otherwise inherit another sandbox's policy.
+type iptablesFirewall struct {
+	path    string // iptables binary
+	comment string // Tags this scope's jump rules
+
+	mu    sync.Mutex
+	jumps map[string]string // Sandbox ID to the source IP its jump rule matches
+}
+
+func newIPTablesFirewall(scope string) *iptablesFirewall {
+	return &iptablesFirewall{path: "iptables", comment: "sandboxai:" + scope, jumps: make(map[string]string)}
+}
+
+// firewallChain returns the chain holding a sandbox's rules. Chain names are
+// limited to 28 characters.
+func firewallChain(sandboxID string) string {
+	id := strings.ReplaceAll(sandboxID, "-", "")
+	if len(id) > 24 {
+		id = id[:24]
+	}
+	return "SBX-" + id
+}
+
+func (f *iptablesFirewall) run(ctx context.Context, args ...string) error {
+	_, err := f.output(ctx, args...)
+	return err
+}
+
+func (f *iptablesFirewall) output(ctx context.Context, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, f.path, append([]string{"-w"}, args...)...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("iptables %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// jump returns the DOCKER-USER rule sending traffic from sourceIP to chain.
+func (f *iptablesFirewall) jump(op, sourceIP, chain string) []string {
+	return []string{op, "DOCKER-USER", "-s", sourceIP, "-m", "comment", "--comment", f.comment, "-j", chain}
+}
+
+func (f *iptablesFirewall) Apply(ctx context.Context, sandboxID, sourceIP string, allow []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	chain := firewallChain(sandboxID)
+	if err := f.run(ctx, "-N", chain); err != nil {
+		// The chain survives container restarts; start it over.
+		if err := f.run(ctx, "-F", chain); err != nil {
+			return err
+		}
+	}
+
+	rules := [][]string{
+		{"-m", "conntrack", "--ctstate", "ESTABLISHED,RELATED", "-j", "RETURN"},
+		{"-p", "udp", "--dport", "53", "-j", "RETURN"},
+		{"-p", "tcp", "--dport", "53", "-j", "RETURN"},
+	}
+	for _, cidr := range allow {
+		rules = append(rules, []string{"-d", cidr, "-j", "RETURN"})
+	}
+	rules = append(rules, []string{"-j", "DROP"})
+	for _, rule := range rules {
+		if err := f.run(ctx, append([]string{"-A", chain}, rule...)...); err != nil {
+			return err
+		}
+	}
+
+	if oldIP, ok := f.jumps[sandboxID]; ok && oldIP != sourceIP {
+		_ = f.run(ctx, f.jump("-D", oldIP, chain)...)
+		delete(f.jumps, sandboxID)
+	}
+	if _, ok := f.jumps[sandboxID]; !ok {
+		if err := f.run(ctx, f.jump("-I", sourceIP, chain)...); err != nil {
+			return err
+		}
+		f.jumps[sandboxID] = sourceIP
+	}
+	return nil
+}
+
+func (f *iptablesFirewall) Remove(ctx context.Context, sandboxID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sourceIP, ok := f.jumps[sandboxID]
+	if !ok {
+		return nil
+	}
+	chain := firewallChain(sandboxID)
+	var firstErr error
+	for _, args := range [][]string{
+		f.jump("-D", sourceIP, chain),
+		{"-F", chain},
+		{"-X", chain},
+	} {
+		if err := f.run(ctx, args...); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	delete(f.jumps, sandboxID)
+	return firstErr
+}
+
+func (f *iptablesFirewall) Reset(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rules, err := f.output(ctx, "-S", "DOCKER-USER")
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil // No iptables, so no rules were installed
+	}
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	for _, line := range strings.Split(rules, "\n") {
+		sourceIP, chain, ok := f.parseJump(line)
+		if !ok {
+			continue
+		}
+		for _, args := range [][]string{f.jump("-D", sourceIP, chain), {"-F", chain}, {"-X", chain}} {
+			if err := f.run(ctx, args...); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	clear(f.jumps)
+	return firstErr
+}
+
+// parseJump returns the source IP and chain of a DOCKER-USER rule, as listed by
+// iptables -S, if it is a jump rule of this scope.
+func (f *iptablesFirewall) parseJump(rule string) (sourceIP, chain string, ok bool) {
+	fields := strings.Fields(rule)
+	var comment string
+	for i := 0; i+1 < len(fields); i++ {
+		switch fields[i] {
+		case "-s":
+			sourceIP = fields[i+1]
+		case "--comment":
+			comment = strings.Trim(fields[i+1], `"`)
+		case "-j":
+			chain = fields[i+1]
+		}
+	}
+	if comment != f.comment || sourceIP == "" || !strings.HasPrefix(chain, "SBX-") {
+		return "", "", false
+	}
+	return sourceIP, chain, true
+}
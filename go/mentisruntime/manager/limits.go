@@ -0,0 +1,39 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSandboxLimitReached is returned when creating a sandbox would exceed a
+// configured limit.
+var ErrSandboxLimitReached = errors.New("sandbox limit reached")
+
+// WithSandboxLimits caps the number of sandboxes on the server and in each
+// space. Zero means unlimited. Idle warm pool containers do not count.
+func WithSandboxLimits(maxSandboxes, maxPerSpace int) Option {
+	return func(m *SandboxManager) {
+		m.maxSandboxes = maxSandboxes
+		m.maxSandboxesPerSpace = maxPerSpace
+	}
+}
+
+// checkLimitsLocked reports whether another sandbox fits in spaceID. m.mu must
+// be held.
+func (m *SandboxManager) checkLimitsLocked(spaceID string) error {
+	if m.maxSandboxes > 0 && len(m.sandboxes) >= m.maxSandboxes {
+		return fmt.Errorf("%w: the server allows %d sandboxes", ErrSandboxLimitReached, m.maxSandboxes)
+	}
+	if m.maxSandboxesPerSpace > 0 {
+		count := 0
+		for _, state := range m.sandboxes {
+			if state.SpaceID == spaceID {
+				count++
+			}
+		}
+		if count >= m.maxSandboxesPerSpace {
+			return fmt.Errorf("%w: space %s allows %d sandboxes", ErrSandboxLimitReached, spaceID, m.maxSandboxesPerSpace)
+		}
+	}
+	return nil
+}
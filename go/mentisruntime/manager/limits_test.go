@@ -0,0 +1,29 @@
+package manager
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SandboxLimits(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	m := &SandboxManager{
+		sandboxes:    make(map[string]*SandboxState),
+		logger:       logger,
+		spaceManager: NewSpaceManager(logger),
+	}
+	WithSandboxLimits(3, 2)(m)
+
+	require.NoError(t, m.registerSandbox(&SandboxState{ID: "a1", SpaceID: "a"}))
+	require.NoError(t, m.registerSandbox(&SandboxState{ID: "a2", SpaceID: "a"}))
+	require.ErrorIs(t, m.registerSandbox(&SandboxState{ID: "a3", SpaceID: "a"}), ErrSandboxLimitReached, "space limit")
+	require.NoError(t, m.registerSandbox(&SandboxState{ID: "b1", SpaceID: "b"}))
+	require.ErrorIs(t, m.registerSandbox(&SandboxState{ID: "b2", SpaceID: "b"}), ErrSandboxLimitReached, "server limit")
+	require.Len(t, m.sandboxes, 3)
+
+	m.unregisterSandbox("a1", "a")
+	require.NoError(t, m.registerSandbox(&SandboxState{ID: "b2", SpaceID: "b"}))
+}
@@ -8,8 +8,8 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
-	"os"
 	"sync"
 	"time"
 
@@ -20,7 +20,15 @@ import (
 	"github.com/docker/docker/client"
 	"github.com/docker/go-connections/nat"
 	"github.com/google/uuid"
-
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/audit"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/metrics"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/recording"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/secrets"
 	"github.com/foreveryh/sandboxai/go/mentisruntime/ws"
 )
 
@@ -31,48 +39,178 @@ var (
 	ErrSandboxNotFound   = errors.New("sandbox not found")
 )
 
+// Container labels used to identify sandboxes managed by this runtime.
+const (
+	labelScope = "sandboxai.scope"
+	labelID    = "sandboxai.id"
+	labelSpace = "sandboxai.space"
+	labelPool  = "sandboxai.pool" // Set to the image name on warm pool containers, kept once claimed
+)
+
+// DefaultImage is the box image sandboxes run when neither the spec nor
+// WithDefaultImage names one.
+const DefaultImage = "mentisai/sandboxai-box:latest"
+
+// The port the agent listens on inside every box container.
+const (
+	agentPortNumber = 8000
+	agentPort       = "8000/tcp"
+)
+
 // SpaceState represents the state of a space
 type SpaceState struct {
-	ID          string
-	Name        string
-	Description string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Metadata    map[string]interface{}
-	Sandboxes   map[string]*SandboxState // Map sandboxID to its state
+	ID              string
+	Name            string
+	Description     string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Metadata        map[string]interface{}
+	Sandboxes       map[string]*SandboxState // Map sandboxID to its state
+	SecurityProfile *SecurityProfile         // Overrides the server-wide profile for sandboxes in this space
+	Quota           *SpaceQuota              // Limits on the space's sandboxes, nil for none
 }
 
 // SandboxState represents the state of a sandbox
 type SandboxState struct {
-	ID          string `json:"sandbox_id"` // Changed JSON tag back to sandbox_id
-	ContainerID string `json:"container_id,omitempty"` // Add JSON tags for consistency
-	AgentURL    string `json:"agent_url,omitempty"`    // Add JSON tags for consistency
-	IsRunning   bool   `json:"is_running"`           // Add JSON tags for consistency
-	SpaceID     string `json:"space_id,omitempty"`     // Add JSON tags for consistency
-	// Add other relevant state fields
+	ID          string           `json:"sandbox_id"`             // Changed JSON tag back to sandbox_id
+	ContainerID string           `json:"container_id,omitempty"` // Add JSON tags for consistency
+	AgentURL    string           `json:"agent_url,omitempty"`    // Add JSON tags for consistency
+	IsRunning   bool             `json:"is_running"`             // Add JSON tags for consistency
+	SpaceID     string           `json:"space_id,omitempty"`     // Add JSON tags for consistency
+	Image       string           `json:"image,omitempty"`
+	Status      SandboxStatus    `json:"status"`
+	Network     *NetworkPolicy   `json:"network,omitempty"`
+	Security    *SecurityProfile `json:"security_profile,omitempty"` // Effective profile, nil when Docker defaults apply
+	Mounts      []MountSpec      `json:"mounts,omitempty"`
+	Ports       []int            `json:"ports,omitempty"`
+	Resources   *Resources       `json:"resources,omitempty"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"` // Set when the space's quota limits the sandbox's lifetime
+	Secrets     []SecretRef      `json:"secrets,omitempty"`    // References only, never the values
+
+	network          sandboxNetwork     // Docker network the container is attached to
+	observationToken string             // Authenticates the agent's observation callbacks
+	stopChannel      context.CancelFunc // Closes the observation channel, nil when none is open
+	cancelProvision  context.CancelFunc // Aborts in-flight provisioning, nil once provisioned
+	deleting         bool               // Set by DeleteSandbox so container events are ignored
+	expiry           *time.Timer        // Deletes the sandbox at ExpiresAt
+	secretFiles      map[string]string  // Secret files to write whenever the container starts, by path
+	redactions       []string           // Secret values masked in observations
 }
 
 type SandboxManager struct {
 	mu           sync.RWMutex
-	sandboxes    map[string]*SandboxState  // Map sandboxID to its state
+	sandboxes    map[string]*SandboxState // Map sandboxID to its state
+	actions      map[string]*actionRecord // Map actionID to actions awaiting their end observation
 	httpClient   *http.Client
 	logger       *slog.Logger
 	dockerClient *client.Client // Docker client for container operations
-	hub          *ws.Hub          // WebSocket Hub for broadcasting observations
-	spaceManager *SpaceManager    // Add reference to SpaceManager
-	scope        string           // Scope for managing containers
+	hub          *ws.Hub        // WebSocket Hub for broadcasting observations
+	spaceManager *SpaceManager  // Add reference to SpaceManager
+	scope        string         // Scope for managing containers
+	pool         *warmPool      // Pre-started containers, nil when no pool is configured
+
+	statusRefreshInterval time.Duration           // Background status refresh, disabled when zero
+	restartPolicy         container.RestartPolicy // Docker restart policy of sandbox containers
+	agentBindAddress      string                  // Host address agent ports are published on
+	firewall              egressFirewall          // Enforces internal and allowlist network modes
+	securityProfile       *SecurityProfile        // Server-wide default, nil for Docker defaults
+	bindMountAllowlist    []string                // Host directories bind mounts may come from
+	callbackPortNumber    string                  // Port of the listener serving agent callbacks
+	callbackHost          string                  // How agents address the runtime, see WithCallbackHost
+	callbackURL           string                  // Advertised base URL, overrides callbackHost and the port
+	defaultImage          string                  // Box image used when a spec names none
+	agentReadyTimeout     time.Duration           // How long a new or restarted agent has to become healthy
+	maxSandboxes          int                     // Server-wide sandbox limit, unlimited when zero
+	maxSandboxesPerSpace  int                     // Per-space sandbox limit, unlimited when zero
+	meter                 *UsageMeter             // Accounts sandbox usage, nil when not metering
+	metrics               *metrics.Metrics        // Prometheus metrics, nil when not exported
+	audit                 *audit.Log              // Records finished actions, nil when not auditing
+	secrets               *secrets.Store          // Secrets specs may reference, nil when disabled
+	recorder              *recording.Recorder     // Records published observations, nil when not recording
+	usageSampleInterval   time.Duration           // Container stats sampling, disabled when zero
+	usageFlushInterval    time.Duration           // How often the usage meter is saved
+	backgroundCtx         context.Context         // Cancelled on Shutdown
+	stopBackground        context.CancelFunc      // Stops goroutines started by NewSandboxManager
+}
+
+// Option configures optional SandboxManager behaviour.
+type Option func(*SandboxManager)
+
+// WithDefaultImage sets the box image used when a sandbox spec names none.
+func WithDefaultImage(imageName string) Option {
+	return func(m *SandboxManager) {
+		m.defaultImage = imageName
+	}
+}
+
+// WithAgentReadyTimeout sets how long a new or restarted sandbox's agent has to
+// become healthy.
+func WithAgentReadyTimeout(timeout time.Duration) Option {
+	return func(m *SandboxManager) {
+		m.agentReadyTimeout = timeout
+	}
+}
+
+// WithMetrics records the manager's Prometheus metrics in mx.
+func WithMetrics(mx *metrics.Metrics) Option {
+	return func(m *SandboxManager) {
+		m.metrics = mx
+	}
+}
+
+// WithAuditLog records the end of every action in log.
+func WithAuditLog(log *audit.Log) Option {
+	return func(m *SandboxManager) {
+		m.audit = log
+	}
 }
 
 // NewSandboxManager creates a new SandboxManager.
-func NewSandboxManager(ctx context.Context, dockerClient *client.Client, hub *ws.Hub, spaceManager *SpaceManager, logger *slog.Logger, scope string) (*SandboxManager, error) {
+func NewSandboxManager(ctx context.Context, dockerClient *client.Client, hub *ws.Hub, spaceManager *SpaceManager, logger *slog.Logger, scope string, opts ...Option) (*SandboxManager, error) {
 	m := &SandboxManager{
 		sandboxes:    make(map[string]*SandboxState),
+		actions:      make(map[string]*actionRecord),
 		httpClient:   &http.Client{Timeout: 10 * time.Second}, // Add a default timeout
 		logger:       logger.With("component", "sandbox-manager"),
 		dockerClient: dockerClient,
 		hub:          hub,
 		spaceManager: spaceManager, // Store SpaceManager
 		scope:        scope,
+
+		agentBindAddress:  "127.0.0.1",
+		firewall:          newIPTablesFirewall(scope),
+		defaultImage:      DefaultImage,
+		agentReadyTimeout: 30 * time.Second,
+
+		usageFlushInterval: time.Minute,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.firewall != nil {
+		if err := m.firewall.Reset(ctx); err != nil {
+			m.logger.Warn("Failed to remove stale egress rules", "error", err)
+		}
+	}
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	m.backgroundCtx, m.stopBackground = bgCtx, stopBackground
+	if m.pool != nil {
+		m.pool.start()
+		m.logger.Info("Warm pool started", "images", len(m.pool.configs))
+	}
+	if m.dockerClient != nil {
+		go m.watchEvents(bgCtx)
+	}
+	if m.statusRefreshInterval > 0 {
+		go m.watchStatuses(bgCtx, m.statusRefreshInterval)
+		m.logger.Info("Sandbox status watcher started", "interval", m.statusRefreshInterval)
+	}
+	if m.meter != nil && m.usageFlushInterval > 0 {
+		go m.flushUsage(bgCtx, m.usageFlushInterval)
+	}
+	if m.meter != nil && m.usageSampleInterval > 0 && m.dockerClient != nil {
+		go m.watchUsage(bgCtx, m.usageSampleInterval)
+		m.logger.Info("Usage sampling started", "interval", m.usageSampleInterval)
 	}
 
 	// TODO: Consider reconciling existing Docker containers managed by this scope on startup?
@@ -80,6 +218,18 @@ func NewSandboxManager(ctx context.Context, dockerClient *client.Client, hub *ws
 	return m, nil
 }
 
+// Shutdown stops background work owned by the manager and removes idle warm pool
+// containers. Sandboxes handed out to spaces are left running.
+func (m *SandboxManager) Shutdown(ctx context.Context) {
+	m.stopBackground()
+	if m.pool != nil {
+		m.pool.stop()
+	}
+	if err := m.meter.flush(time.Now()); err != nil {
+		m.logger.Error("Failed to save usage", "error", err)
+	}
+}
+
 // SandboxExists checks if a sandbox with the given ID is known to the manager.
 // This method implements the ws.SandboxChecker interface.
 func (m *SandboxManager) SandboxExists(ctx context.Context, sandboxID string) (bool, error) {
@@ -91,6 +241,17 @@ func (m *SandboxManager) SandboxExists(ctx context.Context, sandboxID string) (b
 	return exists, nil
 }
 
+// SandboxSpace returns the ID of the space a sandbox belongs to.
+func (m *SandboxManager) SandboxSpace(sandboxID string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	state, exists := m.sandboxes[sandboxID]
+	if !exists {
+		return "", false
+	}
+	return state.SpaceID, true
+}
+
 // InitiateAction starts an action (shell or ipython) asynchronously.
 // It generates an action ID, validates the sandbox state, launches a goroutine
 // for execution, and returns the action ID immediately.
@@ -99,8 +260,11 @@ func (m *SandboxManager) InitiateAction(ctx context.Context, sandboxID string, a
 	state, exists := m.sandboxes[sandboxID]
 	m.mu.RUnlock()
 
-	if !exists || !state.IsRunning {
-		return "", fmt.Errorf("sandbox %s not found or not running", sandboxID)
+	if !exists {
+		return "", fmt.Errorf("%w: %s", ErrSandboxNotFound, sandboxID)
+	}
+	if !state.IsRunning {
+		return "", fmt.Errorf("%w: %s", ErrSandboxNotRunning, sandboxID)
 	}
 
 	actionID := uuid.NewString()
@@ -128,9 +292,17 @@ func (m *SandboxManager) InitiateAction(ctx context.Context, sandboxID string, a
 		return "", fmt.Errorf("unsupported action type: %s", actionType)
 	}
 
+	// The action's span lasts until its end observation, beyond this request.
+	actionCtx, span := tracer.Start(detachedContext(ctx), "sandbox.action", trace.WithAttributes(
+		attrSandboxID.String(sandboxID), attrSpaceID.String(state.SpaceID), attrActionID.String(actionID), attrActionType.String(actionType)))
+	if err := m.trackAction(&actionRecord{ID: actionID, SandboxID: sandboxID, SpaceID: state.SpaceID, Type: actionType, StartedAt: time.Now().UTC(), actor: audit.ActorFrom(ctx), span: span}); err != nil {
+		endSpan(span, err)
+		return "", err
+	}
+
 	// Launch the goroutine to handle the actual execution and streaming
 	m.logger.Debug("Initiating action goroutine", "sandboxID", sandboxID, "actionID", actionID, "actionType", actionType) // 添加这行
-	go m.handleActionExecution(context.Background(), sandboxID, actionID, agentURL, requestBody, actionType)
+	go m.handleActionExecution(actionCtx, sandboxID, actionID, agentURL, requestBody, actionType)
 
 	m.logger.Info("Action initiated", "sandboxID", sandboxID, "actionID", actionID, "actionType", actionType)
 	return actionID, nil // Return immediately
@@ -140,12 +312,12 @@ func (m *SandboxManager) InitiateAction(ctx context.Context, sandboxID string, a
 type Observation struct {
 	ObservationType string      `json:"observation_type"` // Corrected JSON tag
 	ActionID        string      `json:"action_id"`        // Corrected JSON tag
-	Timestamp       string      `json:"timestamp"`       // Corrected JSON tag
-	Data            interface{} `json:"data,omitempty"`  // Corrected JSON tag
+	Timestamp       string      `json:"timestamp"`        // Corrected JSON tag
+	Data            interface{} `json:"data,omitempty"`   // Corrected JSON tag
 }
 
 type StartObservationData struct {
-	// Add relevant start data if needed
+	ActionType string `json:"action_type"` // shell or ipython
 }
 
 type StreamObservationData struct {
@@ -164,40 +336,52 @@ type EndObservationData struct {
 
 // AgentObservation defines the structure expected from the agent's streaming response lines.
 type AgentObservation struct {
-	Type     string          `json:"type"`               // Corrected JSON tag
-	Stream   string          `json:"stream,omitempty"`   // Corrected JSON tag
-	Line     string          `json:"line,omitempty"`     // Corrected JSON tag
-	ExitCode *int            `json:"exit_code,omitempty"` // Corrected JSON tag
-	Error    string          `json:"error,omitempty"`     // Corrected JSON tag
+	Type     string `json:"type"`                // Corrected JSON tag
+	Stream   string `json:"stream,omitempty"`    // Corrected JSON tag
+	Line     string `json:"line,omitempty"`      // Corrected JSON tag
+	ExitCode *int   `json:"exit_code,omitempty"` // Corrected JSON tag
+	Error    string `json:"error,omitempty"`     // Corrected JSON tag
 }
 
 // handleActionExecution runs in a goroutine to execute the action via the internal agent.
 // It only handles the initial request and immediate HTTP errors.
 // Subsequent observations (stream, result) are handled by ReceiveInternalObservation.
 func (m *SandboxManager) handleActionExecution(ctx context.Context, sandboxID, actionID, agentURL string, requestBody []byte, actionType string) {
-	m.logger.Debug("Goroutine started for action", "sandboxID", sandboxID, "actionID", actionID, "actionType", actionType) 
+	m.logger.Debug("Goroutine started for action", "sandboxID", sandboxID, "actionID", actionID, "actionType", actionType)
 	// Send StartObservation immediately via the Hub
-	m.pushObservation(sandboxID, actionID, "start", StartObservationData{})
+	m.pushObservation(sandboxID, actionID, "start", StartObservationData{ActionType: actionType})
+
+	ctx, span := tracer.Start(ctx, "agent.request", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attrSandboxID.String(sandboxID), attrActionID.String(actionID), attribute.String("url.full", agentURL)))
+	var spanErr error
+	defer func() { endSpan(span, spanErr) }()
 
 	req, err := http.NewRequestWithContext(ctx, "POST", agentURL, bytes.NewReader(requestBody))
 	if err != nil {
+		spanErr = err
 		errMsg := fmt.Sprintf("Failed to create request to agent: %v", err)
+		m.finishAction(actionID, -1)
 		m.pushErrorObservation(sandboxID, actionID, errMsg)
 		m.pushObservation(sandboxID, actionID, "end", EndObservationData{ExitCode: -1, Error: errMsg})
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
+	// The agent sends the trace context back with its observations.
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 	// We don't strictly need Accept header anymore if we don't read the body for observations
-	// req.Header.Set("Accept", "application/x-ndjson") 
+	// req.Header.Set("Accept", "application/x-ndjson")
 
 	resp, err := m.httpClient.Do(req)
 	if err != nil {
+		spanErr = err
 		errMsg := fmt.Sprintf("Failed to execute action request via agent: %v", err)
+		m.finishAction(actionID, -1)
 		m.pushErrorObservation(sandboxID, actionID, errMsg)
 		m.pushObservation(sandboxID, actionID, "end", EndObservationData{ExitCode: -1, Error: errMsg})
 		return
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 
 	// Handle only immediate HTTP errors from the agent
 	if resp.StatusCode >= 400 {
@@ -208,6 +392,8 @@ func (m *SandboxManager) handleActionExecution(ctx context.Context, sandboxID, a
 		} else if readErr != nil {
 			errorMsg += fmt.Sprintf(" (failed to read error body: %v)", readErr)
 		}
+		spanErr = errors.New(errorMsg)
+		m.finishAction(actionID, -1)
 		m.pushErrorObservation(sandboxID, actionID, errorMsg)
 		m.pushObservation(sandboxID, actionID, "end", EndObservationData{ExitCode: -1, Error: errorMsg})
 		return
@@ -238,8 +424,7 @@ func (m *SandboxManager) pushObservation(sandboxID, actionID, obsType string, da
 	}
 
 	m.logger.Debug("Pushing observation via Hub", "sandboxID", sandboxID, "actionID", actionID, "type", obsType, "size", len(jsonData))
-	// Send via Hub
-	m.hub.SubmitBroadcast(sandboxID, jsonData)
+	m.publish(sandboxID, jsonData)
 }
 
 // pushErrorObservation formats and sends an error observation.
@@ -249,135 +434,423 @@ func (m *SandboxManager) pushErrorObservation(sandboxID, actionID, errorMsg stri
 }
 
 // CreateSandbox creates and starts a new sandbox container within a specific space.
-// It pulls the necessary image, creates and starts the container,
-// discovers its IP address, performs a health check on the agent,
-// and stores its state.
-func (m *SandboxManager) CreateSandbox(ctx context.Context, spaceID string, imageArg string, command []string) (string, error) { // command is now []string
+// If the warm pool holds a ready container for the requested image, that container
+// is claimed instead. Otherwise it pulls the necessary image, creates and starts
+// the container, discovers its agent URL, performs a health check on the agent,
+// and stores its state. The call blocks until the sandbox is ready.
+func (m *SandboxManager) CreateSandbox(ctx context.Context, spaceID string, spec SandboxSpec, command []string) (string, error) { // command is now []string
+	state, err := m.beginSandbox(ctx, spaceID, &spec)
+	if err != nil {
+		return "", err
+	}
+	if state.IsRunning {
+		return state.ID, nil // Claimed from the warm pool
+	}
+
+	if err := m.provisionSandbox(ctx, state.ID, spec); err != nil {
+		// A synchronous create that fails leaves nothing behind.
+		m.unregisterSandbox(state.ID, state.SpaceID)
+		return "", err
+	}
+	return state.ID, nil
+}
+
+// CreateSandboxAsync registers a sandbox and provisions it in the background.
+// It returns as soon as the sandbox is registered, typically in the provisioning
+// state; progress is reflected in its Status and published on its stream as
+// "provisioning" observations. A sandbox that fails to provision stays registered
+// in the failed state until it is deleted.
+func (m *SandboxManager) CreateSandboxAsync(ctx context.Context, spaceID string, spec SandboxSpec, command []string) (*SandboxState, error) {
+	state, err := m.beginSandbox(ctx, spaceID, &spec)
+	if err != nil {
+		return nil, err
+	}
+	if state.IsRunning {
+		return state, nil // Claimed from the warm pool
+	}
+
+	// Provisioning must outlive the request that triggered it.
+	provisionCtx, cancel := context.WithCancel(detachedContext(ctx))
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	if s, ok := m.sandboxes[state.ID]; ok {
+		s.cancelProvision = cancel
+	}
+	m.mu.Unlock()
 
+	go func() {
+		defer cancel()
+		if err := m.provisionSandbox(provisionCtx, state.ID, spec); err != nil {
+			m.logger.Error("Asynchronous sandbox provisioning failed", "sandboxID", state.ID, "spaceID", spaceID, "error", err)
+		}
+	}()
+	return state, nil
+}
+
+// beginSandbox validates the space and spec, claims a warm pool container if one
+// is available, and otherwise registers a new sandbox in the pending phase. It
+// returns a copy of the registered state.
+func (m *SandboxManager) beginSandbox(ctx context.Context, spaceID string, spec *SandboxSpec) (*SandboxState, error) {
 	// Check if space exists using SpaceManager
-	_, err := m.spaceManager.GetSpace(ctx, spaceID)
+	space, err := m.spaceManager.GetSpace(ctx, spaceID)
 	if err != nil {
 		if errors.Is(err, ErrSpaceNotFound) {
-			return "", ErrSpaceNotFound // Return the specific error
+			return nil, ErrSpaceNotFound // Return the specific error
 		} else {
 			m.logger.Error("Failed to check space existence before creating sandbox", "spaceID", spaceID, "error", err)
-			return "", fmt.Errorf("failed to verify space %s: %w", spaceID, err)
+			return nil, fmt.Errorf("failed to verify space %s: %w", spaceID, err)
 		}
 	}
 
-	sandboxID := uuid.NewString() // Generate a unique ID
+	if spec.Image == "" {
+		spec.Image = m.defaultImage
+	}
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	if err := m.validateMounts(spec); err != nil {
+		return nil, err
+	}
+	if err := m.resolveSecrets(spaceID, spec); err != nil {
+		return nil, err
+	}
+	imageName := spec.Image
+	// A space's own profile replaces the server-wide one.
+	spec.security = m.securityProfile
+	if space.SecurityProfile != nil {
+		spec.security = space.SecurityProfile
+	}
+	m.logger.Debug("Using box image", "image", imageName, "network", spec.Network.Mode)
+
+	// Fast path: claim a pre-started container from the warm pool. Pooled
+	// containers run with the server-wide security profile.
+	if m.pool != nil && spec.pooled() && spec.security == m.securityProfile {
+		claimStart := time.Now()
+		if state := m.pool.claim(ctx, imageName, spaceID); state != nil {
+			now := time.Now().UTC()
+			state.SpaceID = spaceID
+			state.Image = imageName
+			state.Network = spec.Network
+			state.Security = spec.security
+			state.Resources = spec.Resources
+			state.Status = SandboxStatus{State: StateProvisioning, CreatedAt: now}
+			state.Status.setPhase(PhaseReady, now)
+			if err := m.registerSandbox(state); err != nil {
+				m.removeContainer(state.ContainerID)
+				return nil, err
+			}
+			m.openObservationChannel(state.ID)
+			m.metrics.ObserveCreate(claimStart, nil)
+			m.logger.Info("Sandbox claimed from warm pool", "sandboxID", state.ID, "containerID", state.ContainerID, "spaceID", spaceID, "image", imageName)
+			stateCopy := *state
+			return &stateCopy, nil
+		}
+	}
 
-	// Get image name from environment variable or use default
-	imageName := imageArg
-	if imageName == "" {
-		imageName = os.Getenv("BOX_IMAGE")
-		if imageName == "" {
-			imageName = "mentisai/sandboxai-box:latest" // Default if no environment variable set
+	state := &SandboxState{
+		ID:        uuid.NewString(), // Generate a unique ID
+		SpaceID:   spaceID,
+		Image:     imageName,
+		Network:   spec.Network,
+		Security:  spec.security,
+		Mounts:    spec.Mounts,
+		Ports:     spec.Ports,
+		Resources: spec.Resources,
+		Secrets:   spec.Secrets,
+		Status: SandboxStatus{
+			State:     StateProvisioning,
+			Phase:     PhasePending,
+			CreatedAt: time.Now().UTC(),
+		},
+	}
+	state.secretFiles = spec.secretFiles()
+	state.redactions = spec.redactedValues()
+	if err := m.registerSandbox(state); err != nil {
+		return nil, err
+	}
+	m.logger.Info("Creating sandbox", "sandboxID", state.ID, "spaceID", spaceID, "image", imageName)
+
+	stateCopy := *state
+	return &stateCopy, nil
+}
+
+// provisionSandbox creates the container for a registered sandbox, keeping its
+// status up to date and publishing progress on the sandbox stream.
+func (m *SandboxManager) provisionSandbox(ctx context.Context, sandboxID string, spec SandboxSpec) (err error) {
+	m.mu.RLock()
+	state, exists := m.sandboxes[sandboxID]
+	var spaceID string
+	var createdAt time.Time
+	if exists {
+		spaceID = state.SpaceID
+		createdAt = state.Status.CreatedAt
+	}
+	m.mu.RUnlock()
+	if !exists {
+		return ErrSandboxNotFound
+	}
+	ctx, span := tracer.Start(ctx, "sandbox.provision", trace.WithAttributes(
+		attrSandboxID.String(sandboxID), attrSpaceID.String(spaceID), attrImage.String(spec.Image)))
+	defer func() {
+		m.metrics.ObserveCreate(createdAt, err)
+		endSpan(span, err)
+	}()
+
+	labels := map[string]string{
+		labelScope: m.scope,
+		labelID:    sandboxID,
+		labelSpace: spaceID, // Add space label
+	}
+	report := func(u provisionUpdate) {
+		m.mu.Lock()
+		if s, ok := m.sandboxes[sandboxID]; ok {
+			if u.ContainerID != "" {
+				s.ContainerID = u.ContainerID
+			}
+			if u.Phase != "" && u.Phase != s.Status.Phase {
+				s.Status.setPhase(u.Phase, time.Now().UTC())
+			}
+			if u.Message != "" {
+				s.Status.Message = u.Message
+			}
+			if u.PullProgress != nil {
+				s.Status.PullProgress = u.PullProgress
+			}
+		}
+		m.mu.Unlock()
+		if u.Phase != "" || u.PullProgress != nil {
+			m.pushObservation(sandboxID, "", "provisioning", ProvisioningObservationData{Phase: u.Phase, Message: u.Message, PullProgress: u.PullProgress})
 		}
 	}
-	m.logger.Debug("Using box image", "image", imageName)
 
-	agentPortInt := 8000
-	agentPortProto := "tcp"
-	agentPortString := fmt.Sprintf("%d/%s", agentPortInt, agentPortProto)
+	nw := m.networkFor(spaceID, sandboxID, spec.Network)
+	created, err := m.provisionContainer(ctx, sandboxID, spec, nw, labels, report)
+	if err != nil {
+		reason := provisionFailureReason(err)
+		if ctx.Err() != nil {
+			reason = ReasonProvisionCancelled
+		}
+		m.mu.Lock()
+		if s, ok := m.sandboxes[sandboxID]; ok {
+			s.ContainerID = ""
+			s.Status.Reason = reason
+			s.Status.Message = err.Error()
+			s.Status.setPhase(PhaseFailed, time.Now().UTC())
+			s.cancelProvision = nil
+		}
+		m.meter.stop(sandboxID, time.Now()) // Metering starts once provisioned, so this accounts nothing
+		m.mu.Unlock()
+		m.pushObservation(sandboxID, "", "provisioning", ProvisioningObservationData{Phase: PhaseFailed, Reason: reason, Message: err.Error()})
+		return err
+	}
 
-	m.logger.Info("Creating sandbox", "sandboxID", sandboxID, "spaceID", spaceID, "image", imageName)
+	m.mu.Lock()
+	s, ok := m.sandboxes[sandboxID]
+	if ok {
+		s.ContainerID = created.ContainerID
+		s.AgentURL = created.AgentURL
+		s.IsRunning = true
+		s.network = nw
+		s.observationToken = created.observationToken
+		s.Status.Message = ""
+		s.Status.setPhase(PhaseReady, time.Now().UTC())
+		s.cancelProvision = nil
+		m.meter.start(spaceID, sandboxID, time.Now())
+	}
+	m.mu.Unlock()
+	if !ok {
+		// Deleted while provisioning; the container is no longer wanted.
+		m.removeContainer(created.ContainerID)
+		m.releaseNetwork(sandboxID, nw)
+		return ErrSandboxNotFound
+	}
+	m.pushObservation(sandboxID, "", "provisioning", ProvisioningObservationData{Phase: PhaseReady})
+	m.openObservationChannel(sandboxID)
 
-	// 1. Ensure image exists locally
-	// Use a shorter timeout for image pull check/pull
-	pullCtx, pullCancel := context.WithTimeout(ctx, 5*time.Minute)
-	defer pullCancel()
+	m.logger.Info("Sandbox created and registered successfully", "sandboxID", sandboxID, "containerID", created.ContainerID, "agentURL", created.AgentURL, "spaceID", spaceID)
+	return nil
+}
 
-	// First check if image exists locally
-	inspectCtx, inspectCancel := context.WithTimeout(ctx, 10*time.Second)
-	defer inspectCancel()
-	_, _, errInspect := m.dockerClient.ImageInspectWithRaw(inspectCtx, imageName)
-	if errInspect == nil {
-		// Image exists locally, no need to pull
-		m.logger.Info("Image exists locally, skipping pull", "image", imageName)
-	} else {
-		// Try to pull the image only if it doesn't exist locally
-		m.logger.Info("Image not found locally, attempting to pull", "image", imageName)
-		// Corrected: Use image.PullOptions{} instead of types.
-		out, err := m.dockerClient.ImagePull(pullCtx, imageName, image.PullOptions{})
-		if err != nil {
-			m.logger.Error("Failed to pull image", "image", imageName, "error", err)
-			return "", fmt.Errorf("failed to pull image %s: %w", imageName, err)
+// registerSandbox stores a sandbox in the manager's map and links it to its space.
+func (m *SandboxManager) registerSandbox(state *SandboxState) error {
+	m.mu.Lock()
+	if err := m.checkLimitsLocked(state.SpaceID); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	if err := m.checkQuotaLocked(state); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	m.sandboxes[state.ID] = state
+	m.scheduleExpiry(state)
+	// Runtime is metered while the container runs, so pending sandboxes start
+	// once provisioned. The meter is updated under m.mu to stay ordered with
+	// unregisterSandbox.
+	if state.IsRunning {
+		m.meter.start(state.SpaceID, state.ID, time.Now())
+	}
+	m.mu.Unlock()
+	m.metrics.SandboxAdded(state.SpaceID)
+
+	// Add sandbox reference to the space using SpaceManager
+	if err := m.spaceManager.addSandboxToSpace(state.SpaceID, state.ID, state); err != nil {
+		// This should ideally not happen if space check passed, but handle defensively
+		m.logger.Error("Failed to add sandbox reference to space after creating container", "spaceID", state.SpaceID, "sandboxID", state.ID, "error", err)
+		// Consider cleanup? For now, log and continue, sandbox exists but space link failed.
+	}
+	return nil
+}
+
+// unregisterSandbox removes a sandbox from the manager's map and from its space.
+func (m *SandboxManager) unregisterSandbox(sandboxID, spaceID string) {
+	m.mu.Lock()
+	state, exists := m.sandboxes[sandboxID]
+	if exists {
+		if state.stopChannel != nil {
+			state.stopChannel()
 		}
-		// IMPORTANT: Block and drain the output to ensure the pull completes before proceeding.
-		// Discard the output, but log errors if reading fails.
-		defer out.Close()
-		if _, err = io.Copy(io.Discard, out); err != nil {
-			m.logger.Error("Failed reading image pull output", "image", imageName, "error", err)
-			return "", fmt.Errorf("failed reading image pull output for %s: %w", imageName, err)
+		if state.expiry != nil {
+			state.expiry.Stop()
 		}
-		m.logger.Info("Image pull completed", "image", imageName)
+	}
+	delete(m.sandboxes, sandboxID)
+	taken := m.takeActionsLocked(sandboxID)
+	m.mu.Unlock()
+	for _, rec := range taken {
+		m.recordActionEnd(rec, -1)
+	}
+	m.meter.stop(sandboxID, time.Now())
+	m.recorder.Finish(spaceID, sandboxID)
+	if exists {
+		m.metrics.SandboxRemoved(spaceID)
 	}
 
-	// Add an explicit check after pulling to ensure the image exists locally
-	// Use a new context for this inspection to avoid using the already potentially cancelled inspectCtx
-	inspectCtx2, inspectCancel2 := context.WithTimeout(ctx, 10*time.Second)
-	defer inspectCancel2()
-	_, _, errInspect2 := m.dockerClient.ImageInspectWithRaw(inspectCtx2, imageName)
-	if errInspect2 != nil {
-		m.logger.Error("Image inspect failed after pull", "image", imageName, "error", errInspect2)
-		return "", fmt.Errorf("image %s not found locally after pull attempt: %w", imageName, errInspect2)
+	// Remove sandbox reference from the space using SpaceManager
+	if errSpace := m.spaceManager.removeSandboxFromSpace(spaceID, sandboxID); errSpace != nil {
+		// Log error but don't make the overall deletion fail because of this
+		m.logger.Error("Failed to remove sandbox reference from space", "spaceID", spaceID, "sandboxID", sandboxID, "error", errSpace)
+	}
+}
+
+// provisionContainer ensures the image exists, then creates and starts a container
+// for sandboxID on network nw and waits until its agent is healthy. The returned
+// state is not registered with the manager; on failure the container, and the
+// network if the sandbox owns it, are removed.
+func (m *SandboxManager) provisionContainer(ctx context.Context, sandboxID string, spec SandboxSpec, nw sandboxNetwork, labels map[string]string, report provisionReporter) (state *SandboxState, err error) {
+	imageName := spec.Image
+	// 1. Ensure image exists locally
+	phaseStart := time.Now()
+	if err := m.ensureImage(ctx, imageName, report); err != nil {
+		return nil, &provisionError{Reason: ReasonImagePullFailed, Err: err}
+	}
+	m.metrics.ObserveCreatePhase(metrics.PhasePull, phaseStart)
+	report.report(provisionUpdate{Phase: PhaseStarting, Message: "Starting container"})
+
+	networkLabels := map[string]string{labelScope: m.scope}
+	for _, key := range []string{labelSpace, labelPool} {
+		if v, ok := labels[key]; ok && nw.Shared {
+			networkLabels[key] = v
+		}
+	}
+	if !nw.Shared {
+		networkLabels[labelID] = sandboxID
+	}
+	if err := m.ensureNetwork(ctx, nw, networkLabels); err != nil {
+		return nil, &provisionError{Reason: ReasonNetworkFailed, Err: err}
+	}
+	if !nw.Shared {
+		defer func() {
+			if err != nil {
+				m.removeNetwork(nw.Name)
+			}
+		}()
 	}
-	m.logger.Info("Image confirmed to exist locally", "image", imageName)
 
 	// 2. Create the container
+	phaseStart = time.Now()
 	containerName := fmt.Sprintf("sandboxai-%s-%s", m.scope, sandboxID)
-	labels := map[string]string{
-		"sandboxai.scope": m.scope,
-		"sandboxai.id":    sandboxID,
-		"sandboxai.space": spaceID, // Add space label
+	mounts, err := m.prepareMounts(ctx, labels[labelSpace], spec.Mounts)
+	if err != nil {
+		return nil, &provisionError{Reason: ReasonContainerFailed, Err: err}
 	}
-	// Determine the host address Runtime is listening on, as seen from the container
-	// Using host.docker.internal which works for Docker Desktop. Might need configuration for other environments.
-	runtimeHost := "host.docker.internal"
-	// Get the port Runtime is listening on (assuming it's passed via env var or default)
-	runtimePort := os.Getenv("SANDBOXAID_PORT")
-	if runtimePort == "" {
-		runtimePort = "5266" // Default port used in main.go
+
+	// Determine the runtime's address as seen from the container
+	callbackBase, extraHosts, err := m.callbackEndpoint(ctx, nw)
+	if err != nil {
+		return nil, &provisionError{Reason: ReasonNetworkFailed, Err: err}
+	}
+	internalObservationURL := fmt.Sprintf("%s/v1/internal/observations/%s", callbackBase, sandboxID)
+	observationToken, err := newObservationToken()
+	if err != nil {
+		return nil, &provisionError{Reason: ReasonContainerFailed, Err: err}
 	}
-	internalObservationURL := fmt.Sprintf("http://%s:%s/v1/internal/observations/%s", runtimeHost, runtimePort, sandboxID)
 
-	envVars := []string{
+	envVars := append(spec.envList(),
 		fmt.Sprintf("SANDBOX_ID=%s", sandboxID),
 		// Add other necessary env vars for the agent
 		fmt.Sprintf("RUNTIME_OBSERVATION_URL=%s", internalObservationURL), // Add URL for agent to push observations
+		fmt.Sprintf("RUNTIME_OBSERVATION_TOKEN=%s", observationToken),
+	)
+	envVars = append(envVars, spec.secretEnv()...)
+	if names := spec.secretEnvNames(); names != "" {
+		labels[labelSecretEnv] = names
+	}
+
+	// Docker does not publish ports of containers on internal networks; the
+	// runtime reaches those agents at their container address instead.
+	exposedPorts := nat.PortSet{nat.Port(agentPort): struct{}{}}
+	for _, p := range spec.Ports {
+		exposedPorts[servicePort(p)] = struct{}{}
+	}
+	var portBindings nat.PortMap
+	if !nw.Internal {
+		portBindings = nat.PortMap{}
+		for p := range exposedPorts {
+			portBindings[p] = []nat.PortBinding{
+				{
+					HostIP:   m.agentBindAddress, // Loopback by default so agents are not exposed off-host
+					HostPort: "",                 // Let Docker assign a random available port
+				},
+			}
+		}
 	}
 
 	// Use a shorter timeout for container operations
 	createCtx, createCancel := context.WithTimeout(ctx, 30*time.Second)
 	defer createCancel()
 
+	containerConfig := &container.Config{
+		Image:  imageName,
+		Labels: labels,
+		Env:    envVars,
+		// Expose the agent port and the spec's service ports
+		ExposedPorts: exposedPorts,
+		Tty:          true,
+		OpenStdin:    true,
+	}
+	hostConfig := &container.HostConfig{
+		NetworkMode:   container.NetworkMode(nw.Name),
+		PortBindings:  portBindings,
+		RestartPolicy: m.restartPolicy,
+		Mounts:        mounts,
+		ExtraHosts:    extraHosts,
+		// AutoRemove: true, // Consider adding this if desired
+	}
+	spec.Resources.apply(hostConfig)
+	if err := spec.security.apply(containerConfig, hostConfig); err != nil {
+		return nil, &provisionError{Reason: ReasonContainerFailed, Err: fmt.Errorf("failed to apply security profile: %w", err)}
+	}
+	if len(spec.secretFiles()) > 0 {
+		if hostConfig.Tmpfs == nil {
+			hostConfig.Tmpfs = make(map[string]string)
+		}
+		hostConfig.Tmpfs[SecretsDir] = secretsTmpfs(spec.security)
+	}
+
 	resp, err := m.dockerClient.ContainerCreate(
 		createCtx,
-		&container.Config{
-			Image:        imageName,
-			Labels:       labels,
-			Env:          envVars,
-			// Expose agent port
-			ExposedPorts: nat.PortSet{nat.Port(agentPortString): struct{}{}},
-			Tty:          true,
-			OpenStdin:    true,
-		},
-		&container.HostConfig{
-			NetworkMode: "bridge",
-			// Re-introduce PortBindings for reliable connection
-			PortBindings: nat.PortMap{
-				nat.Port(agentPortString): []nat.PortBinding{
-					{
-						HostIP:   "0.0.0.0", // Bind to all host interfaces
-						HostPort: "",      // Let Docker assign a random available port
-					},
-				},
-			},
-			// AutoRemove: true, // Consider adding this if desired
-		},
+		containerConfig,
+		hostConfig,
 		&network.NetworkingConfig{ // Default network is usually fine
 		},
 		nil, // Platform is usually nil
@@ -385,61 +858,138 @@ func (m *SandboxManager) CreateSandbox(ctx context.Context, spaceID string, imag
 	)
 	if err != nil {
 		m.logger.Error("Failed to create container", "sandboxID", sandboxID, "name", containerName, "error", err)
-		return "", fmt.Errorf("failed to create container: %w", err)
+		return nil, &provisionError{Reason: ReasonContainerFailed, Err: fmt.Errorf("failed to create container: %w", err)}
 	}
+	report.report(provisionUpdate{ContainerID: resp.ID})
+	m.metrics.ObserveCreatePhase(metrics.PhaseCreate, phaseStart)
 
 	m.logger.Info("Container created", "sandboxID", sandboxID, "containerID", resp.ID, "name", containerName)
 
 	// 3. Start the container
+	phaseStart = time.Now()
 	startCtx, startCancel := context.WithTimeout(ctx, 15*time.Second)
 	defer startCancel()
 	if err := m.dockerClient.ContainerStart(startCtx, resp.ID, container.StartOptions{}); err != nil {
 		m.logger.Error("Failed to start container", "sandboxID", sandboxID, "containerID", resp.ID, "error", err)
 		// Attempt to remove the created container on start failure
-		rmCtx, rmCancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer rmCancel()
-		if rmErr := m.dockerClient.ContainerRemove(rmCtx, resp.ID, container.RemoveOptions{Force: true}); rmErr != nil {
-			m.logger.Error("Failed to remove container after start failure", "containerID", resp.ID, "removeError", rmErr)
-		}
-		return "", fmt.Errorf("failed to start container %s: %w", resp.ID, err)
-	}
-	
-	// 添加诊断日志，查看容器是否成功启动
-	m.logger.Info("Container started, checking status", "sandboxID", sandboxID, "containerID", resp.ID)
-	
-	// 立即检查容器状态，添加更多诊断信息
-	diagCtx, diagCancel := context.WithTimeout(ctx, 5*time.Second)
-	defer diagCancel()
-	inspectAfterStart, diagErr := m.dockerClient.ContainerInspect(diagCtx, resp.ID)
-	if diagErr != nil {
-		m.logger.Warn("Failed to inspect container after start for diagnostics", "error", diagErr)
-	} else {
-		m.logger.Info("Container status after start", 
-			"state", inspectAfterStart.State.Status,
-			"running", inspectAfterStart.State.Running,
-			"exitCode", inspectAfterStart.State.ExitCode,
-			"error", inspectAfterStart.State.Error,
-			"startedAt", inspectAfterStart.State.StartedAt)
+		m.removeContainer(resp.ID)
+		return nil, &provisionError{Reason: ReasonContainerFailed, Err: fmt.Errorf("failed to start container %s: %w", resp.ID, err)}
+	}
+	m.metrics.ObserveCreatePhase(metrics.PhaseStart, phaseStart)
+
+	// 4. Restrict egress before anything runs in the sandbox on the agent's behalf
+	if err := m.applyEgressPolicy(ctx, sandboxID, resp.ID, nw, spec.Network); err != nil {
+		m.removeContainer(resp.ID)
+		m.removeEgressPolicy(sandboxID)
+		return nil, &provisionError{Reason: ReasonNetworkFailed, Err: err}
+	}
+
+	// Secret files are in place before anything runs on the agent's behalf
+	if err := m.writeSecretFiles(ctx, resp.ID, spec.secretFiles()); err != nil {
+		m.removeContainer(resp.ID)
+		m.removeEgressPolicy(sandboxID)
+		return nil, &provisionError{Reason: ReasonContainerFailed, Err: err}
+	}
+
+	// 5. Get Agent URL - Prioritize Port Mapping
+	phaseStart = time.Now()
+	agentURL, err := m.resolveAgentURL(ctx, sandboxID, resp.ID)
+	if err != nil {
+		m.removeContainer(resp.ID)
+		m.removeEgressPolicy(sandboxID)
+		return nil, &provisionError{Reason: ReasonContainerFailed, Err: err}
+	}
+	m.metrics.ObserveCreatePhase(metrics.PhasePortDiscovery, phaseStart)
+	report.report(provisionUpdate{Message: "Waiting for agent"})
+
+	m.logger.Info("Constructed agent URL", "sandboxID", sandboxID, "agentURL", agentURL)
+
+	// 6. Health Check
+	healthCheckURL := fmt.Sprintf("%s/health", agentURL)
+	agentReadyTimeout := m.agentReadyTimeout
+	m.logger.Info("Starting agent health check", "sandboxID", sandboxID, "healthURL", healthCheckURL, "timeout", agentReadyTimeout)
+
+	phaseStart = time.Now()
+	if err := m.waitForAgentReady(ctx, healthCheckURL, agentReadyTimeout); err != nil {
+		m.logger.Error("Agent health check failed", "sandboxID", sandboxID, "healthURL", healthCheckURL, "error", err)
+		m.removeContainer(resp.ID)
+		m.removeEgressPolicy(sandboxID)
+		return nil, &provisionError{Reason: ReasonAgentUnreachable, Err: fmt.Errorf("agent health check failed: %w", err)}
+	}
+	m.metrics.ObserveCreatePhase(metrics.PhaseHealthCheck, phaseStart)
+	m.logger.Info("Agent health check successful", "sandboxID", sandboxID)
+
+	return &SandboxState{
+		ID:          sandboxID,
+		ContainerID: resp.ID,
+		AgentURL:    agentURL,
+		IsRunning:   true,
+
+		observationToken: observationToken,
+	}, nil
+}
+
+// ensureImage makes sure imageName is available locally, pulling it if necessary.
+// Pull progress parsed from the Docker output is passed to report.
+func (m *SandboxManager) ensureImage(ctx context.Context, imageName string, report provisionReporter) error {
+	// First check if image exists locally
+	inspectCtx, inspectCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer inspectCancel()
+	_, _, errInspect := m.dockerClient.ImageInspectWithRaw(inspectCtx, imageName)
+	if errInspect == nil {
+		// Image exists locally, no need to pull
+		m.logger.Info("Image exists locally, skipping pull", "image", imageName)
+		return nil
+	}
+
+	// Try to pull the image only if it doesn't exist locally
+	m.logger.Info("Image not found locally, attempting to pull", "image", imageName)
+	report.report(provisionUpdate{Phase: PhasePulling, Message: fmt.Sprintf("Pulling image %s", imageName)})
+	pullCtx, pullCancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer pullCancel()
+	out, err := m.dockerClient.ImagePull(pullCtx, imageName, image.PullOptions{})
+	if err != nil {
+		m.logger.Error("Failed to pull image", "image", imageName, "error", err)
+		return fmt.Errorf("failed to pull image %s: %w", imageName, err)
+	}
+	// IMPORTANT: Block and drain the output to ensure the pull completes before proceeding.
+	defer out.Close()
+	err = consumePullStream(out, imageName, time.Second, func(p *PullProgress) {
+		report.report(provisionUpdate{PullProgress: p})
+	})
+	if err != nil {
+		m.logger.Error("Failed reading image pull output", "image", imageName, "error", err)
+		return fmt.Errorf("failed reading image pull output for %s: %w", imageName, err)
+	}
+	m.logger.Info("Image pull completed", "image", imageName)
+
+	// Add an explicit check after pulling to ensure the image exists locally
+	inspectCtx2, inspectCancel2 := context.WithTimeout(ctx, 10*time.Second)
+	defer inspectCancel2()
+	if _, _, err := m.dockerClient.ImageInspectWithRaw(inspectCtx2, imageName); err != nil {
+		m.logger.Error("Image inspect failed after pull", "image", imageName, "error", err)
+		return fmt.Errorf("image %s not found locally after pull attempt: %w", imageName, err)
 	}
+	m.logger.Info("Image confirmed to exist locally", "image", imageName)
+	return nil
+}
 
-	// 4. Get Agent URL - Prioritize Port Mapping
+// resolveAgentURL waits for the container to run and returns the URL of its agent,
+// preferring the published host port and falling back to the container IP.
+func (m *SandboxManager) resolveAgentURL(ctx context.Context, sandboxID, containerID string) (string, error) {
 	var agentURL string
-	var containerIP string // Still try to get IP for logging/fallback
-	var mappedPort string
-	var inspectData types.ContainerJSON
 	maxRetries := 5
 	retryDelay := 1 * time.Second
 
-	m.logger.Info("Waiting for container network setup and port mapping", "sandboxID", sandboxID, "containerID", resp.ID, "maxRetries", maxRetries)
+	m.logger.Info("Waiting for container network setup and port mapping", "sandboxID", sandboxID, "containerID", containerID, "maxRetries", maxRetries)
 
-	var lastInspectErr error
 	for retry := 0; retry < maxRetries; retry++ {
-		inspectCtxRetry, inspectCancelRetry := context.WithTimeout(ctx, 10*time.Second)
-		inspectData, lastInspectErr = m.dockerClient.ContainerInspect(inspectCtxRetry, resp.ID)
-		inspectCancelRetry()
+		inspectCtx, inspectCancel := context.WithTimeout(ctx, 10*time.Second)
+		inspectData, err := m.dockerClient.ContainerInspect(inspectCtx, containerID)
+		inspectCancel()
 
-		if lastInspectErr != nil {
-			m.logger.Warn("Container inspect failed on retry", "retry", retry+1, "error", lastInspectErr)
+		if err != nil {
+			m.logger.Warn("Container inspect failed on retry", "retry", retry+1, "error", err)
 			time.Sleep(retryDelay)
 			continue
 		}
@@ -451,14 +1001,11 @@ func (m *SandboxManager) CreateSandbox(ctx context.Context, spaceID string, imag
 		}
 
 		// Check for Port Mapping first
-		if inspectData.NetworkSettings != nil && len(inspectData.NetworkSettings.Ports) > 0 {
-			if portBindings, exists := inspectData.NetworkSettings.Ports[nat.Port(agentPortString)]; exists && len(portBindings) > 0 && portBindings[0].HostPort != "" {
-				mappedPort = portBindings[0].HostPort
-				m.logger.Info("Found mapped port", "containerPort", agentPortString, "hostPort", mappedPort)
-				// Construct URL using localhost and mapped port
-				agentURL = fmt.Sprintf("http://localhost:%s", mappedPort)
-				break // Found the preferred URL
-			}
+		if hostPort := mappedHostPort(inspectData, agentPort); hostPort != "" {
+			m.logger.Info("Found mapped port", "containerPort", agentPort, "hostPort", hostPort)
+			// Construct URL using the published address and mapped port
+			agentURL = fmt.Sprintf("http://%s", net.JoinHostPort(m.agentHost(), hostPort))
+			break // Found the preferred URL
 		}
 
 		m.logger.Info("Mapped port not found yet, retrying", "retry", retry+1, "maxRetries", maxRetries)
@@ -469,40 +1016,25 @@ func (m *SandboxManager) CreateSandbox(ctx context.Context, spaceID string, imag
 	if agentURL == "" {
 		m.logger.Warn("Could not find mapped port after retries, falling back to container IP method", "sandboxID", sandboxID)
 		for retry := 0; retry < maxRetries; retry++ {
-			inspectCtxIP, inspectCancelIP := context.WithTimeout(ctx, 10*time.Second)
-			inspectDataIP, inspectErrIP := m.dockerClient.ContainerInspect(inspectCtxIP, resp.ID)
-			inspectCancelIP()
+			inspectCtx, inspectCancel := context.WithTimeout(ctx, 10*time.Second)
+			inspectData, err := m.dockerClient.ContainerInspect(inspectCtx, containerID)
+			inspectCancel()
 
-			if inspectErrIP != nil {
-				m.logger.Warn("Container inspect failed on IP fallback retry", "retry", retry+1, "error", inspectErrIP)
+			if err != nil {
+				m.logger.Warn("Container inspect failed on IP fallback retry", "retry", retry+1, "error", err)
 				time.Sleep(retryDelay)
 				continue
 			}
 
-			if !inspectDataIP.State.Running {
-				m.logger.Warn("Container not running on IP fallback retry", "retry", retry+1, "state", inspectDataIP.State.Status)
+			if !inspectData.State.Running {
+				m.logger.Warn("Container not running on IP fallback retry", "retry", retry+1, "state", inspectData.State.Status)
 				time.Sleep(retryDelay)
 				continue
 			}
 
-			if inspectDataIP.NetworkSettings != nil {
-				if inspectDataIP.NetworkSettings.Networks != nil {
-					for netName, netConfig := range inspectDataIP.NetworkSettings.Networks {
-						if netConfig.IPAddress != "" {
-							containerIP = netConfig.IPAddress
-							m.logger.Info("Found container IP address (fallback)", "network", netName, "ip", containerIP)
-							break
-						}
-					}
-				}
-				if containerIP == "" && inspectDataIP.NetworkSettings.IPAddress != "" {
-					containerIP = inspectDataIP.NetworkSettings.IPAddress
-					m.logger.Info("Using root NetworkSettings.IPAddress (fallback)", "ip", containerIP)
-				}
-			}
-
-			if containerIP != "" {
-				agentURL = fmt.Sprintf("http://%s:%d", containerIP, agentPortInt)
+			if containerIP := containerIPAddress(inspectData); containerIP != "" {
+				m.logger.Info("Found container IP address (fallback)", "ip", containerIP)
+				agentURL = fmt.Sprintf("http://%s:%d", containerIP, agentPortNumber)
 				break // Found fallback URL
 			}
 
@@ -513,56 +1045,50 @@ func (m *SandboxManager) CreateSandbox(ctx context.Context, spaceID string, imag
 
 	// Final check: If no URL could be constructed, fail
 	if agentURL == "" {
-		m.logger.Error("Failed to determine agent URL via port mapping or container IP after multiple retries", "sandboxID", sandboxID, "containerID", resp.ID)
-		// Cleanup container
-		rmCtx, rmCancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer rmCancel()
-		_ = m.dockerClient.ContainerRemove(rmCtx, resp.ID, container.RemoveOptions{Force: true})
-		return "", fmt.Errorf("failed to determine agent URL for container %s after %d retries", resp.ID, maxRetries)
+		m.logger.Error("Failed to determine agent URL via port mapping or container IP after multiple retries", "sandboxID", sandboxID, "containerID", containerID)
+		return "", fmt.Errorf("failed to determine agent URL for container %s after %d retries", containerID, maxRetries)
 	}
+	return agentURL, nil
+}
 
-	m.logger.Info("Constructed agent URL", "sandboxID", sandboxID, "agentURL", agentURL)
-
-	// 6. Health Check (Add this step)
-	healthCheckURL := fmt.Sprintf("%s/health", agentURL)
-	agentReadyTimeout := 30 * time.Second // Adjust timeout as needed
-	m.logger.Info("Starting agent health check", "sandboxID", sandboxID, "healthURL", healthCheckURL, "timeout", agentReadyTimeout)
-
-	if err := m.waitForAgentReady(ctx, healthCheckURL, agentReadyTimeout); err != nil {
-		m.logger.Error("Agent health check failed", "sandboxID", sandboxID, "healthURL", healthCheckURL, "error", err)
-		// Cleanup container
-		rmCtx, rmCancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer rmCancel()
-		_ = m.dockerClient.ContainerRemove(rmCtx, resp.ID, container.RemoveOptions{Force: true})
-		return "", fmt.Errorf("agent health check failed: %w", err)
+// mappedHostPort returns the host port published for containerPort, if any.
+func mappedHostPort(inspectData types.ContainerJSON, containerPort string) string {
+	if inspectData.NetworkSettings == nil {
+		return ""
 	}
-	m.logger.Info("Agent health check successful", "sandboxID", sandboxID)
-
-	// 7. 创建沙箱状态并存储 (Renumbered from 6)
-	state := &SandboxState{
-		ID:          sandboxID,
-		ContainerID: resp.ID,
-		AgentURL:    agentURL,
-		IsRunning:   true,
-		SpaceID:     spaceID,
+	if portBindings, exists := inspectData.NetworkSettings.Ports[nat.Port(containerPort)]; exists && len(portBindings) > 0 {
+		return portBindings[0].HostPort
 	}
+	return ""
+}
 
-	// Add sandbox to manager's map
-	m.sandboxes[sandboxID] = state
-
-	// Add sandbox reference to the space using SpaceManager
-	if err := m.spaceManager.addSandboxToSpace(spaceID, sandboxID, state); err != nil {
-		// This should ideally not happen if space check passed, but handle defensively
-		m.logger.Error("Failed to add sandbox reference to space after creating container", "spaceID", spaceID, "sandboxID", sandboxID, "error", err)
-		// Consider cleanup? For now, log and continue, sandbox exists but space link failed.
+// containerIPAddress returns the first IP address the container has on any network.
+func containerIPAddress(inspectData types.ContainerJSON) string {
+	if inspectData.NetworkSettings == nil {
+		return ""
 	}
+	for _, netConfig := range inspectData.NetworkSettings.Networks {
+		if netConfig.IPAddress != "" {
+			return netConfig.IPAddress
+		}
+	}
+	return inspectData.NetworkSettings.IPAddress
+}
 
-	m.logger.Info("Sandbox created and registered successfully", "sandboxID", sandboxID, "containerID", resp.ID, "agentURL", agentURL, "spaceID", spaceID)
-	return sandboxID, nil
+// removeContainer force-removes a container, logging any failure. It is used to
+// clean up after failed provisioning, so it does not use the caller's context.
+func (m *SandboxManager) removeContainer(containerID string) {
+	rmCtx, rmCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer rmCancel()
+	if err := m.dockerClient.ContainerRemove(rmCtx, containerID, container.RemoveOptions{Force: true}); err != nil {
+		m.logger.Error("Failed to remove container", "containerID", containerID, "error", err)
+	}
 }
 
 // Add the waitForAgentReady helper function (if not already present)
-func (m *SandboxManager) waitForAgentReady(ctx context.Context, healthURL string, timeout time.Duration) error {
+func (m *SandboxManager) waitForAgentReady(ctx context.Context, healthURL string, timeout time.Duration) (err error) {
+	ctx, span := tracer.Start(ctx, "agent.wait_ready", trace.WithAttributes(attribute.String("url.full", healthURL)))
+	defer func() { endSpan(span, err) }()
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
@@ -604,8 +1130,30 @@ func (m *SandboxManager) waitForAgentReady(ctx context.Context, healthURL string
 	}
 }
 
+// checkAgentHealth performs a single health probe against an agent.
+func (m *SandboxManager) checkAgentHealth(ctx context.Context, agentURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", agentURL+"/health", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("agent health check returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // DeleteSandbox stops and removes a sandbox container.
-func (m *SandboxManager) DeleteSandbox(ctx context.Context, sandboxID string) error {
+func (m *SandboxManager) DeleteSandbox(ctx context.Context, sandboxID string) (err error) {
+	ctx, span := tracer.Start(ctx, "sandbox.delete", trace.WithAttributes(attrSandboxID.String(sandboxID)))
+	defer func() { endSpan(span, err) }()
 	m.logger.Info("Attempting to delete sandbox", "sandboxID", sandboxID)
 
 	m.mu.Lock() // Lock for modifying sandboxes map
@@ -616,94 +1164,100 @@ func (m *SandboxManager) DeleteSandbox(ctx context.Context, sandboxID string) er
 		return ErrSandboxNotFound
 	}
 	spaceID := state.SpaceID // Get spaceID before deleting state
+	containerID := state.ContainerID
+	nw := state.network
+	state.deleting = true
+	if state.cancelProvision != nil {
+		// Still provisioning: abort it, provisionContainer removes what it created.
+		state.cancelProvision()
+		state.cancelProvision = nil
+	}
 	m.mu.Unlock() // Unlock early, Docker operations can be slow
 
+	if containerID == "" {
+		// Provisioning never produced a container (pending, cancelled or failed).
+		m.unregisterSandbox(sandboxID, spaceID)
+		m.logger.Info("Sandbox without container deleted from manager state", "sandboxID", sandboxID)
+		return nil
+	}
+
 	// Attempt to stop the container
 	stopTimeoutDuration := 5 * time.Second
 	stopTimeoutSeconds := int(stopTimeoutDuration.Seconds()) // Convert to int seconds
-	m.logger.Info("Stopping container", "containerID", state.ContainerID, "sandboxID", sandboxID, "timeout", stopTimeoutDuration)
+	m.logger.Info("Stopping container", "containerID", containerID, "sandboxID", sandboxID, "timeout", stopTimeoutDuration)
 	stopCtx, stopCancel := context.WithTimeout(ctx, stopTimeoutDuration+2*time.Second) // Give slightly more time
 	defer stopCancel()
-	err := m.dockerClient.ContainerStop(stopCtx, state.ContainerID, container.StopOptions{Timeout: &stopTimeoutSeconds})
+	err = m.dockerClient.ContainerStop(stopCtx, containerID, container.StopOptions{Timeout: &stopTimeoutSeconds})
 	if err != nil {
-		m.logger.Error("Failed to stop container, proceeding with removal attempt", "containerID", state.ContainerID, "sandboxID", sandboxID, "error", err)
+		m.logger.Error("Failed to stop container, proceeding with removal attempt", "containerID", containerID, "sandboxID", sandboxID, "error", err)
 	} else {
-		m.logger.Info("Container stopped successfully", "containerID", state.ContainerID, "sandboxID", sandboxID)
+		m.logger.Info("Container stopped successfully", "containerID", containerID, "sandboxID", sandboxID)
 	}
 
 	// Attempt to remove the container
-	m.logger.Info("Removing container", "containerID", state.ContainerID, "sandboxID", sandboxID)
+	m.logger.Info("Removing container", "containerID", containerID, "sandboxID", sandboxID)
 	rmCtx, rmCancel := context.WithTimeout(ctx, 15*time.Second)
 	defer rmCancel()
-	err = m.dockerClient.ContainerRemove(rmCtx, state.ContainerID, container.RemoveOptions{
+	err = m.dockerClient.ContainerRemove(rmCtx, containerID, container.RemoveOptions{
 		Force: true,
 	})
 	if err != nil {
-		m.logger.Error("Failed to remove container", "containerID", state.ContainerID, "sandboxID", sandboxID, "error", err)
+		m.logger.Error("Failed to remove container", "containerID", containerID, "sandboxID", sandboxID, "error", err)
 		// Don't return yet, still need to clean up maps
 	} else {
-		m.logger.Info("Container removed successfully", "containerID", state.ContainerID, "sandboxID", sandboxID)
+		m.logger.Info("Container removed successfully", "containerID", containerID, "sandboxID", sandboxID)
 	}
 
-	// Remove from manager's sandbox map
-	m.mu.Lock()
-	delete(m.sandboxes, sandboxID)
-	m.mu.Unlock()
+	m.releaseNetwork(sandboxID, nw)
 
-	// Remove sandbox reference from the space using SpaceManager
-	if errSpace := m.spaceManager.removeSandboxFromSpace(spaceID, sandboxID); errSpace != nil {
-		// Log error but don't make the overall deletion fail because of this
-		m.logger.Error("Failed to remove sandbox reference from space", "spaceID", spaceID, "sandboxID", sandboxID, "error", errSpace)
-	}
+	// Remove from manager's sandbox map and its space
+	m.unregisterSandbox(sandboxID, spaceID)
 
 	m.logger.Info("Sandbox deleted successfully from manager state", "sandboxID", sandboxID)
 
 	// Return the container removal error, if any
 	if err != nil {
-		return fmt.Errorf("failed to remove container %s: %w", state.ContainerID, err)
+		return fmt.Errorf("failed to remove container %s: %w", containerID, err)
 	}
 	return nil
 }
 
-// GetSandbox retrieves the state of a specific sandbox by its ID.
+// GetSandbox retrieves the state of a specific sandbox by its ID. The status is
+// refreshed from the container and its agent unless it was refreshed recently;
+// a sandbox whose container has disappeared is reported as lost.
 func (m *SandboxManager) GetSandbox(ctx context.Context, sandboxID string) (*SandboxState, error) {
 	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	state, exists := m.sandboxes[sandboxID]
+	_, exists := m.sandboxes[sandboxID]
+	m.mu.RUnlock()
 	if !exists {
-		// Consider defining and returning a specific ErrSandboxNotFound error
-		// return nil, fmt.Errorf("sandbox %s not found", sandboxID)
 		return nil, ErrSandboxNotFound
 	}
 
-	// Optionally, inspect the container to get the latest status from Docker
-	// This adds overhead but provides the most up-to-date info.
-	// For now, we return the cached state.
-	// _, err := m.dockerClient.ContainerInspect(ctx, state.ContainerID)
-	// if err != nil {
-	// 	 if client.IsErrNotFound(err) {
-	// 		 // Container doesn't exist in Docker anymore, update our state?
-	// 		 // This indicates a potential inconsistency.
-	// 		 m.logger.Warn("Sandbox found in map but container not found in Docker", "sandboxID", sandboxID, "containerID", state.ContainerID)
-	// 		 // Maybe remove from map here and return not found?
-	// 		 // For now, return the state but log the inconsistency.
-	// 	 } else {
-	// 		 m.logger.Error("Failed to inspect container during GetSandbox", "sandboxID", sandboxID, "containerID", state.ContainerID, "error", err)
-	// 		 // Return the cached state but maybe log the inspection error?
-	// 	 }
-	// }
+	m.refreshSandboxStatus(ctx, sandboxID, false)
 
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	state, exists := m.sandboxes[sandboxID]
+	if !exists {
+		return nil, ErrSandboxNotFound // Deleted while refreshing
+	}
 	// Return a copy to prevent modification of the internal map state
 	stateCopy := *state
 	return &stateCopy, nil
 }
 
 // ReceiveInternalObservation receives raw observation data pushed from an agent.
-func (m *SandboxManager) ReceiveInternalObservation(sandboxID string, observationBytes []byte) error {
+// ctx carries the trace context the observation was sent with, if any.
+func (m *SandboxManager) ReceiveInternalObservation(ctx context.Context, sandboxID string, observationBytes []byte) error {
 	m.mu.RLock()
-	_, exists := m.sandboxes[sandboxID]
+	state, exists := m.sandboxes[sandboxID]
+	var redactions []string
+	if exists {
+		redactions = state.redactions
+	}
 	m.mu.RUnlock()
+	// Output echoing an injected secret never reaches clients or the logs.
+	observationBytes = redactSecrets(observationBytes, redactions)
 
 	if !exists {
 		m.logger.Warn("Received internal observation for non-existent or deleted sandbox", "sandboxID", sandboxID)
@@ -716,7 +1270,7 @@ func (m *SandboxManager) ReceiveInternalObservation(sandboxID string, observatio
 		ObservationType string          `json:"observation_type"`
 		ActionID        string          `json:"action_id"`
 		Timestamp       time.Time       `json:"timestamp"`
-		Data            json.RawMessage `json:"data"` // Keep data raw initially for flexibility
+		Data            json.RawMessage `json:"data"`                // Keep data raw initially for flexibility
 		ExitCode        *int            `json:"exit_code,omitempty"` // Added for result/error
 		Error           *string         `json:"error,omitempty"`     // Added for result/error
 	}
@@ -728,11 +1282,16 @@ func (m *SandboxManager) ReceiveInternalObservation(sandboxID string, observatio
 		// Let's broadcast the raw bytes if parsing fails, so client at least gets something.
 		if m.hub != nil {
 			m.logger.Warn("Broadcasting unparseable raw observation data", "sandboxID", sandboxID)
-			m.hub.SubmitBroadcast(sandboxID, observationBytes)
+			m.publish(sandboxID, observationBytes)
 		}
 		return fmt.Errorf("failed to parse observation JSON: %w", err)
 	}
 
+	m.metrics.ObservationReceived(obs.ObservationType)
+	_, span := tracer.Start(m.observationContext(ctx, obs.ActionID), "sandbox.observation", trace.WithAttributes(
+		attrSandboxID.String(sandboxID), attrActionID.String(obs.ActionID), attrObservationType.String(obs.ObservationType)))
+	defer span.End()
+
 	m.logger.Debug("Parsed internal observation struct",
 		"sandboxID", sandboxID,
 		"parsedActionID", obs.ActionID,
@@ -743,7 +1302,7 @@ func (m *SandboxManager) ReceiveInternalObservation(sandboxID string, observatio
 	// Broadcast the parsed (original) bytes AFTER successful parsing
 	if m.hub != nil {
 		m.logger.Debug("Broadcasting successfully parsed observation data", "sandboxID", sandboxID, "type", obs.ObservationType)
-		m.hub.SubmitBroadcast(sandboxID, observationBytes)
+		m.publish(sandboxID, observationBytes)
 	}
 
 	m.logger.Debug("Received internal observation", "sandboxID", sandboxID, "actionID", obs.ActionID, "type", obs.ObservationType)
@@ -779,6 +1338,7 @@ func (m *SandboxManager) processParsedObservation(sandboxID string, obs *struct
 		} else {
 			m.logger.Warn("Received 'result' observation without an exit_code, defaulting to 0", "sandboxID", sandboxID, "actionID", obs.ActionID)
 		}
+		m.finishAction(obs.ActionID, exitCode)
 		m.sendEndObservation(sandboxID, obs.ActionID, exitCode)
 
 	case "error":
@@ -797,10 +1357,20 @@ func (m *SandboxManager) processParsedObservation(sandboxID string, obs *struct
 		if obs.ExitCode != nil {
 			exitCode = *obs.ExitCode
 		}
+		m.finishAction(obs.ActionID, exitCode)
 		m.sendEndObservation(sandboxID, obs.ActionID, exitCode)
 
-	// Add cases for other types if needed (e.g., 'start', 'stream')
-	// Currently, 'start' is sent by InitiateAction, and 'stream' is just broadcast.
+	case "display_data":
+		// The MIME bundle has already been broadcast; the action record keeps
+		// which kinds of output the action produced.
+		var bundle map[string]json.RawMessage
+		if err := json.Unmarshal(obs.Data, &bundle); err != nil || len(bundle) == 0 {
+			return fmt.Errorf("display_data observation without a MIME bundle")
+		}
+		m.noteDisplayData(obs.ActionID, bundle)
+
+		// Add cases for other types if needed (e.g., 'start', 'stream')
+		// Currently, 'start' is sent by InitiateAction, and 'stream' is just broadcast.
 	}
 	return nil
 }
@@ -830,7 +1400,7 @@ func (m *SandboxManager) sendEndObservation(sandboxID, actionID string, exitCode
 	}
 
 	m.logger.Debug("Pushing observation via Hub", "sandboxID", sandboxID, "actionID", actionID, "type", "end", "size", len(endBytes))
-	m.hub.SubmitBroadcast(sandboxID, endBytes)
+	m.publish(sandboxID, endBytes)
 }
 
 // CreateSpace delegates to SpaceManager.
@@ -853,8 +1423,9 @@ func (m *SandboxManager) UpdateSpace(ctx context.Context, spaceID string, descri
 	return m.spaceManager.UpdateSpace(ctx, spaceID, description, metadata)
 }
 
-// DeleteSpace deletes a space and all its sandboxes.
-func (m *SandboxManager) DeleteSpace(ctx context.Context, spaceID string) error {
+// DeleteSpace deletes a space and all its sandboxes. The space's volumes are
+// removed as well unless retainVolumes is set.
+func (m *SandboxManager) DeleteSpace(ctx context.Context, spaceID string, retainVolumes bool) error {
 	// Get list of sandbox IDs in the space first
 	sandboxIDs, err := m.spaceManager.getSpaceSandboxes(spaceID)
 	if err != nil {
@@ -877,12 +1448,26 @@ func (m *SandboxManager) DeleteSpace(ctx context.Context, spaceID string) error
 		}
 	}
 
+	// The space network is only removed once it has no sandboxes left on it.
+	if firstErr == nil {
+		m.removeNetwork(m.spaceNetworkName(spaceID))
+	}
+	if firstErr == nil && !retainVolumes {
+		if err := m.removeSpaceVolumes(ctx, spaceID); err != nil {
+			firstErr = err
+		}
+	}
+
 	// After attempting to delete all sandboxes, delete the space entry itself
 	if spaceDelErr := m.spaceManager.DeleteSpace(ctx, spaceID); spaceDelErr != nil {
 		m.logger.Error("Failed to delete space entry after deleting sandboxes", "spaceID", spaceID, "error", spaceDelErr)
 		if firstErr == nil { // Prioritize sandbox deletion errors
 			firstErr = spaceDelErr
 		}
+	} else {
+		m.metrics.SpaceDeleted(spaceID)
+		m.deleteSpaceSecrets(spaceID)
+		m.deleteSpaceRecordings(spaceID)
 	}
 
 	if firstErr != nil {
@@ -892,4 +1477,4 @@ func (m *SandboxManager) DeleteSpace(ctx context.Context, spaceID string) error
 
 	m.logger.Info("Space and associated sandboxes deleted successfully", "spaceID", spaceID)
 	return nil
-}
\ No newline at end of file
+}
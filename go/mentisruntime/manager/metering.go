@@ -0,0 +1,439 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// ErrInvalidUsageQuery is returned when a UsageQuery fails validation.
+var ErrInvalidUsageQuery = errors.New("invalid usage query")
+
+// MeteredUsage is what sandboxes used over some time.
+type MeteredUsage struct {
+	RuntimeSeconds  float64 `json:"runtime_seconds"` // Wall-clock time the sandboxes existed
+	Actions         int     `json:"actions"`         // Actions that finished
+	ActionSeconds   float64 `json:"action_seconds"`  // Time the finished actions took
+	CPUSeconds      float64 `json:"cpu_seconds"`
+	MemoryMBSeconds float64 `json:"memory_mb_seconds"` // Sampled memory in MiB times the time it was held
+	PeakMemoryMB    float64 `json:"peak_memory_mb"`    // Highest sample of a single sandbox
+}
+
+func (u *MeteredUsage) add(o MeteredUsage) {
+	u.RuntimeSeconds += o.RuntimeSeconds
+	u.Actions += o.Actions
+	u.ActionSeconds += o.ActionSeconds
+	u.CPUSeconds += o.CPUSeconds
+	u.MemoryMBSeconds += o.MemoryMBSeconds
+	u.PeakMemoryMB = max(u.PeakMemoryMB, o.PeakMemoryMB)
+}
+
+// UsageBucket is the usage of a space, or of one of its sandboxes, from Start
+// to End. The meter stores usage in buckets of an hour per sandbox.
+type UsageBucket struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	SpaceID   string    `json:"space_id"`
+	SandboxID string    `json:"sandbox_id,omitempty"` // Empty in buckets of a whole space
+	MeteredUsage
+}
+
+// UsageQuery selects the metered usage of a space.
+type UsageQuery struct {
+	From      time.Time     // Rounded down to the hour
+	To        time.Time     // Exclusive
+	Bucket    time.Duration // A multiple of an hour; days start at midnight UTC
+	BySandbox bool          // Report each sandbox separately
+}
+
+// Validate checks the range and bucket length.
+func (q UsageQuery) Validate() error {
+	if !q.From.Before(q.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidUsageQuery)
+	}
+	if q.Bucket <= 0 || q.Bucket%time.Hour != 0 {
+		return fmt.Errorf("%w: buckets must be whole hours", ErrInvalidUsageQuery)
+	}
+	return nil
+}
+
+// UsageMeter accounts the usage of sandboxes for billing. It keeps an hourly
+// bucket per sandbox, persisted to a JSON file when it has a path, for as long
+// as its retention. A nil meter records nothing.
+type UsageMeter struct {
+	mu        sync.Mutex
+	path      string
+	retention time.Duration // How long hourly buckets are kept, forever when zero
+	hours     map[usageKey]*UsageBucket
+	running   map[string]*meteredSandbox // Map sandboxID to sandboxes being metered
+	dirty     bool                       // Set when hours changed since the last save
+}
+
+type usageKey struct {
+	start     int64 // Unix time of the hour
+	sandboxID string
+}
+
+// meteredSandbox is the metering state of a sandbox that exists.
+type meteredSandbox struct {
+	spaceID   string
+	since     time.Time // Runtime is accounted up to here
+	cpuTotal  uint64    // Cumulative CPU time of the last sample in nanoseconds
+	sampledAt time.Time // Zero before the first sample
+}
+
+// NewUsageMeter creates a meter, loading buckets from path if it exists. An
+// empty path keeps usage in memory only. Buckets that ended more than retention
+// ago are dropped; a zero retention keeps them all.
+func NewUsageMeter(path string, retention time.Duration) (*UsageMeter, error) {
+	um := &UsageMeter{
+		path:      path,
+		retention: retention,
+		hours:     make(map[usageKey]*UsageBucket),
+		running:   make(map[string]*meteredSandbox),
+	}
+	if path == "" {
+		return um, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return um, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage file: %w", err)
+	}
+	var buckets []UsageBucket
+	if err := json.Unmarshal(data, &buckets); err != nil {
+		return nil, fmt.Errorf("failed to parse usage file %s: %w", path, err)
+	}
+	for i := range buckets {
+		b := &buckets[i]
+		um.hours[usageKey{b.Start.Unix(), b.SandboxID}] = b
+	}
+	um.pruneLocked(time.Now())
+	return um, nil
+}
+
+// WithUsageMeter accounts sandbox usage in meter, sampling the CPU and memory
+// of running containers at the given interval. A zero interval meters runtime
+// and actions only.
+func WithUsageMeter(meter *UsageMeter, sampleInterval time.Duration) Option {
+	return func(m *SandboxManager) {
+		m.meter = meter
+		m.usageSampleInterval = sampleInterval
+	}
+}
+
+// WithUsageFlushInterval sets how often the usage meter is saved, which bounds
+// the usage lost if the runtime crashes. It defaults to a minute.
+func WithUsageFlushInterval(interval time.Duration) Option {
+	return func(m *SandboxManager) {
+		m.usageFlushInterval = interval
+	}
+}
+
+// bucketLocked returns the hourly bucket of a sandbox containing t, creating
+// it if needed. um.mu must be held.
+func (um *UsageMeter) bucketLocked(spaceID, sandboxID string, t time.Time) *UsageBucket {
+	start := t.UTC().Truncate(time.Hour)
+	key := usageKey{start.Unix(), sandboxID}
+	b, ok := um.hours[key]
+	if !ok {
+		b = &UsageBucket{Start: start, End: start.Add(time.Hour), SpaceID: spaceID, SandboxID: sandboxID}
+		um.hours[key] = b
+	}
+	um.dirty = true
+	return b
+}
+
+// accrueLocked accounts the runtime of every metered sandbox up to now,
+// splitting it at hour boundaries. um.mu must be held.
+func (um *UsageMeter) accrueLocked(now time.Time) {
+	for sandboxID, s := range um.running {
+		for s.since.Before(now) {
+			end := s.since.Truncate(time.Hour).Add(time.Hour)
+			if end.After(now) {
+				end = now
+			}
+			um.bucketLocked(s.spaceID, sandboxID, s.since).RuntimeSeconds += end.Sub(s.since).Seconds()
+			s.since = end
+		}
+	}
+}
+
+// pruneLocked drops the buckets that ended before the retention period.
+// um.mu must be held.
+func (um *UsageMeter) pruneLocked(now time.Time) {
+	if um.retention <= 0 {
+		return
+	}
+	cutoff := now.Add(-um.retention)
+	for key, h := range um.hours {
+		if !h.End.After(cutoff) {
+			delete(um.hours, key)
+			um.dirty = true
+		}
+	}
+}
+
+// start begins metering a sandbox whose container runs. Sandboxes already
+// metered are left alone.
+func (um *UsageMeter) start(spaceID, sandboxID string, now time.Time) {
+	if um == nil {
+		return
+	}
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	if _, ok := um.running[sandboxID]; !ok {
+		um.running[sandboxID] = &meteredSandbox{spaceID: spaceID, since: now}
+	}
+}
+
+// stop accounts the remaining runtime of a sandbox whose container stopped or
+// was deleted and stops metering it.
+func (um *UsageMeter) stop(sandboxID string, now time.Time) {
+	if um == nil {
+		return
+	}
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	um.accrueLocked(now)
+	delete(um.running, sandboxID)
+}
+
+// recordAction accounts an action that finished at now. Actions of sandboxes
+// that are not metered are ignored.
+func (um *UsageMeter) recordAction(sandboxID string, startedAt, now time.Time) {
+	if um == nil {
+		return
+	}
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	s, ok := um.running[sandboxID]
+	if !ok {
+		return
+	}
+	b := um.bucketLocked(s.spaceID, sandboxID, now)
+	b.Actions++
+	b.ActionSeconds += max(now.Sub(startedAt), 0).Seconds()
+}
+
+// sample accounts a reading of a sandbox's container: CPU time is the
+// difference to the previous reading and memory is taken to have been held
+// since then.
+func (um *UsageMeter) sample(sandboxID string, cpuTotal, memoryBytes uint64, now time.Time) {
+	if um == nil {
+		return
+	}
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	s, ok := um.running[sandboxID]
+	if !ok {
+		return
+	}
+	memoryMB := float64(memoryBytes) / (1 << 20)
+	b := um.bucketLocked(s.spaceID, sandboxID, now)
+	b.PeakMemoryMB = max(b.PeakMemoryMB, memoryMB)
+	if !s.sampledAt.IsZero() {
+		cpuDelta := cpuTotal // The counter restarts with the container
+		if cpuTotal >= s.cpuTotal {
+			cpuDelta -= s.cpuTotal
+		}
+		b.CPUSeconds += float64(cpuDelta) / 1e9
+		b.MemoryMBSeconds += memoryMB * now.Sub(s.sampledAt).Seconds()
+	}
+	s.cpuTotal, s.sampledAt = cpuTotal, now
+}
+
+// query aggregates the hourly buckets of a space into the buckets of q.
+func (um *UsageMeter) query(spaceID string, q UsageQuery, now time.Time) []UsageBucket {
+	if um == nil {
+		return nil
+	}
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	um.accrueLocked(now)
+
+	from := q.From.UTC().Truncate(time.Hour)
+	grouped := make(map[usageKey]*UsageBucket)
+	for _, h := range um.hours {
+		if h.SpaceID != spaceID || h.Start.Before(from) || !h.Start.Before(q.To) {
+			continue
+		}
+		start := h.Start.Truncate(q.Bucket)
+		key := usageKey{start: start.Unix()}
+		if q.BySandbox {
+			key.sandboxID = h.SandboxID
+		}
+		b, ok := grouped[key]
+		if !ok {
+			b = &UsageBucket{Start: start, End: start.Add(q.Bucket), SpaceID: spaceID, SandboxID: key.sandboxID}
+			grouped[key] = b
+		}
+		b.add(h.MeteredUsage)
+	}
+
+	buckets := make([]UsageBucket, 0, len(grouped))
+	for _, b := range grouped {
+		buckets = append(buckets, *b)
+	}
+	sortBuckets(buckets)
+	return buckets
+}
+
+// sortBuckets orders buckets by start and sandbox ID.
+func sortBuckets(buckets []UsageBucket) {
+	sort.Slice(buckets, func(i, j int) bool {
+		if !buckets[i].Start.Equal(buckets[j].Start) {
+			return buckets[i].Start.Before(buckets[j].Start)
+		}
+		return buckets[i].SandboxID < buckets[j].SandboxID
+	})
+}
+
+// flush accounts runtime up to now, drops expired buckets and saves the
+// buckets if they changed.
+func (um *UsageMeter) flush(now time.Time) error {
+	if um == nil {
+		return nil
+	}
+	um.mu.Lock()
+	um.accrueLocked(now)
+	um.pruneLocked(now)
+	if um.path == "" || !um.dirty {
+		um.mu.Unlock()
+		return nil
+	}
+	buckets := make([]UsageBucket, 0, len(um.hours))
+	for _, h := range um.hours {
+		buckets = append(buckets, *h)
+	}
+	um.dirty = false
+	um.mu.Unlock()
+
+	sortBuckets(buckets)
+	if err := um.save(buckets); err != nil {
+		um.mu.Lock()
+		um.dirty = true
+		um.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// save replaces the usage file atomically.
+func (um *UsageMeter) save(buckets []UsageBucket) error {
+	data, err := json.Marshal(buckets)
+	if err != nil {
+		return fmt.Errorf("failed to encode usage: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(um.path), ".usage-*")
+	if err != nil {
+		return fmt.Errorf("failed to save usage: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save usage: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save usage: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), um.path); err != nil {
+		return fmt.Errorf("failed to save usage: %w", err)
+	}
+	return nil
+}
+
+// SpaceUsageHistory returns the metered usage of a space.
+func (m *SandboxManager) SpaceUsageHistory(ctx context.Context, spaceID string, q UsageQuery) ([]UsageBucket, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := m.spaceManager.GetSpace(ctx, spaceID); err != nil {
+		return nil, err
+	}
+	return m.meter.query(spaceID, q, time.Now()), nil
+}
+
+// watchUsage samples the CPU and memory of running sandboxes until ctx is
+// cancelled.
+func (m *SandboxManager) watchUsage(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.mu.RLock()
+		containers := make(map[string]string, len(m.sandboxes)) // Map sandboxID to containerID
+		for id, state := range m.sandboxes {
+			if state.IsRunning && state.ContainerID != "" {
+				containers[id] = state.ContainerID
+			}
+		}
+		m.mu.RUnlock()
+
+		for sandboxID, containerID := range containers {
+			stats, err := m.containerStats(ctx, containerID)
+			if err != nil {
+				m.logger.Warn("Failed to sample container stats", "sandboxID", sandboxID, "containerID", containerID, "error", err)
+				continue
+			}
+			m.meter.sample(sandboxID, stats.CPUStats.CPUUsage.TotalUsage, memoryInUse(stats.MemoryStats), time.Now())
+		}
+	}
+}
+
+// flushUsage saves the meter at the given interval until ctx is cancelled.
+func (m *SandboxManager) flushUsage(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := m.meter.flush(time.Now()); err != nil {
+			m.logger.Error("Failed to save usage", "error", err)
+		}
+	}
+}
+
+func (m *SandboxManager) containerStats(ctx context.Context, containerID string) (container.StatsResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	var stats container.StatsResponse
+	reader, err := m.dockerClient.ContainerStatsOneShot(ctx, containerID)
+	if err != nil {
+		return stats, err
+	}
+	defer reader.Body.Close()
+	err = json.NewDecoder(reader.Body).Decode(&stats)
+	return stats, err
+}
+
+// memoryInUse is the memory of a container without the reclaimable page
+// cache, as docker stats reports it.
+func memoryInUse(stats container.MemoryStats) uint64 {
+	cache := stats.Stats["inactive_file"] // cgroup v2
+	if cache == 0 {
+		cache = stats.Stats["total_inactive_file"] // cgroup v1
+	}
+	if cache > stats.Usage {
+		return 0
+	}
+	return stats.Usage - cache
+}
@@ -0,0 +1,116 @@
+package manager
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_UsageMeter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	meter, err := NewUsageMeter(path, 0)
+	require.NoError(t, err)
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return day.Add(d) }
+
+	// a runs from 10:30 to 12:15, b from 11:00 and is still running at 13:00.
+	meter.start("s1", "a", at(10*time.Hour+30*time.Minute))
+	meter.start("s1", "b", at(11*time.Hour))
+	meter.start("s2", "c", at(11*time.Hour))
+	meter.start("s1", "b", at(11*time.Hour+30*time.Minute)) // Already metered
+	meter.recordAction("a", at(11*time.Hour), at(11*time.Hour+10*time.Second))
+	meter.recordAction("missing", at(11*time.Hour), at(11*time.Hour+time.Second))
+
+	// The first sample sets the baseline, a container restart resets the CPU counter.
+	meter.sample("a", 5e9, 100<<20, at(11*time.Hour))
+	meter.sample("a", 7e9, 300<<20, at(11*time.Hour+10*time.Second))
+	meter.sample("a", 1e9, 100<<20, at(11*time.Hour+20*time.Second))
+	meter.stop("a", at(12*time.Hour+15*time.Minute))
+	meter.sample("a", 9e9, 100<<20, at(12*time.Hour+20*time.Minute))
+
+	now := at(13 * time.Hour)
+	hourly := meter.query("s1", UsageQuery{From: day, To: now, Bucket: time.Hour, BySandbox: true}, now)
+	require.Len(t, hourly, 5)
+	require.Equal(t, UsageBucket{Start: at(10 * time.Hour), End: at(11 * time.Hour), SpaceID: "s1", SandboxID: "a",
+		MeteredUsage: MeteredUsage{RuntimeSeconds: 1800}}, hourly[0])
+	require.Equal(t, UsageBucket{Start: at(11 * time.Hour), End: at(12 * time.Hour), SpaceID: "s1", SandboxID: "a",
+		MeteredUsage: MeteredUsage{RuntimeSeconds: 3600, Actions: 1, ActionSeconds: 10, CPUSeconds: 3, MemoryMBSeconds: 4000, PeakMemoryMB: 300}}, hourly[1])
+	require.Equal(t, "b", hourly[2].SandboxID)
+	require.Equal(t, 3600.0, hourly[2].RuntimeSeconds)
+	require.Equal(t, 900.0, hourly[3].RuntimeSeconds)
+	require.Equal(t, 3600.0, hourly[4].RuntimeSeconds)
+
+	daily := meter.query("s1", UsageQuery{From: day, To: now, Bucket: 24 * time.Hour}, now)
+	require.Len(t, daily, 1)
+	require.Equal(t, day, daily[0].Start)
+	require.Equal(t, at(24*time.Hour), daily[0].End)
+	require.Empty(t, daily[0].SandboxID)
+	require.Equal(t, 1800+3600+900+2*3600.0, daily[0].RuntimeSeconds)
+	require.Equal(t, 300.0, daily[0].PeakMemoryMB)
+
+	// Buckets before the range are left out.
+	require.Len(t, meter.query("s1", UsageQuery{From: at(12*time.Hour + 30*time.Minute), To: now, Bucket: time.Hour}, now), 1)
+
+	require.NoError(t, meter.flush(now))
+	loaded, err := NewUsageMeter(path, 0)
+	require.NoError(t, err)
+	require.Equal(t, daily, loaded.query("s1", UsageQuery{From: day, To: now, Bucket: 24 * time.Hour}, now))
+	require.Len(t, loaded.query("s2", UsageQuery{From: day, To: now, Bucket: time.Hour}, now), 2)
+}
+
+func Test_UsageMeterRetention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	meter, err := NewUsageMeter(path, 24*time.Hour)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Hour)
+	meter.start("s1", "a", now.Add(-26*time.Hour))
+	meter.stop("a", now.Add(-23*time.Hour))
+	require.Len(t, meter.hours, 3)
+
+	// The hour that ended a day ago and the one before it are dropped, also from the file.
+	require.NoError(t, meter.flush(now))
+	require.Len(t, meter.hours, 1)
+	require.Equal(t, now.Add(-24*time.Hour), meter.query("s1", UsageQuery{From: now.Add(-48 * time.Hour), To: now, Bucket: time.Hour}, now)[0].Start)
+	loaded, err := NewUsageMeter(path, 0)
+	require.NoError(t, err)
+	require.Len(t, loaded.hours, 1)
+}
+
+func Test_flushUsage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	meter, err := NewUsageMeter(path, 0)
+	require.NoError(t, err)
+	m := &SandboxManager{meter: meter, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	meter.start("s1", "a", time.Now().Add(-time.Minute))
+
+	// Usage is saved without container stats sampling.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.flushUsage(ctx, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func Test_UsageQueryValidate(t *testing.T) {
+	now := time.Now()
+	require.NoError(t, UsageQuery{From: now.Add(-time.Hour), To: now, Bucket: time.Hour}.Validate())
+	require.ErrorIs(t, UsageQuery{From: now, To: now, Bucket: time.Hour}.Validate(), ErrInvalidUsageQuery)
+	require.ErrorIs(t, UsageQuery{From: now.Add(-time.Hour), To: now, Bucket: time.Minute}.Validate(), ErrInvalidUsageQuery)
+}
+
+func Test_memoryInUse(t *testing.T) {
+	require.Equal(t, uint64(70), memoryInUse(container.MemoryStats{Usage: 100, Stats: map[string]uint64{"inactive_file": 30}}))
+	require.Equal(t, uint64(60), memoryInUse(container.MemoryStats{Usage: 100, Stats: map[string]uint64{"total_inactive_file": 40}}))
+	require.Equal(t, uint64(0), memoryInUse(container.MemoryStats{Usage: 10, Stats: map[string]uint64{"inactive_file": 30}}))
+}
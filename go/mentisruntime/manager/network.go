@@ -0,0 +1,284 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+)
+
+// NetworkMode selects how much network access a sandbox gets.
+type NetworkMode string
+
+const (
+	// NetworkNone attaches the sandbox to a network of its own with no egress.
+	// Only the runtime can reach its agent.
+	NetworkNone NetworkMode = "none"
+	// NetworkInternal lets the sandbox reach other sandboxes in its space but
+	// nothing outside it.
+	NetworkInternal NetworkMode = "internal"
+	// NetworkAllowlist is NetworkInternal plus egress to the destinations in
+	// NetworkPolicy.Allow.
+	NetworkAllowlist NetworkMode = "allowlist"
+	// NetworkFull gives the sandbox unrestricted egress. Sandboxes in other
+	// spaces remain unreachable.
+	NetworkFull NetworkMode = "full"
+)
+
+// NetworkPolicy is the network isolation policy of a sandbox.
+type NetworkPolicy struct {
+	Mode NetworkMode `json:"mode"`
+	// Allow lists the egress destinations permitted in allowlist mode, as IP
+	// addresses, CIDRs or host names. Host names are resolved when the sandbox
+	// is created.
+	Allow []string `json:"allow,omitempty"`
+}
+
+func (p *NetworkPolicy) validate() error {
+	switch p.Mode {
+	case "":
+		p.Mode = NetworkFull
+	case NetworkNone, NetworkInternal, NetworkFull:
+	case NetworkAllowlist:
+		if len(p.Allow) == 0 {
+			return fmt.Errorf("%w: network mode allowlist requires at least one allowed destination", ErrInvalidSpec)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown network mode %q", ErrInvalidSpec, p.Mode)
+	}
+	if len(p.Allow) > 0 {
+		return fmt.Errorf("%w: allowed destinations require network mode allowlist", ErrInvalidSpec)
+	}
+	return nil
+}
+
+// restrictsEgress reports whether the policy needs the egress firewall.
+func (p *NetworkPolicy) restrictsEgress() bool {
+	return p.Mode == NetworkInternal || p.Mode == NetworkAllowlist
+}
+
+// sandboxNetwork is the Docker network a sandbox container is attached to.
+type sandboxNetwork struct {
+	Name     string
+	Internal bool // Docker internal network: no egress and no published ports
+	Shared   bool // Shared by the sandboxes of a space rather than owned by one sandbox
+}
+
+// networkFor returns the network a sandbox with the given policy joins. Every
+// space gets its own bridge network so sandboxes in a space can reach each
+// other but not sandboxes in other spaces; NetworkNone sandboxes get an internal
+// network of their own.
+func (m *SandboxManager) networkFor(spaceID, sandboxID string, policy *NetworkPolicy) sandboxNetwork {
+	if policy.Mode == NetworkNone {
+		return sandboxNetwork{Name: fmt.Sprintf("sandboxai-%s-sandbox-%s", m.scope, sandboxID), Internal: true}
+	}
+	return sandboxNetwork{Name: m.spaceNetworkName(spaceID), Shared: true}
+}
+
+// spaceNetworkName returns the name of the bridge network shared by a space.
+func (m *SandboxManager) spaceNetworkName(spaceID string) string {
+	return fmt.Sprintf("sandboxai-%s-%s", m.scope, spaceID)
+}
+
+// poolNetworkName returns the network idle warm pool containers wait on.
+func (m *SandboxManager) poolNetworkName() string {
+	return fmt.Sprintf("sandboxai-%s-pool", m.scope)
+}
+
+// ensureNetwork creates the network unless it already exists.
+func (m *SandboxManager) ensureNetwork(ctx context.Context, nw sandboxNetwork, labels map[string]string) error {
+	if _, err := m.dockerClient.NetworkInspect(ctx, nw.Name, network.InspectOptions{}); err == nil {
+		return nil
+	} else if !client.IsErrNotFound(err) {
+		return fmt.Errorf("failed to inspect network %s: %w", nw.Name, err)
+	}
+
+	_, err := m.dockerClient.NetworkCreate(ctx, nw.Name, network.CreateOptions{
+		Driver:   "bridge",
+		Internal: nw.Internal,
+		Labels:   labels,
+	})
+	if err != nil {
+		// Another sandbox in the space may have created it concurrently.
+		if _, inspectErr := m.dockerClient.NetworkInspect(ctx, nw.Name, network.InspectOptions{}); inspectErr == nil {
+			return nil
+		}
+		return fmt.Errorf("failed to create network %s: %w", nw.Name, err)
+	}
+	m.logger.Info("Network created", "network", nw.Name, "internal", nw.Internal)
+	return nil
+}
+
+// removeNetwork removes a network, ignoring networks that no longer exist. It
+// does not use the caller's context so cleanup runs even after cancellation.
+func (m *SandboxManager) removeNetwork(name string) error {
+	rmCtx, rmCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer rmCancel()
+	if err := m.dockerClient.NetworkRemove(rmCtx, name); err != nil && !client.IsErrNotFound(err) {
+		m.logger.Error("Failed to remove network", "network", name, "error", err)
+		return err
+	}
+	return nil
+}
+
+// networkGateway returns the gateway address and subnet of a network.
+func (m *SandboxManager) networkGateway(ctx context.Context, name string) (gateway string, subnet string, err error) {
+	inspect, err := m.dockerClient.NetworkInspect(ctx, name, network.InspectOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to inspect network %s: %w", name, err)
+	}
+	for _, cfg := range inspect.IPAM.Config {
+		if cfg.Subnet == "" {
+			continue
+		}
+		if ip, _, err := net.ParseCIDR(cfg.Subnet); err != nil || ip.To4() == nil {
+			continue
+		}
+		return cfg.Gateway, cfg.Subnet, nil
+	}
+	return "", "", fmt.Errorf("network %s has no IPv4 subnet", name)
+}
+
+// containerNetworkIP returns the container's address on the named network.
+func containerNetworkIP(inspectData types.ContainerJSON, name string) string {
+	if inspectData.NetworkSettings == nil {
+		return ""
+	}
+	if endpoint, ok := inspectData.NetworkSettings.Networks[name]; ok && endpoint != nil {
+		return endpoint.IPAddress
+	}
+	return ""
+}
+
+// applyEgressPolicy installs the egress firewall rules for a sandbox whose
+// policy restricts egress. It is called after the container starts and again
+// after a restart, since the container's address may change.
+func (m *SandboxManager) applyEgressPolicy(ctx context.Context, sandboxID, containerID string, nw sandboxNetwork, policy *NetworkPolicy) error {
+	if policy == nil || !policy.restrictsEgress() {
+		return nil
+	}
+	if m.firewall == nil {
+		return fmt.Errorf("network mode %s requires an egress firewall, but none is configured", policy.Mode)
+	}
+
+	inspectData, err := m.dockerClient.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+	sourceIP := containerNetworkIP(inspectData, nw.Name)
+	if sourceIP == "" {
+		return fmt.Errorf("container %s has no address on network %s", containerID, nw.Name)
+	}
+	_, subnet, err := m.networkGateway(ctx, nw.Name)
+	if err != nil {
+		return err
+	}
+
+	allow := []string{subnet}
+	if policy.Mode == NetworkAllowlist {
+		resolved, err := resolveAllowlist(ctx, policy.Allow)
+		if err != nil {
+			return err
+		}
+		allow = append(allow, resolved...)
+	}
+	if err := m.firewall.Apply(ctx, sandboxID, sourceIP, allow); err != nil {
+		return fmt.Errorf("failed to apply egress policy: %w", err)
+	}
+	m.logger.Info("Egress policy applied", "sandboxID", sandboxID, "mode", policy.Mode, "sourceIP", sourceIP, "allowed", allow)
+	return nil
+}
+
+// resolveAllowlist turns allowlist entries into CIDRs, resolving host names.
+func resolveAllowlist(ctx context.Context, entries []string) ([]string, error) {
+	var cidrs []string
+	for _, entry := range entries {
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			cidrs = append(cidrs, ipNet.String())
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			if ip.To4() != nil {
+				cidrs = append(cidrs, ip.String()+"/32")
+			}
+			continue
+		}
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve allowed host %s: %w", entry, err)
+		}
+		for _, addr := range addrs {
+			if addr.IP.To4() != nil {
+				cidrs = append(cidrs, addr.IP.String()+"/32")
+			}
+		}
+	}
+	return cidrs, nil
+}
+
+// WithAgentBindAddress sets the host address agent ports are published on. The
+// default, 127.0.0.1, keeps agents unreachable from other hosts; use 0.0.0.0 if
+// the runtime reaches Docker over the network.
+func WithAgentBindAddress(addr string) Option {
+	return func(m *SandboxManager) {
+		m.agentBindAddress = addr
+	}
+}
+
+// agentHost returns the host used to reach published agent ports.
+func (m *SandboxManager) agentHost() string {
+	if m.agentBindAddress == "" || m.agentBindAddress == "0.0.0.0" || m.agentBindAddress == "::" {
+		return "localhost"
+	}
+	return m.agentBindAddress
+}
+
+// removeEgressPolicy deletes a sandbox's egress firewall rules, if any.
+func (m *SandboxManager) removeEgressPolicy(sandboxID string) {
+	if m.firewall == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := m.firewall.Remove(ctx, sandboxID); err != nil {
+		m.logger.Error("Failed to remove egress policy", "sandboxID", sandboxID, "error", err)
+	}
+}
+
+// releaseNetwork removes a sandbox's egress rules and, if the sandbox owns it,
+// its network. The container must already be removed.
+func (m *SandboxManager) releaseNetwork(sandboxID string, nw sandboxNetwork) {
+	m.removeEgressPolicy(sandboxID)
+	if nw.Name != "" && !nw.Shared {
+		m.removeNetwork(nw.Name)
+	}
+}
+
+// joinSpaceNetwork moves a claimed warm pool container from the pool network to
+// the network of its space and returns the agent URL, which may have changed.
+func (m *SandboxManager) joinSpaceNetwork(ctx context.Context, state *SandboxState, spaceID string) (string, error) {
+	nw := sandboxNetwork{Name: m.spaceNetworkName(spaceID), Shared: true}
+	if err := m.ensureNetwork(ctx, nw, map[string]string{labelScope: m.scope, labelSpace: spaceID}); err != nil {
+		return "", err
+	}
+	if err := m.dockerClient.NetworkConnect(ctx, nw.Name, state.ContainerID, nil); err != nil {
+		return "", fmt.Errorf("failed to connect container %s to network %s: %w", state.ContainerID, nw.Name, err)
+	}
+	if err := m.dockerClient.NetworkDisconnect(ctx, m.poolNetworkName(), state.ContainerID, true); err != nil {
+		return "", fmt.Errorf("failed to disconnect container %s from the pool network: %w", state.ContainerID, err)
+	}
+	agentURL, err := m.resolveAgentURL(ctx, state.ID, state.ContainerID)
+	if err != nil {
+		return "", err
+	}
+	if err := m.checkAgentHealth(ctx, agentURL); err != nil {
+		return "", err
+	}
+	state.network = nw
+	return agentURL, nil
+}
@@ -0,0 +1,325 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PoolConfig configures the warm pool kept for a single image.
+type PoolConfig struct {
+	Image   string // Box image the pooled containers run
+	MinIdle int    // Number of ready containers the pool keeps idle when unused
+	MaxSize int    // Number of idle plus starting containers the pool grows to under demand
+}
+
+// ParsePoolConfigs parses a comma-separated list of pool definitions of the form
+// "<image>=<minIdle>:<maxSize>", e.g. "mentisai/sandboxai-box:latest=2:4".
+// The ":<maxSize>" part is optional and defaults to minIdle.
+func ParsePoolConfigs(s string) ([]PoolConfig, error) {
+	var configs []PoolConfig
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		imageName, sizes, ok := strings.Cut(entry, "=")
+		if !ok || imageName == "" {
+			return nil, fmt.Errorf("invalid pool definition %q: expected <image>=<minIdle>[:<maxSize>]", entry)
+		}
+		minStr, maxStr, hasMax := strings.Cut(sizes, ":")
+		minIdle, err := strconv.Atoi(minStr)
+		if err != nil || minIdle < 0 {
+			return nil, fmt.Errorf("invalid pool definition %q: bad minIdle %q", entry, minStr)
+		}
+		maxSize := minIdle
+		if hasMax {
+			maxSize, err = strconv.Atoi(maxStr)
+			if err != nil || maxSize < minIdle {
+				return nil, fmt.Errorf("invalid pool definition %q: maxSize must be an integer >= minIdle", entry)
+			}
+		}
+		configs = append(configs, PoolConfig{Image: imageName, MinIdle: minIdle, MaxSize: maxSize})
+	}
+	return configs, nil
+}
+
+// WithWarmPool keeps pre-started, healthy containers for each configured image
+// so CreateSandbox can hand one out instead of waiting for a cold start.
+func WithWarmPool(configs ...PoolConfig) Option {
+	return func(m *SandboxManager) {
+		if len(configs) == 0 {
+			return
+		}
+		m.pool = newWarmPool(m, configs)
+	}
+}
+
+// warmPool maintains idle sandbox containers per image. Pooled containers are
+// provisioned with their final sandbox ID (the agent's env cannot be changed after
+// start) but without a space; claiming one assigns it to the requested space.
+//
+// Docker labels are fixed at creation, so a claimed container keeps labelPool
+// and never gets labelSpace; its space is recorded by the space network it is
+// moved onto, which carries labelSpace.
+//
+// Each image has a target number of idle plus starting containers. It starts at
+// MinIdle; every claim raises it by one up to MaxSize, and every check interval
+// without claims lowers it by one back towards MinIdle, removing the surplus.
+type warmPool struct {
+	m       *SandboxManager
+	configs map[string]PoolConfig
+
+	mu       sync.Mutex
+	idle     map[string][]*SandboxState // Image to ready containers, oldest first
+	starting map[string]int             // Image to containers being provisioned
+	target   map[string]int             // Image to the containers to keep idle or starting
+	claimed  map[string]bool            // Images claimed since the last check
+
+	refill        chan struct{}
+	checkInterval time.Duration
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+}
+
+func newWarmPool(m *SandboxManager, configs []PoolConfig) *warmPool {
+	p := &warmPool{
+		m:             m,
+		configs:       make(map[string]PoolConfig, len(configs)),
+		idle:          make(map[string][]*SandboxState),
+		starting:      make(map[string]int),
+		target:        make(map[string]int),
+		claimed:       make(map[string]bool),
+		refill:        make(chan struct{}, 1),
+		checkInterval: 30 * time.Second,
+	}
+	for _, cfg := range configs {
+		p.configs[cfg.Image] = cfg
+		p.target[cfg.Image] = cfg.MinIdle
+	}
+	return p
+}
+
+// start launches the background loop that maintains the pool.
+func (p *warmPool) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	go p.run(ctx)
+}
+
+// run keeps the pool topped up until ctx is cancelled. Idle containers are
+// health-checked every checkInterval and replaced if their agent stops responding.
+func (p *warmPool) run(ctx context.Context) {
+	ticker := time.NewTicker(p.checkInterval)
+	defer ticker.Stop()
+
+	p.fill(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.refill:
+			p.fill(ctx)
+		case <-ticker.C:
+			p.evictUnhealthy(ctx)
+			p.shrink()
+			p.fill(ctx)
+		}
+	}
+}
+
+// requestRefill wakes the pool loop without blocking the caller.
+func (p *warmPool) requestRefill() {
+	select {
+	case p.refill <- struct{}{}:
+	default:
+	}
+}
+
+// fill starts provisioning goroutines until every image has its target number
+// of containers idle or starting.
+func (p *warmPool) fill(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for imageName := range p.configs {
+		for {
+			total := len(p.idle[imageName]) + p.starting[imageName]
+			if total >= p.target[imageName] {
+				break
+			}
+			p.starting[imageName]++
+			p.wg.Add(1)
+			go p.provision(ctx, imageName)
+		}
+	}
+}
+
+// provision starts one pooled container and adds it to the idle list.
+func (p *warmPool) provision(ctx context.Context, imageName string) {
+	defer p.wg.Done()
+
+	sandboxID := uuid.NewString()
+	labels := map[string]string{
+		labelScope: p.m.scope,
+		labelID:    sandboxID,
+		labelPool:  imageName,
+	}
+	spec := SandboxSpec{Image: imageName, Network: &NetworkPolicy{Mode: NetworkFull}, security: p.m.securityProfile}
+	nw := sandboxNetwork{Name: p.m.poolNetworkName(), Shared: true}
+	state, err := p.m.provisionContainer(ctx, sandboxID, spec, nw, labels, nil)
+
+	p.mu.Lock()
+	p.starting[imageName]--
+	if err == nil && ctx.Err() == nil {
+		p.idle[imageName] = append(p.idle[imageName], state)
+	}
+	p.mu.Unlock()
+
+	if err != nil {
+		p.m.logger.Error("Failed to provision warm pool container", "image", imageName, "error", err)
+		return
+	}
+	if ctx.Err() != nil {
+		// The pool is shutting down; don't leave the container behind.
+		p.m.removeContainer(state.ContainerID)
+		return
+	}
+	p.m.logger.Info("Warm pool container ready", "image", imageName, "sandboxID", sandboxID, "containerID", state.ContainerID)
+}
+
+// claim hands out a healthy idle container for imageName, moved onto the network
+// of spaceID, or nil if none is available. Containers that fail the health check
+// or cannot be moved are discarded.
+func (p *warmPool) claim(ctx context.Context, imageName, spaceID string) *SandboxState {
+	cfg, ok := p.configs[imageName]
+	if !ok {
+		return nil
+	}
+	p.mu.Lock()
+	p.claimed[imageName] = true
+	p.target[imageName] = min(p.target[imageName]+1, cfg.MaxSize)
+	p.mu.Unlock()
+	defer p.requestRefill()
+
+	for {
+		p.mu.Lock()
+		idle := p.idle[imageName]
+		if len(idle) == 0 {
+			p.mu.Unlock()
+			p.m.logger.Info("Warm pool empty, falling back to cold start", "image", imageName)
+			return nil
+		}
+		state := idle[0]
+		p.idle[imageName] = idle[1:]
+		p.mu.Unlock()
+
+		if err := p.m.checkAgentHealth(ctx, state.AgentURL); err != nil {
+			p.m.logger.Warn("Discarding unhealthy warm pool container", "sandboxID", state.ID, "containerID", state.ContainerID, "error", err)
+			p.m.removeContainer(state.ContainerID)
+			continue
+		}
+		agentURL, err := p.m.joinSpaceNetwork(ctx, state, spaceID)
+		if err != nil {
+			p.m.logger.Warn("Discarding warm pool container that could not join its space network", "sandboxID", state.ID, "containerID", state.ContainerID, "spaceID", spaceID, "error", err)
+			p.m.removeContainer(state.ContainerID)
+			continue
+		}
+		state.AgentURL = agentURL
+		return state
+	}
+}
+
+// evictUnhealthy removes idle containers whose agent no longer answers. The
+// containers stay claimable while they are checked; claim checks health again.
+func (p *warmPool) evictUnhealthy(ctx context.Context) {
+	p.mu.Lock()
+	var snapshot []*SandboxState
+	for _, idle := range p.idle {
+		snapshot = append(snapshot, idle...)
+	}
+	p.mu.Unlock()
+
+	unhealthy := make(map[*SandboxState]error)
+	for _, state := range snapshot {
+		if err := p.m.checkAgentHealth(ctx, state.AgentURL); err != nil {
+			unhealthy[state] = err
+		}
+	}
+	if len(unhealthy) == 0 {
+		return
+	}
+
+	var evicted []*SandboxState
+	p.mu.Lock()
+	for imageName, idle := range p.idle {
+		kept := idle[:0:0]
+		for _, state := range idle {
+			if _, ok := unhealthy[state]; ok {
+				evicted = append(evicted, state)
+			} else {
+				kept = append(kept, state)
+			}
+		}
+		p.idle[imageName] = kept
+	}
+	p.mu.Unlock()
+
+	// Containers claimed during the check are left to their sandbox.
+	for _, state := range evicted {
+		p.m.logger.Warn("Evicting unhealthy warm pool container", "sandboxID", state.ID, "containerID", state.ContainerID, "error", unhealthy[state])
+		p.m.removeContainer(state.ContainerID)
+	}
+}
+
+// shrink lowers the target of every image that was not claimed since the last
+// check and removes the idle containers above it, oldest first.
+func (p *warmPool) shrink() {
+	var surplus []*SandboxState
+	p.mu.Lock()
+	for imageName, cfg := range p.configs {
+		if !p.claimed[imageName] && p.target[imageName] > cfg.MinIdle {
+			p.target[imageName]--
+		}
+		p.claimed[imageName] = false
+		idle := p.idle[imageName]
+		excess := min(len(idle)+p.starting[imageName]-p.target[imageName], len(idle))
+		if excess > 0 {
+			surplus = append(surplus, idle[:excess]...)
+			p.idle[imageName] = idle[excess:]
+		}
+	}
+	p.mu.Unlock()
+
+	for _, state := range surplus {
+		p.m.logger.Info("Removing surplus warm pool container", "sandboxID", state.ID, "containerID", state.ContainerID)
+		p.m.removeContainer(state.ContainerID)
+	}
+}
+
+// stop halts the background loop, waits for in-flight provisioning and removes
+// every idle container.
+func (p *warmPool) stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+
+	p.mu.Lock()
+	var all []*SandboxState
+	for imageName, idle := range p.idle {
+		all = append(all, idle...)
+		delete(p.idle, imageName)
+	}
+	p.mu.Unlock()
+
+	for _, state := range all {
+		p.m.removeContainer(state.ContainerID)
+	}
+	p.m.removeNetwork(p.m.poolNetworkName())
+	p.m.logger.Info("Warm pool drained", "removed", len(all))
+}
@@ -0,0 +1,215 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePoolDocker serves the Docker API calls of the warm pool. Every container
+// it runs publishes the agent port on the port of agent.
+type fakePoolDocker struct {
+	mu      sync.Mutex
+	created int
+	removed []string
+}
+
+func newPoolTestManager(t *testing.T, agent *httptest.Server) (*SandboxManager, *fakePoolDocker) {
+	f := &fakePoolDocker{}
+	agentURL, err := url.Parse(agent.URL)
+	require.NoError(t, err)
+	docker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		path := r.URL.Path
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		switch {
+		case r.Method == "POST" && strings.HasSuffix(path, "/containers/create"):
+			f.created++
+			fmt.Fprintf(w, `{"Id":"c%d"}`, f.created)
+		case r.Method == "POST" && strings.HasSuffix(path, "/start"):
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "GET" && strings.Contains(path, "/containers/"):
+			fmt.Fprintf(w, `{"State":{"Running":true},"NetworkSettings":{"Ports":{"8000/tcp":[{"HostIp":"127.0.0.1","HostPort":%q}]}}}`, agentURL.Port())
+		case r.Method == "DELETE" && strings.Contains(path, "/containers/"):
+			f.removed = append(f.removed, path[strings.LastIndex(path, "/")+1:])
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "DELETE" && strings.Contains(path, "/networks/"):
+			w.WriteHeader(http.StatusNoContent)
+		default: // Images, networks and network (dis)connects exist and succeed
+			w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(docker.Close)
+	dockerClient, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(docker.URL, "http://")), client.WithVersion("1.47"))
+	require.NoError(t, err)
+
+	m := &SandboxManager{
+		dockerClient:      dockerClient,
+		httpClient:        agent.Client(),
+		logger:            slog.New(slog.NewTextHandler(io.Discard, nil)),
+		scope:             "pooltest",
+		agentBindAddress:  "127.0.0.1",
+		callbackURL:       "http://runtime.test",
+		agentReadyTimeout: 5 * time.Second,
+	}
+	return m, f
+}
+
+func (f *fakePoolDocker) counts() (created, removed int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.created, len(f.removed)
+}
+
+func (p *warmPool) sizes(imageName string) (idle, starting, target int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.idle[imageName]), p.starting[imageName], p.target[imageName]
+}
+
+func Test_warmPool(t *testing.T) {
+	var unhealthy, slow atomic.Bool
+	checking, resume := make(chan struct{}), make(chan struct{})
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slow.Load() {
+			checking <- struct{}{}
+			<-resume
+		}
+		if unhealthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer agent.Close()
+	m, docker := newPoolTestManager(t, agent)
+	p := newWarmPool(m, []PoolConfig{{Image: "box", MinIdle: 1, MaxSize: 3}})
+	ctx := context.Background()
+	ready := func(idle int) {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			n, starting, _ := p.sizes("box")
+			return n == idle && starting == 0
+		}, 10*time.Second, 10*time.Millisecond)
+	}
+
+	p.fill(ctx)
+	ready(1)
+	p.fill(ctx)
+	ready(1)
+	created, _ := docker.counts()
+	require.Equal(t, 1, created, "an unclaimed pool stays at MinIdle")
+
+	// Claims raise the target up to MaxSize.
+	for _, expTarget := range []int{2, 3, 3} {
+		state := p.claim(ctx, "box", "s1")
+		require.NotNil(t, state)
+		require.Equal(t, m.spaceNetworkName("s1"), state.network.Name)
+		_, _, target := p.sizes("box")
+		require.Equal(t, expTarget, target)
+		p.fill(ctx)
+		ready(expTarget)
+	}
+	require.Nil(t, p.claim(ctx, "other", "s1"), "images without a pool are not claimed")
+
+	// A check with claims keeps the target; checks without claims shrink it
+	// back to MinIdle, removing the surplus.
+	p.shrink()
+	idle, _, target := p.sizes("box")
+	require.Equal(t, 3, idle)
+	require.Equal(t, 3, target)
+	p.shrink()
+	p.shrink()
+	p.shrink()
+	idle, _, target = p.sizes("box")
+	require.Equal(t, 1, idle)
+	require.Equal(t, 1, target)
+	_, removed := docker.counts()
+	require.Equal(t, 2, removed)
+
+	// Idle containers stay in the pool while their health is checked.
+	slow.Store(true)
+	evicted := make(chan struct{})
+	go func() {
+		p.evictUnhealthy(ctx)
+		close(evicted)
+	}()
+	<-checking
+	idle, _, _ = p.sizes("box")
+	require.Equal(t, 1, idle)
+	slow.Store(false)
+	close(resume)
+	<-evicted
+	idle, _, _ = p.sizes("box")
+	require.Equal(t, 1, idle)
+
+	// Unhealthy containers are evicted and not handed out.
+	unhealthy.Store(true)
+	p.evictUnhealthy(ctx)
+	idle, _, _ = p.sizes("box")
+	require.Zero(t, idle)
+	require.Nil(t, p.claim(ctx, "box", "s1"))
+	_, removed = docker.counts()
+	require.Equal(t, 3, removed)
+
+	p.stop()
+}
+
+func Test_ParsePoolConfigs(t *testing.T) {
+	cases := []struct {
+		input  string
+		exp    []PoolConfig
+		expErr bool
+	}{
+		{
+			input: "",
+			exp:   nil,
+		},
+		{
+			input: "mentisai/sandboxai-box:latest=2:4",
+			exp:   []PoolConfig{{Image: "mentisai/sandboxai-box:latest", MinIdle: 2, MaxSize: 4}},
+		},
+		{
+			input: "python:3.12=1, busybox=0:2",
+			exp: []PoolConfig{
+				{Image: "python:3.12", MinIdle: 1, MaxSize: 1},
+				{Image: "busybox", MinIdle: 0, MaxSize: 2},
+			},
+		},
+		{
+			input:  "python:3.12",
+			expErr: true,
+		},
+		{
+			input:  "python:3.12=3:1",
+			expErr: true,
+		},
+		{
+			input:  "=1",
+			expErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			configs, err := ParsePoolConfigs(c.input)
+			if c.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.exp, configs)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/docker/go-connections/nat"
+)
+
+var (
+	// ErrPortNotExposed is returned for ports the sandbox spec did not declare.
+	ErrPortNotExposed = errors.New("port not exposed")
+	// ErrSandboxNotRunning is returned when a sandbox's container is not running.
+	ErrSandboxNotRunning = errors.New("sandbox not running")
+)
+
+// validatePorts checks the service ports declared in a spec.
+func validatePorts(ports []int) error {
+	seen := make(map[int]bool, len(ports))
+	for _, p := range ports {
+		if p < 1 || p > 65535 {
+			return fmt.Errorf("%w: port %d out of range", ErrInvalidSpec, p)
+		}
+		if p == agentPortNumber {
+			return fmt.Errorf("%w: port %d is used by the agent", ErrInvalidSpec, p)
+		}
+		if seen[p] {
+			return fmt.Errorf("%w: duplicate port %d", ErrInvalidSpec, p)
+		}
+		seen[p] = true
+	}
+	return nil
+}
+
+// servicePort returns the Docker port of a service port.
+func servicePort(port int) nat.Port {
+	return nat.Port(strconv.Itoa(port) + "/tcp")
+}
+
+// ServiceTarget returns the base URL of a service listening on a declared port
+// inside the sandbox. Ports are published on the agent bind address like the
+// agent port, or reached at the container address on internal networks.
+// Sandboxes of other spaces are reported as not found.
+func (m *SandboxManager) ServiceTarget(ctx context.Context, spaceID, sandboxID string, port int) (*url.URL, error) {
+	m.mu.RLock()
+	state, exists := m.sandboxes[sandboxID]
+	var containerID string
+	var nw sandboxNetwork
+	declared := false
+	exists = exists && state.SpaceID == spaceID
+	if exists {
+		containerID, nw = state.ContainerID, state.network
+		for _, p := range state.Ports {
+			declared = declared || p == port
+		}
+	}
+	m.mu.RUnlock()
+	if !exists {
+		return nil, ErrSandboxNotFound
+	}
+	if !declared {
+		return nil, ErrPortNotExposed
+	}
+	if containerID == "" {
+		return nil, ErrSandboxNotRunning
+	}
+
+	inspectData, err := m.dockerClient.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+	if inspectData.State == nil || !inspectData.State.Running {
+		return nil, ErrSandboxNotRunning
+	}
+
+	var host string
+	if nw.Internal {
+		ip := containerNetworkIP(inspectData, nw.Name)
+		if ip == "" {
+			return nil, fmt.Errorf("container %s has no address on network %s", containerID, nw.Name)
+		}
+		host = net.JoinHostPort(ip, strconv.Itoa(port))
+	} else {
+		hostPort := mappedHostPort(inspectData, string(servicePort(port)))
+		if hostPort == "" {
+			return nil, fmt.Errorf("port %d of container %s is not published", port, containerID)
+		}
+		host = net.JoinHostPort(m.agentHost(), hostPort)
+	}
+	return &url.URL{Scheme: "http", Host: host}, nil
+}
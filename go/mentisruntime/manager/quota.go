@@ -0,0 +1,204 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+var (
+	// ErrQuotaExceeded is returned when a sandbox or action would take a space
+	// over its quota.
+	ErrQuotaExceeded = errors.New("space quota exceeded")
+	// ErrInvalidQuota is returned when a SpaceQuota fails validation.
+	ErrInvalidQuota = errors.New("invalid space quota")
+)
+
+// Resources caps the CPU and memory of a sandbox's container. Zero leaves a
+// dimension unlimited.
+type Resources struct {
+	CPUs     float64 `json:"cpus,omitempty"`
+	MemoryMB int64   `json:"memory_mb,omitempty"`
+}
+
+func (r *Resources) validate() error {
+	if r == nil {
+		return nil
+	}
+	if r.CPUs < 0 || r.MemoryMB < 0 {
+		return fmt.Errorf("%w: resources must not be negative", ErrInvalidSpec)
+	}
+	return nil
+}
+
+// apply sets the limits on a container's host config.
+func (r *Resources) apply(hostConfig *container.HostConfig) {
+	if r == nil {
+		return
+	}
+	hostConfig.NanoCPUs = int64(r.CPUs * 1e9)
+	hostConfig.Memory = r.MemoryMB << 20
+}
+
+// SpaceQuota limits what the sandboxes of a space may use. Zero fields are
+// unlimited. Lowering a limit leaves existing sandboxes alone; new sandboxes and
+// actions are refused while the space is over it.
+type SpaceQuota struct {
+	MaxSandboxes         int           `json:"max_sandboxes,omitempty"`
+	MaxConcurrentActions int           `json:"max_concurrent_actions,omitempty"`
+	CPUs                 float64       `json:"cpus,omitempty"`      // Total of the sandboxes' Resources.CPUs
+	MemoryMB             int64         `json:"memory_mb,omitempty"` // Total of the sandboxes' Resources.MemoryMB
+	MaxLifetime          time.Duration `json:"max_lifetime,omitempty"`
+}
+
+// Validate checks that no limit is negative.
+func (q *SpaceQuota) Validate() error {
+	if q.MaxSandboxes < 0 || q.MaxConcurrentActions < 0 || q.CPUs < 0 || q.MemoryMB < 0 || q.MaxLifetime < 0 {
+		return fmt.Errorf("%w: limits must not be negative", ErrInvalidQuota)
+	}
+	return nil
+}
+
+// SpaceUsage is what the sandboxes of a space currently use.
+type SpaceUsage struct {
+	Sandboxes         int
+	ConcurrentActions int
+	CPUs              float64
+	MemoryMB          int64
+	Quota             *SpaceQuota // nil when the space has none
+}
+
+// SetQuota sets or, with a nil quota, clears the quota of a space.
+func (sm *SpaceManager) SetQuota(ctx context.Context, spaceID string, quota *SpaceQuota) error {
+	if quota != nil {
+		if err := quota.Validate(); err != nil {
+			return err
+		}
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	space, exists := sm.spaces[spaceID]
+	if !exists {
+		return ErrSpaceNotFound
+	}
+	space.Quota = quota
+	space.UpdatedAt = time.Now()
+	sm.logger.Info("Space quota updated", "spaceID", spaceID, "cleared", quota == nil)
+	return nil
+}
+
+// quota returns the quota of a space, nil when it has none or does not exist.
+// A nil SpaceManager has no quotas.
+func (sm *SpaceManager) quota(spaceID string) *SpaceQuota {
+	if sm == nil {
+		return nil
+	}
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	if space, exists := sm.spaces[spaceID]; exists {
+		return space.Quota
+	}
+	return nil
+}
+
+// SpaceUsage returns what the sandboxes of a space currently use.
+func (m *SandboxManager) SpaceUsage(ctx context.Context, spaceID string) (*SpaceUsage, error) {
+	space, err := m.spaceManager.GetSpace(ctx, spaceID)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	usage := m.usageLocked(spaceID)
+	m.mu.RUnlock()
+	usage.Quota = space.Quota
+	return &usage, nil
+}
+
+// usageLocked sums the usage of a space. m.mu must be held.
+func (m *SandboxManager) usageLocked(spaceID string) SpaceUsage {
+	var usage SpaceUsage
+	for _, state := range m.sandboxes {
+		if state.SpaceID != spaceID {
+			continue
+		}
+		usage.Sandboxes++
+		if state.Resources != nil {
+			usage.CPUs += state.Resources.CPUs
+			usage.MemoryMB += state.Resources.MemoryMB
+		}
+	}
+	for _, rec := range m.actions {
+		if state, ok := m.sandboxes[rec.SandboxID]; ok && state.SpaceID == spaceID {
+			usage.ConcurrentActions++
+		}
+	}
+	return usage
+}
+
+// checkQuotaLocked reports whether state fits in its space's quota next to the
+// space's other sandboxes. m.mu must be held.
+func (m *SandboxManager) checkQuotaLocked(state *SandboxState) error {
+	q := m.spaceManager.quota(state.SpaceID)
+	if q == nil {
+		return nil
+	}
+	var res Resources
+	if state.Resources != nil {
+		res = *state.Resources
+	}
+	// Unlimited sandboxes would make the totals meaningless.
+	if q.CPUs > 0 && res.CPUs == 0 {
+		return fmt.Errorf("%w: space %s has a CPU quota, so sandboxes must set resources.cpus", ErrInvalidSpec, state.SpaceID)
+	}
+	if q.MemoryMB > 0 && res.MemoryMB == 0 {
+		return fmt.Errorf("%w: space %s has a memory quota, so sandboxes must set resources.memory_mb", ErrInvalidSpec, state.SpaceID)
+	}
+
+	usage := m.usageLocked(state.SpaceID)
+	switch {
+	case q.MaxSandboxes > 0 && usage.Sandboxes+1 > q.MaxSandboxes:
+		return fmt.Errorf("%w: space %s allows %d sandboxes", ErrQuotaExceeded, state.SpaceID, q.MaxSandboxes)
+	case q.CPUs > 0 && usage.CPUs+res.CPUs > q.CPUs:
+		return fmt.Errorf("%w: space %s allows %g CPUs and uses %g", ErrQuotaExceeded, state.SpaceID, q.CPUs, usage.CPUs)
+	case q.MemoryMB > 0 && usage.MemoryMB+res.MemoryMB > q.MemoryMB:
+		return fmt.Errorf("%w: space %s allows %d MiB of memory and uses %d", ErrQuotaExceeded, state.SpaceID, q.MemoryMB, usage.MemoryMB)
+	}
+	if q.MaxLifetime > 0 {
+		expiresAt := state.Status.CreatedAt.Add(q.MaxLifetime)
+		state.ExpiresAt = &expiresAt
+	}
+	return nil
+}
+
+// checkActionQuotaLocked reports whether another action may start in spaceID.
+// m.mu must be held.
+func (m *SandboxManager) checkActionQuotaLocked(spaceID string) error {
+	q := m.spaceManager.quota(spaceID)
+	if q == nil || q.MaxConcurrentActions == 0 {
+		return nil
+	}
+	if m.usageLocked(spaceID).ConcurrentActions >= q.MaxConcurrentActions {
+		return fmt.Errorf("%w: space %s allows %d concurrent actions", ErrQuotaExceeded, spaceID, q.MaxConcurrentActions)
+	}
+	return nil
+}
+
+// scheduleExpiry deletes a sandbox once it reaches its ExpiresAt.
+func (m *SandboxManager) scheduleExpiry(state *SandboxState) {
+	if state.ExpiresAt == nil {
+		return
+	}
+	sandboxID := state.ID
+	state.expiry = time.AfterFunc(time.Until(*state.ExpiresAt), func() {
+		if m.backgroundCtx.Err() != nil {
+			return // Shut down; the sandbox outlives the runtime
+		}
+		m.logger.Info("Deleting sandbox that reached its maximum lifetime", "sandboxID", sandboxID)
+		if err := m.DeleteSandbox(m.backgroundCtx, sandboxID); err != nil && !errors.Is(err, ErrSandboxNotFound) {
+			m.logger.Error("Failed to delete expired sandbox", "sandboxID", sandboxID, "error", err)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package manager
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SpaceQuota(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	m := &SandboxManager{
+		sandboxes:     make(map[string]*SandboxState),
+		actions:       make(map[string]*actionRecord),
+		logger:        logger,
+		spaceManager:  NewSpaceManager(logger),
+		backgroundCtx: context.Background(),
+	}
+	ctx := context.Background()
+	require.ErrorIs(t, m.spaceManager.SetQuota(ctx, "default", &SpaceQuota{CPUs: -1}), ErrInvalidQuota)
+	require.NoError(t, m.spaceManager.SetQuota(ctx, "default", &SpaceQuota{MaxSandboxes: 2, CPUs: 1.5, MaxConcurrentActions: 1, MaxLifetime: time.Hour}))
+
+	sandbox := func(id string, cpus float64) *SandboxState {
+		state := &SandboxState{ID: id, SpaceID: "default", Status: SandboxStatus{CreatedAt: time.Now()}}
+		if cpus > 0 {
+			state.Resources = &Resources{CPUs: cpus}
+		}
+		return state
+	}
+	require.ErrorIs(t, m.registerSandbox(sandbox("a", 0)), ErrInvalidSpec, "CPU quota requires CPUs")
+	require.NoError(t, m.registerSandbox(sandbox("a", 1)))
+	require.ErrorIs(t, m.registerSandbox(sandbox("b", 1)), ErrQuotaExceeded, "CPU quota")
+	require.NoError(t, m.registerSandbox(sandbox("b", 0.5)))
+	require.ErrorIs(t, m.registerSandbox(sandbox("c", 0.1)), ErrQuotaExceeded, "sandbox quota")
+
+	state, err := m.GetSandbox(ctx, "a")
+	require.NoError(t, err)
+	require.NotNil(t, state.ExpiresAt)
+	require.WithinDuration(t, state.Status.CreatedAt.Add(time.Hour), *state.ExpiresAt, time.Second)
+
+	require.NoError(t, m.trackAction(&actionRecord{ID: "x1", SandboxID: "a"}))
+	require.ErrorIs(t, m.trackAction(&actionRecord{ID: "x2", SandboxID: "b"}), ErrQuotaExceeded)
+
+	usage, err := m.SpaceUsage(ctx, "default")
+	require.NoError(t, err)
+	require.Equal(t, 2, usage.Sandboxes)
+	require.Equal(t, 1, usage.ConcurrentActions)
+	require.Equal(t, 1.5, usage.CPUs)
+	require.Equal(t, 2, usage.Quota.MaxSandboxes)
+
+	// Removing a sandbox frees its share and stops its expiry.
+	m.unregisterSandbox("b", "default")
+	require.NoError(t, m.registerSandbox(sandbox("c", 0.5)))
+	_, err = m.SpaceUsage(ctx, "missing")
+	require.ErrorIs(t, err, ErrSpaceNotFound)
+}
+
+func Test_SpaceQuotaLifetime(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	m := &SandboxManager{
+		sandboxes:     make(map[string]*SandboxState),
+		actions:       make(map[string]*actionRecord),
+		logger:        logger,
+		spaceManager:  NewSpaceManager(logger),
+		backgroundCtx: context.Background(),
+	}
+	require.NoError(t, m.spaceManager.SetQuota(context.Background(), "default", &SpaceQuota{MaxLifetime: 10 * time.Millisecond}))
+	require.NoError(t, m.registerSandbox(&SandboxState{ID: "a", SpaceID: "default", Status: SandboxStatus{CreatedAt: time.Now()}}))
+
+	require.Eventually(t, func() bool {
+		exists, _ := m.SandboxExists(context.Background(), "a")
+		return !exists
+	}, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,50 @@
+package manager
+
+import (
+	"context"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/recording"
+)
+
+// WithRecorder records every observation published on a sandbox's stream in r.
+func WithRecorder(r *recording.Recorder) Option {
+	return func(m *SandboxManager) {
+		m.recorder = r
+	}
+}
+
+// publish records an observation of a sandbox and broadcasts it to the
+// sandbox's stream.
+func (m *SandboxManager) publish(sandboxID string, observation []byte) {
+	m.mu.RLock()
+	state, exists := m.sandboxes[sandboxID]
+	var spaceID string
+	if exists {
+		spaceID = state.SpaceID
+	}
+	m.mu.RUnlock()
+	if exists {
+		if err := m.recorder.Record(spaceID, sandboxID, observation); err != nil {
+			m.logger.Error("Failed to record observation", "sandboxID", sandboxID, "error", err)
+		}
+	}
+	if m.hub != nil {
+		m.hub.SubmitBroadcast(sandboxID, observation)
+	}
+}
+
+// ReadRecording calls fn with the recorded observations of a sandbox of a
+// space in order. Recordings outlive their sandbox.
+func (m *SandboxManager) ReadRecording(ctx context.Context, spaceID, sandboxID string, fn func(recording.Entry) error) error {
+	if _, err := m.spaceManager.GetSpace(ctx, spaceID); err != nil {
+		return err
+	}
+	return m.recorder.Each(spaceID, sandboxID, fn)
+}
+
+// deleteSpaceRecordings removes the recordings of a deleted space.
+func (m *SandboxManager) deleteSpaceRecordings(spaceID string) {
+	if err := m.recorder.DeleteSpace(spaceID); err != nil {
+		m.logger.Error("Failed to delete recordings of space", "spaceID", spaceID, "error", err)
+	}
+}
@@ -0,0 +1,68 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/recording"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/ws"
+)
+
+func Test_observationRecording(t *testing.T) {
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer agent.Close()
+	recorder, err := recording.NewRecorder(t.TempDir())
+	require.NoError(t, err)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	m := &SandboxManager{
+		sandboxes:    make(map[string]*SandboxState),
+		actions:      make(map[string]*actionRecord),
+		httpClient:   agent.Client(),
+		logger:       logger,
+		hub:          ws.NewHub(logger),
+		spaceManager: NewSpaceManager(logger),
+		recorder:     recorder,
+	}
+	m.sandboxes["sb1"] = &SandboxState{ID: "sb1", SpaceID: "s1", AgentURL: agent.URL, IsRunning: true, redactions: []string{"hunter2"}}
+
+	actionID, err := m.InitiateAction(context.Background(), "sb1", "shell", map[string]interface{}{"command": "echo $PASSWORD"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		var started bool
+		recorder.Each("s1", "sb1", func(recording.Entry) error { started = true; return nil })
+		return started
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, m.ReceiveInternalObservation(context.Background(), "sb1",
+		[]byte(`{"observation_type":"stream","action_id":"`+actionID+`","stream":"stdout","line":"hunter2"}`)))
+	require.NoError(t, m.ReceiveInternalObservation(context.Background(), "sb1",
+		[]byte(`{"observation_type":"result","action_id":"`+actionID+`","exit_code":0}`)))
+
+	var recorded []recording.Entry
+	var observations []Observation
+	require.NoError(t, recorder.Each("s1", "sb1", func(e recording.Entry) error {
+		var obs Observation
+		require.NoError(t, json.Unmarshal(e.Observation, &obs))
+		recorded = append(recorded, e)
+		observations = append(observations, obs)
+		return nil
+	}))
+	require.Len(t, observations, 4)
+	for i, typ := range []string{"start", "stream", "result", "end"} {
+		require.Equal(t, typ, observations[i].ObservationType)
+	}
+	require.Equal(t, map[string]any{"action_type": "shell"}, observations[0].Data)
+	require.Contains(t, string(recorded[1].Observation), "REDACTED", "secrets are redacted before recording")
+	require.NotContains(t, string(recorded[1].Observation), "hunter2")
+
+	m.unregisterSandbox("sb1", "s1")
+	require.NoError(t, m.recorder.Each("s1", "sb1", func(recording.Entry) error { return nil }), "recordings outlive their sandbox")
+}
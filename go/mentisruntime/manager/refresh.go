@@ -0,0 +1,152 @@
+package manager
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+// statusFreshness is how long a refreshed status is reused before GetSandbox
+// inspects the container again.
+const statusFreshness = 2 * time.Second
+
+// WithStatusRefresh refreshes the status of every sandbox from Docker at the
+// given interval, in addition to the refresh performed by GetSandbox.
+func WithStatusRefresh(interval time.Duration) Option {
+	return func(m *SandboxManager) {
+		m.statusRefreshInterval = interval
+	}
+}
+
+// watchStatuses refreshes all sandbox statuses until ctx is cancelled.
+func (m *SandboxManager) watchStatuses(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.mu.RLock()
+		ids := make([]string, 0, len(m.sandboxes))
+		for id := range m.sandboxes {
+			ids = append(ids, id)
+		}
+		m.mu.RUnlock()
+
+		for _, id := range ids {
+			m.refreshSandboxStatus(ctx, id, true)
+		}
+	}
+}
+
+// refreshSandboxStatus updates a sandbox's status from its container and agent.
+// Unless force is set, a status refreshed within statusFreshness is left alone.
+// Sandboxes that are still provisioning or failed to provision are skipped.
+func (m *SandboxManager) refreshSandboxStatus(ctx context.Context, sandboxID string, force bool) {
+	m.mu.RLock()
+	state, exists := m.sandboxes[sandboxID]
+	if !exists {
+		m.mu.RUnlock()
+		return
+	}
+	containerID := state.ContainerID
+	agentURL := state.AgentURL
+	skip := containerID == "" || state.Status.State == StateProvisioning || state.Status.State == StateFailed ||
+		(!force && state.Status.CheckedAt != nil && time.Since(*state.Status.CheckedAt) < statusFreshness)
+	m.mu.RUnlock()
+	if skip {
+		return
+	}
+
+	inspectCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	inspectData, err := m.dockerClient.ContainerInspect(inspectCtx, containerID)
+	cancel()
+	now := time.Now().UTC()
+
+	if err != nil {
+		if !client.IsErrNotFound(err) {
+			m.logger.Warn("Failed to inspect container while refreshing sandbox status", "sandboxID", sandboxID, "containerID", containerID, "error", err)
+			return
+		}
+		m.logger.Warn("Sandbox container no longer exists, marking sandbox lost", "sandboxID", sandboxID, "containerID", containerID)
+		m.mu.Lock()
+		if s, ok := m.sandboxes[sandboxID]; ok {
+			s.IsRunning = false
+			s.Status.State = StateLost
+			s.Status.Reason = ReasonContainerNotFound
+			s.Status.ContainerState = ""
+			s.Status.AgentReachable = nil
+			s.Status.CheckedAt = &now
+			m.meter.stop(sandboxID, now)
+		}
+		m.mu.Unlock()
+		return
+	}
+
+	var reachable *bool
+	if inspectData.State != nil && inspectData.State.Running && agentURL != "" {
+		ok := m.checkAgentHealth(ctx, agentURL) == nil
+		reachable = &ok
+	}
+
+	m.mu.Lock()
+	if s, ok := m.sandboxes[sandboxID]; ok {
+		applyContainerState(&s.Status, inspectData)
+		s.Status.AgentReachable = reachable
+		s.Status.CheckedAt = &now
+		s.IsRunning = s.Status.State == StateRunning
+		// Picks up restarts and exits the event stream missed.
+		if s.IsRunning {
+			m.meter.start(s.SpaceID, sandboxID, now)
+		} else {
+			m.meter.stop(sandboxID, now)
+		}
+	}
+	m.mu.Unlock()
+}
+
+// applyContainerState copies the observed container state into status.
+func applyContainerState(status *SandboxStatus, inspectData types.ContainerJSON) {
+	if inspectData.ContainerJSONBase == nil || inspectData.State == nil {
+		return
+	}
+	st := inspectData.State
+	status.ContainerState = st.Status
+	status.StartedAt = parseDockerTime(st.StartedAt)
+	status.FinishedAt = parseDockerTime(st.FinishedAt)
+	status.OOMKilled = st.OOMKilled
+	status.RestartCount = inspectData.RestartCount
+	status.Health = ""
+	if st.Health != nil {
+		status.Health = st.Health.Status
+	}
+
+	if st.Running {
+		status.State = StateRunning
+		status.ExitCode = nil
+		status.Reason = ""
+		return
+	}
+	exitCode := st.ExitCode
+	status.ExitCode = &exitCode
+	status.State = StateExited
+	if st.OOMKilled {
+		status.Reason = ReasonOOMKilled
+	}
+}
+
+// parseDockerTime parses a timestamp from container inspect output. Docker
+// reports unset times as the zero time, which is returned as nil.
+func parseDockerTime(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil || t.IsZero() || t.Year() <= 1 {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
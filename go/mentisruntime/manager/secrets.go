@@ -0,0 +1,408 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/audit"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/secrets"
+)
+
+// SecretsDir is the tmpfs in sandboxes that secret files are written to.
+const SecretsDir = "/run/secrets"
+
+// labelSecretEnv lists the environment variables of a container that hold
+// secrets, so tools inspecting it can leave them out.
+const labelSecretEnv = "sandboxai.secret-env"
+
+// minRedactLength is the shortest secret value redacted from observations;
+// shorter ones would mask ordinary output.
+const minRedactLength = 4
+
+// SecretRef injects a secret of the sandbox's space as an environment
+// variable, a file under SecretsDir, or both. With neither set, the secret is
+// injected as the variable of its name.
+type SecretRef struct {
+	Name string `json:"name"`
+	Env  string `json:"env,omitempty"`
+	File string `json:"file,omitempty"`
+}
+
+var secretFilePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+
+// WithSecrets lets sandbox specs reference the secrets of their space in store.
+func WithSecrets(store *secrets.Store) Option {
+	return func(m *SandboxManager) {
+		m.secrets = store
+	}
+}
+
+// validateSecrets checks the secret references of a spec and fills in the
+// default variable names.
+func (s *SandboxSpec) validateSecrets() error {
+	files := make(map[string]bool, len(s.Secrets))
+	envs := make(map[string]bool, len(s.Secrets))
+	for i := range s.Secrets {
+		ref := &s.Secrets[i]
+		if err := secrets.ValidateName(ref.Name); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+		}
+		if ref.Env == "" && ref.File == "" {
+			ref.Env = ref.Name
+		}
+		if ref.Env != "" {
+			if strings.ContainsAny(ref.Env, "= ") {
+				return fmt.Errorf("%w: invalid environment variable name %q", ErrInvalidSpec, ref.Env)
+			}
+			if reservedEnv[ref.Env] {
+				return fmt.Errorf("%w: environment variable %s is reserved", ErrInvalidSpec, ref.Env)
+			}
+			if _, ok := s.Env[ref.Env]; ok || envs[ref.Env] {
+				return fmt.Errorf("%w: environment variable %s is set more than once", ErrInvalidSpec, ref.Env)
+			}
+			envs[ref.Env] = true
+		}
+		if ref.File != "" {
+			if !secretFilePattern.MatchString(ref.File) {
+				return fmt.Errorf("%w: invalid secret file name %q", ErrInvalidSpec, ref.File)
+			}
+			if files[ref.File] {
+				return fmt.Errorf("%w: duplicate secret file %s", ErrInvalidSpec, ref.File)
+			}
+			files[ref.File] = true
+		}
+	}
+	return nil
+}
+
+// resolveSecrets looks up the values of the secrets a spec references.
+func (m *SandboxManager) resolveSecrets(spaceID string, spec *SandboxSpec) error {
+	if len(spec.Secrets) == 0 {
+		return nil
+	}
+	if m.secrets == nil {
+		return fmt.Errorf("%w: secrets are not enabled", ErrInvalidSpec)
+	}
+	names := make([]string, len(spec.Secrets))
+	for i, ref := range spec.Secrets {
+		names[i] = ref.Name
+	}
+	values, err := m.secrets.Resolve(spaceID, names)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	} else if err != nil {
+		return err
+	}
+	spec.secretValues = values
+	return nil
+}
+
+// secretEnv formats the secrets injected as environment variables for the
+// Docker API.
+func (s *SandboxSpec) secretEnv() []string {
+	var env []string
+	for _, ref := range s.Secrets {
+		if ref.Env != "" {
+			env = append(env, ref.Env+"="+s.secretValues[ref.Name])
+		}
+	}
+	return env
+}
+
+// secretEnvNames returns the value of labelSecretEnv, empty when no secret is
+// injected as a variable.
+func (s *SandboxSpec) secretEnvNames() string {
+	var names []string
+	for _, ref := range s.Secrets {
+		if ref.Env != "" {
+			names = append(names, ref.Env)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// secretFiles maps the path of each secret file to its value.
+func (s *SandboxSpec) secretFiles() map[string]string {
+	var files map[string]string
+	for _, ref := range s.Secrets {
+		if ref.File == "" {
+			continue
+		}
+		if files == nil {
+			files = make(map[string]string)
+		}
+		files[SecretsDir+"/"+ref.File] = s.secretValues[ref.Name]
+	}
+	return files
+}
+
+// redactedValues returns the secret values to redact from observations,
+// longest first so a value containing another is masked whole.
+func (s *SandboxSpec) redactedValues() []string {
+	var values []string
+	for _, v := range s.secretValues {
+		if len(v) >= minRedactLength && !slices.Contains(values, v) {
+			values = append(values, v)
+		}
+	}
+	slices.SortFunc(values, func(a, b string) int { return len(b) - len(a) })
+	return values
+}
+
+// secretsTmpfs returns the mount options of SecretsDir. The files are written
+// as the container's user, which owns the tmpfs when the security profile
+// names it numerically; otherwise the tmpfs is shared like /tmp and the files
+// themselves are private.
+func secretsTmpfs(security *SecurityProfile) string {
+	opts := "rw,noexec,nosuid,nodev,size=1m"
+	if security != nil {
+		uid, gid, _ := strings.Cut(security.User, ":")
+		if isNumeric(uid) && (gid == "" || isNumeric(gid)) {
+			opts += ",mode=0700,uid=" + uid
+			if gid != "" {
+				opts += ",gid=" + gid
+			}
+			return opts
+		}
+	}
+	return opts + ",mode=1777"
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// writeSecretFiles writes secret files into a running container. The tmpfs is
+// empty whenever the container starts, so this is repeated after restarts.
+func (m *SandboxManager) writeSecretFiles(ctx context.Context, containerID string, files map[string]string) error {
+	for path, value := range files {
+		exec, err := m.dockerClient.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+			AttachStdin:  true,
+			AttachStdout: true,
+			AttachStderr: true,
+			Cmd:          []string{"/bin/sh", "-c", `umask 077 && cat > "$1"`, "sh", path},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write secret file %s: %w", path, err)
+		}
+		conn, err := m.dockerClient.ContainerExecAttach(ctx, exec.ID, container.ExecAttachOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to write secret file %s: %w", path, err)
+		}
+		_, err = io.WriteString(conn.Conn, value)
+		if err == nil {
+			err = conn.CloseWrite()
+		}
+		io.Copy(io.Discard, conn.Reader)
+		conn.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write secret file %s: %w", path, err)
+		}
+		if exitCode, err := m.execExitCode(ctx, exec.ID); err != nil {
+			return fmt.Errorf("failed to write secret file %s: %w", path, err)
+		} else if exitCode != 0 {
+			return fmt.Errorf("failed to write secret file %s: exit code %d", path, exitCode)
+		}
+	}
+	return nil
+}
+
+// sandboxRedactions returns the secret values masked in a sandbox's output.
+func (m *SandboxManager) sandboxRedactions(sandboxID string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if state, exists := m.sandboxes[sandboxID]; exists {
+		return state.redactions
+	}
+	return nil
+}
+
+// redactingReader masks secret values in a stream of output. Unless it is
+// interactive, it holds back the end of what it read while that could be the
+// start of a value, so values split across reads are masked too.
+type redactingReader struct {
+	r           io.Reader
+	values      [][]byte // Longest first, so the longest match wins
+	interactive bool     // Pass output on at once; values split across reads show
+	pending     []byte   // Read but not yet passed on
+	out         []byte   // Redacted and ready to be read
+	err         error    // Error of the underlying reader
+}
+
+func newRedactingReader(r io.Reader, values []string, interactive bool) *redactingReader {
+	rr := &redactingReader{r: r, interactive: interactive}
+	for _, v := range values {
+		if v != "" {
+			rr.values = append(rr.values, []byte(v))
+		}
+	}
+	slices.SortFunc(rr.values, func(a, b []byte) int { return len(b) - len(a) })
+	return rr
+}
+
+func (rr *redactingReader) Read(p []byte) (int, error) {
+	if len(rr.values) == 0 {
+		return rr.r.Read(p)
+	}
+	for len(rr.out) == 0 {
+		if rr.err != nil {
+			return 0, rr.err
+		}
+		buf := make([]byte, len(p))
+		n, err := rr.r.Read(buf)
+		rr.pending = append(rr.pending, buf[:n]...)
+		rr.err = err
+		rr.redact(err != nil || rr.interactive)
+	}
+	n := copy(p, rr.out)
+	rr.out = rr.out[n:]
+	return n, nil
+}
+
+// redact moves pending output to out, masking values. Unless final, a tail
+// that may continue into a value stays pending.
+func (rr *redactingReader) redact(final bool) {
+	i := 0
+scan:
+	for i < len(rr.pending) {
+		rest := rr.pending[i:]
+		for _, v := range rr.values {
+			if bytes.HasPrefix(rest, v) {
+				rr.out = append(rr.out, audit.Redacted...)
+				i += len(v)
+				continue scan
+			}
+			if !final && len(rest) < len(v) && bytes.HasPrefix(v, rest) {
+				break scan
+			}
+		}
+		rr.out = append(rr.out, rr.pending[i])
+		i++
+	}
+	rr.pending = append(rr.pending[:0], rr.pending[i:]...)
+}
+
+// redactSecrets masks secret values in the strings of an observation. An
+// observation that is not JSON has the values masked in place.
+func redactSecrets(observation []byte, values []string) []byte {
+	if len(values) == 0 {
+		return observation
+	}
+	dec := json.NewDecoder(bytes.NewReader(observation))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		for _, v := range values {
+			observation = bytes.ReplaceAll(observation, []byte(v), []byte(audit.Redacted))
+		}
+		return observation
+	}
+	redacted, changed := redactValue(generic, values)
+	if !changed {
+		return observation
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(redacted); err != nil {
+		return observation
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+func redactValue(v any, values []string) (any, bool) {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		for k, elem := range v {
+			var c bool
+			v[k], c = redactValue(elem, values)
+			changed = changed || c
+		}
+	case []any:
+		for i, elem := range v {
+			var c bool
+			v[i], c = redactValue(elem, values)
+			changed = changed || c
+		}
+	case string:
+		for _, secret := range values {
+			if strings.Contains(v, secret) {
+				v = strings.ReplaceAll(v, secret, audit.Redacted)
+				changed = true
+			}
+		}
+		return v, changed
+	}
+	return v, changed
+}
+
+// ListSecrets returns the secrets of a space, without their values.
+func (m *SandboxManager) ListSecrets(ctx context.Context, spaceID string) ([]secrets.Secret, error) {
+	if _, err := m.spaceManager.GetSpace(ctx, spaceID); err != nil {
+		return nil, err
+	}
+	if m.secrets == nil {
+		return nil, nil
+	}
+	return m.secrets.List(spaceID), nil
+}
+
+// SetSecret creates or replaces a secret of a space. It reports whether the
+// secret is new.
+func (m *SandboxManager) SetSecret(ctx context.Context, spaceID, name, value string) (secrets.Secret, bool, error) {
+	if _, err := m.spaceManager.GetSpace(ctx, spaceID); err != nil {
+		return secrets.Secret{}, false, err
+	}
+	if m.secrets == nil {
+		return secrets.Secret{}, false, errors.New("secrets are not enabled")
+	}
+	secret, created, err := m.secrets.Set(spaceID, name, value)
+	if err != nil {
+		return secrets.Secret{}, false, err
+	}
+	m.logger.Info("Secret set", "spaceID", spaceID, "name", name, "created", created)
+	return secret, created, nil
+}
+
+// DeleteSecret deletes a secret of a space.
+func (m *SandboxManager) DeleteSecret(ctx context.Context, spaceID, name string) error {
+	if _, err := m.spaceManager.GetSpace(ctx, spaceID); err != nil {
+		return err
+	}
+	if m.secrets == nil {
+		return fmt.Errorf("%w: %s", secrets.ErrNotFound, name)
+	}
+	if err := m.secrets.Delete(spaceID, name); err != nil {
+		return err
+	}
+	m.logger.Info("Secret deleted", "spaceID", spaceID, "name", name)
+	return nil
+}
+
+// deleteSpaceSecrets removes the secrets of a deleted space.
+func (m *SandboxManager) deleteSpaceSecrets(spaceID string) {
+	if m.secrets == nil {
+		return
+	}
+	if err := m.secrets.DeleteSpace(spaceID); err != nil {
+		m.logger.Error("Failed to delete secrets of space", "spaceID", spaceID, "error", err)
+	}
+}
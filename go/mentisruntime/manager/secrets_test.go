@@ -0,0 +1,72 @@
+package manager
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/secrets"
+)
+
+func Test_SandboxSpecSecrets(t *testing.T) {
+	store, err := secrets.NewStore("", nil)
+	require.NoError(t, err)
+	_, _, err = store.Set("s1", "OPENAI_API_KEY", "sk-0123456789")
+	require.NoError(t, err)
+	_, _, err = store.Set("s1", "db.password", "hunter2")
+	require.NoError(t, err)
+	m := &SandboxManager{secrets: store}
+
+	spec := SandboxSpec{Secrets: []SecretRef{{Name: "OPENAI_API_KEY"}, {Name: "db.password", Env: "DB_PASSWORD", File: "db"}}}
+	require.NoError(t, spec.validate())
+	require.NoError(t, m.resolveSecrets("s1", &spec))
+	require.Equal(t, []string{"OPENAI_API_KEY=sk-0123456789", "DB_PASSWORD=hunter2"}, spec.secretEnv())
+	require.Equal(t, "OPENAI_API_KEY,DB_PASSWORD", spec.secretEnvNames())
+	require.Equal(t, map[string]string{"/run/secrets/db": "hunter2"}, spec.secretFiles())
+	require.Equal(t, []string{"sk-0123456789", "hunter2"}, spec.redactedValues())
+
+	missing := SandboxSpec{Secrets: []SecretRef{{Name: "OPENAI_API_KEY"}}}
+	require.ErrorIs(t, m.resolveSecrets("s2", &missing), ErrInvalidSpec, "secrets of other spaces are not visible")
+	require.ErrorIs(t, (&SandboxManager{}).resolveSecrets("s1", &missing), ErrInvalidSpec)
+}
+
+func Test_redactSecrets(t *testing.T) {
+	values := []string{"sk-0123456789", "hunter2"}
+	out := redactSecrets([]byte(`{"type":"stdout","text":"key sk-0123456789 & pw hunter2\n","n":1.50}`), values)
+	require.JSONEq(t, `{"type":"stdout","text":"key REDACTED & pw REDACTED\n","n":1.50}`, string(out))
+	require.Contains(t, string(out), "&", "HTML characters are not escaped")
+	require.Contains(t, string(out), "1.50", "numbers are kept as sent")
+
+	unchanged := []byte(`{"type":"stdout","text":"nothing to hide"}`)
+	require.Equal(t, unchanged, redactSecrets(unchanged, values))
+	require.Equal(t, []byte("raw REDACTED"), redactSecrets([]byte("raw hunter2"), values))
+}
+
+func Test_redactingReader(t *testing.T) {
+	values := []string{"hunter2", "sk-0123456789"}
+	// One byte per read splits every value across reads.
+	r := newRedactingReader(iotest.OneByteReader(strings.NewReader("pw hunter2, key sk-0123456789, hunt")), values, false)
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "pw REDACTED, key REDACTED, hunt", string(out))
+
+	// Interactive output is masked only when a value arrives in one read.
+	r = newRedactingReader(strings.NewReader("$ cat key\nsk-0123456789\n"), values, true)
+	out, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "$ cat key\nREDACTED\n", string(out))
+
+	r = newRedactingReader(strings.NewReader("hunter2"), nil, false)
+	out, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", string(out), "nothing to mask")
+}
+
+func Test_secretsTmpfs(t *testing.T) {
+	require.Equal(t, "rw,noexec,nosuid,nodev,size=1m,mode=1777", secretsTmpfs(nil))
+	require.Equal(t, "rw,noexec,nosuid,nodev,size=1m,mode=0700,uid=1000,gid=1000", secretsTmpfs(&SecurityProfile{User: "1000:1000"}))
+	require.Equal(t, "rw,noexec,nosuid,nodev,size=1m,mode=1777", secretsTmpfs(&SecurityProfile{User: "sandbox"}))
+}
@@ -0,0 +1,152 @@
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// ErrInvalidSecurityProfile is returned when a SecurityProfile fails validation.
+var ErrInvalidSecurityProfile = errors.New("invalid security profile")
+
+// SecurityProfile hardens sandbox containers. The zero value applies Docker's
+// defaults. A profile is set server-wide with WithSecurityProfile and can be
+// replaced per space with SpaceManager.SetSecurityProfile.
+type SecurityProfile struct {
+	// DropAllCapabilities drops every capability except those in Capabilities.
+	DropAllCapabilities bool     `json:"drop_all_capabilities,omitempty"`
+	Capabilities        []string `json:"capabilities,omitempty"` // e.g. ["CHOWN", "SETUID"]
+	NoNewPrivileges     bool     `json:"no_new_privileges,omitempty"`
+	// ReadOnlyRootfs mounts the root filesystem read-only with writable tmpfs
+	// mounts at /work and /tmp. HOME is set to /tmp unless the spec sets it.
+	ReadOnlyRootfs bool   `json:"read_only_rootfs,omitempty"`
+	TmpfsSize      string `json:"tmpfs_size,omitempty"` // Size of each tmpfs mount, e.g. "512m"
+	User           string `json:"user,omitempty"`       // User to run as, e.g. "1000:1000"
+	// SeccompProfile is the path of a seccomp profile on the runtime host.
+	SeccompProfile string `json:"seccomp_profile,omitempty"`
+	Runtime        string `json:"runtime,omitempty"` // OCI runtime, e.g. "runsc" for gVisor
+}
+
+// HardenedSecurityProfile returns the recommended profile: no capabilities,
+// no privilege escalation, a read-only root filesystem and a non-root user.
+func HardenedSecurityProfile() *SecurityProfile {
+	return &SecurityProfile{
+		DropAllCapabilities: true,
+		NoNewPrivileges:     true,
+		ReadOnlyRootfs:      true,
+		TmpfsSize:           "512m",
+		User:                "1000:1000",
+	}
+}
+
+// LoadSecurityProfile returns the hardened profile for "hardened" and otherwise
+// reads a JSON profile from the given path.
+func LoadSecurityProfile(nameOrPath string) (*SecurityProfile, error) {
+	if nameOrPath == "hardened" {
+		return HardenedSecurityProfile(), nil
+	}
+	data, err := os.ReadFile(nameOrPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read security profile: %w", err)
+	}
+	var p SecurityProfile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse security profile %s: %w", nameOrPath, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// WithSecurityProfile sets the server-wide security profile applied to sandboxes
+// in spaces without a profile of their own.
+func WithSecurityProfile(p *SecurityProfile) Option {
+	return func(m *SandboxManager) {
+		m.securityProfile = p
+	}
+}
+
+// Validate normalises capability names and checks that the seccomp profile is
+// readable JSON.
+func (p *SecurityProfile) Validate() error {
+	for i, c := range p.Capabilities {
+		c = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(c)), "CAP_")
+		if c == "" {
+			return fmt.Errorf("%w: empty capability", ErrInvalidSecurityProfile)
+		}
+		p.Capabilities[i] = c
+	}
+	if p.SeccompProfile != "" {
+		if _, err := p.seccompJSON(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSecurityProfile, err)
+		}
+	}
+	return nil
+}
+
+// seccompJSON reads the seccomp profile. Docker expects the profile's content
+// rather than a path.
+func (p *SecurityProfile) seccompJSON() (string, error) {
+	data, err := os.ReadFile(p.SeccompProfile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read seccomp profile: %w", err)
+	}
+	if !json.Valid(data) {
+		return "", fmt.Errorf("seccomp profile %s is not valid JSON", p.SeccompProfile)
+	}
+	return string(data), nil
+}
+
+// apply sets the profile's options on the container configuration. A nil
+// profile leaves Docker's defaults in place.
+func (p *SecurityProfile) apply(cfg *container.Config, hostConfig *container.HostConfig) error {
+	if p == nil {
+		return nil
+	}
+	if p.DropAllCapabilities {
+		hostConfig.CapDrop = []string{"ALL"}
+	}
+	hostConfig.CapAdd = append(hostConfig.CapAdd, p.Capabilities...)
+	if p.NoNewPrivileges {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "no-new-privileges:true")
+	}
+	if p.SeccompProfile != "" {
+		profile, err := p.seccompJSON()
+		if err != nil {
+			return err
+		}
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "seccomp="+profile)
+	}
+	if p.ReadOnlyRootfs {
+		hostConfig.ReadonlyRootfs = true
+		opts := "rw,exec,nosuid,nodev,mode=1777"
+		if p.TmpfsSize != "" {
+			opts += ",size=" + p.TmpfsSize
+		}
+		hostConfig.Tmpfs = map[string]string{"/work": opts, "/tmp": opts}
+		if !hasEnv(cfg.Env, "HOME") {
+			cfg.Env = append(cfg.Env, "HOME=/tmp")
+		}
+	}
+	if p.User != "" {
+		cfg.User = p.User
+	}
+	if p.Runtime != "" {
+		hostConfig.Runtime = p.Runtime
+	}
+	return nil
+}
+
+func hasEnv(env []string, name string) bool {
+	for _, kv := range env {
+		if strings.HasPrefix(kv, name+"=") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SecurityProfileApply(t *testing.T) {
+	var nilProfile *SecurityProfile
+	cfg := &container.Config{}
+	hostConfig := &container.HostConfig{}
+	require.NoError(t, nilProfile.apply(cfg, hostConfig))
+	require.Equal(t, &container.HostConfig{}, hostConfig)
+
+	seccomp := filepath.Join(t.TempDir(), "seccomp.json")
+	require.NoError(t, os.WriteFile(seccomp, []byte(`{"defaultAction":"SCMP_ACT_ERRNO"}`), 0o644))
+
+	profile := HardenedSecurityProfile()
+	profile.Capabilities = []string{"cap_chown", " setuid"}
+	profile.SeccompProfile = seccomp
+	profile.Runtime = "runsc"
+	require.NoError(t, profile.Validate())
+	require.Equal(t, []string{"CHOWN", "SETUID"}, profile.Capabilities)
+
+	cfg = &container.Config{Env: []string{"SANDBOX_ID=x"}}
+	hostConfig = &container.HostConfig{}
+	require.NoError(t, profile.apply(cfg, hostConfig))
+	require.EqualValues(t, []string{"ALL"}, hostConfig.CapDrop)
+	require.EqualValues(t, []string{"CHOWN", "SETUID"}, hostConfig.CapAdd)
+	require.Equal(t, []string{"no-new-privileges:true", `seccomp={"defaultAction":"SCMP_ACT_ERRNO"}`}, hostConfig.SecurityOpt)
+	require.True(t, hostConfig.ReadonlyRootfs)
+	require.Contains(t, hostConfig.Tmpfs, "/work")
+	require.Contains(t, hostConfig.Tmpfs, "/tmp")
+	require.Contains(t, hostConfig.Tmpfs["/work"], "size=512m")
+	require.Equal(t, "runsc", hostConfig.Runtime)
+	require.Equal(t, "1000:1000", cfg.User)
+	require.Equal(t, []string{"SANDBOX_ID=x", "HOME=/tmp"}, cfg.Env)
+}
+
+func Test_LoadSecurityProfile(t *testing.T) {
+	p, err := LoadSecurityProfile("hardened")
+	require.NoError(t, err)
+	require.Equal(t, HardenedSecurityProfile(), p)
+
+	path := filepath.Join(t.TempDir(), "profile.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"no_new_privileges":true,"seccomp_profile":"/does/not/exist"}`), 0o644))
+	_, err = LoadSecurityProfile(path)
+	require.ErrorIs(t, err, ErrInvalidSecurityProfile)
+
+	_, err = LoadSecurityProfile(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
@@ -185,4 +185,24 @@ func (sm *SpaceManager) getSpaceSandboxes(spaceID string) ([]string, error) {
 		ids = append(ids, id)
 	}
 	return ids, nil
-}
\ No newline at end of file
+}
+
+// SetSecurityProfile sets or, with a nil profile, clears the security profile of
+// a space. It applies to sandboxes created afterwards.
+func (sm *SpaceManager) SetSecurityProfile(ctx context.Context, spaceID string, profile *SecurityProfile) error {
+	if profile != nil {
+		if err := profile.Validate(); err != nil {
+			return err
+		}
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	space, exists := sm.spaces[spaceID]
+	if !exists {
+		return ErrSpaceNotFound
+	}
+	space.SecurityProfile = profile
+	space.UpdatedAt = time.Now()
+	sm.logger.Info("Space security profile updated", "spaceID", spaceID, "cleared", profile == nil)
+	return nil
+}
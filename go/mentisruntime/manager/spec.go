@@ -0,0 +1,78 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidSpec is returned when a SandboxSpec fails validation.
+var ErrInvalidSpec = errors.New("invalid sandbox spec")
+
+// SandboxSpec describes the sandbox to create. The zero value creates a sandbox
+// from the default box image with full network access.
+type SandboxSpec struct {
+	Image     string            `json:"image,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	Network   *NetworkPolicy    `json:"network,omitempty"`
+	Mounts    []MountSpec       `json:"mounts,omitempty"`
+	Ports     []int             `json:"ports,omitempty"` // Service ports to proxy, see SandboxManager.ServiceTarget
+	Resources *Resources        `json:"resources,omitempty"`
+	Secrets   []SecretRef       `json:"secrets,omitempty"`
+
+	security     *SecurityProfile  // Chosen by the runtime from the server and space profiles
+	secretValues map[string]string // Values of the referenced secrets, by name
+}
+
+// reservedEnv lists variables the runtime sets for the agent; a spec may not override them.
+var reservedEnv = map[string]bool{
+	"SANDBOX_ID":                true,
+	"RUNTIME_OBSERVATION_URL":   true,
+	"RUNTIME_OBSERVATION_TOKEN": true,
+}
+
+// validate checks the spec and fills in defaults.
+func (s *SandboxSpec) validate() error {
+	if s.Image == "" {
+		s.Image = DefaultImage
+	}
+	for k := range s.Env {
+		if k == "" || strings.ContainsAny(k, "= ") {
+			return fmt.Errorf("%w: invalid environment variable name %q", ErrInvalidSpec, k)
+		}
+		if reservedEnv[k] {
+			return fmt.Errorf("%w: environment variable %s is reserved", ErrInvalidSpec, k)
+		}
+	}
+	if err := s.validateSecrets(); err != nil {
+		return err
+	}
+	if err := validatePorts(s.Ports); err != nil {
+		return err
+	}
+	if err := s.Resources.validate(); err != nil {
+		return err
+	}
+	if s.Network == nil {
+		s.Network = &NetworkPolicy{Mode: NetworkFull}
+	}
+	return s.Network.validate()
+}
+
+// pooled reports whether a warm pool container can serve the spec. Pooled
+// containers run the image with no extra configuration, no resource limits and
+// full network access.
+func (s *SandboxSpec) pooled() bool {
+	return len(s.Env) == 0 && len(s.Mounts) == 0 && len(s.Ports) == 0 && len(s.Secrets) == 0 && s.Resources == nil && s.Network.Mode == NetworkFull
+}
+
+// envList formats the spec's environment for the Docker API.
+func (s *SandboxSpec) envList() []string {
+	env := make([]string, 0, len(s.Env))
+	for k, v := range s.Env {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
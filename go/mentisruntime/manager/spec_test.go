@@ -0,0 +1,176 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SandboxSpecValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		spec    SandboxSpec
+		expMode NetworkMode
+		expErr  bool
+	}{
+		{
+			name:    "defaults",
+			spec:    SandboxSpec{},
+			expMode: NetworkFull,
+		},
+		{
+			name:    "none",
+			spec:    SandboxSpec{Image: "python:3.12", Network: &NetworkPolicy{Mode: NetworkNone}},
+			expMode: NetworkNone,
+		},
+		{
+			name:    "allowlist",
+			spec:    SandboxSpec{Network: &NetworkPolicy{Mode: NetworkAllowlist, Allow: []string{"10.0.0.0/8", "pypi.org"}}},
+			expMode: NetworkAllowlist,
+		},
+		{
+			name:   "allowlist without destinations",
+			spec:   SandboxSpec{Network: &NetworkPolicy{Mode: NetworkAllowlist}},
+			expErr: true,
+		},
+		{
+			name:   "destinations without allowlist",
+			spec:   SandboxSpec{Network: &NetworkPolicy{Mode: NetworkInternal, Allow: []string{"10.0.0.1"}}},
+			expErr: true,
+		},
+		{
+			name:   "unknown mode",
+			spec:   SandboxSpec{Network: &NetworkPolicy{Mode: "bridge"}},
+			expErr: true,
+		},
+		{
+			name:   "reserved env",
+			spec:   SandboxSpec{Env: map[string]string{"SANDBOX_ID": "x"}},
+			expErr: true,
+		},
+		{
+			name:   "invalid env name",
+			spec:   SandboxSpec{Env: map[string]string{"A=B": "x"}},
+			expErr: true,
+		},
+		{
+			name:    "ports",
+			spec:    SandboxSpec{Ports: []int{8501, 5000}},
+			expMode: NetworkFull,
+		},
+		{
+			name:   "agent port",
+			spec:   SandboxSpec{Ports: []int{8000}},
+			expErr: true,
+		},
+		{
+			name:   "port out of range",
+			spec:   SandboxSpec{Ports: []int{70000}},
+			expErr: true,
+		},
+		{
+			name:   "duplicate port",
+			spec:   SandboxSpec{Ports: []int{8501, 8501}},
+			expErr: true,
+		},
+		{
+			name:    "secrets",
+			spec:    SandboxSpec{Secrets: []SecretRef{{Name: "OPENAI_API_KEY"}, {Name: "db.password", Env: "DB_PASSWORD", File: "db"}}},
+			expMode: NetworkFull,
+		},
+		{
+			name:   "invalid secret name",
+			spec:   SandboxSpec{Secrets: []SecretRef{{Name: "../key"}}},
+			expErr: true,
+		},
+		{
+			name:   "secret env set twice",
+			spec:   SandboxSpec{Env: map[string]string{"TOKEN": "x"}, Secrets: []SecretRef{{Name: "TOKEN"}}},
+			expErr: true,
+		},
+		{
+			name:   "reserved secret env",
+			spec:   SandboxSpec{Secrets: []SecretRef{{Name: "key", Env: "SANDBOX_ID"}}},
+			expErr: true,
+		},
+		{
+			name:   "duplicate secret file",
+			spec:   SandboxSpec{Secrets: []SecretRef{{Name: "a", File: "key"}, {Name: "b", File: "key"}}},
+			expErr: true,
+		},
+		{
+			name:   "secret file outside the secrets directory",
+			spec:   SandboxSpec{Secrets: []SecretRef{{Name: "a", File: "../etc/passwd"}}},
+			expErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.spec.validate()
+			if c.expErr {
+				require.ErrorIs(t, err, ErrInvalidSpec)
+				return
+			}
+			require.NoError(t, err)
+			require.NotEmpty(t, c.spec.Image)
+			require.Equal(t, c.expMode, c.spec.Network.Mode)
+		})
+	}
+}
+
+func Test_SandboxSpecEnvList(t *testing.T) {
+	spec := SandboxSpec{Env: map[string]string{"B": "2", "A": "1"}}
+	require.Equal(t, []string{"A=1", "B=2"}, spec.envList())
+}
+
+func Test_resolveAllowlist(t *testing.T) {
+	cidrs, err := resolveAllowlist(context.Background(), []string{"10.1.2.3", "192.168.0.0/16", "::1"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.1.2.3/32", "192.168.0.0/16"}, cidrs)
+}
+
+func Test_firewallChain(t *testing.T) {
+	chain := firewallChain("0b8f2a4e-5c1d-4e7a-9f3b-2d6c8e1a7b90")
+	require.Equal(t, "SBX-0b8f2a4e5c1d4e7a9f3b2d6c", chain)
+	require.LessOrEqual(t, len(chain), 28)
+}
+
+func Test_iptablesFirewallReset(t *testing.T) {
+	// A fake iptables listing DOCKER-USER and logging the other commands.
+	dir := t.TempDir()
+	script := filepath.Join(dir, "iptables")
+	calls := filepath.Join(dir, "calls")
+	require.NoError(t, os.WriteFile(script, []byte(`#!/bin/sh
+shift # -w
+if [ "$1" = "-S" ]; then
+	echo '-N DOCKER-USER'
+	echo '-A DOCKER-USER -s 172.18.0.2/32 -m comment --comment "sandboxai:default" -j SBX-old'
+	echo '-A DOCKER-USER -s 172.18.0.3/32 -m comment --comment "sandboxai:other" -j SBX-theirs'
+	echo '-A DOCKER-USER -j RETURN'
+	exit 0
+fi
+echo "$@" >> `+calls+`
+`), 0o755))
+
+	f := newIPTablesFirewall("default")
+	f.path = script
+	f.jumps["sb1"] = "172.18.0.9"
+	require.NoError(t, f.Reset(context.Background()))
+	require.Empty(t, f.jumps)
+
+	logged, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"-D DOCKER-USER -s 172.18.0.2/32 -m comment --comment sandboxai:default -j SBX-old",
+		"-F SBX-old",
+		"-X SBX-old",
+	}, strings.Split(strings.TrimSpace(string(logged)), "\n"))
+
+	f.path = "sandboxai-missing-iptables"
+	require.NoError(t, f.Reset(context.Background()), "no iptables, nothing to remove")
+}
@@ -0,0 +1,252 @@
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+// Sandbox states reported in SandboxStatus.State.
+const (
+	StateProvisioning = "provisioning"
+	StateRunning      = "running"
+	StateFailed       = "failed"
+	StateExited       = "exited" // The container stopped, crashed or was OOM killed
+	StateLost         = "lost"   // The container disappeared from Docker
+)
+
+// SandboxPhase describes how far provisioning of a sandbox has progressed.
+type SandboxPhase string
+
+const (
+	PhasePending  SandboxPhase = "pending"
+	PhasePulling  SandboxPhase = "pulling"
+	PhaseStarting SandboxPhase = "starting"
+	PhaseReady    SandboxPhase = "ready"
+	PhaseFailed   SandboxPhase = "failed"
+)
+
+// Reasons reported when provisioning fails.
+const (
+	ReasonImagePullFailed    = "ImagePullFailed"
+	ReasonContainerFailed    = "ContainerFailed"
+	ReasonAgentUnreachable   = "AgentUnreachable"
+	ReasonProvisionCancelled = "Cancelled"
+	ReasonContainerNotFound  = "ContainerNotFound"
+	ReasonOOMKilled          = "OOMKilled"
+	ReasonNetworkFailed      = "NetworkFailed"
+)
+
+// SandboxStatus is the observed status of a sandbox.
+type SandboxStatus struct {
+	State        string        `json:"state"`
+	Phase        SandboxPhase  `json:"phase"`
+	Reason       string        `json:"reason,omitempty"`
+	Message      string        `json:"message,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	PullingAt    *time.Time    `json:"pulling_at,omitempty"`
+	StartingAt   *time.Time    `json:"starting_at,omitempty"`
+	ReadyAt      *time.Time    `json:"ready_at,omitempty"`
+	FailedAt     *time.Time    `json:"failed_at,omitempty"`
+	PullProgress *PullProgress `json:"pull_progress,omitempty"`
+
+	// Fields below are observed from Docker once the container exists.
+	ContainerState string     `json:"container_state,omitempty"` // Docker's state, e.g. "running" or "exited"
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+	ExitCode       *int       `json:"exit_code,omitempty"`
+	OOMKilled      bool       `json:"oom_killed,omitempty"`
+	RestartCount   int        `json:"restart_count"`
+	Health         string     `json:"health,omitempty"` // Docker healthcheck status, if the image defines one
+	AgentReachable *bool      `json:"agent_reachable,omitempty"`
+	CheckedAt      *time.Time `json:"checked_at,omitempty"` // When the fields above were last refreshed
+}
+
+// setPhase moves the status to phase and stamps the matching timestamp.
+func (s *SandboxStatus) setPhase(phase SandboxPhase, at time.Time) {
+	s.Phase = phase
+	switch phase {
+	case PhasePulling:
+		s.PullingAt = &at
+	case PhaseStarting:
+		s.StartingAt = &at
+	case PhaseReady:
+		s.State = StateRunning
+		s.ReadyAt = &at
+		s.PullProgress = nil
+	case PhaseFailed:
+		s.State = StateFailed
+		s.FailedAt = &at
+	}
+}
+
+// PullProgress summarises an in-progress image pull across all layers.
+type PullProgress struct {
+	Image        string `json:"image"`
+	Layers       int    `json:"layers"`
+	LayersDone   int    `json:"layers_done"`
+	CurrentBytes int64  `json:"current_bytes"`
+	TotalBytes   int64  `json:"total_bytes"`
+}
+
+// ProvisioningObservationData is published on the sandbox stream while it is provisioned.
+type ProvisioningObservationData struct {
+	Phase        SandboxPhase  `json:"phase"`
+	Message      string        `json:"message,omitempty"`
+	Reason       string        `json:"reason,omitempty"`
+	PullProgress *PullProgress `json:"pull_progress,omitempty"`
+}
+
+// provisionUpdate is reported by provisionContainer as it makes progress.
+type provisionUpdate struct {
+	Phase        SandboxPhase
+	Message      string
+	ContainerID  string
+	PullProgress *PullProgress
+}
+
+// provisionReporter receives provisioning progress. A nil reporter is allowed.
+type provisionReporter func(provisionUpdate)
+
+func (r provisionReporter) report(u provisionUpdate) {
+	if r != nil {
+		r(u)
+	}
+}
+
+// provisionError records why provisioning failed.
+type provisionError struct {
+	Reason string
+	Err    error
+}
+
+func (e *provisionError) Error() string { return e.Err.Error() }
+func (e *provisionError) Unwrap() error { return e.Err }
+
+// Is matches the package errors for the failure reasons that have one.
+func (e *provisionError) Is(target error) bool {
+	switch e.Reason {
+	case ReasonImagePullFailed:
+		return target == ErrImagePullFailed
+	case ReasonAgentUnreachable:
+		return target == ErrAgentUnreachable
+	}
+	return false
+}
+
+// provisionFailureReason extracts the failure reason from a provisioning error.
+func provisionFailureReason(err error) string {
+	var perr *provisionError
+	if errors.As(err, &perr) {
+		return perr.Reason
+	}
+	return ReasonContainerFailed
+}
+
+// pullProgressTracker aggregates per-layer progress from a Docker image pull stream.
+type pullProgressTracker struct {
+	image  string
+	layers map[string]*layerProgress
+}
+
+type layerProgress struct {
+	current, total int64
+	done           bool
+}
+
+// layerStatuses are the statuses of pull messages about a single layer.
+var layerStatuses = map[string]bool{
+	"Pulling fs layer":   true,
+	"Waiting":            true,
+	"Downloading":        true,
+	"Verifying Checksum": true,
+	"Download complete":  true,
+	"Extracting":         true,
+	"Pull complete":      true,
+	"Already exists":     true,
+}
+
+func newPullProgressTracker(image string) *pullProgressTracker {
+	return &pullProgressTracker{image: image, layers: make(map[string]*layerProgress)}
+}
+
+// update applies one message from the pull stream. It returns an error if the
+// message reports a pull failure.
+func (t *pullProgressTracker) update(msg jsonmessage.JSONMessage) error {
+	if msg.Error != nil {
+		return errors.New(msg.Error.Message)
+	}
+	if msg.ErrorMessage != "" {
+		return errors.New(msg.ErrorMessage)
+	}
+	if msg.ID == "" || !layerStatuses[msg.Status] {
+		// Messages without an ID describe the whole image (e.g. "Digest: ..."),
+		// and some with one too: "Pulling from library/python" has the tag as ID.
+		return nil
+	}
+	layer, ok := t.layers[msg.ID]
+	if !ok {
+		layer = &layerProgress{}
+		t.layers[msg.ID] = layer
+	}
+	switch msg.Status {
+	case "Pull complete", "Already exists":
+		layer.done = true
+		if layer.total > 0 {
+			layer.current = layer.total
+		}
+	case "Downloading":
+		if msg.Progress != nil {
+			layer.current = msg.Progress.Current
+			if msg.Progress.Total > 0 {
+				layer.total = msg.Progress.Total
+			}
+		}
+	}
+	return nil
+}
+
+// snapshot returns the aggregated progress so far.
+func (t *pullProgressTracker) snapshot() *PullProgress {
+	p := &PullProgress{Image: t.image, Layers: len(t.layers)}
+	for _, layer := range t.layers {
+		if layer.done {
+			p.LayersDone++
+		}
+		p.CurrentBytes += layer.current
+		p.TotalBytes += layer.total
+	}
+	return p
+}
+
+// consumePullStream reads a Docker image pull stream until EOF, reporting
+// aggregated progress at most once per interval.
+func consumePullStream(r io.Reader, imageName string, interval time.Duration, report func(*PullProgress)) error {
+	tracker := newPullProgressTracker(imageName)
+	dec := json.NewDecoder(r)
+	var lastReport time.Time
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("decoding pull output: %w", err)
+		}
+		if err := tracker.update(msg); err != nil {
+			return err
+		}
+		if report != nil && time.Since(lastReport) >= interval {
+			report(tracker.snapshot())
+			lastReport = time.Now()
+		}
+	}
+	if report != nil {
+		report(tracker.snapshot())
+	}
+	return nil
+}
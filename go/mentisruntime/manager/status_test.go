@@ -0,0 +1,97 @@
+package manager
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_consumePullStream(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"status":"Pulling from library/python","id":"3.12-slim"}`,
+		`{"status":"Pulling fs layer","id":"aaa"}`,
+		`{"status":"Already exists","id":"bbb"}`,
+		`{"status":"Downloading","progressDetail":{"current":50,"total":200},"id":"aaa"}`,
+		`{"status":"Downloading","progressDetail":{"current":200,"total":200},"id":"aaa"}`,
+		`{"status":"Pull complete","id":"aaa"}`,
+		`{"status":"Digest: sha256:abc"}`,
+	}, "\n")
+
+	var reports []*PullProgress
+	err := consumePullStream(strings.NewReader(stream), "python:3.12-slim", 0, func(p *PullProgress) {
+		reports = append(reports, p)
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, reports)
+
+	final := reports[len(reports)-1]
+	require.Equal(t, "python:3.12-slim", final.Image)
+	require.Equal(t, 2, final.Layers) // Not the "3.12-slim" tag line
+	require.Equal(t, 2, final.LayersDone)
+	require.Equal(t, int64(200), final.CurrentBytes)
+	require.Equal(t, int64(200), final.TotalBytes)
+}
+
+func Test_consumePullStreamError(t *testing.T) {
+	stream := `{"status":"Pulling fs layer","id":"aaa"}
+{"errorDetail":{"message":"manifest unknown"},"error":"manifest unknown"}`
+
+	err := consumePullStream(strings.NewReader(stream), "missing:tag", time.Second, nil)
+	require.EqualError(t, err, "manifest unknown")
+}
+
+func Test_SandboxStatusSetPhase(t *testing.T) {
+	now := time.Now()
+	s := SandboxStatus{State: StateProvisioning, Phase: PhasePending, CreatedAt: now}
+
+	s.setPhase(PhasePulling, now)
+	require.Equal(t, StateProvisioning, s.State)
+	require.NotNil(t, s.PullingAt)
+
+	s.PullProgress = &PullProgress{Layers: 1}
+	s.setPhase(PhaseReady, now)
+	require.Equal(t, StateRunning, s.State)
+	require.NotNil(t, s.ReadyAt)
+	require.Nil(t, s.PullProgress)
+
+	s.setPhase(PhaseFailed, now)
+	require.Equal(t, StateFailed, s.State)
+	require.NotNil(t, s.FailedAt)
+}
+
+func Test_applyContainerState(t *testing.T) {
+	inspect := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			RestartCount: 2,
+			State: &container.State{
+				Status:     "exited",
+				ExitCode:   137,
+				OOMKilled:  true,
+				StartedAt:  "2025-01-02T03:04:05.000000006Z",
+				FinishedAt: "2025-01-02T03:05:05Z",
+			},
+		},
+	}
+
+	var s SandboxStatus
+	applyContainerState(&s, inspect)
+	require.Equal(t, StateExited, s.State)
+	require.Equal(t, ReasonOOMKilled, s.Reason)
+	require.Equal(t, "exited", s.ContainerState)
+	require.Equal(t, 2, s.RestartCount)
+	require.NotNil(t, s.ExitCode)
+	require.Equal(t, 137, *s.ExitCode)
+	require.NotNil(t, s.StartedAt)
+	require.NotNil(t, s.FinishedAt)
+
+	inspect.State = &container.State{Status: "running", Running: true, StartedAt: "2025-01-02T03:04:05Z", FinishedAt: "0001-01-01T00:00:00Z"}
+	applyContainerState(&s, inspect)
+	require.Equal(t, StateRunning, s.State)
+	require.Empty(t, s.Reason)
+	require.Nil(t, s.ExitCode)
+	require.Nil(t, s.FinishedAt)
+}
@@ -0,0 +1,104 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+)
+
+// terminalShell starts bash where the image has it and sh otherwise.
+var terminalShell = []string{"/bin/sh", "-c", "if command -v bash >/dev/null; then exec bash -l; else exec sh -l; fi"}
+
+// Terminal is an interactive shell in a sandbox, attached to a pseudo-terminal.
+// Reads return the terminal's output and writes are its input.
+type Terminal struct {
+	m      *SandboxManager
+	execID string
+	conn   types.HijackedResponse
+	output io.Reader // Output with secret values masked
+}
+
+// OpenTerminal starts a login shell in a sandbox with a terminal of the given
+// size. The caller must Close it.
+func (m *SandboxManager) OpenTerminal(ctx context.Context, spaceID, sandboxID string, rows, cols uint) (*Terminal, error) {
+	containerID, err := m.sandboxContainer(spaceID, sandboxID)
+	if err != nil {
+		return nil, err
+	}
+	var size *[2]uint
+	if rows > 0 && cols > 0 {
+		size = &[2]uint{rows, cols}
+	}
+	exec, err := m.dockerClient.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+		Tty:          true,
+		ConsoleSize:  size,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Env:          []string{"TERM=xterm-256color"},
+		Cmd:          terminalShell,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create terminal: %w", err)
+	}
+	conn, err := m.dockerClient.ContainerExecAttach(ctx, exec.ID, container.ExecAttachOptions{Tty: true, ConsoleSize: size})
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach terminal: %w", err)
+	}
+	m.logger.Info("Terminal opened", "sandboxID", sandboxID, "execID", exec.ID)
+	// Output is passed on as soon as it arrives, so a secret value is only
+	// masked if it arrives in one piece, e.g. printed by a command rather
+	// than echoed as it is typed.
+	output := newRedactingReader(conn.Reader, m.sandboxRedactions(sandboxID), true)
+	return &Terminal{m: m, execID: exec.ID, conn: conn, output: output}, nil
+}
+
+// Read reads the terminal's output, masking secret values.
+func (t *Terminal) Read(p []byte) (int, error) {
+	return t.output.Read(p)
+}
+
+// Write writes to the terminal's input.
+func (t *Terminal) Write(p []byte) (int, error) {
+	return t.conn.Conn.Write(p)
+}
+
+// Resize changes the size of the terminal.
+func (t *Terminal) Resize(ctx context.Context, rows, cols uint) error {
+	return t.m.dockerClient.ContainerExecResize(ctx, t.execID, container.ResizeOptions{Height: rows, Width: cols})
+}
+
+// ExitCode waits for the shell to exit and returns its exit code. Docker may
+// report the shell running for a moment after its output ends.
+func (t *Terminal) ExitCode(ctx context.Context) (int, error) {
+	return t.m.execExitCode(ctx, t.execID)
+}
+
+// execExitCode waits for an exec to exit and returns its exit code.
+func (m *SandboxManager) execExitCode(ctx context.Context, execID string) (int, error) {
+	for {
+		inspect, err := m.dockerClient.ContainerExecInspect(ctx, execID)
+		if err != nil {
+			return 0, err
+		}
+		if !inspect.Running {
+			return inspect.ExitCode, nil
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// Close detaches from the terminal. A shell that is still running gets a
+// hangup when its terminal closes.
+func (t *Terminal) Close() error {
+	t.conn.Close()
+	return nil
+}
@@ -0,0 +1,55 @@
+package manager
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer records the manager's spans with the global tracer provider. Docker
+// API calls made with a traced context become child spans of them.
+var tracer = otel.Tracer("github.com/foreveryh/sandboxai/go/mentisruntime/manager")
+
+// Span attribute keys.
+const (
+	attrSandboxID       = attribute.Key("sandbox.id")
+	attrSpaceID         = attribute.Key("space.id")
+	attrImage           = attribute.Key("sandbox.image")
+	attrActionID        = attribute.Key("action.id")
+	attrActionType      = attribute.Key("action.type")
+	attrExitCode        = attribute.Key("action.exit_code")
+	attrObservationType = attribute.Key("observation.type")
+)
+
+// endSpan ends span, marking it failed if err is set.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// detachedContext returns a context carrying only the span of ctx, for work
+// that outlives the request it belongs to.
+func detachedContext(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+}
+
+// observationContext returns the context an observation of actionID is
+// processed in. Observations pushed over HTTP carry the agent's trace context;
+// those from the observation channel continue the action's span.
+func (m *SandboxManager) observationContext(ctx context.Context, actionID string) context.Context {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if rec, ok := m.actions[actionID]; ok && rec.span != nil {
+		return trace.ContextWithSpan(ctx, rec.span)
+	}
+	return ctx
+}
@@ -0,0 +1,92 @@
+package manager
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/audit"
+	"github.com/foreveryh/sandboxai/go/mentisruntime/ws"
+)
+
+var (
+	recordSpans   sync.Once
+	recordedSpans = tracetest.NewInMemoryExporter()
+)
+
+// spanRecorder returns the exporter of the global tracer provider, emptied. The
+// package's tracer is bound to the first global provider, so it is set once.
+func spanRecorder() *tracetest.InMemoryExporter {
+	recordSpans.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(recordedSpans)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	recordedSpans.Reset()
+	return recordedSpans
+}
+
+func Test_actionTracing(t *testing.T) {
+	recorder := spanRecorder()
+
+	traceparents := make(chan string, 1)
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents <- r.Header.Get("traceparent")
+	}))
+	defer agent.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	auditLog := audit.New(audit.NewMemorySink(10), nil, logger)
+	m := &SandboxManager{
+		sandboxes:  make(map[string]*SandboxState),
+		actions:    make(map[string]*actionRecord),
+		httpClient: agent.Client(),
+		logger:     logger,
+		hub:        ws.NewHub(logger),
+		audit:      auditLog,
+	}
+	m.sandboxes["sb1"] = &SandboxState{ID: "sb1", SpaceID: "s1", AgentURL: agent.URL, IsRunning: true}
+
+	actionID, err := m.InitiateAction(context.Background(), "sb1", "shell", map[string]interface{}{"command": "true"})
+	require.NoError(t, err)
+	var traceparent string
+	select {
+	case traceparent = <-traceparents:
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent was not called")
+	}
+	require.Eventually(t, func() bool { return len(recorder.GetSpans()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	request := recorder.GetSpans()[0]
+	require.Equal(t, "agent.request", request.Name)
+	require.Contains(t, traceparent, request.SpanContext.SpanID().String(), "the agent continues the request span")
+
+	// Observations from the channel carry no trace context and continue the action's span.
+	require.NoError(t, m.ReceiveInternalObservation(context.Background(), "sb1", []byte(`{"observation_type":"result","action_id":"`+actionID+`","exit_code":2}`)))
+	spans := recorder.GetSpans()
+	require.Len(t, spans, 3)
+	action, observation := spans[1], spans[2]
+	require.Equal(t, "sandbox.action", action.Name)
+	require.Equal(t, action.SpanContext.SpanID(), request.Parent.SpanID())
+	require.Contains(t, action.Attributes, attrExitCode.Int(2))
+	require.Equal(t, "sandbox.observation", observation.Name)
+	require.Equal(t, action.SpanContext.SpanID(), observation.Parent.SpanID())
+
+	// The end of the action is audited.
+	entries, err := auditLog.Query(audit.Query{SpaceID: "s1"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "actionFinished", entries[0].Operation)
+	require.Equal(t, actionID, entries[0].ActionID)
+	require.Equal(t, audit.OutcomeFailure, entries[0].Outcome)
+	require.Equal(t, 2, *entries[0].ExitCode)
+}
@@ -0,0 +1,180 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+)
+
+// labelVolume is set on space volumes to the name used in MountSpec.Source.
+const labelVolume = "sandboxai.volume"
+
+// MountType selects what a MountSpec mounts.
+type MountType string
+
+const (
+	// MountVolume mounts a named volume shared by the sandboxes of a space. It
+	// is created on first use and removed with the space.
+	MountVolume MountType = "volume"
+	// MountBind mounts a host directory read-only. The path must be inside one
+	// of the directories allowed with WithBindMountAllowlist.
+	MountBind MountType = "bind"
+)
+
+// MountSpec declares a mount in a SandboxSpec.
+type MountSpec struct {
+	Type     MountType `json:"type"`
+	Source   string    `json:"source"` // Volume name within the space, or host path for binds
+	Target   string    `json:"target"` // Absolute path in the sandbox
+	ReadOnly bool      `json:"read_only,omitempty"`
+}
+
+var volumeNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,62}$`)
+
+// WithBindMountAllowlist allows read-only bind mounts of host paths inside the
+// given directories. Bind mounts are rejected when the list is empty.
+func WithBindMountAllowlist(dirs ...string) Option {
+	return func(m *SandboxManager) {
+		for _, dir := range dirs {
+			if dir = strings.TrimSpace(dir); dir == "" {
+				continue
+			}
+			// Compare against real paths, as bind sources are resolved too.
+			if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+				dir = resolved
+			}
+			m.bindMountAllowlist = append(m.bindMountAllowlist, filepath.Clean(dir))
+		}
+	}
+}
+
+// validateMounts checks the mounts of a spec. Bind sources are resolved to
+// their real path so symlinks cannot escape the allowlist, and are always
+// mounted read-only.
+func (m *SandboxManager) validateMounts(spec *SandboxSpec) error {
+	targets := make(map[string]bool, len(spec.Mounts))
+	for i := range spec.Mounts {
+		mnt := &spec.Mounts[i]
+		if !path.IsAbs(mnt.Target) || path.Clean(mnt.Target) == "/" {
+			return fmt.Errorf("%w: mount target %q must be an absolute path other than /", ErrInvalidSpec, mnt.Target)
+		}
+		mnt.Target = path.Clean(mnt.Target)
+		if targets[mnt.Target] {
+			return fmt.Errorf("%w: duplicate mount target %s", ErrInvalidSpec, mnt.Target)
+		}
+		targets[mnt.Target] = true
+
+		switch mnt.Type {
+		case MountVolume:
+			if !volumeNamePattern.MatchString(mnt.Source) {
+				return fmt.Errorf("%w: invalid volume name %q", ErrInvalidSpec, mnt.Source)
+			}
+		case MountBind:
+			if !filepath.IsAbs(mnt.Source) {
+				return fmt.Errorf("%w: bind mount source %q must be an absolute path", ErrInvalidSpec, mnt.Source)
+			}
+			resolved, err := filepath.EvalSymlinks(mnt.Source)
+			if err != nil {
+				return fmt.Errorf("%w: bind mount source %s: %v", ErrInvalidSpec, mnt.Source, err)
+			}
+			if !m.bindMountAllowed(resolved) {
+				return fmt.Errorf("%w: bind mount source %s is not in an allowed directory", ErrInvalidSpec, mnt.Source)
+			}
+			mnt.Source = resolved
+			mnt.ReadOnly = true
+		default:
+			return fmt.Errorf("%w: unknown mount type %q", ErrInvalidSpec, mnt.Type)
+		}
+	}
+	return nil
+}
+
+// bindMountAllowed reports whether hostPath is inside an allowed directory.
+func (m *SandboxManager) bindMountAllowed(hostPath string) bool {
+	for _, dir := range m.bindMountAllowlist {
+		if hostPath == dir || strings.HasPrefix(hostPath, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// spaceVolumeName returns the Docker volume backing a space volume.
+func (m *SandboxManager) spaceVolumeName(spaceID, name string) string {
+	return fmt.Sprintf("sandboxai-%s-%s-%s", m.scope, spaceID, name)
+}
+
+// prepareMounts creates missing space volumes and returns the Docker mounts for
+// the spec.
+func (m *SandboxManager) prepareMounts(ctx context.Context, spaceID string, mounts []MountSpec) ([]mount.Mount, error) {
+	var result []mount.Mount
+	for _, mnt := range mounts {
+		switch mnt.Type {
+		case MountVolume:
+			name := m.spaceVolumeName(spaceID, mnt.Source)
+			if err := m.ensureVolume(ctx, name, spaceID, mnt.Source); err != nil {
+				return nil, err
+			}
+			result = append(result, mount.Mount{Type: mount.TypeVolume, Source: name, Target: mnt.Target, ReadOnly: mnt.ReadOnly})
+		case MountBind:
+			result = append(result, mount.Mount{Type: mount.TypeBind, Source: mnt.Source, Target: mnt.Target, ReadOnly: true})
+		}
+	}
+	return result, nil
+}
+
+// ensureVolume creates a space volume unless it already exists.
+func (m *SandboxManager) ensureVolume(ctx context.Context, name, spaceID, volumeName string) error {
+	if _, err := m.dockerClient.VolumeInspect(ctx, name); err == nil {
+		return nil
+	} else if !client.IsErrNotFound(err) {
+		return fmt.Errorf("failed to inspect volume %s: %w", name, err)
+	}
+	_, err := m.dockerClient.VolumeCreate(ctx, volume.CreateOptions{
+		Name: name,
+		Labels: map[string]string{
+			labelScope:  m.scope,
+			labelSpace:  spaceID,
+			labelVolume: volumeName,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create volume %s: %w", name, err)
+	}
+	m.logger.Info("Space volume created", "spaceID", spaceID, "volume", volumeName, "dockerVolume", name)
+	return nil
+}
+
+// removeSpaceVolumes removes every volume of a space. Its sandboxes must be
+// deleted first, since Docker refuses to remove volumes that are in use.
+func (m *SandboxManager) removeSpaceVolumes(ctx context.Context, spaceID string) error {
+	list, err := m.dockerClient.VolumeList(ctx, volume.ListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("label", labelScope+"="+m.scope),
+			filters.Arg("label", labelSpace+"="+spaceID),
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list volumes of space %s: %w", spaceID, err)
+	}
+	var firstErr error
+	for _, vol := range list.Volumes {
+		if err := m.dockerClient.VolumeRemove(ctx, vol.Name, false); err != nil && !client.IsErrNotFound(err) {
+			m.logger.Error("Failed to remove space volume", "spaceID", spaceID, "volume", vol.Name, "error", err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to remove volume %s: %w", vol.Name, err)
+			}
+			continue
+		}
+		m.logger.Info("Space volume removed", "spaceID", spaceID, "volume", vol.Name)
+	}
+	return firstErr
+}
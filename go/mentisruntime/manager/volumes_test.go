@@ -0,0 +1,87 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_validateMounts(t *testing.T) {
+	allowed := t.TempDir()
+	data := filepath.Join(allowed, "data")
+	require.NoError(t, os.Mkdir(data, 0o755))
+	outside := t.TempDir()
+	escape := filepath.Join(allowed, "escape")
+	require.NoError(t, os.Symlink(outside, escape))
+
+	m := &SandboxManager{scope: "test"}
+	WithBindMountAllowlist(allowed)(m)
+
+	cases := []struct {
+		name   string
+		mounts []MountSpec
+		expErr bool
+	}{
+		{
+			name:   "volume",
+			mounts: []MountSpec{{Type: MountVolume, Source: "workspace", Target: "/work/shared/"}},
+		},
+		{
+			name:   "allowed bind",
+			mounts: []MountSpec{{Type: MountBind, Source: data, Target: "/data"}},
+		},
+		{
+			name:   "bind outside allowlist",
+			mounts: []MountSpec{{Type: MountBind, Source: outside, Target: "/data"}},
+			expErr: true,
+		},
+		{
+			name:   "bind escaping through symlink",
+			mounts: []MountSpec{{Type: MountBind, Source: escape, Target: "/data"}},
+			expErr: true,
+		},
+		{
+			name:   "relative target",
+			mounts: []MountSpec{{Type: MountVolume, Source: "workspace", Target: "work"}},
+			expErr: true,
+		},
+		{
+			name: "duplicate target",
+			mounts: []MountSpec{
+				{Type: MountVolume, Source: "a", Target: "/data"},
+				{Type: MountVolume, Source: "b", Target: "/data/"},
+			},
+			expErr: true,
+		},
+		{
+			name:   "invalid volume name",
+			mounts: []MountSpec{{Type: MountVolume, Source: "../etc", Target: "/data"}},
+			expErr: true,
+		},
+		{
+			name:   "unknown type",
+			mounts: []MountSpec{{Type: "tmpfs", Source: "x", Target: "/data"}},
+			expErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			spec := SandboxSpec{Mounts: c.mounts}
+			err := m.validateMounts(&spec)
+			if c.expErr {
+				require.ErrorIs(t, err, ErrInvalidSpec)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	spec := SandboxSpec{Mounts: []MountSpec{{Type: MountBind, Source: data, Target: "/data"}, {Type: MountVolume, Source: "ws", Target: "/ws/"}}}
+	require.NoError(t, m.validateMounts(&spec))
+	require.True(t, spec.Mounts[0].ReadOnly, "bind mounts are always read-only")
+	require.Equal(t, "/ws", spec.Mounts[1].Target)
+	require.Equal(t, "sandboxai-test-space1-ws", m.spaceVolumeName("space1", "ws"))
+}
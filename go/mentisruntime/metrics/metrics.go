@@ -0,0 +1,221 @@
+// Package metrics defines the Prometheus metrics of the runtime. A nil
+// *Metrics records nothing, so components may be used without metrics.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "sandboxai"
+
+// Phases of sandbox creation, the values of the phase label.
+const (
+	PhasePull          = "pull"
+	PhaseCreate        = "create"
+	PhaseStart         = "start"
+	PhasePortDiscovery = "port_discovery"
+	PhaseHealthCheck   = "health_check"
+)
+
+// Metrics holds the runtime's collectors and the registry they are exposed
+// from.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	createPhaseDuration *prometheus.HistogramVec
+	createDuration      *prometheus.HistogramVec
+	sandboxes           *prometheus.GaugeVec
+	actions             *prometheus.CounterVec
+	actionDuration      *prometheus.HistogramVec
+	observations        *prometheus.CounterVec
+	broadcastDrops      *prometheus.CounterVec
+	wsClients           prometheus.Gauge
+	httpDuration        *prometheus.HistogramVec
+}
+
+// New creates the metrics on a registry of their own, together with the Go
+// runtime and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		createPhaseDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "sandbox_create_phase_duration_seconds",
+			Help:      "Duration of the phases of sandbox creation that completed.",
+			Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+		}, []string{"phase"}),
+		createDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "sandbox_create_duration_seconds",
+			Help:      "Duration of sandbox creation from request to ready or failure.",
+			Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+		}, []string{"result"}),
+		sandboxes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "sandboxes_active",
+			Help:      "Sandboxes that exist, by space.",
+		}, []string{"space"}),
+		actions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "actions_total",
+			Help:      "Actions that finished, by type and exit status: ok, failed (non-zero exit code) or error (did not run to completion).",
+		}, []string{"type", "status"}),
+		actionDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "action_duration_seconds",
+			Help:      "Duration of finished actions.",
+			Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300, 900},
+		}, []string{"type"}),
+		observations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "observations_received_total",
+			Help:      "Observations received from sandbox agents, by observation type.",
+		}, []string{"type"}),
+		broadcastDrops: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "hub_broadcast_drops_total",
+			Help:      "Messages the WebSocket hub dropped because a buffer was full: hub for its own queue, client for a subscriber's.",
+		}, []string{"buffer"}),
+		wsClients: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ws_clients_connected",
+			Help:      "WebSocket clients subscribed to observations.",
+		}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests by route template, method and status code. Streaming routes last as long as their streams.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "code"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.createPhaseDuration, m.createDuration, m.sandboxes, m.actions, m.actionDuration,
+		m.observations, m.broadcastDrops, m.wsClients, m.httpDuration,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveCreatePhase records a completed phase of sandbox creation that
+// started at start.
+func (m *Metrics) ObserveCreatePhase(phase string, start time.Time) {
+	if m == nil {
+		return
+	}
+	m.createPhaseDuration.WithLabelValues(phase).Observe(time.Since(start).Seconds())
+}
+
+// ObserveCreate records a sandbox creation that started at start.
+func (m *Metrics) ObserveCreate(start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.createDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+}
+
+// SandboxAdded counts a new sandbox of a space.
+func (m *Metrics) SandboxAdded(spaceID string) {
+	if m == nil {
+		return
+	}
+	m.sandboxes.WithLabelValues(spaceID).Inc()
+}
+
+// SandboxRemoved counts a deleted sandbox of a space.
+func (m *Metrics) SandboxRemoved(spaceID string) {
+	if m == nil {
+		return
+	}
+	m.sandboxes.WithLabelValues(spaceID).Dec()
+}
+
+// SpaceDeleted drops the series of a deleted space.
+func (m *Metrics) SpaceDeleted(spaceID string) {
+	if m == nil {
+		return
+	}
+	m.sandboxes.DeleteLabelValues(spaceID)
+}
+
+// ObserveAction records a finished action. A negative exit code means the
+// action did not run to completion.
+func (m *Metrics) ObserveAction(actionType string, exitCode int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	status := "ok"
+	switch {
+	case exitCode < 0:
+		status = "error"
+	case exitCode > 0:
+		status = "failed"
+	}
+	m.actions.WithLabelValues(actionType, status).Inc()
+	m.actionDuration.WithLabelValues(actionType).Observe(duration.Seconds())
+}
+
+// ObservationReceived counts an observation pushed by an agent.
+func (m *Metrics) ObservationReceived(observationType string) {
+	if m == nil {
+		return
+	}
+	m.observations.WithLabelValues(observationType).Inc()
+}
+
+// BroadcastDropped counts a message the hub dropped because buffer, "hub" or
+// "client", was full.
+func (m *Metrics) BroadcastDropped(buffer string) {
+	if m == nil {
+		return
+	}
+	m.broadcastDrops.WithLabelValues(buffer).Inc()
+}
+
+// SetWSClients sets the number of connected WebSocket clients.
+func (m *Metrics) SetWSClients(n int) {
+	if m == nil {
+		return
+	}
+	m.wsClients.Set(float64(n))
+}
+
+type routeKey struct{}
+
+// Middleware records the latency of requests by their mux route template. It
+// must run after routing, i.e. with mux.Router.Use.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+	routeOf := func(ctx context.Context) string {
+		route, _ := ctx.Value(routeKey{}).(string)
+		return route
+	}
+	instrumented := promhttp.InstrumentHandlerDuration(m.httpDuration, next, promhttp.WithLabelFromCtx("route", routeOf))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if tmpl, err := current.GetPathTemplate(); err == nil {
+				route = tmpl
+			}
+		}
+		instrumented.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeKey{}, route)))
+	})
+}
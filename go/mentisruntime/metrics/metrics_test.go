@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Metrics(t *testing.T) {
+	m := New()
+	m.ObserveCreatePhase(PhasePull, time.Now())
+	m.ObserveCreate(time.Now(), nil)
+	m.ObserveCreate(time.Now(), errors.New("boom"))
+	m.SandboxAdded("s1")
+	m.SandboxAdded("s1")
+	m.SandboxRemoved("s1")
+	m.SandboxAdded("s2")
+	m.SpaceDeleted("s2")
+	m.ObserveAction("shell", 0, time.Second)
+	m.ObserveAction("shell", 2, time.Second)
+	m.ObserveAction("ipython", -1, time.Second)
+	m.ObservationReceived("stream")
+	m.BroadcastDropped("hub")
+	m.SetWSClients(3)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(m.sandboxes.WithLabelValues("s1")))
+	require.Equal(t, 1, testutil.CollectAndCount(m.sandboxes), "deleted spaces have no series")
+	require.Equal(t, 1.0, testutil.ToFloat64(m.actions.WithLabelValues("shell", "ok")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.actions.WithLabelValues("shell", "failed")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.actions.WithLabelValues("ipython", "error")))
+	require.Equal(t, 2, testutil.CollectAndCount(m.createDuration))
+	require.Equal(t, 3.0, testutil.ToFloat64(m.wsClients))
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	for _, name := range []string{"sandboxai_sandbox_create_phase_duration_seconds", "sandboxai_observations_received_total", "sandboxai_hub_broadcast_drops_total", "go_goroutines"} {
+		require.Contains(t, rec.Body.String(), name)
+	}
+}
+
+func Test_Middleware(t *testing.T) {
+	m := New()
+	router := mux.NewRouter()
+	router.Use(m.Middleware)
+	router.HandleFunc("/v1/spaces/{space_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/spaces/a", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/spaces/b", nil))
+
+	count, err := testutil.GatherAndCount(m.registry, "sandboxai_http_request_duration_seconds")
+	require.NoError(t, err)
+	require.Equal(t, 1, count, "requests are grouped by route template")
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Contains(t, rec.Body.String(), `sandboxai_http_request_duration_seconds_count{code="404",method="get",route="/v1/spaces/{space_id}"} 2`)
+}
+
+func Test_NilMetrics(t *testing.T) {
+	var m *Metrics
+	m.ObserveAction("shell", 0, time.Second)
+	m.SandboxAdded("s1")
+	m.BroadcastDropped("client")
+	h := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	require.NotNil(t, m.Middleware(h))
+}
@@ -0,0 +1,123 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// Terminal size declared in asciicast headers. Shell output is not tied to a
+// terminal, so this only sets the player's initial size.
+const (
+	asciicastWidth  = 120
+	asciicastHeight = 40
+)
+
+// observation holds the fields of an observation the recording formats use.
+type observation struct {
+	ObservationType string `json:"observation_type"`
+	ActionID        string `json:"action_id"`
+	Stream          string `json:"stream"`
+	Line            string `json:"line"`
+	Data            struct {
+		ActionType string `json:"action_type"`
+	} `json:"data"`
+}
+
+type asciicastHeader struct {
+	Version   int    `json:"version"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+	Title     string `json:"title,omitempty"`
+}
+
+// AsciicastWriter renders the shell output of a recording as an asciicast v2
+// file: a header line, then an output event per line of stdout or stderr,
+// timed from the first one.
+type AsciicastWriter struct {
+	w       *bufio.Writer
+	title   string
+	shell   map[string]bool // Actions that are shell commands
+	start   time.Time       // Time of the first event, zero before it
+	written bool            // Whether the header was written
+}
+
+// NewAsciicastWriter returns a writer of an asciicast titled title to w. Call
+// Write for each entry of the recording, then Close.
+func NewAsciicastWriter(w io.Writer, title string) *AsciicastWriter {
+	return &AsciicastWriter{w: bufio.NewWriter(w), title: title, shell: make(map[string]bool)}
+}
+
+// Write adds an entry of the recording. Only the output of shell commands
+// becomes events.
+func (a *AsciicastWriter) Write(entry Entry) error {
+	var obs observation
+	if json.Unmarshal(entry.Observation, &obs) != nil {
+		return nil
+	}
+	switch obs.ObservationType {
+	case "start":
+		if obs.Data.ActionType == "shell" {
+			a.shell[obs.ActionID] = true
+		}
+		return nil
+	case "end":
+		delete(a.shell, obs.ActionID)
+		return nil
+	case "stream":
+	default:
+		return nil
+	}
+	if !a.shell[obs.ActionID] || obs.Line == "" {
+		return nil
+	}
+
+	if a.start.IsZero() {
+		a.start = entry.Time
+	}
+	if err := a.header(); err != nil {
+		return err
+	}
+	// The agent sends shell output line by line with its newlines. A last line
+	// without one, or a line from an older agent, is ended so the next output
+	// starts on a line of its own.
+	text := obs.Line
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	text = strings.ReplaceAll(text, "\n", "\r\n")
+	event, err := json.Marshal([]any{entry.Time.Sub(a.start).Seconds(), "o", text})
+	if err != nil {
+		return err
+	}
+	_, err = a.w.Write(append(event, '\n'))
+	return err
+}
+
+// Close writes the header if no event did and flushes the output.
+func (a *AsciicastWriter) Close() error {
+	if err := a.header(); err != nil {
+		return err
+	}
+	return a.w.Flush()
+}
+
+func (a *AsciicastWriter) header() error {
+	if a.written {
+		return nil
+	}
+	a.written = true
+	h := asciicastHeader{Version: 2, Width: asciicastWidth, Height: asciicastHeight, Title: a.title}
+	if !a.start.IsZero() {
+		h.Timestamp = a.start.Unix()
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	_, err = a.w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,195 @@
+// Package recording records the observations of sandboxes so their sessions
+// can be reviewed and replayed after the fact. Each sandbox's observations are
+// appended as JSON lines to a file of its space's directory, and the file is
+// kept after the sandbox is deleted.
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when a sandbox has no recording.
+var ErrNotFound = errors.New("recording not found")
+
+// Entry is an observation as it was published, with the time it was.
+type Entry struct {
+	Time        time.Time       `json:"time"`
+	Observation json.RawMessage `json:"observation"`
+}
+
+// Recorder appends the observations of sandboxes to files under a directory.
+// A nil Recorder records nothing and has no recordings.
+type Recorder struct {
+	dir   string
+	mu    sync.Mutex
+	files map[string]*os.File // Open recordings by path
+}
+
+// NewRecorder creates a recorder keeping its files under dir.
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	return &Recorder{dir: dir, files: make(map[string]*os.File)}, nil
+}
+
+// Record appends an observation published on a sandbox's stream. An
+// observation that is not JSON is recorded as a string.
+func (r *Recorder) Record(spaceID, sandboxID string, observation []byte) error {
+	if r == nil {
+		return nil
+	}
+	path, err := r.path(spaceID, sandboxID)
+	if err != nil {
+		return err
+	}
+	if !json.Valid(observation) {
+		observation, _ = json.Marshal(string(observation))
+	}
+	line, err := json.Marshal(Entry{Time: time.Now().UTC(), Observation: observation})
+	if err != nil {
+		return fmt.Errorf("failed to encode observation: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.files[path]
+	if !ok {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return fmt.Errorf("failed to create recording: %w", err)
+		}
+		f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to create recording: %w", err)
+		}
+		r.files[path] = f
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	return nil
+}
+
+// Finish closes the recording of a deleted sandbox. The recording is kept.
+func (r *Recorder) Finish(spaceID, sandboxID string) {
+	if r == nil {
+		return
+	}
+	path, err := r.path(spaceID, sandboxID)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.files[path]; ok {
+		f.Close()
+		delete(r.files, path)
+	}
+}
+
+// Each calls fn with the entries of a sandbox's recording in order, stopping at
+// the first error fn returns. A line cut short by a crash is skipped.
+func (r *Recorder) Each(spaceID, sandboxID string, fn func(Entry) error) error {
+	if r == nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, sandboxID)
+	}
+	path, err := r.path(spaceID, sandboxID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, sandboxID)
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrNotFound, sandboxID)
+	} else if err != nil {
+		return fmt.Errorf("failed to read recording: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && err == nil {
+			var entry Entry
+			if json.Unmarshal(line, &entry) == nil {
+				if err := fn(entry); err != nil {
+					return err
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read recording: %w", err)
+		}
+	}
+}
+
+// DeleteSpace removes the recordings of a deleted space.
+func (r *Recorder) DeleteSpace(spaceID string) error {
+	if r == nil {
+		return nil
+	}
+	dir, err := r.spaceDir(spaceID)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for path, f := range r.files {
+		if filepath.Dir(path) == dir {
+			f.Close()
+			delete(r.files, path)
+		}
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to delete recordings: %w", err)
+	}
+	return nil
+}
+
+// Close closes the open recordings.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for path, f := range r.files {
+		errs = append(errs, f.Close())
+		delete(r.files, path)
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Recorder) path(spaceID, sandboxID string) (string, error) {
+	dir, err := r.spaceDir(spaceID)
+	if err != nil {
+		return "", err
+	}
+	if !validID(sandboxID) {
+		return "", fmt.Errorf("invalid sandbox ID %q", sandboxID)
+	}
+	return filepath.Join(dir, sandboxID+".jsonl"), nil
+}
+
+func (r *Recorder) spaceDir(spaceID string) (string, error) {
+	if !validID(spaceID) {
+		return "", fmt.Errorf("invalid space ID %q", spaceID)
+	}
+	return filepath.Join(r.dir, spaceID), nil
+}
+
+// validID reports whether id can name a file without leaving its directory.
+func validID(id string) bool {
+	return id != "" && id != "." && id != ".." && !strings.ContainsAny(id, `/\`)
+}
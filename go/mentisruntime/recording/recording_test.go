@@ -0,0 +1,101 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func entries(t *testing.T, r *Recorder, spaceID, sandboxID string) []Entry {
+	var out []Entry
+	require.NoError(t, r.Each(spaceID, sandboxID, func(e Entry) error {
+		out = append(out, e)
+		return nil
+	}))
+	return out
+}
+
+func Test_Recorder(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewRecorder(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, r.Record("s1", "sb1", []byte(`{"observation_type":"start","action_id":"a1"}`)))
+	require.NoError(t, r.Record("s1", "sb1", []byte(`not json`)))
+	require.NoError(t, r.Record("s2", "sb2", []byte(`{"observation_type":"end","action_id":"a2"}`)))
+	require.Error(t, r.Record("s1", "../sb", []byte(`{}`)))
+
+	recorded := entries(t, r, "s1", "sb1")
+	require.Len(t, recorded, 2)
+	require.JSONEq(t, `{"observation_type":"start","action_id":"a1"}`, string(recorded[0].Observation))
+	require.JSONEq(t, `"not json"`, string(recorded[1].Observation))
+	require.False(t, recorded[0].Time.IsZero())
+	require.ErrorIs(t, r.Each("s2", "sb1", func(Entry) error { return nil }), ErrNotFound, "recordings are per space")
+
+	// Recordings outlive their sandbox, and a line cut short by a crash is skipped.
+	r.Finish("s1", "sb1")
+	f, err := os.OpenFile(filepath.Join(dir, "s1", "sb1.jsonl"), os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"time":`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.Len(t, entries(t, r, "s1", "sb1"), 2)
+
+	require.NoError(t, r.DeleteSpace("s2"))
+	require.ErrorIs(t, r.Each("s2", "sb2", func(Entry) error { return nil }), ErrNotFound)
+	require.NoError(t, r.Close())
+
+	var nilRecorder *Recorder
+	require.NoError(t, nilRecorder.Record("s1", "sb1", []byte(`{}`)))
+	require.ErrorIs(t, nilRecorder.Each("s1", "sb1", func(Entry) error { return nil }), ErrNotFound)
+}
+
+func Test_AsciicastWriter(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	recorded := []Entry{
+		{Time: start, Observation: json.RawMessage(`{"observation_type":"start","action_id":"sh","data":{"action_type":"shell"}}`)},
+		{Time: start.Add(time.Second), Observation: json.RawMessage(`{"observation_type":"start","action_id":"py","data":{"action_type":"ipython"}}`)},
+		{Time: start.Add(2 * time.Second), Observation: json.RawMessage(`{"observation_type":"stream","action_id":"sh","stream":"stdout","line":"hello"}`)},
+		{Time: start.Add(3 * time.Second), Observation: json.RawMessage(`{"observation_type":"stream","action_id":"py","stream":"stdout","line":"ignored\n"}`)},
+		{Time: start.Add(3500 * time.Millisecond), Observation: json.RawMessage(`{"observation_type":"stream","action_id":"sh","stream":"stderr","line":"oops"}`)},
+	}
+	var buf bytes.Buffer
+	a := NewAsciicastWriter(&buf, "sandbox sb1")
+	for _, e := range recorded {
+		require.NoError(t, a.Write(e))
+	}
+	require.NoError(t, a.Close())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	require.JSONEq(t, `{"version":2,"width":120,"height":40,"timestamp":1767225602,"title":"sandbox sb1"}`, lines[0])
+	require.JSONEq(t, `[0, "o", "hello\r\n"]`, lines[1])
+	require.JSONEq(t, `[1.5, "o", "oops\r\n"]`, lines[2])
+
+	buf.Reset()
+	require.NoError(t, NewAsciicastWriter(&buf, "").Close())
+	require.JSONEq(t, `{"version":2,"width":120,"height":40}`, buf.String(), "a recording without shell output has a header only")
+}
+
+func Test_Replayer(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := Replayer{Speed: 4, MaxIdle: time.Minute}
+	require.Zero(t, r.delay(start))
+	r.prev = start
+	require.Equal(t, 500*time.Millisecond, r.delay(start.Add(2*time.Second)))
+	require.Equal(t, time.Minute, r.delay(start.Add(time.Hour)), "idle time is capped")
+	require.Zero(t, r.delay(start.Add(-time.Second)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, (&Replayer{Speed: 1}).Next(context.Background(), Entry{Time: start}))
+	r = Replayer{Speed: 1, prev: start}
+	require.ErrorIs(t, r.Next(ctx, Entry{Time: start.Add(time.Hour)}), context.Canceled)
+}
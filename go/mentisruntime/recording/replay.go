@@ -0,0 +1,47 @@
+package recording
+
+import (
+	"context"
+	"time"
+)
+
+// Replayer paces the entries of a recording as they were published: Next
+// waits out the gap since the previous entry, divided by Speed and capped at
+// MaxIdle when it is set.
+type Replayer struct {
+	Speed   float64       // 1 replays at the original speed, 2 twice as fast
+	MaxIdle time.Duration // Longest wait between entries, 0 for no limit
+
+	prev time.Time
+}
+
+// Next waits until entry is due, or until ctx is done.
+func (r *Replayer) Next(ctx context.Context, entry Entry) error {
+	wait := r.delay(entry.Time)
+	r.prev = entry.Time
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (r *Replayer) delay(t time.Time) time.Duration {
+	if r.prev.IsZero() || !t.After(r.prev) {
+		return 0
+	}
+	wait := t.Sub(r.prev)
+	if r.Speed > 0 {
+		wait = time.Duration(float64(wait) / r.Speed)
+	}
+	if r.MaxIdle > 0 && wait > r.MaxIdle {
+		wait = r.MaxIdle
+	}
+	return wait
+}
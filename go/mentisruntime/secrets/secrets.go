@@ -0,0 +1,289 @@
+// Package secrets stores the secrets of spaces for injection into sandboxes.
+// Values are encrypted at rest with a server key and are only read back to
+// provision a sandbox; nothing the store lists includes them.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound     = errors.New("secret not found")
+	ErrInvalidName  = errors.New("invalid secret name")
+	ErrInvalidValue = errors.New("invalid secret value")
+	ErrInvalidKey   = errors.New("invalid secrets key")
+)
+
+// KeySize is the size of the server key in bytes, for AES-256.
+const KeySize = 32
+
+// MaxValueSize bounds the size of a secret value.
+const MaxValueSize = 64 * 1024
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+
+// ValidateName checks that name can name a secret: up to 128 letters, digits,
+// underscores, dots and dashes, not starting with a dot or dash.
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return nil
+}
+
+// ParseKey decodes a base64-encoded server key.
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not base64: %v", ErrInvalidKey, err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("%w: must be %d bytes, is %d", ErrInvalidKey, KeySize, len(key))
+	}
+	return key, nil
+}
+
+// Secret describes a stored secret. It never carries the value.
+type Secret struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// storedSecret is the on-disk form of a secret.
+type storedSecret struct {
+	Secret
+	SpaceID string `json:"space_id"`
+	Nonce   []byte `json:"nonce"`
+	Value   []byte `json:"value"` // AES-GCM ciphertext, bound to the space and name
+}
+
+// Store holds the secrets of all spaces. Secrets are persisted to a JSON file
+// when the store has a path.
+type Store struct {
+	mu      sync.RWMutex
+	path    string
+	aead    cipher.AEAD
+	secrets map[string]map[string]*storedSecret // Map space ID to secret name to secret
+}
+
+// NewStore creates a store encrypting with key, loading secrets from path if it
+// exists. A nil key generates one, which only suits a store without a path: its
+// secrets could not be read after a restart.
+func NewStore(path string, key []byte) (*Store, error) {
+	if key == nil {
+		if path != "" {
+			return nil, fmt.Errorf("%w: a key is required to persist secrets", ErrInvalidKey)
+		}
+		key = make([]byte, KeySize)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate secrets key: %w", err)
+		}
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	s := &Store{path: path, aead: aead, secrets: make(map[string]map[string]*storedSecret)}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets: %w", err)
+	}
+	var stored []*storedSecret
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets %s: %w", path, err)
+	}
+	for _, secret := range stored {
+		// Fail at startup rather than when a sandbox needs the secret.
+		if _, err := s.open(secret); err != nil {
+			return nil, fmt.Errorf("%w: cannot decrypt secret %s of space %s", ErrInvalidKey, secret.Name, secret.SpaceID)
+		}
+		s.spaceLocked(secret.SpaceID)[secret.Name] = secret
+	}
+	return s, nil
+}
+
+// Set creates or replaces a secret. It reports whether the secret is new.
+func (s *Store) Set(spaceID, name, value string) (Secret, bool, error) {
+	if err := ValidateName(name); err != nil {
+		return Secret{}, false, err
+	}
+	if value == "" || len(value) > MaxValueSize {
+		return Secret{}, false, fmt.Errorf("%w: must be 1 to %d bytes", ErrInvalidValue, MaxValueSize)
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return Secret{}, false, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	space := s.spaceLocked(spaceID)
+	previous, exists := space[name]
+	secret := &storedSecret{
+		Secret:  Secret{Name: name, CreatedAt: now, UpdatedAt: now},
+		SpaceID: spaceID,
+		Nonce:   nonce,
+		Value:   s.aead.Seal(nil, nonce, []byte(value), additionalData(spaceID, name)),
+	}
+	if exists {
+		secret.CreatedAt = previous.CreatedAt
+	}
+	space[name] = secret
+	if err := s.saveLocked(); err != nil {
+		if exists {
+			space[name] = previous
+		} else {
+			delete(space, name)
+		}
+		return Secret{}, false, err
+	}
+	return secret.Secret, !exists, nil
+}
+
+// List returns the secrets of a space, sorted by name.
+func (s *Store) List(spaceID string) []Secret {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Secret, 0, len(s.secrets[spaceID]))
+	for _, secret := range s.secrets[spaceID] {
+		out = append(out, secret.Secret)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Delete removes a secret. Sandboxes it was injected into keep it.
+func (s *Store) Delete(spaceID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secret, ok := s.secrets[spaceID][name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	delete(s.secrets[spaceID], name)
+	if err := s.saveLocked(); err != nil {
+		s.secrets[spaceID][name] = secret
+		return err
+	}
+	return nil
+}
+
+// DeleteSpace removes every secret of a space.
+func (s *Store) DeleteSpace(spaceID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	space, ok := s.secrets[spaceID]
+	if !ok {
+		return nil
+	}
+	delete(s.secrets, spaceID)
+	if err := s.saveLocked(); err != nil {
+		s.secrets[spaceID] = space
+		return err
+	}
+	return nil
+}
+
+// Resolve returns the values of the named secrets of a space.
+func (s *Store) Resolve(spaceID string, names []string) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		secret, ok := s.secrets[spaceID][name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		value, err := s.open(secret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt secret %s: %w", name, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+func (s *Store) open(secret *storedSecret) (string, error) {
+	value, err := s.aead.Open(nil, secret.Nonce, secret.Value, additionalData(secret.SpaceID, secret.Name))
+	return string(value), err
+}
+
+// additionalData binds a ciphertext to its space and name, so a value cannot
+// be moved to another secret in the file.
+func additionalData(spaceID, name string) []byte {
+	return []byte(spaceID + "/" + name)
+}
+
+// spaceLocked returns the secrets of a space, creating the map if needed. The
+// caller must hold s.mu.
+func (s *Store) spaceLocked(spaceID string) map[string]*storedSecret {
+	space, ok := s.secrets[spaceID]
+	if !ok {
+		space = make(map[string]*storedSecret)
+		s.secrets[spaceID] = space
+	}
+	return space
+}
+
+func (s *Store) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	var stored []*storedSecret
+	for _, space := range s.secrets {
+		for _, secret := range space {
+			stored = append(stored, secret)
+		}
+	}
+	sort.Slice(stored, func(i, j int) bool {
+		if stored[i].SpaceID != stored[j].SpaceID {
+			return stored[i].SpaceID < stored[j].SpaceID
+		}
+		return stored[i].Name < stored[j].Name
+	})
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode secrets: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".secrets-*")
+	if err != nil {
+		return fmt.Errorf("failed to save secrets: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save secrets: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save secrets: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save secrets: %w", err)
+	}
+	return nil
+}
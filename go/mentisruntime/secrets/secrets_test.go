@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T) []byte {
+	key := make([]byte, KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
+
+func Test_Store(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	key := newKey(t)
+	s, err := NewStore(path, key)
+	require.NoError(t, err)
+
+	created, isNew, err := s.Set("s1", "OPENAI_API_KEY", "sk-first")
+	require.NoError(t, err)
+	require.True(t, isNew)
+	updated, isNew, err := s.Set("s1", "OPENAI_API_KEY", "sk-second")
+	require.NoError(t, err)
+	require.False(t, isNew)
+	require.Equal(t, created.CreatedAt, updated.CreatedAt)
+	_, _, err = s.Set("s2", "db.password", "hunter2")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"OPENAI_API_KEY"}, names(s.List("s1")))
+	values, err := s.Resolve("s1", []string{"OPENAI_API_KEY"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"OPENAI_API_KEY": "sk-second"}, values)
+	_, err = s.Resolve("s1", []string{"db.password"})
+	require.ErrorIs(t, err, ErrNotFound, "secrets of other spaces are not visible")
+
+	// Values are encrypted at rest and survive a restart with the same key only.
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(data, []byte("sk-second")))
+	require.False(t, bytes.Contains(data, []byte("hunter2")))
+	reopened, err := NewStore(path, key)
+	require.NoError(t, err)
+	values, err = reopened.Resolve("s2", []string{"db.password"})
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", values["db.password"])
+	_, err = NewStore(path, newKey(t))
+	require.ErrorIs(t, err, ErrInvalidKey)
+
+	require.NoError(t, s.Delete("s1", "OPENAI_API_KEY"))
+	require.ErrorIs(t, s.Delete("s1", "OPENAI_API_KEY"), ErrNotFound)
+	require.NoError(t, s.DeleteSpace("s2"))
+	require.Empty(t, s.List("s2"))
+}
+
+func Test_StoreValidation(t *testing.T) {
+	s, err := NewStore("", nil)
+	require.NoError(t, err)
+	for _, name := range []string{"", ".hidden", "a/b", "with space"} {
+		_, _, err := s.Set("s1", name, "v")
+		require.ErrorIs(t, err, ErrInvalidName, name)
+	}
+	_, _, err = s.Set("s1", "empty", "")
+	require.ErrorIs(t, err, ErrInvalidValue)
+
+	_, err = NewStore(filepath.Join(t.TempDir(), "secrets.json"), nil)
+	require.ErrorIs(t, err, ErrInvalidKey, "persisted secrets need a key")
+}
+
+func Test_ParseKey(t *testing.T) {
+	key := newKey(t)
+	parsed, err := ParseKey(base64.StdEncoding.EncodeToString(key))
+	require.NoError(t, err)
+	require.Equal(t, key, parsed)
+	_, err = ParseKey(base64.StdEncoding.EncodeToString(key[:16]))
+	require.ErrorIs(t, err, ErrInvalidKey)
+	_, err = ParseKey("not base64!")
+	require.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func names(secrets []Secret) []string {
+	out := make([]string, len(secrets))
+	for i, s := range secrets {
+		out[i] = s.Name
+	}
+	return out
+}
@@ -24,8 +24,8 @@ const (
 )
 
 var (
-	newline = []byte{ '\n' }
-	space   = []byte{ ' ' }
+	newline = []byte{'\n'}
+	space   = []byte{' '}
 )
 
 var upgrader = websocket.Upgrader{
@@ -42,9 +42,12 @@ var upgrader = websocket.Upgrader{
 type Client struct {
 	hub *Hub
 
-	// The websocket connection.
+	// The websocket connection, set once the client is registered.
 	conn *websocket.Conn
 
+	// Address of the peer, for logging.
+	remoteAddr string
+
 	// Buffered channel of outbound messages.
 	send chan []byte
 
@@ -67,10 +70,10 @@ func (c *Client) readPump() {
 	}()
 	c.conn.SetReadLimit(maxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(pongWait))
-	c.conn.SetPongHandler(func(string) error { 
+	c.conn.SetPongHandler(func(string) error {
 		c.logger.Debug("Pong received")
-		c.conn.SetReadDeadline(time.Now().Add(pongWait)); 
-		return nil 
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
 	})
 	for {
 		_, message, err := c.conn.ReadMessage()
@@ -143,4 +146,4 @@ func (c *Client) writePump() {
 			c.logger.Debug("Sending Ping")
 		}
 	}
-}
\ No newline at end of file
+}
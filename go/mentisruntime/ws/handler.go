@@ -1,6 +1,7 @@
 package ws
 
 import (
+	"encoding/json"
 	"log/slog"
 	"net/http"
 
@@ -10,15 +11,15 @@ import (
 )
 
 // ServeWs handles websocket requests from the peer.
-// It upgrades the HTTP connection, creates a client, registers it with the hub,
+// It creates a client, registers it with the hub, upgrades the HTTP connection
 // and starts the read/write pumps.
 // It now accepts a SandboxChecker interface instead of a concrete manager.
 func ServeWs(hub *Hub, checker SandboxChecker, w http.ResponseWriter, r *http.Request, logger *slog.Logger) {
 	vars := mux.Vars(r)
-	sandboxID, ok := vars["sandboxID"]
+	sandboxID, ok := vars["sandbox_id"]
 	if !ok {
 		logger.Error("Missing sandboxID in WebSocket path")
-		http.Error(w, "Missing sandboxID", http.StatusBadRequest)
+		writeError(w, "invalid_request", "Missing sandboxID", http.StatusBadRequest)
 		return
 	}
 
@@ -26,38 +27,49 @@ func ServeWs(hub *Hub, checker SandboxChecker, w http.ResponseWriter, r *http.Re
 	exists, err := checker.SandboxExists(r.Context(), sandboxID)
 	if err != nil {
 		logger.Error("Failed to check sandbox existence", "error", err, "sandboxID", sandboxID)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeError(w, "internal", "Internal server error", http.StatusInternalServerError)
 		return
 	}
 	if !exists {
 		logger.Warn("Attempted WebSocket connection to non-existent sandbox", "sandboxID", sandboxID)
-		http.Error(w, "Sandbox not found", http.StatusNotFound)
+		writeError(w, "sandbox_not_found", "Sandbox not found", http.StatusNotFound)
 		return
 	}
 
+	// The client is registered before the upgrade completes: once the peer
+	// sees the handshake response, it receives everything broadcast for the
+	// sandbox, buffered in send until the pumps start.
+	clientLogger := logger.With("component", "websocket-client", "sandboxID", sandboxID, "remoteAddr", r.RemoteAddr)
+	client := &Client{
+		hub:        hub,
+		send:       make(chan []byte, 256), // Buffered channel
+		sandboxID:  sandboxID,
+		remoteAddr: r.RemoteAddr,
+		logger:     clientLogger,
+	}
+	client.hub.register <- client
+
 	conn, err := upgrader.Upgrade(w, r, nil) // upgrader is defined in client.go
 	if err != nil {
 		logger.Error("Failed to upgrade WebSocket connection", "error", err, "sandboxID", sandboxID)
+		client.hub.unregister <- client
 		// Upgrade automatically sends an error response, so no need for http.Error here.
 		return
 	}
-
-	clientLogger := logger.With("component", "websocket-client", "sandboxID", sandboxID, "remoteAddr", conn.RemoteAddr().String())
-	client := &Client{
-		hub:       hub,
-		conn:      conn,
-		send:      make(chan []byte, 256), // Buffered channel
-		sandboxID: sandboxID,
-		logger:    clientLogger,
-	}
+	client.conn = conn
 
 	client.logger.Info("WebSocket client connection established")
 
-	// Allow registration of the client to the hub.
-	client.hub.register <- client
-
 	// Allow collection of memory referenced by the caller by doing all work in
 	// new goroutines.
 	go client.writePump()
 	go client.readPump()
-}
\ No newline at end of file
+}
+
+// writeError writes the API's error body; code is one of manager's error
+// codes, see handler.WriteError.
+func writeError(w http.ResponseWriter, code, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]string{"message": message, "code": code})
+}
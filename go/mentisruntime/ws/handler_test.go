@@ -0,0 +1,46 @@
+package ws
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+type existingSandboxes struct{}
+
+func (existingSandboxes) SandboxExists(context.Context, string) (bool, error) { return true, nil }
+
+func Test_ServeWsSubscribesBeforeHandshake(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	hub := NewHub(logger)
+	go hub.Run()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/sandboxes/{sandbox_id}/stream", func(w http.ResponseWriter, r *http.Request) {
+		ServeWs(hub, existingSandboxes{}, w, r, logger)
+	})
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	for i := 0; i < 20; i++ {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/sandboxes/sb1/stream", nil)
+		require.NoError(t, err)
+		// Published as soon as the handshake completes, like an action started
+		// right after the stream is opened.
+		hub.SubmitBroadcast("sb1", []byte(`{"observation_type":"end"}`))
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+		require.JSONEq(t, `{"observation_type":"end"}`, string(msg))
+		conn.Close()
+	}
+}
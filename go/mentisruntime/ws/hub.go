@@ -4,6 +4,8 @@ import (
 	"log/slog"
 	"strings"
 	"sync"
+
+	"github.com/foreveryh/sandboxai/go/mentisruntime/metrics"
 )
 
 // Hub maintains the set of active clients and broadcasts messages to the
@@ -30,6 +32,8 @@ type Hub struct {
 	mu sync.RWMutex
 
 	logger *slog.Logger
+
+	metrics *metrics.Metrics // Nil when not exported
 }
 
 // BroadcastMessage encapsulates a message intended for a specific sandbox.
@@ -50,6 +54,12 @@ func NewHub(logger *slog.Logger) *Hub {
 	}
 }
 
+// SetMetrics records the hub's connected clients and dropped messages in mx.
+// It must be called before Run.
+func (h *Hub) SetMetrics(mx *metrics.Metrics) {
+	h.metrics = mx
+}
+
 func (h *Hub) Run() {
 	h.logger.Info("WebSocket Hub started")
 	for {
@@ -61,8 +71,9 @@ func (h *Hub) Run() {
 				h.sandboxSubscriptions[client.sandboxID] = make(map[*Client]bool)
 			}
 			h.sandboxSubscriptions[client.sandboxID][client] = true
+			h.metrics.SetWSClients(len(h.clients))
 			h.mu.Unlock()
-			h.logger.Debug("Client registered", "sandboxID", client.sandboxID, "remoteAddr", client.conn.RemoteAddr().String())
+			h.logger.Debug("Client registered", "sandboxID", client.sandboxID, "remoteAddr", client.remoteAddr)
 
 		case client := <-h.unregister:
 			h.mu.Lock()
@@ -75,7 +86,8 @@ func (h *Hub) Run() {
 						delete(h.sandboxSubscriptions, client.sandboxID)
 					}
 				}
-				h.logger.Debug("Client unregistered", "sandboxID", client.sandboxID, "remoteAddr", client.conn.RemoteAddr().String())
+				h.logger.Debug("Client unregistered", "sandboxID", client.sandboxID, "remoteAddr", client.remoteAddr)
+				h.metrics.SetWSClients(len(h.clients))
 			}
 			h.mu.Unlock()
 
@@ -89,7 +101,8 @@ func (h *Hub) Run() {
 					case client.send <- broadcastMsg.Message:
 					default:
 						// Prevent blocking if the client's send buffer is full
-						h.logger.Warn("Client send channel full, closing client", "sandboxID", client.sandboxID, "remoteAddr", client.conn.RemoteAddr().String())
+						h.logger.Warn("Client send channel full, closing client", "sandboxID", client.sandboxID, "remoteAddr", client.remoteAddr)
+						h.metrics.BroadcastDropped("client")
 						// Closing the client here might be too aggressive, consider alternative strategies
 						// For now, we'll rely on the writePump detecting the closed channel
 						// close(client.send)
@@ -118,6 +131,7 @@ func (h *Hub) SubmitBroadcast(sandboxID string, message []byte) {
 	default:
 		// Hub's broadcast channel is full, might indicate a bottleneck or dead hub.
 		h.logger.Error("Hub broadcast channel full, discarding message", "sandboxID", sandboxID)
+		h.metrics.BroadcastDropped("hub")
 	}
 }
 
@@ -136,14 +150,14 @@ func (h *Hub) BroadcastToSandbox(sandboxID string, message []byte) {
 	clientAddrs := []string{}
 	h.mu.RLock()
 	for client := range subscribers {
-		clientAddrs = append(clientAddrs, client.conn.RemoteAddr().String())
+		clientAddrs = append(clientAddrs, client.remoteAddr)
 	}
 	h.mu.RUnlock()
 	h.logger.Debug("Broadcasting message details",
 		"sandboxID", sandboxID,
 		"numSubscribers", len(subscribers),
 		"subscriberAddrs", strings.Join(clientAddrs, ", "), // Log addresses
-		"messageContent", string(message))                   // Log content being sent
+		"messageContent", string(message)) // Log content being sent
 	// *** END ADDED DIAGNOSTIC LOGGING ***
 
 	// Use a temporary map to avoid holding the lock while sending
@@ -156,17 +170,18 @@ func (h *Hub) BroadcastToSandbox(sandboxID string, message []byte) {
 
 	for client := range clientsToSend {
 		// *** ADDED DIAGNOSTIC LOGGING ***
-		h.logger.Debug("Attempting to send to client", "clientAddr", client.conn.RemoteAddr().String())
+		h.logger.Debug("Attempting to send to client", "clientAddr", client.remoteAddr)
 		// *** END ADDED DIAGNOSTIC LOGGING ***
 		select {
 		case client.send <- message:
 			// *** ADDED DIAGNOSTIC LOGGING ***
-			h.logger.Debug("Successfully submitted to client channel", "clientAddr", client.conn.RemoteAddr().String())
+			h.logger.Debug("Successfully submitted to client channel", "clientAddr", client.remoteAddr)
 			// *** END ADDED DIAGNOSTIC LOGGING ***
 		default:
 			// If the send channel is full, assume the client is slow or disconnected.
 			// Close the client connection and remove it.
-			h.logger.Warn("Client send channel full, closing connection", "sandboxID", sandboxID, "clientAddr", client.conn.RemoteAddr().String())
+			h.logger.Warn("Client send channel full, closing connection", "sandboxID", sandboxID, "clientAddr", client.remoteAddr)
+			h.metrics.BroadcastDropped("client")
 			// Need to run unregister in a goroutine or handle locking carefully
 			// to avoid deadlock if unregister tries to lock the hub.
 			go func(c *Client) {
@@ -175,4 +190,4 @@ func (h *Hub) BroadcastToSandbox(sandboxID string, message []byte) {
 			}(client)
 		}
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/moby/term"
+
+	client "github.com/foreveryh/sandboxai/go/client/v1"
+)
+
+func (a *app) attach(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("attach", flag.ContinueOnError)
+	rest, err := subcommand("attach", flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	var rows, cols uint = 24, 80
+	inFd, inIsTerminal := term.GetFdInfo(os.Stdin)
+	outFd, _ := term.GetFdInfo(os.Stdout)
+	if ws, err := term.GetWinsize(outFd); err == nil && ws.Height > 0 {
+		rows, cols = uint(ws.Height), uint(ws.Width)
+	}
+
+	terminal, err := a.client.OpenTerminal(ctx, a.space, rest[0], rows, cols)
+	if err != nil {
+		return err
+	}
+	defer terminal.Close()
+
+	if inIsTerminal {
+		state, err := term.SetRawTerminal(inFd)
+		if err != nil {
+			return err
+		}
+		defer term.RestoreTerminal(inFd, state)
+	}
+	stopResize := watchResize(outFd, terminal)
+	defer stopResize()
+
+	go func() {
+		// Stdin is not closed when the shell exits; closing the terminal below
+		// ends the copy with a write error.
+		io.Copy(terminal, a.stdin)
+	}()
+	go func() {
+		<-ctx.Done()
+		terminal.Close()
+	}()
+
+	_, err = io.Copy(a.stdout, terminal)
+	if ctx.Err() != nil {
+		return exitError{exitInterrupted}
+	}
+	if err != nil {
+		return err
+	}
+	if code, ok := terminal.ExitCode(); ok && code != 0 {
+		if code < 0 || code > 255 {
+			code = 1
+		}
+		return exitError{code}
+	}
+	return nil
+}
+
+// resizeTerminal sends the current size of the local terminal.
+func resizeTerminal(fd uintptr, terminal *client.Terminal) error {
+	ws, err := term.GetWinsize(fd)
+	if err != nil {
+		return err
+	}
+	if ws.Height == 0 {
+		return errors.New("terminal has no size")
+	}
+	return terminal.Resize(uint(ws.Height), uint(ws.Width))
+}
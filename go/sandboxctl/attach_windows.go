@@ -0,0 +1,11 @@
+package main
+
+import (
+	client "github.com/foreveryh/sandboxai/go/client/v1"
+)
+
+// watchResize is a no-op on Windows, which has no SIGWINCH. The terminal keeps
+// the size it was opened with.
+func watchResize(fd uintptr, terminal *client.Terminal) (stop func()) {
+	return func() {}
+}
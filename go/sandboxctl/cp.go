@@ -0,0 +1,215 @@
+package main
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// location is an argument of cp: a local path, or a path in a sandbox written
+// as "<sandbox>:<path>".
+type location struct {
+	sandbox string
+	path    string
+}
+
+func (l location) remote() bool {
+	return l.sandbox != ""
+}
+
+// parseLocation splits a cp argument. Local paths containing a colon can be
+// written as "./name:with:colons".
+func parseLocation(arg string) location {
+	sandbox, p, ok := strings.Cut(arg, ":")
+	if !ok || sandbox == "" || strings.ContainsAny(sandbox, `/\.`) {
+		return location{path: arg}
+	}
+	return location{sandbox: sandbox, path: p}
+}
+
+func (a *app) cp(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("cp", flag.ContinueOnError)
+	rest, err := subcommand("cp", flags, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	src, dst := parseLocation(rest[0]), parseLocation(rest[1])
+	switch {
+	case src.remote() == dst.remote():
+		return usageError{"cp: exactly one of the paths must be <sandbox>:<path>"}
+	case dst.remote():
+		return a.copyIn(ctx, src.path, dst)
+	default:
+		return a.copyOut(ctx, src, dst.path)
+	}
+}
+
+// copyIn copies a local file or directory into a sandbox. A destination ending
+// in "/" is a directory to copy into; otherwise the copy is named after it.
+func (a *app) copyIn(ctx context.Context, src string, dst location) error {
+	if !path.IsAbs(dst.path) {
+		return usageError{"cp: sandbox paths must be absolute"}
+	}
+	dstDir, name := path.Dir(dst.path), path.Base(dst.path)
+	if strings.HasSuffix(dst.path, "/") {
+		dstDir, name = path.Clean(dst.path), filepath.Base(src)
+	}
+	if _, err := os.Lstat(src); err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeTar(pw, src, name))
+	}()
+	err := a.client.CopyToSandbox(ctx, a.space, dst.sandbox, dstDir, pr)
+	pr.Close()
+	return err
+}
+
+// copyOut copies a file or directory out of a sandbox. It is placed inside dst
+// if that is an existing directory and named dst otherwise; "-" writes the tar
+// archive to stdout.
+func (a *app) copyOut(ctx context.Context, src location, dst string) error {
+	if !path.IsAbs(src.path) {
+		return usageError{"cp: sandbox paths must be absolute"}
+	}
+	archive, err := a.client.CopyFromSandbox(ctx, a.space, src.sandbox, src.path)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	if dst == "-" {
+		_, err := io.Copy(a.stdout, archive)
+		return err
+	}
+
+	if info, err := os.Stat(dst); err == nil && info.IsDir() {
+		return extractTar(archive, dst, "", "")
+	}
+	return extractTar(archive, filepath.Dir(dst), path.Base(src.path), filepath.Base(dst))
+}
+
+// writeTar writes src, a file or directory tree, as a tar archive whose root
+// entry is called name.
+func writeTar(w io.Writer, src, name string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var link string
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		hdr.Name = path.Join(name, filepath.ToSlash(rel))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// extractTar extracts a tar archive into dir, renaming its root entry from to
+// to if from is set. Entries escaping dir are rejected. Symbolic links are
+// created last so that no entry is written through one.
+func extractTar(r io.Reader, dir, from, to string) error {
+	type symlink struct{ target, path string }
+	var symlinks []symlink
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		name := path.Clean(hdr.Name)
+		if from != "" && (name == from || strings.HasPrefix(name, from+"/")) {
+			name = to + strings.TrimPrefix(name, from)
+		}
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("archive entry %q is outside the destination", hdr.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		mode := hdr.FileInfo().Mode().Perm()
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode|0o700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			if err := writeFile(target, tr, mode); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			symlinks = append(symlinks, symlink{target: hdr.Linkname, path: target})
+		}
+	}
+	for _, l := range symlinks {
+		if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+			return err
+		}
+		os.Remove(l.path)
+		if err := os.Symlink(l.target, l.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(name string, r io.Reader, mode fs.FileMode) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseLocation(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want location
+	}{
+		{"box:/tmp/a.txt", location{sandbox: "box", path: "/tmp/a.txt"}},
+		{"box:/tmp/", location{sandbox: "box", path: "/tmp/"}},
+		{"a.txt", location{path: "a.txt"}},
+		{"./name:with:colons", location{path: "./name:with:colons"}},
+		{"dir/box:/tmp", location{path: "dir/box:/tmp"}},
+		{":/tmp", location{path: ":/tmp"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			require.Equal(t, tt.want, parseLocation(tt.arg))
+		})
+	}
+}
+
+func Test_tarRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "sub", "b.sh"), []byte("b"), 0o755))
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(src, "link")))
+
+	var buf bytes.Buffer
+	require.NoError(t, writeTar(&buf, src, "project"))
+
+	dst := t.TempDir()
+	require.NoError(t, extractTar(&buf, dst, "project", "copy"))
+
+	data, err := os.ReadFile(filepath.Join(dst, "copy", "a.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "a", string(data))
+	info, err := os.Stat(filepath.Join(dst, "copy", "sub", "b.sh"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+	target, err := os.Readlink(filepath.Join(dst, "copy", "link"))
+	require.NoError(t, err)
+	require.Equal(t, "a.txt", target)
+}
+
+func Test_extractTarRejectsEscapes(t *testing.T) {
+	for _, name := range []string{"../evil", "/etc/evil", "a/../../evil"} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644}))
+			require.NoError(t, tw.Close())
+
+			err := extractTar(&buf, t.TempDir(), "", "")
+			require.ErrorContains(t, err, "outside the destination")
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	v1 "github.com/foreveryh/sandboxai/go/api/v1"
+)
+
+// exitInterrupted is the exit code when sandboxctl is interrupted while
+// waiting for an action, as a shell reports SIGINT.
+const exitInterrupted = 130
+
+// splitOutput asks for stdout and stderr as separate streams, which exec
+// writes to its own stdout and stderr.
+var splitOutput = true
+
+func (a *app) exec(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError{"exec: missing subcommand"}
+	}
+	flags := flag.NewFlagSet("exec "+args[0], flag.ContinueOnError)
+	var start func(ctx context.Context, sandbox string) (string, error)
+	switch args[0] {
+	case "shell":
+		rest, err := subcommand("exec shell", flags, args[1:], 2, -1)
+		if err != nil {
+			return err
+		}
+		words := rest[1:]
+		if words[0] == "--" {
+			words = words[1:]
+		}
+		if len(words) == 0 {
+			return usageError{"exec shell: missing command"}
+		}
+		command := strings.Join(words, " ")
+		args = rest
+		start = func(ctx context.Context, sandbox string) (string, error) {
+			return a.client.StartShellCommand(ctx, a.space, sandbox, &v1.RunShellCommandRequest{Command: command, SplitOutput: &splitOutput})
+		}
+	case "ipython":
+		rest, err := subcommand("exec ipython", flags, args[1:], 1, 2)
+		if err != nil {
+			return err
+		}
+		var code string
+		if len(rest) == 2 {
+			code = rest[1]
+		} else {
+			data, err := io.ReadAll(a.stdin)
+			if err != nil {
+				return fmt.Errorf("failed to read code from stdin: %w", err)
+			}
+			code = string(data)
+		}
+		args = rest
+		start = func(ctx context.Context, sandbox string) (string, error) {
+			return a.client.StartIPythonCell(ctx, a.space, sandbox, &v1.RunIPythonCellRequest{Code: code, SplitOutput: &splitOutput})
+		}
+	default:
+		return usageError{fmt.Sprintf("exec: unknown subcommand %q", args[0])}
+	}
+	return a.runAction(ctx, args[0], start)
+}
+
+// runAction starts an action in sandbox and prints its observations until it
+// ends. It returns an exitError carrying the action's exit code.
+func (a *app) runAction(ctx context.Context, sandbox string, start func(context.Context, string) (string, error)) error {
+	// The stream only carries observations published after it connects, so it
+	// has to be open before the action starts.
+	stream, err := a.client.StreamObservations(ctx, sandbox)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	actionID, err := start(ctx, sandbox)
+	if err != nil {
+		return err
+	}
+
+	exitCode := 0
+	for {
+		obs, err := stream.Next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return exitError{exitInterrupted}
+			}
+			return fmt.Errorf("observation stream closed before the action ended: %w", err)
+		}
+		if obs.ActionID != actionID {
+			continue
+		}
+		if a.output == "json" {
+			if err := json.NewEncoder(a.stdout).Encode(obs); err != nil {
+				return err
+			}
+		} else {
+			a.printObservation(obs)
+		}
+		if obs.ExitCode != nil {
+			exitCode = int(*obs.ExitCode)
+		}
+		if obs.ObservationType == "end" {
+			break
+		}
+	}
+	if exitCode == 0 {
+		return nil
+	}
+	if exitCode < 0 || exitCode > 255 {
+		exitCode = 1
+	}
+	return exitError{exitCode}
+}
+
+// printObservation writes stream lines to stdout or stderr and reports errors
+// on stderr. Rich outputs are shown by their plain text form, or by their MIME
+// types when they have none; --json keeps the whole bundle.
+func (a *app) printObservation(obs *v1.Observation) {
+	switch obs.ObservationType {
+	case "stream":
+		out := a.stdout
+		if deref(obs.Stream) == "stderr" {
+			out = a.stderr
+		}
+		line := deref(obs.Line)
+		if !strings.HasSuffix(line, "\n") {
+			line += "\n"
+		}
+		io.WriteString(out, line)
+	case "display_data":
+		if obs.Data == nil {
+			return
+		}
+		if text, ok := (*obs.Data)["text/plain"].(string); ok {
+			fmt.Fprintln(a.stdout, strings.TrimSuffix(text, "\n"))
+			return
+		}
+		fmt.Fprintf(a.stdout, "[%s]\n", strings.Join(slices.Sorted(maps.Keys(*obs.Data)), ", "))
+	case "error":
+		fmt.Fprintf(a.stderr, "error: %s\n", deref(obs.Error))
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+)
+
+func (a *app) logs(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("logs", flag.ContinueOnError)
+	follow := flags.Bool("follow", false, "")
+	flags.BoolVar(follow, "f", false, "")
+	tail := flags.Int("tail", -1, "")
+	rest, err := subcommand("logs", flags, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	logs, err := a.client.Logs(ctx, a.space, rest[0], *follow, *tail)
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+	_, err = io.Copy(a.stdout, logs)
+	if ctx.Err() != nil {
+		// Interrupting a followed log is the normal way to stop it.
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,168 @@
+// Command sandboxctl manages spaces and sandboxes of a sandboxaid runtime and
+// runs code in them.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	client "github.com/foreveryh/sandboxai/go/client/v1"
+)
+
+const usage = `Usage: sandboxctl [flags] <command> [arguments]
+
+Commands:
+  spaces create <name> [--description text]
+  spaces list
+  spaces get <space>
+  spaces delete <space>
+  spaces usage <space> [--from time] [--to time] [--bucket hour|day] [--by-sandbox] [--csv]
+  sandboxes create [--image image] [--env KEY=VALUE]... [--port port]... [--network mode] [--allow dest]...
+                    [--cpus n] [--memory MiB]
+  sandboxes list
+  sandboxes get <sandbox>
+  sandboxes delete <sandbox>
+  exec shell <sandbox> [--] <command>...
+  exec ipython <sandbox> [code]      reads the code from stdin when omitted
+  cp <src> <dst>                     one side is <sandbox>:<path>; a remote path ending in / is a directory
+  attach <sandbox>                   interactive shell
+  logs <sandbox> [--follow] [--tail n]
+
+Flags:
+  --url url        runtime URL (env SANDBOXAI_URL, default http://127.0.0.1:5266)
+  --api-key key    API key (env SANDBOXAI_API_KEY)
+  --space space    space of the sandboxes (env SANDBOXAI_SPACE, default "default")
+  -o format        output format: table or json (default table)
+
+exec exits with the exit code of the command or cell. Other failures exit with
+code 125 and usage errors with code 2.
+`
+
+// Exit codes for failures of sandboxctl itself, chosen not to clash with
+// common exit codes of commands run in a sandbox.
+const (
+	exitFailure = 125
+	exitUsage   = 2
+)
+
+// exitError ends sandboxctl with an exit code and no message.
+type exitError struct {
+	code int
+}
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit code %d", e.code)
+}
+
+// usageError is reported together with the usage text.
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+// app holds the global flags and the client built from them.
+type app struct {
+	client *client.Client
+	space  string
+	output string // "table" or "json"
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := run(ctx, os.Args[1:])
+	stop()
+
+	var exit exitError
+	var usageErr usageError
+	switch {
+	case err == nil:
+	case errors.As(err, &exit):
+		os.Exit(exit.code)
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(os.Stderr, "sandboxctl: %s\n\n%s", usageErr.msg, usage)
+		os.Exit(exitUsage)
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(exitUsage)
+	default:
+		fmt.Fprintf(os.Stderr, "sandboxctl: %v\n", err)
+		os.Exit(exitFailure)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("sandboxctl", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+	}
+	baseURL := flags.String("url", envOr("SANDBOXAI_URL", "http://127.0.0.1:5266"), "")
+	apiKey := flags.String("api-key", os.Getenv("SANDBOXAI_API_KEY"), "")
+	space := flags.String("space", envOr("SANDBOXAI_SPACE", "default"), "")
+	output := flags.String("o", "table", "")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *output != "table" && *output != "json" {
+		return usageError{fmt.Sprintf("unknown output format %q", *output)}
+	}
+
+	a := &app{
+		client: client.NewClient(*baseURL, client.WithAPIKey(*apiKey)),
+		space:  *space,
+		output: *output,
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		return usageError{"missing command"}
+	}
+	switch args[0] {
+	case "spaces":
+		return a.spaces(ctx, args[1:])
+	case "sandboxes":
+		return a.sandboxes(ctx, args[1:])
+	case "exec":
+		return a.exec(ctx, args[1:])
+	case "cp":
+		return a.cp(ctx, args[1:])
+	case "attach":
+		return a.attach(ctx, args[1:])
+	case "logs":
+		return a.logs(ctx, args[1:])
+	default:
+		return usageError{fmt.Sprintf("unknown command %q", args[0])}
+	}
+}
+
+// subcommand parses the flags of a command and checks its number of arguments.
+func subcommand(name string, flags *flag.FlagSet, args []string, minArgs, maxArgs int) ([]string, error) {
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(args); err != nil {
+		return nil, usageError{fmt.Sprintf("%s: %v", name, err)}
+	}
+	rest := flags.Args()
+	if len(rest) < minArgs || (maxArgs >= 0 && len(rest) > maxArgs) {
+		return nil, usageError{fmt.Sprintf("%s: wrong number of arguments", name)}
+	}
+	return rest, nil
+}
+
+func envOr(key, fallback string) string {
+	if val, ok := os.LookupEnv(key); ok {
+		return val
+	}
+	return fallback
+}
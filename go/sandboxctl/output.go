@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	v1 "github.com/foreveryh/sandboxai/go/api/v1"
+)
+
+// print writes v as indented JSON in json mode, and otherwise as a table with
+// the given header and rows.
+func (a *app) print(v any, header []string, rows [][]string) error {
+	if a.output == "json" {
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+var spaceHeader = []string{"ID", "NAME", "DESCRIPTION", "CREATED"}
+
+func spaceRow(s v1.Space) []string {
+	return []string{s.SpaceID, s.Name, deref(s.Description), formatTime(s.CreatedAt)}
+}
+
+var usageHeader = []string{"RESOURCE", "USED", "QUOTA"}
+
+// usageRows lists each resource of a space's usage. An empty quota is
+// unlimited.
+func usageRows(u v1.SpaceUsage) [][]string {
+	var q v1.SpaceQuota
+	if u.Quota != nil {
+		q = *u.Quota
+	}
+	rows := [][]string{
+		{"sandboxes", strconv.Itoa(u.Sandboxes), optional(q.MaxSandboxes)},
+		{"concurrent actions", strconv.Itoa(u.ConcurrentActions), optional(q.MaxConcurrentActions)},
+		{"cpus", fmt.Sprint(u.Cpus), optional(q.Cpus)},
+		{"memory (MiB)", fmt.Sprint(u.MemoryMb), optional(q.MemoryMb)},
+	}
+	if q.MaxLifetimeSeconds != nil {
+		rows = append(rows, []string{"lifetime", "", (time.Duration(*q.MaxLifetimeSeconds) * time.Second).String()})
+	}
+	return rows
+}
+
+var usageBucketHeader = []string{"START", "SANDBOX", "RUNTIME", "ACTIONS", "ACTION TIME", "CPU SECONDS", "PEAK MEMORY (MiB)"}
+
+func usageBucketRow(b v1.UsageBucket) []string {
+	seconds := func(s float64) string { return (time.Duration(s) * time.Second).String() }
+	return []string{
+		formatTime(&b.Start), deref(b.SandboxID), seconds(b.RuntimeSeconds), strconv.Itoa(b.Actions),
+		seconds(b.ActionSeconds), strconv.FormatFloat(b.CPUSeconds, 'f', 1, 64), strconv.FormatFloat(b.PeakMemoryMb, 'f', 0, 64),
+	}
+}
+
+var sandboxHeader = []string{"ID", "STATE", "PHASE", "CREATED"}
+
+func sandboxRow(s v1.Sandbox) []string {
+	row := []string{s.SandboxID, "", "", ""}
+	if st := s.Status; st != nil {
+		if st.State != nil {
+			row[1] = string(*st.State)
+		}
+		if st.Phase != nil {
+			row[2] = string(*st.Phase)
+		}
+		row[3] = formatTime(st.CreatedAt)
+	}
+	return row
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
+
+// optional formats *p, or nothing when p is nil.
+func optional[T any](p *T) string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprint(*p)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	v1 "github.com/foreveryh/sandboxai/go/api/v1"
+)
+
+// listFlag collects the values of a repeatable flag.
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(v string) error { *l = append(*l, v); return nil }
+
+func (a *app) sandboxes(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError{"sandboxes: missing subcommand"}
+	}
+	flags := flag.NewFlagSet("sandboxes "+args[0], flag.ContinueOnError)
+	switch args[0] {
+	case "create":
+		image := flags.String("image", "", "")
+		network := flags.String("network", "", "")
+		cpus := flags.Float64("cpus", 0, "")
+		memory := flags.Int64("memory", 0, "")
+		var env, ports, allow listFlag
+		flags.Var(&env, "env", "")
+		flags.Var(&ports, "port", "")
+		flags.Var(&allow, "allow", "")
+		if _, err := subcommand("sandboxes create", flags, args[1:], 0, 0); err != nil {
+			return err
+		}
+		spec, err := sandboxSpec(*image, *network, env, ports, allow)
+		if err != nil {
+			return err
+		}
+		if *cpus != 0 || *memory != 0 {
+			spec.Resources = &v1.Resources{}
+			if *cpus != 0 {
+				spec.Resources.Cpus = cpus
+			}
+			if *memory != 0 {
+				spec.Resources.MemoryMb = memory
+			}
+		}
+		sandbox, err := a.client.CreateSandbox(ctx, a.space, &v1.CreateSandboxRequest{Spec: &spec})
+		if err != nil {
+			return err
+		}
+		return a.print(sandbox, sandboxHeader, [][]string{sandboxRow(*sandbox)})
+
+	case "list":
+		if _, err := subcommand("sandboxes list", flags, args[1:], 0, 0); err != nil {
+			return err
+		}
+		sandboxes, err := a.client.ListSandboxes(ctx, a.space)
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(sandboxes))
+		for _, s := range sandboxes {
+			rows = append(rows, sandboxRow(s))
+		}
+		return a.print(sandboxes, sandboxHeader, rows)
+
+	case "get":
+		rest, err := subcommand("sandboxes get", flags, args[1:], 1, 1)
+		if err != nil {
+			return err
+		}
+		sandbox, err := a.client.GetSandbox(ctx, a.space, rest[0])
+		if err != nil {
+			return err
+		}
+		return a.print(sandbox, sandboxHeader, [][]string{sandboxRow(*sandbox)})
+
+	case "delete":
+		rest, err := subcommand("sandboxes delete", flags, args[1:], 1, 1)
+		if err != nil {
+			return err
+		}
+		return a.client.DeleteSandbox(ctx, a.space, rest[0])
+
+	default:
+		return usageError{fmt.Sprintf("sandboxes: unknown subcommand %q", args[0])}
+	}
+}
+
+// sandboxSpec builds a spec from the flags of sandboxes create.
+func sandboxSpec(image, network string, env, ports, allow []string) (v1.SandboxSpec, error) {
+	var spec v1.SandboxSpec
+	if image != "" {
+		spec.Image = &image
+	}
+	if len(env) > 0 {
+		vars := make(map[string]string, len(env))
+		for _, kv := range env {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok || k == "" {
+				return spec, usageError{fmt.Sprintf("sandboxes create: --env %q is not KEY=VALUE", kv)}
+			}
+			vars[k] = v
+		}
+		spec.Env = &vars
+	}
+	if len(ports) > 0 {
+		parsed := make([]int, 0, len(ports))
+		for _, p := range ports {
+			port, err := strconv.Atoi(p)
+			if err != nil {
+				return spec, usageError{fmt.Sprintf("sandboxes create: --port %q is not a number", p)}
+			}
+			parsed = append(parsed, port)
+		}
+		spec.Ports = &parsed
+	}
+	if network != "" || len(allow) > 0 {
+		if network == "" {
+			network = string(v1.NetworkPolicyModeAllowlist)
+		}
+		spec.Network = &v1.NetworkPolicy{Mode: v1.NetworkPolicyMode(network)}
+		if len(allow) > 0 {
+			dests := []string(allow)
+			spec.Network.Allow = &dests
+		}
+	}
+	return spec, nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	v1 "github.com/foreveryh/sandboxai/go/api/v1"
+)
+
+func (a *app) spaces(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError{"spaces: missing subcommand"}
+	}
+	flags := flag.NewFlagSet("spaces "+args[0], flag.ContinueOnError)
+	switch args[0] {
+	case "create":
+		description := flags.String("description", "", "")
+		rest, err := subcommand("spaces create", flags, args[1:], 1, 1)
+		if err != nil {
+			return err
+		}
+		req := &v1.CreateSpaceRequest{Name: rest[0]}
+		if *description != "" {
+			req.Description = description
+		}
+		space, err := a.client.CreateSpace(ctx, req)
+		if err != nil {
+			return err
+		}
+		return a.print(space, spaceHeader, [][]string{spaceRow(*space)})
+
+	case "list":
+		if _, err := subcommand("spaces list", flags, args[1:], 0, 0); err != nil {
+			return err
+		}
+		spaces, err := a.client.ListSpaces(ctx)
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(spaces))
+		for _, s := range spaces {
+			rows = append(rows, spaceRow(s))
+		}
+		return a.print(spaces, spaceHeader, rows)
+
+	case "get":
+		rest, err := subcommand("spaces get", flags, args[1:], 1, 1)
+		if err != nil {
+			return err
+		}
+		space, err := a.client.GetSpace(ctx, rest[0])
+		if err != nil {
+			return err
+		}
+		return a.print(space, spaceHeader, [][]string{spaceRow(*space)})
+
+	case "usage":
+		from := flags.String("from", "", "")
+		to := flags.String("to", "", "")
+		bucket := flags.String("bucket", "", "")
+		bySandbox := flags.Bool("by-sandbox", false, "")
+		csv := flags.Bool("csv", false, "")
+		rest, err := subcommand("spaces usage", flags, args[1:], 1, 1)
+		if err != nil {
+			return err
+		}
+		params, err := usageParams(*from, *to, *bucket, *bySandbox)
+		if err != nil {
+			return err
+		}
+		if *csv {
+			export, err := a.client.ExportSpaceUsage(ctx, rest[0], params)
+			if err != nil {
+				return err
+			}
+			defer export.Close()
+			_, err = io.Copy(a.stdout, export)
+			return err
+		}
+		usage, err := a.client.SpaceUsage(ctx, rest[0], params)
+		if err != nil {
+			return err
+		}
+		if err := a.print(usage, usageHeader, usageRows(*usage)); err != nil || a.output == "json" || len(usage.History) == 0 {
+			return err
+		}
+		fmt.Fprintln(a.stdout)
+		rows := make([][]string, 0, len(usage.History))
+		for _, b := range usage.History {
+			rows = append(rows, usageBucketRow(b))
+		}
+		return a.print(nil, usageBucketHeader, rows)
+
+	case "delete":
+		rest, err := subcommand("spaces delete", flags, args[1:], 1, 1)
+		if err != nil {
+			return err
+		}
+		return a.client.DeleteSpace(ctx, rest[0])
+
+	default:
+		return usageError{fmt.Sprintf("spaces: unknown subcommand %q", args[0])}
+	}
+}
+
+// usageParams builds the parameters of spaces usage. Times are RFC 3339.
+func usageParams(from, to, bucket string, bySandbox bool) (*v1.GetSpaceUsageParams, error) {
+	var params v1.GetSpaceUsageParams
+	for _, t := range []struct {
+		flag, value string
+		dst         **time.Time
+	}{{"from", from, &params.From}, {"to", to, &params.To}} {
+		if t.value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, t.value)
+		if err != nil {
+			return nil, usageError{fmt.Sprintf("spaces usage: --%s %q is not an RFC 3339 time", t.flag, t.value)}
+		}
+		*t.dst = &parsed
+	}
+	if bucket != "" {
+		b := v1.GetSpaceUsageParamsBucket(bucket)
+		params.Bucket = &b
+	}
+	if bySandbox {
+		groupBy := v1.GetSpaceUsageParamsGroupBySandbox
+		params.GroupBy = &groupBy
+	}
+	return &params, nil
+}
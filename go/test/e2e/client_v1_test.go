@@ -8,9 +8,9 @@ import (
 	"testing"
 	"time"
 
-	"github.com/stretchr/testify/require"
 	v1 "github.com/foreveryh/sandboxai/go/api/v1"
 	clientv1 "github.com/foreveryh/sandboxai/go/client/v1"
+	"github.com/stretchr/testify/require"
 )
 
 func TestClientV1(t *testing.T) {
@@ -23,25 +23,31 @@ func TestClientV1(t *testing.T) {
 	require.NoError(t, err, "reading sandbox json file")
 	var spec v1.SandboxSpec
 	require.NoError(t, json.Unmarshal(sandboxJSON, &spec))
-	require.Equal(t, "placeholder-image", spec.Image)
-	spec.Image = cfg.BoxImage
+	require.Equal(t, "placeholder-image", *spec.Image)
+	spec.Image = &cfg.BoxImage
 
 	const space = "default"
 	createdSbx, err := c.CreateSandbox(ctx, space, &v1.CreateSandboxRequest{
-		Spec: spec,
+		Spec: &spec,
 	})
 	require.NoError(t, err, "Creating sandbox")
-	require.NotEmpty(t, createdSbx.Name, "Sandbox name returned from create should not be empty")
+	require.NotEmpty(t, createdSbx.SandboxID, "Sandbox ID returned from create should not be empty")
 
 	t.Cleanup(func() {
-		t.Logf("Cleanup(): Deleting sandbox (space = %q, name = %q)", space, createdSbx.Name)
-		err := c.DeleteSandbox(context.Background(), space, createdSbx.Name)
+		t.Logf("Cleanup(): Deleting sandbox (space = %q, name = %q)", space, createdSbx.SandboxID)
+		err := c.DeleteSandbox(context.Background(), space, createdSbx.SandboxID)
 		require.NoError(t, err, "Failed to delete sandbox")
 	})
 
-	gottenSbx, err := c.GetSandbox(ctx, space, createdSbx.Name)
+	gottenSbx, err := c.GetSandbox(ctx, space, createdSbx.SandboxID)
 	require.NoError(t, err, "Getting sandbox")
-	require.EqualValues(t, createdSbx, gottenSbx, "Sandbox returned from GetSandbox should match that returned from CreateSandbox")
+	// Status and running state change as the sandbox starts, so only compare
+	// what identifies the sandbox and what was asked for.
+	require.Equal(t, createdSbx.SandboxID, gottenSbx.SandboxID, "Sandbox ID returned from GetSandbox should match CreateSandbox")
+	require.Equal(t, createdSbx.SpaceID, gottenSbx.SpaceID, "Space returned from GetSandbox should match CreateSandbox")
+	require.NotNil(t, gottenSbx.Spec, "GetSandbox should return the sandbox's spec")
+	require.Equal(t, cfg.BoxImage, *gottenSbx.Spec.Image, "Image returned from GetSandbox should be the requested one")
+	require.Equal(t, createdSbx.Spec, gottenSbx.Spec, "Spec returned from GetSandbox should match CreateSandbox")
 
 	// IPython Tool //
 
@@ -61,20 +67,20 @@ func TestClientV1(t *testing.T) {
 
 	for _, tc := range ipyCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			resp, err := c.RunIPythonCell(ctx, space, createdSbx.Name, &v1.RunIPythonCellRequest{
+			resp, err := c.RunIPythonCell(ctx, space, createdSbx.SandboxID, &v1.RunIPythonCellRequest{
 				Code:        tc.Code,
-				SplitOutput: tc.Split,
+				SplitOutput: &tc.Split,
 			})
 			require.NoError(t, err, "Running IPython Cell")
 			if tc.ExpectedOutputContains != "" {
-				require.Contains(t, resp.Output, tc.ExpectedOutputContains, "output contains")
+				require.Contains(t, deref(resp.Output), tc.ExpectedOutputContains, "output contains")
 				require.Empty(t, tc.ExpectedOutput, "invalid assertion combo")
 			} else {
-				require.Equal(t, tc.ExpectedOutput, resp.Output, "output")
+				require.Equal(t, tc.ExpectedOutput, deref(resp.Output), "output")
 				require.Empty(t, tc.ExpectedOutputContains, "invalid assertion combo")
 			}
-			require.Equal(t, tc.ExpectedStdout, resp.Stdout, "stdout")
-			require.Equal(t, tc.ExpectedStderr, resp.Stderr, "stderr")
+			require.Equal(t, tc.ExpectedStdout, deref(resp.Stdout), "stdout")
+			require.Equal(t, tc.ExpectedStderr, deref(resp.Stderr), "stderr")
 		})
 	}
 
@@ -95,14 +101,14 @@ func TestClientV1(t *testing.T) {
 
 	for _, tc := range shellCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			resp, err := c.RunShellCommand(ctx, space, createdSbx.Name, &v1.RunShellCommandRequest{
+			resp, err := c.RunShellCommand(ctx, space, createdSbx.SandboxID, &v1.RunShellCommandRequest{
 				Command:     tc.Command,
-				SplitOutput: tc.Split,
+				SplitOutput: &tc.Split,
 			})
 			require.NoError(t, err, "Running shell command")
-			require.Equal(t, tc.ExpectedOutput, resp.Output, "output")
-			require.Equal(t, tc.ExpectedStdout, resp.Stdout, "stdout")
-			require.Equal(t, tc.ExpectedStderr, resp.Stderr, "stderr")
+			require.Equal(t, tc.ExpectedOutput, deref(resp.Output), "output")
+			require.Equal(t, tc.ExpectedStdout, deref(resp.Stdout), "stdout")
+			require.Equal(t, tc.ExpectedStderr, deref(resp.Stderr), "stderr")
 		})
 	}
 }
@@ -114,3 +120,10 @@ func TestClientV1NoOptions(t *testing.T) {
 	c := clientv1.NewClient(cfg.SandboxAIBaseURL)
 	require.NoError(t, c.CheckHealth(ctx))
 }
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -129,13 +129,15 @@ class ActionResult(BaseModel):
 
 class Observation(BaseModel):
     """Model for observations pushed from agent to runtime or streamed via WebSocket"""
-    observation_type: str = Field(..., description="Type of observation (e.g., start, stream, result, error, end)", pattern="^(start|stream|result|error|end)$")
+    observation_type: str = Field(..., description="Type of observation (e.g., start, stream, display_data, result, error, end)", pattern="^(start|stream|display_data|result|error|end)$")
     action_id: str = Field(..., description="Identifier of the action this observation relates to")
     timestamp: datetime = Field(..., description="Timestamp when the observation was generated (UTC)")
     stream: Optional[str] = Field(None, description="Stream type if observation_type is 'stream'", pattern="^(stdout|stderr)$")
     line: Optional[str] = Field(None, description="Content of the stream line if observation_type is 'stream'")
     exit_code: Optional[int] = Field(None, description="Exit code if observation_type is 'result' or 'end'")
     error: Optional[str] = Field(None, description="Error message if observation_type is 'error', 'result' or 'end'")
+    data: Optional[Dict[str, Any]] = Field(None, description="Payload of the observation; the MIME bundle if observation_type is 'display_data'")
+    metadata: Optional[Dict[str, Any]] = Field(None, description="Display metadata if observation_type is 'display_data'")
     # You might nest ActionResult here for 'result' type if preferred
 
 
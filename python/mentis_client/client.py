@@ -17,6 +17,7 @@ from .models import (
     BaseObservation, 
     parse_observation, # Make sure parse_observation is exported from models.py
     IPythonOutputObservationPart, 
+    IPythonDisplayObservation,
     IPythonResultObservation, 
     ErrorObservation, 
     CmdOutputObservationPart, 
@@ -588,6 +589,7 @@ class MentisSandbox:
 
         stdout_buffer = ""
         stderr_buffer = ""
+        displays = [] # MIME types of each rich output (plots, HTML tables, ...)
         error_message = None
         exit_code = None
 
@@ -618,6 +620,11 @@ class MentisSandbox:
                             elif obs.stream == "stderr":
                                 stderr_buffer += obs.data
                                 
+                    # IPython rich output
+                    elif obs.observation_type == "display_data":
+                        if isinstance(obs, IPythonDisplayObservation):
+                            displays.append(", ".join(sorted(obs.data)))
+
                     # IPython End
                     elif obs.observation_type == "end" or obs.observation_type == "result":
                         if isinstance(obs, IPythonResultObservation):
@@ -662,6 +669,8 @@ class MentisSandbox:
             result_str += f"STDOUT:\n{stdout_buffer.strip()}\n"
         if stderr_buffer:
             result_str += f"STDERR:\n{stderr_buffer.strip()}\n"
+        for mime_types in displays:
+            result_str += f"DISPLAY_DATA: {mime_types}\n"
         if error_message:
              result_str += f"ERROR: {error_message}\n"
         if exit_code is not None:
@@ -44,6 +44,12 @@ class IPythonOutputObservationPart(BaseObservation):
     data: Any # Can be str for stdout/err, Dict[str, Any] for rich outputs
     line: Optional[str] = None # 服务器发送的'stream'类型使用'line'字段而不是'data'字段
 
+class IPythonDisplayObservation(BaseObservation):
+    # A rich output of a cell, e.g. a plot or a DataFrame table
+    observation_type: Literal["display_data"]
+    data: Dict[str, Any] # MIME bundle: image/png (base64), text/html, application/json, text/plain
+    metadata: Dict[str, Any] = Field(default_factory=dict)
+
 class IPythonResultObservation(BaseObservation):
     observation_type: Literal["IPythonResultObservation", "result", "end"]
     status: Literal["ok", "error"] = "ok"  # 默认为ok
@@ -75,6 +81,7 @@ StreamMessage = Union[
     CmdEndObservation,
     IPythonStartObservation,
     IPythonOutputObservationPart,
+    IPythonDisplayObservation,
     IPythonResultObservation,
     ErrorObservation,
     AgentStateObservation,
@@ -105,6 +112,13 @@ def parse_observation(data: Dict[str, Any]) -> BaseObservation:
         logger.debug(f"Converting 'stream' observation with action_id: {original_action_id}")
         return IPythonOutputObservationPart(**data_copy)
     
+    # 处理富输出（图像、HTML、JSON 等 MIME bundle）
+    if obs_type == "display_data":
+        if data_copy.get("metadata") is None:
+            data_copy["metadata"] = {}
+        logger.debug(f"Converting 'display_data' observation with action_id: {original_action_id}")
+        return IPythonDisplayObservation(**data_copy)
+
     # 处理服务器发送的'start'类型观察数据
     if obs_type == "start":
         # 确保数据包含必要的字段
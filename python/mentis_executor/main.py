@@ -1,16 +1,24 @@
 # -*- coding: utf-8 -*-
-from fastapi import FastAPI, HTTPException, Response
+from fastapi import FastAPI, Header, HTTPException, Response, WebSocket, WebSocketDisconnect
 from IPython.core.interactiveshell import InteractiveShell
+from IPython.core.displayhook import DisplayHook
+from IPython.core.displaypub import DisplayPublisher
 from contextlib import redirect_stdout, redirect_stderr
 import json
+import asyncio
+import contextvars
+import hmac
 import threading
 import collections
 import subprocess
 import io
 import os
+import base64
+import importlib.util
 import requests
 import logging
 import traceback # Import traceback
+import uuid
 from datetime import datetime, timezone # Added for timestamp
 
 # Import Pydantic models from sandboxai library if possible,
@@ -49,6 +57,78 @@ logger = logging.getLogger("mentis-executor")
 # 全局锁字典，为每个 sandbox_id 存储一个独立的线程锁
 # defaultdict 会在首次访问不存在的 key 时自动创建 Lock 对象
 ipython_locks = collections.defaultdict(threading.Lock)
+
+# W3C trace context headers of the action being run. They are sent back with its
+# observations so the runtime can follow an action end to end.
+trace_headers = contextvars.ContextVar("trace_headers", default={})
+
+
+def set_trace_headers(traceparent, tracestate):
+    headers = {}
+    if traceparent:
+        headers["traceparent"] = traceparent
+        if tracestate:
+            headers["tracestate"] = tracestate
+    trace_headers.set(headers)
+
+
+class ObservationChannel:
+    """
+    Buffers observations for the runtime's persistent channel.
+
+    Every observation gets a sequence number and stays buffered until the runtime
+    acknowledges it, so observations are delivered in order and survive
+    reconnects. The runtime owns flow control: it advertises how many
+    unacknowledged observations it accepts in each ack.
+    """
+
+    def __init__(self, window: int = 256):
+        self._cond = threading.Condition()
+        # Identifies this process to the runtime: a restarted agent numbers its
+        # observations from 1 again, so a runtime resuming another instance must
+        # start over.
+        self.instance = uuid.uuid4().hex
+        self._pending = collections.deque()  # (seq, observation) not yet acknowledged
+        self._next_seq = 1
+        self._acked = 0
+        self._window = window
+        # Set once the runtime connects; until then observations fall back to HTTP callbacks.
+        self.active = False
+
+    def put(self, observation: dict) -> None:
+        with self._cond:
+            self._pending.append((self._next_seq, observation))
+            self._next_seq += 1
+            self._cond.notify_all()
+
+    @property
+    def next_seq(self) -> int:
+        with self._cond:
+            return self._next_seq
+
+    def ack(self, seq: int, window: int = None) -> None:
+        with self._cond:
+            while self._pending and self._pending[0][0] <= seq:
+                self._pending.popleft()
+            self._acked = max(self._acked, seq)
+            if window:
+                self._window = window
+            self._cond.notify_all()
+
+    def take(self, after: int, limit: int, timeout: float) -> list:
+        """Wait for observations after seq `after` that fit in the window."""
+        with self._cond:
+            def ready():
+                return [(seq, obs) for seq, obs in self._pending
+                        if after < seq <= self._acked + self._window][:limit]
+            batch = ready()
+            if not batch:
+                self._cond.wait(timeout)
+                batch = ready()
+            return batch
+
+
+observation_channel = ObservationChannel()
 # Initialize FastAPI app
 app = FastAPI(
     title="Mentis Sandbox Executor",
@@ -56,6 +136,61 @@ app = FastAPI(
     description="The server that runs python code and shell commands in a MentisSandbox environment.",
 )
 
+class CellDisplays:
+    """
+    Collects the rich outputs (MIME bundles) displayed by the running cell.
+
+    Outputs with only a text/plain form keep being printed, so they stay in the
+    cell's stdout.
+    """
+
+    def __init__(self):
+        self.outputs = None  # A list while a cell runs
+
+    def start(self):
+        self.outputs = []
+
+    def take(self) -> list:
+        outputs, self.outputs = self.outputs or [], None
+        return outputs
+
+    def add(self, data: dict, metadata: dict = None) -> bool:
+        """Collects a bundle, returning False if it should be printed instead."""
+        if self.outputs is None or not any(mime != "text/plain" for mime in data):
+            return False
+        # Binary formats such as image/png travel base64 encoded, as in Jupyter.
+        bundle = {
+            mime: base64.b64encode(value).decode("ascii") if isinstance(value, bytes) else value
+            for mime, value in data.items()
+        }
+        self.outputs.append({"data": bundle, "metadata": metadata or {}})
+        return True
+
+
+cell_displays = CellDisplays()
+
+
+class CapturingDisplayPublisher(DisplayPublisher):
+    """Collects display() calls, e.g. of plots and DataFrames."""
+
+    def publish(self, data, metadata=None, source=None, **kwargs):
+        if not cell_displays.add(data, metadata):
+            super().publish(data, metadata=metadata, source=source, **kwargs)
+
+
+class CapturingDisplayHook(DisplayHook):
+    """Collects the rich form of a cell's last expression (its execute_result)."""
+
+    def write_output_prompt(self):
+        # Written with the data, which may be collected rather than printed.
+        pass
+
+    def write_format_data(self, format_dict, md_dict=None):
+        if not cell_displays.add(format_dict, md_dict):
+            super().write_output_prompt()
+            super().write_format_data(format_dict, md_dict)
+
+
 # Initialize IPython shell
 # Use a try-except block for robustness, especially in container environments
 try:
@@ -64,8 +199,18 @@ try:
     with warnings.catch_warnings():
         warnings.simplefilter("ignore")
         # Disable noisy startup messages if possible (might depend on IPython version)
-        ipy = InteractiveShell.instance(banner1='', exit_msg='')
+        ipy = InteractiveShell.instance(
+            banner1='', exit_msg='',
+            displayhook_class=CapturingDisplayHook,
+            display_pub_class=CapturingDisplayPublisher,
+        )
     logger.info("IPython InteractiveShell initialized successfully.")
+    # Render matplotlib figures as display_data images instead of windows.
+    if importlib.util.find_spec("matplotlib") is not None:
+        try:
+            ipy.enable_matplotlib("inline")
+        except Exception as mpl_err:
+            logger.warning(f"Failed to enable inline matplotlib: {mpl_err}")
 except Exception as ipy_init_err:
     logger.error(f"Failed to initialize IPython InteractiveShell: {ipy_init_err}", exc_info=True)
     ipy = None # Set ipy to None if initialization fails
@@ -86,17 +231,71 @@ def health():
     return Response(status_code=200)
 
 
+@app.websocket("/observations/channel")
+async def observations_channel(websocket: WebSocket, resume: int = 0, instance: str = ""):
+    """
+    Persistent channel the runtime opens to receive observations.
+
+    The first frame sent is {"instance": id, "next_seq": n}, then frames are
+    {"batch": [{"seq": n, "observation": {...}}, ...]}. The runtime replies with
+    {"ack": n, "window": w}. On reconnect it passes the last acknowledged
+    sequence number in `resume` and the instance that numbered it in `instance`;
+    unacknowledged observations are sent again. A `resume` of another instance
+    is ignored.
+    """
+    expected = os.environ.get('RUNTIME_OBSERVATION_TOKEN', '')
+    token = websocket.headers.get('x-observation-token', '')
+    if not expected or not hmac.compare_digest(expected, token):
+        await websocket.close(code=1008)
+        return
+    await websocket.accept()
+    if instance != observation_channel.instance:
+        resume = 0
+    observation_channel.ack(resume)
+    await websocket.send_text(json.dumps(
+        {"instance": observation_channel.instance, "next_seq": observation_channel.next_seq}
+    ))
+    observation_channel.active = True
+    logger.info(f"[AGENT] Runtime observation channel connected, resuming after {resume}")
+
+    async def receive_acks():
+        while True:
+            msg = await websocket.receive_json()
+            observation_channel.ack(int(msg.get("ack", 0)), msg.get("window"))
+
+    receiver = asyncio.create_task(receive_acks())
+    sent = resume
+    try:
+        while not receiver.done():
+            batch = await asyncio.to_thread(observation_channel.take, sent, 100, 1.0)
+            if not batch:
+                continue
+            await websocket.send_text(json.dumps(
+                {"batch": [{"seq": seq, "observation": obs} for seq, obs in batch]},
+                ensure_ascii=False,
+            ))
+            sent = batch[-1][0]
+    except WebSocketDisconnect:
+        pass
+    except Exception as e:
+        logger.warning(f"[AGENT] Observation channel failed: {e}")
+    finally:
+        receiver.cancel()
+    logger.info(f"[AGENT] Runtime observation channel disconnected after {sent}")
+
+
 @app.post(
     "/tools:run_ipython_cell",
     summary="Invoke a cell in a stateful IPython (Jupyter) kernel",
     response_description="NDJSON stream of observations (stdout, stderr, result)",
     status_code=200, # Return 200 OK immediately
 )
-def run_ipython_cell(request: RunIPythonCellRequest): # 保持函数签名不变
+def run_ipython_cell(request: RunIPythonCellRequest, traceparent: str = Header(None), tracestate: str = Header(None)):
     """
     Execute code in an IPython kernel. Observations are pushed asynchronously.
     IPython executions for the SAME sandbox_id are serialized by a lock.
     """
+    set_trace_headers(traceparent, tracestate)
     # --- 获取 Sandbox ID ---
     # 假设 sandbox_id 通过环境变量获取，和之前日志一致
     sandbox_id = os.environ.get('SANDBOX_ID')
@@ -135,14 +334,18 @@ def run_ipython_cell(request: RunIPythonCellRequest): # 保持函数签名不变
             stdout_buf = io.StringIO()
             stderr_buf = io.StringIO()
 
-            with redirect_stdout(stdout_buf), redirect_stderr(stderr_buf):
-                # 实际执行 IPython 代码
-                exec_result = ipy.run_cell(request.code, store_history=True)
+            cell_displays.start()
+            try:
+                with redirect_stdout(stdout_buf), redirect_stderr(stderr_buf):
+                    # 实际执行 IPython 代码
+                    exec_result = ipy.run_cell(request.code, store_history=True)
+            finally:
+                displays = cell_displays.take()
 
             stdout = stdout_buf.getvalue()
             stderr = stderr_buf.getvalue()
 
-            logger.info(f"[AGENT] IPython execution finished inside lock. ActionID: {action_id}. Success: {exec_result.success}. Stdout: {len(stdout)} chars. Stderr: {len(stderr)} chars.")
+            logger.info(f"[AGENT] IPython execution finished inside lock. ActionID: {action_id}. Success: {exec_result.success}. Stdout: {len(stdout)} chars. Stderr: {len(stderr)} chars. Displays: {len(displays)}.")
 
             # --- 发送观测数据的逻辑 (保持不变，但现在它在锁的保护下) ---
             if runtime_observation_url and action_id:
@@ -164,6 +367,15 @@ def run_ipython_cell(request: RunIPythonCellRequest): # 保持函数签名不变
                         "line": stderr
                     })
 
+                # Rich outputs, in the order they were displayed
+                for display in displays:
+                    send_observation(runtime_observation_url, {
+                        "observation_type": "display_data",
+                        "action_id": action_id,
+                        "data": display["data"],
+                        "metadata": display["metadata"],
+                    })
+
                 # 发送 result 观测
                 if exec_result.error_before_exec or exec_result.error_in_exec:
                    # (这里是你上次修改过的、提取 error_name/value/traceback 的逻辑)
@@ -247,12 +459,13 @@ def run_ipython_cell(request: RunIPythonCellRequest): # 保持函数签名不变
     response_description="NDJSON stream of observations (stdout, stderr, result)",
     status_code=200, # Return 200 OK immediately
 )
-def run_shell_command(request: RunShellCommandRequest):
+def run_shell_command(request: RunShellCommandRequest, traceparent: str = Header(None), tracestate: str = Header(None)):
     """
     Execute a shell command. Observations are pushed asynchronously
     to the RUNTIME_OBSERVATION_URL.
     Returns an immediate 200 OK if the request is accepted.
     """
+    set_trace_headers(traceparent, tracestate)
     # --- Use correct action_id from request ---
     action_id = request.action_id
      # ---
@@ -287,31 +500,28 @@ def run_shell_command(request: RunShellCommandRequest):
         # --- Send Observations ---
         if runtime_observation_url and action_id:
             # Send stdout lines
-            if stdout:
-                stdout_lines = stdout.rstrip('\n').split('\n')
-                for line in stdout_lines:
-                    if line:
-                        send_observation(runtime_observation_url, {
-                            "observation_type": "stream", # Correct key
-                            "action_id": action_id,
-                            "stream": "stdout",
-                            "line": line
-                        })
+            # Lines keep their newlines so clients can join them back into the
+            # exact output, including a last line without one.
+            for line in stdout.splitlines(keepends=True):
+                send_observation(runtime_observation_url, {
+                    "observation_type": "stream", # Correct key
+                    "action_id": action_id,
+                    "stream": "stdout",
+                    "line": line
+                })
 
             # Send stderr lines
             if stderr:
-                stderr_lines = stderr.rstrip('\n').split('\n')
                 if exit_code != 0:
                     error_output = stderr.strip()
 
-                for line in stderr_lines:
-                    if line:
-                         send_observation(runtime_observation_url, {
-                            "observation_type": "stream", # Correct key
-                            "action_id": action_id,
-                            "stream": "stderr",
-                            "line": line
-                        })
+                for line in stderr.splitlines(keepends=True):
+                    send_observation(runtime_observation_url, {
+                        "observation_type": "stream", # Correct key
+                        "action_id": action_id,
+                        "stream": "stderr",
+                        "line": line
+                    })
 
             # Send final result observation
             send_observation(runtime_observation_url, {
@@ -375,11 +585,23 @@ def send_observation(url: str, data: dict):
     logger.debug(f"[AGENT SENDING] URL: {url}, ActionID: {action_id}, Type: {obs_type}, Data: {data_str}")
     # ---
 
+    # Once the runtime has opened its channel, observations go through it in order.
+    if observation_channel.active:
+        observation_channel.put(data)
+        return
+
+    headers = {"Content-Type": "application/json"} # 明确设置以防万一
+    # The runtime rejects observations without the sandbox's token
+    observation_token = os.environ.get('RUNTIME_OBSERVATION_TOKEN')
+    if observation_token:
+        headers["X-Observation-Token"] = observation_token
+    headers.update(trace_headers.get())
+
     try:
         response = requests.post(
             url,
             json=data, # requests 会自动设置 Content-Type: application/json
-            headers={"Content-Type": "application/json"}, # 明确设置以防万一
+            headers=headers,
             timeout=10 # 设置请求超时
         )
         response.raise_for_status() # 对 4xx/5xx 状态码抛出异常
@@ -4,7 +4,7 @@ const RUNTIME_URL = process.env.RUNTIME_URL || 'http://localhost:5266';
 
 export async function GET() {
   try {
-    const response = await fetch(`${RUNTIME_URL}/v1/spaces/default`, {
+    const response = await fetch(`${RUNTIME_URL}/v1/spaces/default/sandboxes`, {
       method: 'GET',
       headers: {
         'Content-Type': 'application/json',
@@ -18,8 +18,8 @@ export async function GET() {
     const data = await response.json();
     
     // 提取沙箱列表并格式化
-    const sandboxes = Object.entries(data.Sandboxes || {}).map(([id, state]: [string, any]) => ({
-      id,
+    const sandboxes = (data || []).map((state: any) => ({
+      id: state.sandbox_id,
       isRunning: state.is_running,
       agentUrl: state.agent_url,
       containerId: state.container_id,